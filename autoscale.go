@@ -0,0 +1,149 @@
+package dgqueue
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// AutoscaledWorker registers a worker whose pool grows and shrinks between
+// minWorkers and maxWorkers with demand.
+//
+// Every Config.AutoscaleInterval the pool is sized to the workers needed to
+// clear the jobs waiting for it within one interval, estimated from its
+// average processing time. Jobs still in the served queues count as waiting
+// once the pool's buffer is full. The pool grows at once, and shrinks by half
+// the surplus per interval so a short lull doesn't tear it down; busy workers
+// finish their job before exiting.
+func (m *Manager) AutoscaledWorker(name string, minWorkers, maxWorkers int, handler WorkerFunc, opts ...WorkerOption) error {
+	if minWorkers <= 0 {
+		minWorkers = 1
+	}
+	if maxWorkers < minWorkers {
+		return fmt.Errorf("%w: worker %q max workers %d below min workers %d", ErrInvalidConfig, name, maxWorkers, minWorkers)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pool := m.newWorkerPool("", name, minWorkers, handler, opts)
+	pool.minConcurrency = minWorkers
+	pool.maxConcurrency = maxWorkers
+	pool.jobs = make(chan *Job, maxWorkers*2)
+	pool.shrink = make(chan struct{}, maxWorkers)
+
+	// The first autoscaled pool on a running manager needs the resizing task
+	if m.running && m.config.AutoscaleInterval > 0 && !m.hasAutoscaledPools() {
+		m.startMaintenance([]maintenanceTask{m.autoscaleTask()})
+	}
+	m.installPool(pool)
+
+	return nil
+}
+
+// autoscaled reports whether the pool was registered with AutoscaledWorker.
+func (pool *workerPool) autoscaled() bool {
+	return pool.maxConcurrency > 0
+}
+
+// hasAutoscaledPools reports whether any worker was registered with
+// AutoscaledWorker. Callers must hold m.mu.
+func (m *Manager) hasAutoscaledPools() bool {
+	for _, pool := range m.workers {
+		if pool.autoscaled() {
+			return true
+		}
+	}
+	return false
+}
+
+// observeDuration folds a handler run into the pool's average processing time.
+func (pool *workerPool) observeDuration(d time.Duration) {
+	for {
+		avg := pool.avgDuration.Load()
+		next := int64(d)
+		if avg > 0 {
+			// Exponentially weighted, so the average follows changing workloads
+			next = avg + (int64(d)-avg)/5
+		}
+		if pool.avgDuration.CompareAndSwap(avg, next) {
+			return
+		}
+	}
+}
+
+// desiredConcurrency returns the number of workers the pool should run, given
+// the number of jobs in the served queues.
+func (pool *workerPool) desiredConcurrency(backlog int64, interval time.Duration) int {
+	waiting := int64(len(pool.jobs))
+	if len(pool.jobs) == cap(pool.jobs) {
+		waiting += backlog
+	}
+
+	// Without timings yet, assume each waiting job takes a whole interval
+	avg := time.Duration(pool.avgDuration.Load())
+	if avg <= 0 {
+		avg = interval
+	}
+
+	needed := float64(pool.busy.Load()) + math.Ceil(float64(waiting)*avg.Seconds()/interval.Seconds())
+	desired := int(min(max(needed, float64(pool.minConcurrency)), float64(pool.maxConcurrency)))
+
+	if surplus := pool.concurrency - desired; surplus > 0 {
+		desired = pool.concurrency - (surplus+1)/2
+	}
+	return desired
+}
+
+// autoscaleTask is the maintenance task resizing autoscaled pools.
+func (m *Manager) autoscaleTask() maintenanceTask {
+	return maintenanceTask{
+		name:     "autoscale",
+		interval: m.config.AutoscaleInterval,
+		run:      m.autoscale,
+	}
+}
+
+// autoscale resizes every autoscaled pool to its desired concurrency.
+func (m *Manager) autoscale(ctx context.Context) {
+	var backlog int64
+	for _, queue := range m.servedQueues() {
+		if size, err := m.driver.Size(ctx, queue); err == nil {
+			backlog += size
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return
+	}
+	for _, pool := range m.workers {
+		if !pool.autoscaled() {
+			continue
+		}
+		if desired := pool.desiredConcurrency(backlog, m.config.AutoscaleInterval); desired != pool.concurrency {
+			m.logInfo("Resizing worker pool", "worker", pool.name, "from", pool.concurrency, "to", desired)
+			m.resizePool(pool, desired)
+		}
+	}
+}
+
+// resizePool starts or stops workers until the pool runs n of them. Stopped
+// workers exit once they finish their current job. Callers must hold m.mu.
+func (m *Manager) resizePool(pool *workerPool, n int) {
+	for ; pool.concurrency < n; pool.concurrency++ {
+		select {
+		case <-pool.shrink:
+			// Cancel a pending exit instead of starting a worker
+		default:
+			pool.wg.Add(1)
+			go m.runWorker(pool, pool.concurrency)
+		}
+	}
+	for ; pool.concurrency > n; pool.concurrency-- {
+		pool.shrink <- struct{}{}
+	}
+}
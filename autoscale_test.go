@@ -0,0 +1,102 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_DesiredConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		busy        int64
+		buffered    int
+		capacity    int
+		backlog     int64
+		avg         time.Duration
+		want        int
+	}{
+		{name: "idle at min", concurrency: 1, capacity: 20, avg: time.Second, want: 1},
+		{name: "grows with buffered work", concurrency: 2, busy: 2, buffered: 10, capacity: 20, avg: 500 * time.Millisecond, want: 7},
+		{name: "short jobs need few workers", concurrency: 2, busy: 2, buffered: 10, capacity: 20, avg: 10 * time.Millisecond, want: 3},
+		{name: "backlog counts once buffer is full", concurrency: 2, busy: 2, buffered: 4, capacity: 4, backlog: 100, avg: 10 * time.Millisecond, want: 4},
+		{name: "backlog ignored while buffer has room", concurrency: 2, busy: 2, buffered: 3, capacity: 4, backlog: 100, avg: 10 * time.Millisecond, want: 3},
+		{name: "capped at max", concurrency: 2, busy: 2, buffered: 4, capacity: 4, backlog: 1_000_000, avg: time.Second, want: 10},
+		{name: "unknown timings", concurrency: 1, busy: 1, buffered: 3, capacity: 20, want: 4},
+		{name: "shrinks by half the surplus", concurrency: 9, capacity: 20, avg: time.Second, want: 5},
+		{name: "keeps busy workers", concurrency: 6, busy: 6, capacity: 20, avg: time.Second, want: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &workerPool{
+				concurrency:    tt.concurrency,
+				minConcurrency: 1,
+				maxConcurrency: 10,
+				jobs:           make(chan *Job, tt.capacity),
+			}
+			for i := 0; i < tt.buffered; i++ {
+				pool.jobs <- &Job{}
+			}
+			pool.busy.Store(tt.busy)
+			pool.avgDuration.Store(int64(tt.avg))
+
+			assert.Equal(t, tt.want, pool.desiredConcurrency(tt.backlog, time.Second))
+		})
+	}
+}
+
+func TestWorkerPool_ObserveDuration(t *testing.T) {
+	pool := &workerPool{}
+	pool.observeDuration(100 * time.Millisecond)
+	assert.Equal(t, int64(100*time.Millisecond), pool.avgDuration.Load())
+
+	pool.observeDuration(600 * time.Millisecond)
+	assert.Equal(t, int64(200*time.Millisecond), pool.avgDuration.Load())
+}
+
+func TestManager_AutoscaledWorker(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AutoscaleInterval = 10 * time.Millisecond
+
+	m := New(cfg)
+	m.SetDriver(emptyDriver{})
+
+	release := make(chan struct{})
+	assert.NoError(t, m.AutoscaledWorker("resize", 1, 4, func(ctx context.Context, job *Job) error {
+		<-release
+		return nil
+	}))
+	assert.Equal(t, 1, m.PoolStats()["resize"].Concurrency)
+
+	assert.NoError(t, m.Start())
+	defer m.Stop(context.Background())
+
+	// One job running and a full buffer behind it
+	pool := m.workers["resize"]
+	for i := 0; i <= cap(pool.jobs); i++ {
+		pool.jobs <- m.NewJob("resize", i)
+	}
+	assert.Eventually(t, func() bool {
+		return m.PoolStats()["resize"].Concurrency == 4
+	}, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return m.PoolStats()["resize"].Busy == 4
+	}, time.Second, time.Millisecond)
+
+	// Idle workers are stopped again
+	close(release)
+	assert.Eventually(t, func() bool {
+		return m.PoolStats()["resize"].Concurrency == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestManager_AutoscaledWorkerInvalidBounds(t *testing.T) {
+	m := New(DefaultConfig())
+	err := m.AutoscaledWorker("resize", 4, 2, func(ctx context.Context, job *Job) error { return nil })
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
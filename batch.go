@@ -3,9 +3,17 @@ package dgqueue
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+// batchDedupTTL is how long dispatched batch items and batch statuses are
+// remembered.
+const batchDedupTTL = 24 * time.Hour
+
 // Batch provides batch processing capabilities.
 type Batch struct {
 	manager *Manager
@@ -18,14 +26,59 @@ func NewBatch(manager *Manager) *Batch {
 	}
 }
 
-// DispatchBatch dispatches multiple jobs in batches.
+// BatchIterator yields the items of a streamed batch one at a time, reporting
+// false once there are no more.
+type BatchIterator func() (interface{}, bool)
+
+// DispatchBatch dispatches multiple jobs in batches. The returned status is
+// updated as items are dispatched, so read it through its methods or Snapshot
+// until it completes; if the driver implements BatchStore it is also saved
+// after every chunk, so Manager.Batch can report it from any instance.
 func (b *Batch) DispatchBatch(ctx context.Context, name string, items []interface{}, config BatchConfig) (*BatchStatus, error) {
-	if len(items) == 0 {
-		return nil, fmt.Errorf("items cannot be empty")
+	return DispatchBatchT(ctx, b, name, items, config)
+}
+
+// DispatchStream dispatches the items next yields like DispatchBatch, reading
+// only a chunk at a time, so batches can stream from a database cursor or a
+// file with bounded memory. next is called from the batch's goroutine. As the
+// size of the stream isn't known upfront, the status's Total counts the items
+// read so far, and it doesn't keep the IDs of the jobs dispatched.
+func (b *Batch) DispatchStream(ctx context.Context, name string, next BatchIterator, config BatchConfig) (*BatchStatus, error) {
+	if next == nil {
+		return nil, fmt.Errorf("iterator cannot be nil")
+	}
+	return b.dispatch(ctx, name, next, -1, config)
+}
+
+// DispatchChannel dispatches the items received from items like
+// DispatchStream, until the channel is closed or ctx is done.
+func (b *Batch) DispatchChannel(ctx context.Context, name string, items <-chan interface{}, config BatchConfig) (*BatchStatus, error) {
+	if items == nil {
+		return nil, fmt.Errorf("channel cannot be nil")
+	}
+
+	next := func() (interface{}, bool) {
+		select {
+		case item, ok := <-items:
+			return item, ok
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+	return b.dispatch(ctx, name, next, -1, config)
+}
+
+// dispatch dispatches the items next yields in chunks. total is the number of
+// items, or -1 if it isn't known until next is exhausted.
+func (b *Batch) dispatch(ctx context.Context, name string, next BatchIterator, total int, config BatchConfig) (*BatchStatus, error) {
+	id := config.BatchID
+	if id == "" {
+		id = uuid.New().String()
 	}
 
 	status := &BatchStatus{
-		Total:      len(items),
+		ID:         id,
+		Total:      max(total, 0),
 		Processed:  0,
 		Failed:     0,
 		StartedAt:  time.Now(),
@@ -38,25 +91,73 @@ func (b *Batch) DispatchBatch(ctx context.Context, name string, items []interfac
 		chunkSize = 100 // Default chunk size
 	}
 
+	release, err := b.manager.acquireBatchSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.manager.saveBatch(ctx, status)
+	status.tracker = b.manager.trackBatch(status.ID)
+
 	go func() {
+		defer release()
 		defer func() {
+			status.mu.Lock()
 			status.InProgress = false
 			status.CompletedAt = time.Now()
+			status.mu.Unlock()
+			b.manager.saveBatch(context.WithoutCancel(ctx), status)
+			b.manager.batchDispatched(status)
 		}()
 
-		for i := 0; i < len(items); i += chunkSize {
-			end := i + chunkSize
-			if end > len(items) {
-				end = len(items)
+		chunk := make([]interface{}, 0, chunkSize)
+		for i := 0; ; i += len(chunk) {
+			chunk = chunk[:0]
+			for len(chunk) < chunkSize {
+				item, ok := next()
+				if !ok {
+					break
+				}
+				chunk = append(chunk, item)
+			}
+			if len(chunk) == 0 {
+				return
+			}
+			if total < 0 {
+				status.mu.Lock()
+				status.Total += len(chunk)
+				status.mu.Unlock()
 			}
 
-			chunk := items[i:end]
+			// Rate limiting
+			if config.RateLimit > 0 && i > 0 {
+				delay := time.Duration(chunkSize) * time.Second / time.Duration(config.RateLimit)
+				time.Sleep(delay)
+			}
 
 			// Process chunk
-			for _, item := range chunk {
-				job, err := b.manager.Dispatch(ctx, name, item)
-				if err != nil {
+			for j, item := range chunk {
+				key := ""
+				if config.BatchID != "" {
+					key = fmt.Sprintf("batch:%s:%d", config.BatchID, i+j)
+					claimed, err := b.manager.dedup.Claim(ctx, key, batchDedupTTL)
+					if err == nil && !claimed {
+						// Already dispatched by a previous run of this batch
+						status.mu.Lock()
+						status.Skipped++
+						status.mu.Unlock()
+						continue
+					}
+				}
+
+				job := WithMetadata(b.manager.NewJob(name, item), MetadataBatchID, status.ID)
+				if err := b.manager.Enqueue(ctx, job); err != nil {
+					if key != "" {
+						b.manager.dedup.Release(ctx, key)
+					}
+					status.mu.Lock()
 					status.Failed++
+					status.mu.Unlock()
 					if config.OnError != nil {
 						config.OnError(item, err)
 					}
@@ -66,20 +167,24 @@ func (b *Batch) DispatchBatch(ctx context.Context, name string, items []interfac
 					continue
 				}
 
+				status.mu.Lock()
 				status.Processed++
-				status.JobIDs = append(status.JobIDs, job.ID)
+				if total >= 0 {
+					status.JobIDs = append(status.JobIDs, job.ID)
+				}
+				processed, total := status.Processed, status.Total
+				status.mu.Unlock()
 
 				// Progress callback
 				if config.OnProgress != nil {
-					config.OnProgress(status.Processed, status.Total)
+					config.OnProgress(processed, total)
 				}
 			}
 
-			// Rate limiting
-			if config.RateLimit > 0 && i+chunkSize < len(items) {
-				delay := time.Duration(chunkSize) * time.Second / time.Duration(config.RateLimit)
-				time.Sleep(delay)
+			if len(chunk) < chunkSize {
+				return
 			}
+			b.manager.saveBatch(ctx, status)
 		}
 	}()
 
@@ -111,26 +216,185 @@ func (b *Batch) Map(ctx context.Context, name string, items []interface{}, mappe
 	return b.DispatchBatch(ctx, name, mappedItems, config)
 }
 
+// DispatchBatchT dispatches items of any type like Batch.DispatchBatch,
+// without converting them to []interface{} first.
+func DispatchBatchT[T any](ctx context.Context, b *Batch, name string, items []T, config BatchConfig) (*BatchStatus, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("items cannot be empty")
+	}
+
+	i := 0
+	next := func() (interface{}, bool) {
+		if i == len(items) {
+			return nil, false
+		}
+		i++
+		return items[i-1], true
+	}
+	return b.dispatch(ctx, name, next, len(items), config)
+}
+
+// MapT applies a typed mapper to each item and dispatches the results like
+// Batch.Map. Items the mapper fails on are passed to config.OnError.
+func MapT[T, R any](ctx context.Context, b *Batch, name string, items []T, mapper func(T) (R, error), config BatchConfig) (*BatchStatus, error) {
+	if mapper == nil {
+		return nil, fmt.Errorf("mapper cannot be nil")
+	}
+
+	// Map items
+	mappedItems := make([]R, 0, len(items))
+	for _, item := range items {
+		mapped, err := mapper(item)
+		if err != nil {
+			if config.OnError != nil {
+				config.OnError(item, err)
+			}
+			if !config.ContinueOnError {
+				return nil, err
+			}
+			continue
+		}
+		mappedItems = append(mappedItems, mapped)
+	}
+
+	return DispatchBatchT(ctx, b, name, mappedItems, config)
+}
+
+// Batch returns the status of the batch with the given ID, as last saved by
+// the instance dispatching it, or ErrBatchNotFound once it has expired.
+// It returns ErrNotSupported if the driver doesn't implement BatchStore.
+func (m *Manager) Batch(ctx context.Context, id string) (*BatchStatus, error) {
+	m.mu.RLock()
+	store, ok := m.driver.(BatchStore)
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	status, err := store.Batch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Drivers keeping statuses in memory may hand back the dispatcher's tracker
+	status.tracker = nil
+	return status, nil
+}
+
+// saveBatch saves the batch's status if the driver can store it.
+func (m *Manager) saveBatch(ctx context.Context, status *BatchStatus) {
+	m.mu.RLock()
+	store, ok := m.driver.(BatchStore)
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	// Save a copy, as the batch's goroutine keeps updating the status. Saves
+	// during the batch only record its progress; the job IDs are saved once it
+	// completes
+	saved := status.Snapshot()
+	saved.tracker = nil
+	if saved.InProgress {
+		saved.JobIDs = nil
+	}
+	if err := store.SaveBatch(ctx, saved, batchDedupTTL); err != nil {
+		m.logError("Failed to save batch status", err, "batch_id", status.ID)
+	}
+}
+
+// newBatchSlots creates the semaphore limiting concurrent batches (nil = unlimited).
+func newBatchSlots(config Config) chan struct{} {
+	if config.MaxConcurrentBatches <= 0 {
+		return nil
+	}
+	return make(chan struct{}, config.MaxConcurrentBatches)
+}
+
+// acquireBatchSlot reserves a slot for a running batch, blocking or failing
+// with ErrTooManyBatches when the limit is reached.
+// The returned function releases the slot.
+func (m *Manager) acquireBatchSlot(ctx context.Context) (func(), error) {
+	m.mu.RLock()
+	slots := m.batchSlots
+	reject := m.config.RejectExcessBatches
+	m.mu.RUnlock()
+
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	if reject {
+		select {
+		case slots <- struct{}{}:
+		default:
+			return nil, ErrTooManyBatches
+		}
+	} else {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return func() { <-slots }, nil
+}
+
 // BatchStatus represents the status of a batch operation.
 type BatchStatus struct {
-	Total       int
-	Processed   int
-	Failed      int
-	JobIDs      []string
-	StartedAt   time.Time
-	CompletedAt time.Time
-	InProgress  bool
+	ID          string    `json:"id"`
+	Total       int       `json:"total"`
+	Processed   int       `json:"processed"`
+	Failed      int       `json:"failed"`
+	Skipped     int       `json:"skipped"`
+	JobIDs      []string  `json:"job_ids"` // empty for streamed batches, and saved once complete
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	InProgress  bool      `json:"in_progress"`
+
+	// tracker follows the batch's jobs for Wait
+	tracker *batchTracker
+
+	// mu guards the fields while the batch dispatches
+	mu sync.Mutex
+}
+
+// Snapshot returns a copy of the status that the batch's goroutine doesn't
+// update, safe to read while the batch is still dispatching.
+func (bs *BatchStatus) Snapshot() *BatchStatus {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return &BatchStatus{
+		ID:          bs.ID,
+		Total:       bs.Total,
+		Processed:   bs.Processed,
+		Failed:      bs.Failed,
+		Skipped:     bs.Skipped,
+		JobIDs:      slices.Clone(bs.JobIDs),
+		StartedAt:   bs.StartedAt,
+		CompletedAt: bs.CompletedAt,
+		InProgress:  bs.InProgress,
+		tracker:     bs.tracker,
+	}
 }
 
 // Progress returns the progress percentage.
+// Items skipped as already dispatched count towards progress.
 func (bs *BatchStatus) Progress() float64 {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
 	if bs.Total == 0 {
 		return 0
 	}
-	return float64(bs.Processed) / float64(bs.Total) * 100
+	return float64(bs.Processed+bs.Skipped) / float64(bs.Total) * 100
 }
 
 // IsComplete returns true if the batch is complete.
 func (bs *BatchStatus) IsComplete() bool {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
 	return !bs.InProgress
 }
@@ -98,9 +98,10 @@ func TestBatch_Chunking(t *testing.T) {
 
 	// Wait for completion
 	time.Sleep(200 * time.Millisecond)
+	snapshot := status.Snapshot()
 
-	if status.Processed != 250 {
-		t.Errorf("Expected 250 processed, got %d", status.Processed)
+	if snapshot.Processed != 250 {
+		t.Errorf("Expected 250 processed, got %d", snapshot.Processed)
 	}
 }
 
@@ -185,18 +186,19 @@ func TestBatch_ErrorHandling(t *testing.T) {
 
 	// Wait for jobs to be processed and fail
 	time.Sleep(200 * time.Millisecond)
+	snapshot := status.Snapshot()
 
 	// Note: OnError callback is only called during dispatch errors,
 	// not during job processing errors. The test should verify
 	// that jobs were dispatched successfully.
-	if status.Processed != 3 {
-		t.Errorf("Expected 3 processed (dispatched), got %d", status.Processed)
+	if snapshot.Processed != 3 {
+		t.Errorf("Expected 3 processed (dispatched), got %d", snapshot.Processed)
 	}
 
 	// Failed count is only incremented during dispatch errors,
 	// not during job execution errors
-	if status.Failed != 0 {
-		t.Errorf("Expected 0 failed during dispatch, got %d", status.Failed)
+	if snapshot.Failed != 0 {
+		t.Errorf("Expected 0 failed during dispatch, got %d", snapshot.Failed)
 	}
 }
 
@@ -226,9 +228,10 @@ func TestBatch_Map(t *testing.T) {
 
 	// Wait for completion
 	time.Sleep(100 * time.Millisecond)
+	snapshot := status.Snapshot()
 
-	if status.Processed != 3 {
-		t.Errorf("Expected 3 processed, got %d", status.Processed)
+	if snapshot.Processed != 3 {
+		t.Errorf("Expected 3 processed, got %d", snapshot.Processed)
 	}
 }
 
@@ -275,3 +278,352 @@ func TestBatchStatus_Progress(t *testing.T) {
 		t.Errorf("Expected progress 0%% for zero total, got %.2f%%", progress)
 	}
 }
+
+func TestBatch_IdempotentBatchID(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	batch := dgqueue.NewBatch(manager)
+
+	items := []interface{}{1, 2, 3}
+	config := dgqueue.DefaultBatchConfig()
+	config.BatchID = "import-42"
+	ctx := context.Background()
+
+	// First run is interrupted after two items
+	status, err := batch.DispatchBatch(ctx, "import", items[:2], config)
+	assert.NoError(t, err)
+	assert.Eventually(t, status.IsComplete, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, status.Processed)
+
+	// Retrying the full batch only dispatches the remaining item
+	status, err = batch.DispatchBatch(ctx, "import", items, config)
+	assert.NoError(t, err)
+	assert.Eventually(t, status.IsComplete, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, status.Processed)
+	assert.Equal(t, 2, status.Skipped)
+	assert.Equal(t, float64(100), status.Progress())
+
+	size, err := d.Size(ctx, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), size, "Expected each item to be enqueued exactly once")
+}
+
+func TestBatch_DispatchStream(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	batch := dgqueue.NewBatch(manager)
+
+	// A cursor over 250 rows
+	row := 0
+	next := func() (interface{}, bool) {
+		if row == 250 {
+			return nil, false
+		}
+		row++
+		return map[string]int{"row": row}, true
+	}
+
+	config := dgqueue.DefaultBatchConfig()
+	config.ChunkSize = 100
+	status, err := batch.DispatchStream(context.Background(), "import-row", next, config)
+	assert.NoError(t, err)
+	assert.Eventually(t, status.IsComplete, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 250, status.Total)
+	assert.Equal(t, 250, status.Processed)
+	assert.Equal(t, float64(100), status.Progress())
+	assert.Empty(t, status.Snapshot().JobIDs, "Expected a streamed batch not to keep its job IDs")
+
+	size, _ := d.Size(context.Background(), "default")
+	assert.Equal(t, int64(250), size)
+
+	_, err = batch.DispatchStream(context.Background(), "import-row", nil, config)
+	assert.Error(t, err)
+}
+
+func TestBatchStatus_SnapshotWhileDispatching(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	items := make([]interface{}, 500)
+	for i := range items {
+		items[i] = i
+	}
+	config := dgqueue.DefaultBatchConfig()
+	config.ChunkSize = 10
+	config.BatchID = "import-snapshot"
+	status, err := dgqueue.NewBatch(manager).DispatchBatch(context.Background(), "import-row", items, config)
+	assert.NoError(t, err)
+
+	// Reading and saving the status races with nothing while it dispatches
+	for !status.IsComplete() {
+		snapshot := status.Snapshot()
+		assert.Len(t, snapshot.JobIDs, snapshot.Processed)
+		_ = status.Progress()
+		if saved, err := manager.Batch(context.Background(), "import-snapshot"); err == nil && saved.InProgress {
+			assert.Empty(t, saved.JobIDs, "Expected saves during the batch to carry counts only")
+		}
+	}
+	assert.Equal(t, 500, status.Snapshot().Processed)
+}
+
+func TestBatch_DispatchChannel(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	batch := dgqueue.NewBatch(manager)
+
+	items := make(chan interface{})
+	config := dgqueue.DefaultBatchConfig()
+	config.ChunkSize = 2
+	status, err := batch.DispatchChannel(context.Background(), "import-row", items, config)
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		items <- i
+	}
+	close(items)
+
+	assert.Eventually(t, status.IsComplete, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 5, status.Total)
+	assert.Equal(t, 5, status.Processed)
+}
+
+func TestBatch_DispatchChannelCanceled(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	batch := dgqueue.NewBatch(manager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	items := make(chan interface{})
+	status, err := batch.DispatchChannel(ctx, "import-row", items, dgqueue.DefaultBatchConfig())
+	assert.NoError(t, err)
+
+	items <- 1
+	cancel()
+
+	// The batch stops waiting for the channel once ctx is done
+	assert.Eventually(t, status.IsComplete, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, status.Total)
+}
+
+func TestManager_Batch(t *testing.T) {
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	dispatcher := dgqueue.New(dgqueue.DefaultConfig())
+	dispatcher.SetDriver(d)
+	observer := dgqueue.New(dgqueue.DefaultConfig())
+	observer.SetDriver(d)
+	ctx := context.Background()
+
+	config := dgqueue.DefaultBatchConfig()
+	config.BatchID = "import-42"
+	config.ChunkSize = 2
+	status, err := dgqueue.NewBatch(dispatcher).DispatchBatch(ctx, "import", []interface{}{1, 2, 3}, config)
+	assert.NoError(t, err)
+	assert.Equal(t, "import-42", status.ID)
+
+	// Another manager sharing the driver follows the batch to completion
+	var saved *dgqueue.BatchStatus
+	assert.Eventually(t, func() bool {
+		saved, err = observer.Batch(ctx, "import-42")
+		return err == nil && saved.IsComplete()
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 3, saved.Total)
+	assert.Equal(t, 3, saved.Processed)
+	assert.Len(t, saved.JobIDs, 3)
+	assert.False(t, saved.CompletedAt.IsZero())
+
+	_, err = observer.Batch(ctx, "missing")
+	assert.ErrorIs(t, err, dgqueue.ErrBatchNotFound)
+
+	// Batches without an ID get a random one
+	status, err = dgqueue.NewBatch(dispatcher).DispatchBatch(ctx, "import", []interface{}{1}, dgqueue.DefaultBatchConfig())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, status.ID)
+}
+
+func TestBatch_MaxConcurrentBatchesReject(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxConcurrentBatches = 1
+	cfg.RejectExcessBatches = true
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+	batch := dgqueue.NewBatch(manager)
+
+	// One item per chunk at 5 chunks/second keeps the first batch running
+	config := dgqueue.BatchConfig{ChunkSize: 1, RateLimit: 5, ContinueOnError: true}
+	items := []interface{}{1, 2, 3}
+	ctx := context.Background()
+
+	first, err := batch.DispatchBatch(ctx, "test", items, config)
+	assert.NoError(t, err)
+
+	_, err = batch.DispatchBatch(ctx, "test", items, config)
+	assert.ErrorIs(t, err, dgqueue.ErrTooManyBatches)
+
+	// Slot is released once the first batch completes
+	assert.Eventually(t, first.IsComplete, 2*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		_, err := batch.DispatchBatch(ctx, "test", items, config)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestBatch_MaxConcurrentBatchesBlock(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxConcurrentBatches = 1
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+	batch := dgqueue.NewBatch(manager)
+
+	config := dgqueue.BatchConfig{ChunkSize: 1, RateLimit: 5, ContinueOnError: true}
+	items := []interface{}{1, 2, 3}
+
+	_, err := batch.DispatchBatch(context.Background(), "test", items, config)
+	assert.NoError(t, err)
+
+	// Excess batch waits for a slot until its context expires
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = batch.DispatchBatch(ctx, "test", items, config)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Without a deadline it blocks until the first batch finishes
+	start := time.Now()
+	second, err := batch.DispatchBatch(context.Background(), "test", items, config)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Eventually(t, second.IsComplete, 2*time.Second, 10*time.Millisecond)
+}
+
+type signup struct {
+	Email string
+}
+
+func TestDispatchBatchT(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	batch := dgqueue.NewBatch(manager)
+	ctx := context.Background()
+
+	items := []signup{{Email: "a@test.com"}, {Email: "b@test.com"}}
+	status, err := dgqueue.DispatchBatchT(ctx, batch, "welcome", items, dgqueue.DefaultBatchConfig())
+	assert.NoError(t, err)
+	assert.Eventually(t, status.IsComplete, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, status.Processed)
+
+	job, err := d.Pop(ctx, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, signup{Email: "a@test.com"}, job.Payload)
+
+	_, err = dgqueue.DispatchBatchT(ctx, batch, "welcome", []signup{}, dgqueue.DefaultBatchConfig())
+	assert.Error(t, err)
+}
+
+func TestMapT(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	batch := dgqueue.NewBatch(manager)
+	ctx := context.Background()
+
+	var failed []interface{}
+	config := dgqueue.DefaultBatchConfig()
+	config.OnError = func(item interface{}, err error) {
+		failed = append(failed, item)
+	}
+
+	status, err := dgqueue.MapT(ctx, batch, "welcome", []string{"a@test.com", "", "b@test.com"},
+		func(email string) (signup, error) {
+			if email == "" {
+				return signup{}, fmt.Errorf("empty email")
+			}
+			return signup{Email: email}, nil
+		}, config)
+	assert.NoError(t, err)
+	assert.Eventually(t, status.IsComplete, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, status.Processed)
+	assert.Equal(t, []interface{}{""}, failed)
+
+	config.ContinueOnError = false
+	_, err = dgqueue.MapT(ctx, batch, "welcome", []string{""}, func(email string) (signup, error) {
+		return signup{}, fmt.Errorf("empty email")
+	}, config)
+	assert.Error(t, err)
+}
+
+func TestBatchStatus_Wait(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	batch := dgqueue.NewBatch(manager)
+
+	manager.Worker("import-row", 2, func(ctx context.Context, job *dgqueue.Job) error {
+		if job.Payload.(int)%3 == 0 {
+			return dgqueue.Unretryable(fmt.Errorf("invalid row"))
+		}
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(context.Background())
+
+	config := dgqueue.DefaultBatchConfig()
+	config.BatchID = "import-42"
+	status, err := dgqueue.DispatchBatchT(ctx, batch, "import-row", []int{1, 2, 3, 4, 5, 6}, config)
+	assert.NoError(t, err)
+
+	result, err := status.Wait(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, dgqueue.BatchResult{Succeeded: 4, Failed: 2}, result)
+
+	// Statuses loaded from the driver can't be waited on
+	saved, err := manager.Batch(ctx, "import-42")
+	assert.NoError(t, err)
+	_, err = saved.Wait(ctx)
+	assert.ErrorIs(t, err, dgqueue.ErrNotSupported)
+}
+
+func TestBatchStatus_WaitCanceled(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	batch := dgqueue.NewBatch(manager)
+
+	// No workers run the jobs
+	status, err := batch.DispatchBatch(context.Background(), "import-row", []interface{}{1, 2}, dgqueue.DefaultBatchConfig())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err := status.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, dgqueue.BatchResult{}, result)
+}
+
+func TestManager_DispatchBatch(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	ctx := context.Background()
+
+	status, err := manager.DispatchBatch(ctx, "send-email", []interface{}{"a@test.com", "b@test.com"}, dgqueue.DefaultBatchConfig())
+	assert.NoError(t, err)
+	assert.Eventually(t, status.IsComplete, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, status.Processed)
+
+	size, _ := d.Size(ctx, "default")
+	assert.Equal(t, int64(2), size)
+
+	_, err = manager.DispatchBatch(ctx, "send-email", nil, dgqueue.DefaultBatchConfig())
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package dgqueue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BatchResult counts how the jobs of a batch ended.
+type BatchResult struct {
+	// Succeeded is the number of jobs whose handler returned nil
+	Succeeded int
+
+	// Failed is the number of jobs that failed permanently and were
+	// dead-lettered
+	Failed int
+}
+
+// batchTracker counts the outcomes of a batch's jobs as workers finish them.
+type batchTracker struct {
+	mu     sync.Mutex
+	result BatchResult
+
+	// expected is the number of jobs the batch dispatched, -1 until it is done
+	expected int
+
+	// changed is closed and replaced whenever the result changes
+	changed chan struct{}
+}
+
+// done reports whether every dispatched job has ended. The caller holds mu.
+func (t *batchTracker) done() bool {
+	return t.expected >= 0 && t.result.Succeeded+t.result.Failed >= t.expected
+}
+
+// snapshot returns the current result, whether every job has ended, and a
+// channel closed on the next change.
+func (t *batchTracker) snapshot() (BatchResult, bool, <-chan struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.result, t.done(), t.changed
+}
+
+// notify wakes waiters. The caller holds mu.
+func (t *batchTracker) notify() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// Wait blocks until every job the batch dispatched has been processed, either
+// succeeding or failing permanently, or until ctx is done, and returns how the
+// jobs ended. Retried jobs count once they succeed or run out of attempts.
+//
+// Outcomes are observed by the workers of the manager that dispatched the
+// batch, so Wait is meant for processes that also run the batch's workers,
+// such as tests and synchronous imports. It returns ErrNotSupported for
+// statuses loaded with Manager.Batch.
+func (bs *BatchStatus) Wait(ctx context.Context) (BatchResult, error) {
+	t := bs.tracker
+	if t == nil {
+		return BatchResult{}, fmt.Errorf("%w: batch %s wasn't dispatched by this process", ErrNotSupported, bs.ID)
+	}
+
+	for {
+		result, done, changed := t.snapshot()
+		if done {
+			return result, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return result, ctx.Err()
+		}
+	}
+}
+
+// trackBatch starts tracking the outcomes of the batch's jobs.
+func (m *Manager) trackBatch(id string) *batchTracker {
+	t := &batchTracker{
+		expected: -1,
+		changed:  make(chan struct{}),
+	}
+	m.batches.Store(id, t)
+	return t
+}
+
+// batchDispatched records how many jobs the batch dispatched once it is done.
+func (m *Manager) batchDispatched(status *BatchStatus) {
+	t := status.tracker
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status.mu.Lock()
+	t.expected = status.Processed
+	status.mu.Unlock()
+	if t.done() {
+		m.batches.CompareAndDelete(status.ID, t)
+	}
+	t.notify()
+}
+
+// batchJobDone records the outcome of a job dispatched by a batch.
+func (m *Manager) batchJobDone(job *Job, succeeded bool) {
+	id, ok := job.Metadata[MetadataBatchID].(string)
+	if !ok {
+		return
+	}
+	value, ok := m.batches.Load(id)
+	if !ok {
+		return
+	}
+
+	t := value.(*batchTracker)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if succeeded {
+		t.result.Succeeded++
+	} else {
+		t.result.Failed++
+	}
+	if t.done() {
+		m.batches.CompareAndDelete(id, t)
+	}
+	t.notify()
+}
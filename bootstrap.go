@@ -0,0 +1,69 @@
+package dgqueue
+
+import "fmt"
+
+// Option customizes how Bootstrap builds a manager.
+type Option func(*bootstrapOptions)
+
+type bootstrapOptions struct {
+	metrics       bool
+	driverFactory DriverFactory
+}
+
+// WithMetrics registers the OpenTelemetry metrics of the bootstrapped manager.
+func WithMetrics() Option {
+	return func(o *bootstrapOptions) {
+		o.metrics = true
+	}
+}
+
+// WithDriverFactory creates the driver with factory instead of looking up
+// config.Driver in the driver registry.
+func WithDriverFactory(factory DriverFactory) Option {
+	return func(o *bootstrapOptions) {
+		o.driverFactory = factory
+	}
+}
+
+// Bootstrap creates a manager with its driver attached, ready for workers to be
+// registered and Start to be called.
+// The driver is resolved from the registry by config.Driver, so the driver
+// package must be imported (e.g. _ "github.com/donnigundala/dg-queue/drivers/redis").
+func Bootstrap(config Config, opts ...Option) (*Manager, error) {
+	options := &bootstrapOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	factory := options.driverFactory
+	if factory == nil {
+		if config.Driver == "" {
+			return nil, fmt.Errorf("%w: no driver configured", ErrInvalidConfig)
+		}
+
+		globalDriversMu.RLock()
+		registered, ok := globalDrivers[config.Driver]
+		globalDriversMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("%w: %s (is the driver package imported?)", ErrDriverNotFound, config.Driver)
+		}
+		factory = registered
+	}
+
+	driver, err := factory(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create queue driver %s: %w", config.Driver, err)
+	}
+
+	manager := New(config)
+	manager.SetDriver(driver)
+
+	if options.metrics {
+		if err := manager.RegisterMetrics(); err != nil {
+			driver.Close()
+			return nil, fmt.Errorf("failed to register queue metrics: %w", err)
+		}
+	}
+
+	return manager, nil
+}
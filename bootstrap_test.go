@@ -0,0 +1,77 @@
+package dgqueue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootstrap_MemoryDriver(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.Driver = "memory"
+	cfg.WorkerEnabled = true
+
+	manager, err := dgqueue.Bootstrap(cfg)
+	assert.NoError(t, err)
+	assert.IsType(t, &memory.Driver{}, manager.Driver())
+
+	processed := make(chan string, 1)
+	manager.Worker("bootstrapped", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		processed <- job.ID
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	job, err := manager.Dispatch(ctx, "bootstrapped", nil)
+	assert.NoError(t, err)
+
+	select {
+	case id := <-processed:
+		assert.Equal(t, job.ID, id)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected bootstrapped manager to process the job")
+	}
+}
+
+func TestBootstrap_WithMetrics(t *testing.T) {
+	provider := useRecordingMeterProvider(t)
+
+	cfg := dgqueue.DefaultConfig()
+	cfg.Driver = "memory"
+
+	_, err := dgqueue.Bootstrap(cfg, dgqueue.WithMetrics())
+	assert.NoError(t, err)
+	assert.NotNil(t, provider.counter("queue.job.processed"), "Expected metrics to be registered")
+}
+
+func TestBootstrap_WithDriverFactory(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.Driver = "custom"
+
+	d, _ := memory.NewDriver(cfg)
+	manager, err := dgqueue.Bootstrap(cfg, dgqueue.WithDriverFactory(func(dgqueue.Config) (dgqueue.Driver, error) {
+		return d, nil
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, d, manager.Driver())
+}
+
+func TestBootstrap_UnknownDriver(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.Driver = "carrier-pigeon"
+
+	manager, err := dgqueue.Bootstrap(cfg)
+	assert.ErrorIs(t, err, dgqueue.ErrDriverNotFound)
+	assert.Nil(t, manager)
+
+	cfg.Driver = ""
+	_, err = dgqueue.Bootstrap(cfg)
+	assert.ErrorIs(t, err, dgqueue.ErrInvalidConfig)
+}
@@ -0,0 +1,111 @@
+package dgqueue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a clock whose timers fire immediately and record the requested waits.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Now()}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *fakeClock) Waits() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.waits...)
+}
+
+// emptyDriver is a driver with no jobs, for tests that don't need the memory driver.
+type emptyDriver struct{}
+
+func (emptyDriver) Push(ctx context.Context, job *Job) error            { return nil }
+func (emptyDriver) Pop(ctx context.Context, queue string) (*Job, error) { return nil, ErrQueueEmpty }
+func (emptyDriver) Delete(ctx context.Context, jobID string) error      { return nil }
+func (emptyDriver) Retry(ctx context.Context, job *Job) error           { return nil }
+func (emptyDriver) Failed(ctx context.Context, job *Job) error          { return nil }
+func (emptyDriver) Get(ctx context.Context, jobID string) (*Job, error) {
+	return nil, ErrJobNotFound
+}
+func (emptyDriver) Size(ctx context.Context, queue string) (int64, error) { return 0, nil }
+func (emptyDriver) Close() error                                          { return nil }
+
+func TestManager_PollJitter(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PollInterval = 100 * time.Millisecond
+	cfg.PollJitter = 0.2
+
+	clk := newFakeClock()
+	m := New(cfg)
+	m.SetDriver(emptyDriver{})
+	m.clock = clk
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.wg.Add(1)
+	go m.dispatchJobs(ctx)
+
+	assert.Eventually(t, func() bool {
+		return len(clk.Waits()) >= 50
+	}, time.Second, time.Millisecond)
+	cancel()
+	m.wg.Wait()
+
+	waits := clk.Waits()
+	distinct := make(map[time.Duration]bool)
+	for _, w := range waits {
+		assert.GreaterOrEqual(t, w, 80*time.Millisecond)
+		assert.LessOrEqual(t, w, 120*time.Millisecond)
+		distinct[w] = true
+	}
+	assert.Greater(t, len(distinct), 1, "Expected poll intervals to vary")
+}
+
+func TestManager_PollFullJitter(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PollInterval = 50 * time.Millisecond
+	cfg.PollJitter = 1
+
+	m := New(cfg)
+	for i := 0; i < 1000; i++ {
+		interval := m.pollInterval()
+		assert.GreaterOrEqual(t, interval, 5*time.Millisecond)
+		assert.LessOrEqual(t, interval, 100*time.Millisecond)
+	}
+}
+
+func TestManager_PollWithoutJitter(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PollInterval = 50 * time.Millisecond
+
+	m := New(cfg)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, 50*time.Millisecond, m.pollInterval())
+	}
+}
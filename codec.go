@@ -0,0 +1,141 @@
+package dgqueue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec serializes jobs in one format. Every codec is registered under a
+// format byte and a name: the byte prefixes the jobs it writes, so any
+// registered format can be read back, and the name selects it as
+// Config.Serializer.
+type Codec interface {
+	// Name is the serializer name selecting the codec
+	Name() string
+
+	// Format is the byte identifying jobs written by the codec
+	Format() JobFormat
+
+	// Marshal encodes the job, without its format byte
+	Marshal(j *Job) ([]byte, error)
+
+	// Unmarshal decodes a job encoded by Marshal into j
+	Unmarshal(data []byte, j *Job) error
+}
+
+var (
+	globalCodecs   = make(map[JobFormat]Codec)
+	globalCodecsMu sync.RWMutex
+)
+
+func init() {
+	for _, codec := range []Codec{jsonCodec{}, gobCodec{}, msgpackCodec{}, protobufCodec{}} {
+		registerCodec(codec)
+	}
+}
+
+// RegisterCodec registers a codec globally, replacing any codec registered
+// for its format. Format bytes below 0x10 and JSON's '{' are reserved for
+// built-in codecs and return ErrInvalidConfig.
+func RegisterCodec(codec Codec) error {
+	if format := codec.Format(); format < 0x10 || format == FormatJSON {
+		return fmt.Errorf("%w: codec %s: format byte 0x%02x is reserved", ErrInvalidConfig, codec.Name(), byte(format))
+	}
+	registerCodec(codec)
+	return nil
+}
+
+// registerCodec registers a codec without checking its format byte.
+func registerCodec(codec Codec) {
+	globalCodecsMu.Lock()
+	defer globalCodecsMu.Unlock()
+	globalCodecs[codec.Format()] = codec
+}
+
+// LookupCodec returns the codec registered for format.
+func LookupCodec(format JobFormat) (Codec, bool) {
+	globalCodecsMu.RLock()
+	defer globalCodecsMu.RUnlock()
+	codec, ok := globalCodecs[format]
+	return codec, ok
+}
+
+// lookupCodecName returns the codec registered under name.
+func lookupCodecName(name string) (Codec, bool) {
+	globalCodecsMu.RLock()
+	defer globalCodecsMu.RUnlock()
+	for _, codec := range globalCodecs {
+		if codec.Name() == name {
+			return codec, true
+		}
+	}
+	return nil, false
+}
+
+// codecFor returns the codec that wrote data. Data without a registered
+// format byte is JSON, as jobs were written before format bytes existed.
+func codecFor(data []byte) Codec {
+	if codec, ok := LookupCodec(JobFormat(data[0])); ok {
+		return codec
+	}
+	return jsonCodec{}
+}
+
+// jsonCodec is encoding/json.
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string      { return "json" }
+func (jsonCodec) Format() JobFormat { return FormatJSON }
+
+func (jsonCodec) Marshal(j *Job) ([]byte, error) {
+	return json.Marshal(j)
+}
+
+func (jsonCodec) Unmarshal(data []byte, j *Job) error {
+	return json.Unmarshal(data, j)
+}
+
+// gobCodec is encoding/gob.
+type gobCodec struct{}
+
+func (gobCodec) Name() string      { return "gob" }
+func (gobCodec) Format() JobFormat { return FormatGob }
+
+func (gobCodec) Marshal(j *Job) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(j); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, j *Job) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(j)
+}
+
+// msgpackCodec is MessagePack, keyed by the job's JSON field names.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string      { return "msgpack" }
+func (msgpackCodec) Format() JobFormat { return FormatMsgpack }
+
+func (msgpackCodec) Marshal(j *Job) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(j); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, j *Job) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(j)
+}
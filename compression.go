@@ -0,0 +1,174 @@
+package dgqueue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"sync"
+)
+
+// Compressor compresses the payloads of stored jobs. Register one with
+// RegisterCompressor in every process that reads or writes the queue; gzip
+// is built in.
+type Compressor interface {
+	// Name is the algorithm name selecting the compressor and recorded on
+	// compressed jobs
+	Name() string
+
+	// Compress compresses data
+	Compress(data []byte) ([]byte, error)
+
+	// Decompress reverses Compress
+	Decompress(data []byte) ([]byte, error)
+}
+
+// Compression configures compressing the payloads of stored jobs, set from
+// Config.Compression and Config.CompressionThreshold.
+type Compression struct {
+	// Compressor compresses payloads; nil disables compression
+	Compressor Compressor
+
+	// Threshold is the size in bytes of a payload encoded as JSON from
+	// which it is compressed
+	Threshold int
+}
+
+var (
+	globalCompressors   = make(map[string]Compressor)
+	globalCompressorsMu sync.RWMutex
+)
+
+func init() {
+	RegisterCompressor(gzipCompressor{})
+}
+
+// RegisterCompressor registers a compressor globally, replacing any
+// compressor registered under its name.
+func RegisterCompressor(compressor Compressor) {
+	globalCompressorsMu.Lock()
+	defer globalCompressorsMu.Unlock()
+	globalCompressors[compressor.Name()] = compressor
+}
+
+// LookupCompressor returns the compressor registered under name.
+func LookupCompressor(name string) (Compressor, bool) {
+	globalCompressorsMu.RLock()
+	defer globalCompressorsMu.RUnlock()
+	compressor, ok := globalCompressors[name]
+	return compressor, ok
+}
+
+// ParseCompression returns the compression compressing payloads from
+// threshold bytes with the compressor registered under name. An empty name
+// disables compression.
+func ParseCompression(name string, threshold int) (Compression, error) {
+	if name == "" {
+		return Compression{}, nil
+	}
+	compressor, ok := LookupCompressor(name)
+	if !ok {
+		return Compression{}, fmt.Errorf("%w: unknown compression %q", ErrInvalidConfig, name)
+	}
+	return Compression{Compressor: compressor, Threshold: threshold}, nil
+}
+
+// MarshalJobWith marshals the job like MarshalJobAs, first compressing its
+// payload if it reaches the compression threshold. A compressed job records
+// its algorithm in MetadataCompression, and UnmarshalJob decompresses it, so
+// readers handle compressed and uncompressed jobs alike. The job itself is
+// not modified.
+func MarshalJobWith(j *Job, format JobFormat, compression Compression) ([]byte, error) {
+	if compression.Compressor == nil || j.Payload == nil {
+		return MarshalJobAs(j, format)
+	}
+
+	data, err := json.Marshal(j.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if len(data) < compression.Threshold {
+		return MarshalJobAs(j, format)
+	}
+	compressed, err := compression.Compressor.Compress(data)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := *j
+	clone.Payload = compressed
+	clone.Metadata = maps.Clone(j.Metadata)
+	if clone.Metadata == nil {
+		clone.Metadata = make(map[string]interface{})
+	}
+	clone.Metadata[MetadataCompression] = compression.Compressor.Name()
+	return MarshalJobAs(&clone, format)
+}
+
+// decompressPayload decompresses the payload of a job compressed by
+// MarshalJobWith, reporting whether it was compressed. Formats that can't
+// carry bytes leave the payload as a base64 string.
+func decompressPayload(j *Job) (bool, error) {
+	name, _ := j.Metadata[MetadataCompression].(string)
+	if name == "" {
+		return false, nil
+	}
+	compressor, ok := LookupCompressor(name)
+	if !ok {
+		return true, fmt.Errorf("%w: unknown compression %q", ErrInvalidPayload, name)
+	}
+
+	var compressed []byte
+	switch payload := j.Payload.(type) {
+	case []byte:
+		compressed = payload
+	case string:
+		var err error
+		if compressed, err = base64.StdEncoding.DecodeString(payload); err != nil {
+			return true, fmt.Errorf("%w: compressed payload: %v", ErrInvalidPayload, err)
+		}
+	default:
+		return true, fmt.Errorf("%w: compressed payload is %T", ErrInvalidPayload, j.Payload)
+	}
+
+	data, err := compressor.Decompress(compressed)
+	if err != nil {
+		return true, fmt.Errorf("%w: compressed payload: %v", ErrInvalidPayload, err)
+	}
+	var payload interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return true, fmt.Errorf("%w: compressed payload: %v", ErrInvalidPayload, err)
+	}
+	j.Payload = payload
+	delete(j.Metadata, MetadataCompression)
+	return true, nil
+}
+
+// gzipCompressor is compress/gzip.
+type gzipCompressor struct{}
+
+func (gzipCompressor) Name() string { return "gzip" }
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,109 @@
+package dgqueue
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJobWith_Compression(t *testing.T) {
+	compression, err := ParseCompression("gzip", 64)
+	if err != nil {
+		t.Fatalf("Failed to parse compression: %v", err)
+	}
+	report := map[string]interface{}{"rows": strings.Repeat("a,b,c\n", 100)}
+
+	for _, format := range []JobFormat{FormatJSON, FormatGob, FormatMsgpack, FormatProtobuf} {
+		job := NewJob("report", report)
+		WithMetadata(job, "tenant", "acme")
+
+		compressed, err := MarshalJobWith(job, format, compression)
+		if err != nil {
+			t.Fatalf("Failed to marshal job as %d: %v", format, err)
+		}
+		plain, _ := MarshalJobAs(job, format)
+		if len(compressed) >= len(plain) {
+			t.Errorf("Expected compressed job (%d bytes) smaller than plain (%d bytes) as %d", len(compressed), len(plain), format)
+		}
+		if _, ok := job.Metadata[MetadataCompression]; ok || job.Payload == nil {
+			t.Errorf("Expected the job itself to be left alone, got %v", job.Metadata)
+		}
+
+		decoded, err := UnmarshalJob(compressed)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal job written as %d: %v", format, err)
+		}
+		payload, ok := decoded.Payload.(map[string]interface{})
+		if !ok || payload["rows"] != report["rows"] {
+			t.Errorf("Payload mismatch after %d round trip: %v", format, decoded.Payload)
+		}
+		if _, ok := decoded.Metadata[MetadataCompression]; ok || decoded.Metadata["tenant"] != "acme" {
+			t.Errorf("Metadata mismatch after %d round trip: %v", format, decoded.Metadata)
+		}
+	}
+}
+
+func TestMarshalJobWith_BelowThreshold(t *testing.T) {
+	compression, _ := ParseCompression("gzip", 1024)
+	job := NewJob("ping", map[string]interface{}{"host": "db"})
+
+	data, err := MarshalJobWith(job, FormatJSON, compression)
+	if err != nil {
+		t.Fatalf("Failed to marshal job: %v", err)
+	}
+	if strings.Contains(string(data), MetadataCompression) {
+		t.Errorf("Expected a small payload to stay uncompressed, got %s", data)
+	}
+}
+
+func TestMarshalJobWith_RawPayload(t *testing.T) {
+	compression, _ := ParseCompression("gzip", 0)
+	job := WithMetadata(NewJob("upload", []byte("raw bytes")), MetadataRawPayload, true)
+
+	data, err := MarshalJobWith(job, FormatJSON, compression)
+	if err != nil {
+		t.Fatalf("Failed to marshal job: %v", err)
+	}
+	decoded, err := UnmarshalJob(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal job: %v", err)
+	}
+	if raw, ok := decoded.Payload.([]byte); !ok || string(raw) != "raw bytes" {
+		t.Errorf("Expected raw payload to survive compression, got %v", decoded.Payload)
+	}
+}
+
+func TestUnmarshalJob_UnknownCompression(t *testing.T) {
+	job := WithMetadata(NewJob("report", "payload"), MetadataCompression, "brotli")
+	data, _ := MarshalJob(job)
+
+	if _, err := UnmarshalJob(data); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload for an unknown compression, got %v", err)
+	}
+}
+
+func TestUnmarshalJobWithLimits_Compressed(t *testing.T) {
+	compression, _ := ParseCompression("gzip", 0)
+	job := NewJob("nested", map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}},
+	})
+	data, _ := MarshalJobWith(job, FormatJSON, compression)
+
+	// The compressed payload is checked once decompressed
+	if _, err := UnmarshalJobWithLimits(data, DecodeLimits{MaxDepth: 3}); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload for a deep compressed payload, got %v", err)
+	}
+	if _, err := UnmarshalJobWithLimits(data, DecodeLimits{MaxDepth: 5}); err != nil {
+		t.Errorf("Expected payload within limits to decode, got %v", err)
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	compression, err := ParseCompression("", 1024)
+	if err != nil || compression.Compressor != nil {
+		t.Errorf("Expected empty name to disable compression, got %v, %v", compression, err)
+	}
+	if _, err := ParseCompression("zstd", 1024); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for an unregistered compressor, got %v", err)
+	}
+}
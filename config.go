@@ -48,9 +48,150 @@ type Config struct {
 	// RetryDelay is the delay between retries
 	RetryDelay time.Duration `mapstructure:"retry_delay"`
 
+	// RetryPolicies override MaxAttempts, Timeout and RetryDelay per job name
+	RetryPolicies map[string]RetryPolicy `mapstructure:"retry_policies"`
+
+	// IdempotencyTTL is how long the idempotency keys of processed jobs are
+	// remembered (0 = forever)
+	IdempotencyTTL time.Duration `mapstructure:"idempotency_ttl"`
+
+	// FailedMaxAge is how long failed jobs are kept (0 = forever)
+	FailedMaxAge time.Duration `mapstructure:"failed_max_age"`
+
+	// FailedMaxCount is how many failed jobs are kept, newest first (0 = all)
+	FailedMaxCount int `mapstructure:"failed_max_count"`
+
+	// HandlerExecution is how handlers run under their timeout: "goroutine" starts
+	// one per job so a stuck handler can't block its worker; "inline" runs it on
+	// the worker itself, avoiding the per-job goroutine but relying on handlers
+	// to return once their context is done
+	HandlerExecution string `mapstructure:"handler_execution"`
+
+	// DispatchStrategy controls how many jobs the dispatcher pops per poll:
+	// "fifo" pops one job per queue, "capacity" keeps popping while worker
+	// pools have free buffer space
+	DispatchStrategy string `mapstructure:"dispatch_strategy"`
+
+	// ServeQueues lists the queues this instance polls for jobs
+	// If empty, the queues in QueueWeights are polled, or only DefaultQueue
+	ServeQueues []string `mapstructure:"serve_queues"`
+
+	// QueueWeights sets how many jobs each served queue may pop per round
+	// relative to the others, e.g. {critical: 3, default: 1}
+	// Queues without a weight (or below 1) have weight 1
+	QueueWeights map[string]int `mapstructure:"queue_weights"`
+
 	// Workers is the default number of workers
 	Workers int `mapstructure:"workers"`
 
+	// PollInterval is how often the dispatcher polls the driver for jobs
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+
+	// PollJitter randomizes each poll interval by up to this fraction (0-1)
+	// so multiple instances don't hit the driver in lockstep. Intervals never
+	// drop below a tenth of PollInterval.
+	PollJitter float64 `mapstructure:"poll_jitter"`
+
+	// RemovedWorkerPolicy decides what happens to jobs whose worker was removed
+	// with RemoveWorker: "dead_letter" (default), "requeue" or "migrate"
+	RemovedWorkerPolicy string `mapstructure:"removed_worker_policy"`
+
+	// RemovedWorkerGrace is how long requeued jobs of removed workers are delayed
+	RemovedWorkerGrace time.Duration `mapstructure:"removed_worker_grace"`
+
+	// RemovedWorkerFallback is the job name of the worker that runs migrated jobs
+	RemovedWorkerFallback string `mapstructure:"removed_worker_fallback"`
+
+	// DrainIdle is how long queues must stay empty before RunUntilEmpty returns
+	DrainIdle time.Duration `mapstructure:"drain_idle"`
+
+	// DrainWaitDelayed makes RunUntilEmpty wait for delayed jobs (and retries)
+	// to come due and be processed, instead of leaving them in the queue
+	DrainWaitDelayed bool `mapstructure:"drain_wait_delayed"`
+
+	// PurgeExpiredInterval is how often expired jobs are purged from drivers
+	// that support it (0 = disabled)
+	PurgeExpiredInterval time.Duration `mapstructure:"purge_expired_interval"`
+
+	// FailedPruneInterval is how often failed jobs beyond FailedMaxAge or
+	// FailedMaxCount are pruned, on drivers that support it (0 = disabled)
+	FailedPruneInterval time.Duration `mapstructure:"failed_prune_interval"`
+
+	// DedupSweepInterval is how often expired deduplication keys are removed
+	// from the in-memory dedup store (0 = disabled)
+	DedupSweepInterval time.Duration `mapstructure:"dedup_sweep_interval"`
+
+	// OrphanCheckInterval is how often the manager looks for queues holding jobs
+	// that no running instance serves, warning about each one (0 = disabled)
+	OrphanCheckInterval time.Duration `mapstructure:"orphan_check_interval"`
+
+	// StalledCheckInterval is how often the manager heartbeats the jobs it holds
+	// and requeues jobs held by instances that stopped heartbeating, on drivers
+	// that support it (0 = disabled)
+	StalledCheckInterval time.Duration `mapstructure:"stalled_check_interval"`
+
+	// VisibilityTimeout is how long a held job stays invisible to other
+	// instances without a heartbeat before it is requeued. It should be
+	// several StalledCheckIntervals (0 = three intervals)
+	VisibilityTimeout time.Duration `mapstructure:"visibility_timeout"`
+
+	// ReapFactor makes held jobs orphaned once their holder hasn't heartbeated
+	// for Timeout times this factor, or VisibilityTimeout if longer. Orphaned
+	// jobs use up an attempt and are retried or dead-lettered, on drivers that
+	// support it (0 = requeue them without counting the attempt)
+	ReapFactor float64 `mapstructure:"reap_factor"`
+
+	// RegisterInterval is how often the manager registers itself with
+	// drivers that keep an instance registry. Records expire after three
+	// intervals (0 = disabled)
+	RegisterInterval time.Duration `mapstructure:"register_interval"`
+
+	// AutoscaleInterval is how often pools registered with AutoscaledWorker
+	// are resized (0 = disabled; they keep their minimum size)
+	AutoscaleInterval time.Duration `mapstructure:"autoscale_interval"`
+
+	// ThroughputWindow is the sliding window used to compute completed jobs per second
+	ThroughputWindow time.Duration `mapstructure:"throughput_window"`
+
+	// MaxConcurrentRetries limits how many retry attempts run at once across all
+	// worker pools, so mass failures don't retry in lockstep (0 = unlimited).
+	// Retries that find no free slot go back to their queue for a moment, so
+	// workers move on to other jobs; first attempts are not limited.
+	MaxConcurrentRetries int `mapstructure:"max_concurrent_retries"`
+
+	// MaxConcurrentBatches limits how many batches dispatch at once (0 = unlimited)
+	MaxConcurrentBatches int `mapstructure:"max_concurrent_batches"`
+
+	// RejectExcessBatches makes DispatchBatch fail with ErrTooManyBatches instead
+	// of blocking when MaxConcurrentBatches is reached
+	RejectExcessBatches bool `mapstructure:"reject_excess_batches"`
+
+	// Compression is the algorithm drivers compress large job payloads with
+	// (gzip, or one registered with RegisterCompressor); empty disables it.
+	// Compressed jobs are flagged, so readers handle them either way
+	Compression string `mapstructure:"compression"`
+
+	// CompressionThreshold is the size in bytes of a payload, encoded as JSON,
+	// from which it is compressed
+	CompressionThreshold int `mapstructure:"compression_threshold"`
+
+	// SigningKeys make drivers sign the jobs they write with HMAC-SHA256 under
+	// the first key, and dead-letter read jobs not signed with any of them
+	SigningKeys []string `mapstructure:"signing_keys"`
+
+	// Serializer is the codec drivers write jobs in (json, gob, msgpack,
+	// protobuf, or one registered with RegisterCodec). Jobs in any registered
+	// format are always readable, so this can be changed gradually
+	Serializer string `mapstructure:"serializer"`
+
+	// MaxPayloadDepth rejects stored jobs nested deeper than this when drivers
+	// decode them, counting the job itself as 1 (0 = unlimited)
+	MaxPayloadDepth int `mapstructure:"max_payload_depth"`
+
+	// MaxPayloadKeys rejects stored jobs with more keys than this in any single
+	// object when drivers decode them (0 = unlimited)
+	MaxPayloadKeys int `mapstructure:"max_payload_keys"`
+
 	// Options contains driver-specific options
 	Options map[string]interface{} `mapstructure:"options"`
 
@@ -58,17 +199,50 @@ type Config struct {
 	// If false, Start() will be a no-op (useful for web-only or scheduler-only modes)
 	WorkerEnabled bool `mapstructure:"worker_enabled"`
 
+	// RetryDecider decides whether a failed or timed-out job is retried and after
+	// what delay, replacing the MaxAttempts check and RetryDelay backoff. The delay
+	// is measured from when the attempt failed, as the backoff is, not from the
+	// job's creation. It can use job.Attempts to cap retries per error. If nil,
+	// the defaults apply
+	RetryDecider func(job *Job, err error) (retry bool, delay time.Duration)
+
+	// PriorityClass maps a job priority to the class reported in metrics
+	// If nil, DefaultPriorityClass is used
+	PriorityClass func(priority int) string
+
+	// LogFormat is the format of the fallback logging used when Logger is nil
+	// ("text" or "json")
+	LogFormat string `mapstructure:"log_format"`
+
 	// Logger is used for structured logging (optional)
 	// If nil, no logging will be performed
 	Logger Logger
 }
 
+// Dispatch strategies for Config.DispatchStrategy.
+const (
+	// DispatchFIFO pops one job per served queue on each poll
+	DispatchFIFO = "fifo"
+	// DispatchCapacity pops jobs on each poll until the worker pools' free
+	// buffer space is used, so fast pools aren't starved by a slow, full one
+	DispatchCapacity = "capacity"
+)
+
+// Handler execution modes for Config.HandlerExecution.
+const (
+	// HandlerGoroutine runs each handler in its own goroutine
+	HandlerGoroutine = "goroutine"
+	// HandlerInline runs handlers on the worker goroutine with a cooperative timeout
+	HandlerInline = "inline"
+)
+
 // Decode decodes the driver options into the target struct.
 func (c Config) Decode(target interface{}) error {
 	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		Metadata: nil,
-		Result:   target,
-		TagName:  "mapstructure",
+		Metadata:   nil,
+		Result:     target,
+		TagName:    "mapstructure",
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
 	})
 	if err != nil {
 		return err
@@ -79,17 +253,37 @@ func (c Config) Decode(target interface{}) error {
 // DefaultConfig returns a configuration with sensible defaults.
 func DefaultConfig() Config {
 	return Config{
-		Driver:        "memory",
-		Connection:    "default",
-		Prefix:        "queue",
-		DefaultQueue:  "default",
-		MaxAttempts:   3,
-		Timeout:       30 * time.Second,
-		RetryDelay:    time.Second,
-		Workers:       5,
-		Options:       make(map[string]interface{}),
-		Logger:        nil, // No logging by default
-		WorkerEnabled: true,
+		Driver:               "memory",
+		Connection:           "default",
+		Prefix:               "queue",
+		DefaultQueue:         "default",
+		MaxAttempts:          3,
+		Timeout:              30 * time.Second,
+		RetryDelay:           time.Second,
+		IdempotencyTTL:       24 * time.Hour,
+		Workers:              5,
+		PollInterval:         100 * time.Millisecond,
+		PollJitter:           0,
+		DispatchStrategy:     DispatchFIFO,
+		HandlerExecution:     HandlerGoroutine,
+		ThroughputWindow:     10 * time.Second,
+		RemovedWorkerPolicy:  RemovedWorkerDeadLetter,
+		RemovedWorkerGrace:   time.Minute,
+		DrainIdle:            time.Second,
+		PurgeExpiredInterval: time.Minute,
+		FailedPruneInterval:  time.Minute,
+		DedupSweepInterval:   5 * time.Minute,
+		OrphanCheckInterval:  time.Minute,
+		StalledCheckInterval: 30 * time.Second,
+		ReapFactor:           2,
+		RegisterInterval:     15 * time.Second,
+		AutoscaleInterval:    5 * time.Second,
+		Serializer:           "json",
+		CompressionThreshold: 1024,
+		Options:              make(map[string]interface{}),
+		LogFormat:            LogFormatText,
+		Logger:               nil, // No logging by default
+		WorkerEnabled:        true,
 	}
 }
 
@@ -26,6 +26,9 @@ func TestConfig_DefaultConfig(t *testing.T) {
 	if cfg.Workers != 5 {
 		t.Errorf("Expected Workers 5, got %d", cfg.Workers)
 	}
+	if cfg.PollInterval != 100*time.Millisecond {
+		t.Errorf("Expected PollInterval 100ms, got %v", cfg.PollInterval)
+	}
 }
 
 func TestBatchConfig_DefaultBatchConfig(t *testing.T) {
@@ -0,0 +1,95 @@
+package dgqueue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DedupStore records keys that have already been seen.
+// Drivers that implement this interface are used automatically by SetDriver,
+// so deduplication is shared across every process using the same backend.
+type DedupStore interface {
+	// Claim records the key and reports whether it was newly claimed
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Release removes a previously claimed key
+	Release(ctx context.Context, key string) error
+}
+
+// DedupChecker is implemented by DedupStores that can test a key without
+// claiming it. Idempotency checks use it, so a check never makes a key look
+// processed to another instance checking at the same time.
+type DedupChecker interface {
+	// Exists reports whether the key is claimed and hasn't expired
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// dedupSweeper is implemented by stores that need expired keys removed periodically.
+type dedupSweeper interface {
+	sweep(now time.Time) int
+}
+
+// memoryDedupStore is a process-local DedupStore.
+type memoryDedupStore struct {
+	keys map[string]time.Time
+	mu   sync.Mutex
+}
+
+// NewMemoryDedupStore creates a process-local deduplication store.
+func NewMemoryDedupStore() DedupStore {
+	return &memoryDedupStore{
+		keys: make(map[string]time.Time),
+	}
+}
+
+// Claim records the key and reports whether it was newly claimed.
+func (s *memoryDedupStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if expiresAt, exists := s.keys[key]; exists && (expiresAt.IsZero() || now.Before(expiresAt)) {
+		return false, nil
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
+	}
+	s.keys[key] = expiresAt
+	return true, nil
+}
+
+// Release removes a previously claimed key.
+func (s *memoryDedupStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+	return nil
+}
+
+// Exists reports whether the key is claimed and hasn't expired.
+func (s *memoryDedupStore) Exists(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, exists := s.keys[key]
+	return exists && (expiresAt.IsZero() || time.Now().Before(expiresAt)), nil
+}
+
+// sweep removes expired keys, returning how many were removed.
+func (s *memoryDedupStore) sweep(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key, expiresAt := range s.keys {
+		if !expiresAt.IsZero() && !now.Before(expiresAt) {
+			delete(s.keys, key)
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,65 @@
+package dgqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryDedupStore_Claim(t *testing.T) {
+	store := NewMemoryDedupStore()
+	ctx := context.Background()
+
+	claimed, err := store.Claim(ctx, "key", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = store.Claim(ctx, "key", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, claimed, "Expected second claim to be rejected")
+}
+
+func TestMemoryDedupStore_Expiry(t *testing.T) {
+	store := NewMemoryDedupStore()
+	ctx := context.Background()
+
+	store.Claim(ctx, "key", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	claimed, err := store.Claim(ctx, "key", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, claimed, "Expected expired key to be claimable")
+}
+
+func TestMemoryDedupStore_Exists(t *testing.T) {
+	store := NewMemoryDedupStore().(DedupChecker)
+	ctx := context.Background()
+
+	exists, err := store.Exists(ctx, "key")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// Checking doesn't claim the key
+	claimed, _ := store.(DedupStore).Claim(ctx, "key", 10*time.Millisecond)
+	assert.True(t, claimed)
+	exists, _ = store.Exists(ctx, "key")
+	assert.True(t, exists)
+
+	time.Sleep(20 * time.Millisecond)
+	exists, _ = store.Exists(ctx, "key")
+	assert.False(t, exists, "Expected expired key not to exist")
+}
+
+func TestMemoryDedupStore_Release(t *testing.T) {
+	store := NewMemoryDedupStore()
+	ctx := context.Background()
+
+	store.Claim(ctx, "key", time.Minute)
+	assert.NoError(t, store.Release(ctx, "key"))
+
+	claimed, err := store.Claim(ctx, "key", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, claimed, "Expected released key to be claimable")
+}
@@ -0,0 +1,197 @@
+package dgqueue
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RunUntilEmpty processes jobs from the given queues (the served queues if none
+// are given) until they have stayed empty for Config.DrainIdle, then stops the
+// workers and returns. It suits short-lived workers, such as a Kubernetes Job
+// that drains a backlog and exits.
+//
+// With Config.DrainWaitDelayed, delayed jobs (including retries waiting for
+// their backoff) keep it running until they are processed; otherwise they are
+// left in the queue. The driver is not closed.
+func (m *Manager) RunUntilEmpty(ctx context.Context, queues []string) error {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return fmt.Errorf("queue already running")
+	}
+	if len(queues) == 0 {
+		queues = m.servedQueues()
+	}
+	m.stopChan = make(chan struct{})
+	m.running = true
+	for _, pool := range m.workers {
+		m.startWorkerPool(pool)
+	}
+	m.mu.Unlock()
+
+	m.logInfo("Queue manager draining", "queues", queues)
+	defer m.stopDrain()
+
+	var idleSince time.Time
+	for {
+		// Paused queues are left as they are
+		active := m.unpaused(queues)
+
+		popped := m.drainRound(ctx, active)
+
+		now := m.clock.Now()
+		if popped || !m.poolsIdle() || (m.config.DrainWaitDelayed && m.pending(ctx, active)) {
+			idleSince = time.Time{}
+		} else if idleSince.IsZero() {
+			idleSince = now
+		} else if now.Sub(idleSince) >= m.drainIdle() {
+			m.logInfo("Queue manager drained", "queues", queues)
+			return nil
+		}
+
+		select {
+		case <-m.clock.After(m.pollInterval()):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Drain stops the manager for a deployment: it stops fetching jobs, sends
+// jobs buffered in worker pools back to their queues right away, and waits for
+// running handlers to finish before closing the driver.
+//
+// If ctx ends first, Drain returns its error and leaves the driver open, so
+// handlers still running can acknowledge their jobs before the process exits.
+func (m *Manager) Drain(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.running {
+		m.mu.Unlock()
+		return nil
+	}
+
+	m.logInfo("Queue manager draining for shutdown", "workers", len(m.workers))
+
+	m.running = false
+	close(m.stopChan)
+	pools := make([]*workerPool, 0, len(m.workers))
+	for _, pool := range m.workers {
+		pools = append(pools, pool)
+	}
+	m.mu.Unlock()
+
+	// Stop fetching first so nothing new lands in the pools
+	m.wg.Wait()
+	m.unregisterInstance(ctx)
+
+	for _, pool := range pools {
+		close(pool.stopChan)
+	}
+	for _, pool := range pools {
+		m.requeuePool(ctx, pool, m.driver)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for _, pool := range pools {
+			pool.wg.Wait()
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		m.logWarn("Queue manager drain deadline passed with handlers running")
+		return fmt.Errorf("drain: handlers still running: %w", ctx.Err())
+	}
+
+	if m.driver != nil {
+		if err := m.driver.Close(); err != nil {
+			m.logError("Failed to close driver", err)
+			return fmt.Errorf("failed to close driver: %w", err)
+		}
+	}
+
+	m.logInfo("Queue manager drained for shutdown")
+	return nil
+}
+
+// drainRound pops jobs from the queues until they are empty or the worker
+// pools are full, reporting whether any job was popped.
+func (m *Manager) drainRound(ctx context.Context, queues []string) bool {
+	budget := m.freeCapacity()
+	if budget <= 0 {
+		// The pools are full, or there are none: take one job per queue, as a poll does
+		popped := false
+		for _, queue := range queues {
+			if m.fetchAndDispatchFrom(ctx, queue) {
+				popped = true
+			}
+		}
+		return popped
+	}
+
+	popped := false
+	for budget > 0 && ctx.Err() == nil {
+		round := 0
+		for _, queue := range queues {
+			n := m.fetchAndDispatchN(ctx, queue, budget)
+			round += n
+			budget -= n
+			if budget <= 0 {
+				break
+			}
+		}
+		if round == 0 {
+			break
+		}
+		popped = true
+		budget = min(budget, m.freeCapacity())
+	}
+	return popped
+}
+
+// stopDrain stops the workers started by RunUntilEmpty, letting in-flight
+// jobs finish, and sends jobs still buffered in the pools back to their queues.
+func (m *Manager) stopDrain() {
+	m.mu.Lock()
+	m.running = false
+	close(m.stopChan)
+	m.mu.Unlock()
+
+	m.stopWorkerPools()
+	if requeued := m.requeueBuffered(context.Background(), m.driver, m.driver); requeued > 0 {
+		m.logInfo("Requeued buffered jobs", "count", requeued)
+	}
+}
+
+// drainIdle returns how long queues must stay empty before RunUntilEmpty returns.
+func (m *Manager) drainIdle() time.Duration {
+	if m.config.DrainIdle <= 0 {
+		return time.Second
+	}
+	return m.config.DrainIdle
+}
+
+// poolsIdle reports whether no worker pool has buffered or running jobs.
+func (m *Manager) poolsIdle() bool {
+	for _, stat := range m.PoolStats() {
+		if stat.Buffered > 0 || stat.Busy > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// pending reports whether any of the queues still holds jobs, delayed ones included.
+// Errors count as pending so a flaky driver doesn't end the drain early.
+func (m *Manager) pending(ctx context.Context, queues []string) bool {
+	for _, queue := range queues {
+		if size, err := m.driver.Size(ctx, queue); err != nil || size > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,281 @@
+package dgqueue_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDrainManager(waitDelayed bool) (*dgqueue.Manager, dgqueue.Driver, *atomic.Int64) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 10 * time.Millisecond
+	cfg.DrainIdle = 100 * time.Millisecond
+	cfg.DrainWaitDelayed = waitDelayed
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	processed := &atomic.Int64{}
+	manager.Worker("backlog-job", 3, func(ctx context.Context, job *dgqueue.Job) error {
+		time.Sleep(10 * time.Millisecond)
+		processed.Add(1)
+		return nil
+	})
+	return manager, d, processed
+}
+
+func TestManager_RunUntilEmpty(t *testing.T) {
+	manager, d, processed := newDrainManager(false)
+
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		_, err := manager.Dispatch(ctx, "backlog-job", i)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, manager.RunUntilEmpty(ctx, nil))
+	assert.Equal(t, int64(20), processed.Load(), "Expected every job to complete before returning")
+
+	size, _ := d.Size(ctx, "default")
+	assert.Equal(t, int64(0), size)
+}
+
+func TestManager_RunUntilEmptySkipsDelayed(t *testing.T) {
+	manager, d, processed := newDrainManager(false)
+
+	ctx := context.Background()
+	manager.Dispatch(ctx, "backlog-job", "now")
+	manager.DispatchAfter(ctx, "backlog-job", "later", time.Second)
+
+	start := time.Now()
+	assert.NoError(t, manager.RunUntilEmpty(ctx, nil))
+	assert.Less(t, time.Since(start), time.Second, "Expected not to wait for the delayed job")
+	assert.Equal(t, int64(1), processed.Load())
+
+	size, _ := d.Size(ctx, "default")
+	assert.Equal(t, int64(1), size, "Expected the delayed job to be left queued")
+}
+
+func TestManager_RunUntilEmptyWaitsForDelayed(t *testing.T) {
+	manager, d, processed := newDrainManager(true)
+
+	ctx := context.Background()
+	manager.Dispatch(ctx, "backlog-job", "now")
+	manager.DispatchAfter(ctx, "backlog-job", "later", 300*time.Millisecond)
+
+	assert.NoError(t, manager.RunUntilEmpty(ctx, nil))
+	assert.Equal(t, int64(2), processed.Load())
+
+	size, _ := d.Size(ctx, "default")
+	assert.Equal(t, int64(0), size)
+}
+
+func TestManager_RunUntilEmptyCanceled(t *testing.T) {
+	manager, _, _ := newDrainManager(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	manager.DispatchAfter(ctx, "backlog-job", "much later", time.Hour)
+
+	assert.ErrorIs(t, manager.RunUntilEmpty(ctx, nil), context.DeadlineExceeded)
+
+	// The manager can be started normally afterwards
+	assert.NoError(t, manager.RunUntilEmpty(context.Background(), []string{"other"}))
+}
+
+func TestManager_RunUntilEmptyFetchesUntilEmpty(t *testing.T) {
+	manager, _, processed := newDrainManager(false)
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 300 * time.Millisecond
+	cfg.DrainIdle = 50 * time.Millisecond
+	d, _ := memory.NewDriver(cfg)
+	assert.NoError(t, manager.Reload(context.Background(), cfg, d))
+
+	ctx := context.Background()
+	for i := 0; i < 6; i++ {
+		_, err := manager.Dispatch(ctx, "backlog-job", i)
+		assert.NoError(t, err)
+	}
+
+	// Taking one job per poll would need six polls
+	start := time.Now()
+	assert.NoError(t, manager.RunUntilEmpty(ctx, nil))
+	assert.Less(t, time.Since(start), 1200*time.Millisecond)
+	assert.Equal(t, int64(6), processed.Load())
+}
+
+func TestManager_RunUntilEmptyRequeuesBuffered(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	release := make(chan struct{})
+	manager.Worker("backlog-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < 3; i++ {
+		manager.Dispatch(ctx, "backlog-job", i)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- manager.RunUntilEmpty(ctx, nil) }()
+	assert.Eventually(t, func() bool {
+		stat := manager.PoolStats()["backlog-job"]
+		return stat.Busy == 1 && stat.Buffered == 2
+	}, time.Second, time.Millisecond)
+
+	// Let the drain stop the pool before the running job finishes
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	size, _ := d.Size(context.Background(), "default")
+	assert.Equal(t, int64(2), size, "Expected buffered jobs to be sent back to the queue")
+}
+
+// closeRecorder is a memory driver that records Close instead of clearing its
+// jobs and, like network drivers, fails pushes once their context is done.
+type closeRecorder struct {
+	dgqueue.Driver
+	closed atomic.Bool
+}
+
+func (d *closeRecorder) Push(ctx context.Context, job *dgqueue.Job) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.Driver.Push(ctx, job)
+}
+
+func (d *closeRecorder) Close() error {
+	d.closed.Store(true)
+	return nil
+}
+
+// newBlockedManager starts a manager whose single worker is stuck on the first
+// of five jobs, with two more buffered in its pool.
+func newBlockedManager(t *testing.T) (*dgqueue.Manager, *closeRecorder, chan struct{}) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	inner, _ := memory.NewDriver(cfg)
+	driver := &closeRecorder{Driver: inner}
+	manager.SetDriver(driver)
+
+	release := make(chan struct{})
+	manager.Worker("deploy", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		<-release
+		return nil
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		manager.Dispatch(ctx, "deploy", i)
+	}
+	assert.NoError(t, manager.Start())
+	assert.Eventually(t, func() bool {
+		stat := manager.PoolStats()["deploy"]
+		return stat.Busy == 1 && stat.Buffered == 2
+	}, time.Second, time.Millisecond)
+
+	return manager, driver, release
+}
+
+func TestManager_Drain(t *testing.T) {
+	manager, driver, release := newBlockedManager(t)
+	ctx := context.Background()
+
+	drained := make(chan error, 1)
+	go func() { drained <- manager.Drain(ctx) }()
+
+	// Buffered jobs go back to the queue while the running one finishes
+	assert.Eventually(t, func() bool {
+		size, _ := driver.Size(ctx, "default")
+		return size == 4
+	}, time.Second, time.Millisecond)
+	assert.False(t, driver.closed.Load())
+
+	close(release)
+	assert.NoError(t, <-drained)
+	assert.True(t, driver.closed.Load())
+	assert.Zero(t, manager.PoolStats()["deploy"].Buffered)
+}
+
+func TestManager_DrainDeadline(t *testing.T) {
+	manager, driver, release := newBlockedManager(t)
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// The driver stays open for the handler that is still running
+	assert.ErrorIs(t, manager.Drain(ctx), context.DeadlineExceeded)
+	assert.False(t, driver.closed.Load())
+	size, _ := driver.Size(context.Background(), "default")
+	assert.Equal(t, int64(4), size)
+}
+
+func TestManager_StopRequeuesBuffered(t *testing.T) {
+	manager, driver, release := newBlockedManager(t)
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+
+	assert.NoError(t, manager.Stop(context.Background()))
+	size, _ := driver.Size(context.Background(), "default")
+	assert.Equal(t, int64(4), size)
+}
+
+func TestManager_StopRequeuesWithEndedContext(t *testing.T) {
+	manager, driver, release := newBlockedManager(t)
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, manager.Stop(ctx))
+	size, _ := driver.Size(context.Background(), "default")
+	assert.Equal(t, int64(4), size)
+}
+
+func TestManager_StopRequeuesOrderedLanes(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	inner, _ := memory.NewDriver(cfg)
+	driver := &closeRecorder{Driver: inner}
+	manager.SetDriver(driver)
+
+	release := make(chan struct{})
+	manager.OrderedWorker("ordered", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		<-release
+		return nil
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		manager.Enqueue(ctx, dgqueue.WithPartitionKey(manager.NewJob("ordered", i), "account-1"))
+	}
+	assert.NoError(t, manager.Start())
+	assert.Eventually(t, func() bool {
+		stat := manager.PoolStats()["ordered"]
+		return stat.Busy == 1 && stat.Buffered == 2
+	}, time.Second, time.Millisecond)
+
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+	assert.NoError(t, manager.Stop(ctx))
+	size, _ := driver.Size(ctx, "default")
+	assert.Equal(t, int64(2), size)
+}
@@ -0,0 +1,211 @@
+package dgqueue
+
+import (
+	"context"
+	"time"
+)
+
+// DelayLimiter is implemented by drivers that cap how far a job can be delayed
+// (e.g. SQS allows at most 15 minutes).
+// Every dispatch clamps the job's delay, counted from its creation, so the
+// returned job reports the effective AvailableAt rather than the requested one.
+type DelayLimiter interface {
+	// MaxDelay returns the longest supported delay (0 means unlimited)
+	MaxDelay() time.Duration
+}
+
+// GlobalPauser is implemented by drivers that can store a pause flag shared by
+// every manager instance using the same backend.
+// While the flag is set, dispatchers stop fetching jobs; dispatching still works.
+type GlobalPauser interface {
+	// SetPaused sets or clears the global pause flag
+	SetPaused(ctx context.Context, paused bool) error
+
+	// IsPaused reports whether the global pause flag is set
+	IsPaused(ctx context.Context) (bool, error)
+}
+
+// ExpiredPurger is implemented by drivers that can remove jobs whose TTL has
+// passed before they were ever processed.
+// The manager calls it periodically for every served queue.
+type ExpiredPurger interface {
+	// PurgeExpired removes expired jobs from the queue, returning how many were removed
+	PurgeExpired(ctx context.Context, queue string) (int64, error)
+}
+
+// OldestJobAger is implemented by drivers that can report how long the job at
+// the head of a queue has been waiting, a staleness signal depth alone can't give.
+type OldestJobAger interface {
+	// OldestJobAge returns the wait time of the oldest ready job (0 when none are ready)
+	OldestJobAge(ctx context.Context, queue string) (time.Duration, error)
+}
+
+// QueueLister is implemented by drivers that can enumerate their queues.
+type QueueLister interface {
+	// Queues returns the names of queues currently holding jobs
+	Queues(ctx context.Context) ([]string, error)
+}
+
+// ConsumerTracker is implemented by drivers that can record which queues are
+// served by running instances, so queues nobody polls can be detected across
+// every instance sharing the backend.
+type ConsumerTracker interface {
+	// MarkServed records that an instance serves the queues for the next ttl
+	MarkServed(ctx context.Context, queues []string, ttl time.Duration) error
+
+	// IsServed reports whether any instance has marked the queue served within its ttl
+	IsServed(ctx context.Context, queue string) (bool, error)
+}
+
+// FailedPruner is implemented by drivers whose failed store can be pruned, so
+// it doesn't grow without bound.
+type FailedPruner interface {
+	// PruneFailed removes the failed jobs that failed before cutoff (unless it
+	// is zero) or aren't among the newest keep (unless keep is 0), oldest
+	// first. Each job is passed to archive, if not nil, before it is removed;
+	// pruning stops at the first archive error. It returns how many jobs were
+	// removed.
+	PruneFailed(ctx context.Context, cutoff time.Time, keep int, archive func(*Job) error) (int64, error)
+}
+
+// InstanceRegistry is implemented by drivers that can record the manager
+// instances consuming from them, so operators can see which processes serve
+// which queues across every instance sharing the backend.
+type InstanceRegistry interface {
+	// RegisterInstance records the instance for the next ttl, replacing the
+	// record it registered before
+	RegisterInstance(ctx context.Context, info InstanceInfo, ttl time.Duration) error
+
+	// UnregisterInstance removes the record of the instance with the given ID
+	UnregisterInstance(ctx context.Context, id string) error
+
+	// Instances returns the instances registered within their ttl
+	Instances(ctx context.Context) ([]InstanceInfo, error)
+}
+
+// RateLimiter is implemented by drivers that can keep token buckets shared by
+// every instance using the same backend, for Manager.RateLimit.
+type RateLimiter interface {
+	// TakeToken takes a token from the bucket named key, which holds up to n
+	// tokens and refills at n per interval. It returns 0 if a token was taken,
+	// or how long until one is available if the bucket is empty
+	TakeToken(ctx context.Context, key string, n int, per time.Duration) (time.Duration, error)
+}
+
+// ConcurrencyLimiter is implemented by drivers that can keep semaphores shared
+// by every instance using the same backend, for Manager.Funnel.
+type ConcurrencyLimiter interface {
+	// AcquireSlot takes one of limit slots of the semaphore named key for
+	// holder, reporting false if all are taken. Slots not released within ttl
+	// expire, so a crashed holder can't keep its slot forever
+	AcquireSlot(ctx context.Context, key, holder string, limit int, ttl time.Duration) (bool, error)
+
+	// ReleaseSlot releases holder's slot of the semaphore named key
+	ReleaseSlot(ctx context.Context, key, holder string) error
+}
+
+// BatchStore is implemented by drivers that can store batch progress, so it
+// survives restarts and can be followed from every instance sharing the backend.
+type BatchStore interface {
+	// SaveBatch records the batch's status under its ID for the next ttl
+	SaveBatch(ctx context.Context, status *BatchStatus, ttl time.Duration) error
+
+	// Batch returns the status saved under id, or ErrBatchNotFound
+	Batch(ctx context.Context, id string) (*BatchStatus, error)
+}
+
+// BatchPopper is implemented by drivers that can pop several jobs in one round trip.
+// The dispatcher uses it whenever it wants more than one job from a queue.
+type BatchPopper interface {
+	// PopN pops up to n available jobs in the order Pop would return them,
+	// or returns ErrQueueEmpty if there are none
+	PopN(ctx context.Context, queue string, n int) ([]*Job, error)
+}
+
+// Notifier is implemented by drivers that can signal pushed jobs, so the
+// dispatcher fetches them right away instead of at the next poll.
+// Notifications are best-effort; the dispatcher keeps polling as a backstop.
+type Notifier interface {
+	// Notify returns a channel that receives a job's queue name when it is pushed.
+	// The channel is closed once ctx is done or the subscription fails.
+	Notify(ctx context.Context) (<-chan string, error)
+}
+
+// HealthChecker is implemented by drivers that can check their backend is reachable.
+type HealthChecker interface {
+	// Ping returns an error if the backend can't currently be reached
+	Ping(ctx context.Context) error
+}
+
+// StalledJobRecoverer is implemented by drivers that hold popped jobs until
+// they are deleted, retried or failed, so jobs held by an instance that died
+// mid-processing can be returned to their queues.
+// The manager heartbeats and recovers periodically.
+type StalledJobRecoverer interface {
+	// Heartbeat marks the jobs this instance holds as alive for the next ttl
+	Heartbeat(ctx context.Context, ttl time.Duration) error
+
+	// RecoverStalled requeues jobs held by instances whose heartbeat expired,
+	// returning how many were requeued
+	RecoverStalled(ctx context.Context) (int64, error)
+}
+
+// OrphanReaper is implemented by drivers that can hand jobs held by stopped
+// instances back to the manager instead of requeuing them, so the attempt that
+// was lost counts and jobs that keep killing their worker are dead-lettered.
+// The manager retries or fails every job returned.
+type OrphanReaper interface {
+	// ReapOrphaned takes over the jobs whose holder hasn't heartbeated for
+	// olderThan and returns them, held by this instance
+	ReapOrphaned(ctx context.Context, olderThan time.Duration) ([]*Job, error)
+}
+
+// BackendIdentifier is implemented by drivers that can name the backend their
+// jobs are stored in. Drivers reporting the same ID share their jobs, so Reload
+// leaves the jobs where they are instead of moving them.
+type BackendIdentifier interface {
+	BackendID() string
+}
+
+// JobMigrator is implemented by drivers that can hand over every job they
+// store, so Reload moves delayed and failed jobs along with ready ones.
+type JobMigrator interface {
+	// MigrateJobs calls fn for each waiting (ready or delayed) and failed job,
+	// removing the job once fn returns nil, and stops at the first error.
+	// Jobs held by an instance are left in place.
+	MigrateJobs(ctx context.Context, fn func(job *Job, failed bool) error) error
+}
+
+// JobRunner runs a job through the worker registered for its name.
+type JobRunner func(ctx context.Context, job *Job) error
+
+// RunnerBinder is implemented by drivers that run jobs themselves instead of
+// queueing them. SetDriver binds the manager's runner, which executes the job's
+// middleware and handler on the caller's goroutine and returns the handler's error.
+type RunnerBinder interface {
+	BindRunner(run JobRunner)
+}
+
+// Capabilities describes which Driver operations a driver fully supports.
+type Capabilities struct {
+	// Get reports whether jobs can be looked up by ID
+	Get bool
+
+	// Delete reports whether waiting jobs can be removed by ID, rather than
+	// only jobs the driver has popped
+	Delete bool
+}
+
+// CapabilityReporter is implemented by drivers that don't support every Driver
+// operation. Drivers that don't implement it are assumed to support them all.
+type CapabilityReporter interface {
+	Capabilities() Capabilities
+}
+
+// DriverCapabilities returns what the driver supports.
+func DriverCapabilities(driver Driver) Capabilities {
+	if reporter, ok := driver.(CapabilityReporter); ok {
+		return reporter.Capabilities()
+	}
+	return Capabilities{Get: true, Delete: true}
+}
@@ -0,0 +1,442 @@
+package bbolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/donnigundala/dg-core/contracts/queue"
+	dgqueue "github.com/donnigundala/dg-queue"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket names.
+var (
+	// jobsBucket maps job IDs to serialized jobs, including jobs being processed
+	jobsBucket = []byte("jobs")
+	// indexBucket maps IDs of waiting jobs to their queue and ready key
+	indexBucket = []byte("index")
+	// queuesBucket holds a bucket per queue mapping ready keys to job IDs
+	queuesBucket = []byte("queues")
+	// failedBucket maps job IDs to permanently failed jobs
+	failedBucket = []byte("failed")
+)
+
+// Driver is an embedded queue driver backed by a bbolt file.
+// Each queue is a bucket ordered by when its jobs become available, so Pop
+// takes the first key; jobs being processed stay in the jobs bucket until
+// they are deleted, retried or failed.
+//
+// bbolt locks the file for one process, so jobs left in processing by a crash
+// are requeued when the file is opened again.
+type Driver struct {
+	db       *bolt.DB
+	ownsDB   bool
+	encoding dgqueue.JobEncoding
+}
+
+func init() {
+	dgqueue.RegisterDriver("bbolt", NewDriver)
+}
+
+// Config represents the bbolt driver configuration.
+type Config struct {
+	// Path is the database file, created if missing (default "<prefix>.bolt")
+	Path string `mapstructure:"path"`
+
+	// OpenTimeout bounds how long to wait for another process to release
+	// the file lock (default 1s)
+	OpenTimeout time.Duration `mapstructure:"open_timeout"`
+}
+
+// NewDriver creates a new bbolt queue driver, creating the database file if needed.
+func NewDriver(config dgqueue.Config) (dgqueue.Driver, error) {
+	var boltConfig Config
+	if err := config.Decode(&boltConfig); err != nil {
+		return nil, err
+	}
+
+	encoding, err := dgqueue.NewJobEncoding(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if boltConfig.Path == "" {
+		boltConfig.Path = config.Prefix + ".bolt"
+	}
+	if boltConfig.OpenTimeout <= 0 {
+		boltConfig.OpenTimeout = time.Second
+	}
+
+	db, err := bolt.Open(boltConfig.Path, 0o600, &bolt.Options{Timeout: boltConfig.OpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bbolt database %s: %w", boltConfig.Path, err)
+	}
+
+	driver, err := NewDriverWithDB(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	driver.ownsDB = true
+	driver.encoding = encoding
+
+	return driver, nil
+}
+
+// NewDriverWithDB creates a new bbolt queue driver with an open database,
+// creating its buckets and requeuing jobs that were being processed when the
+// database was last closed. Close does not close a shared database.
+func NewDriverWithDB(db *bolt.DB) (*Driver, error) {
+	driver := &Driver{
+		db:       db,
+		encoding: dgqueue.JobEncoding{Format: dgqueue.FormatJSON},
+	}
+
+	if err := driver.recover(); err != nil {
+		return nil, fmt.Errorf("failed to recover bbolt queue: %w", err)
+	}
+	return driver, nil
+}
+
+// SetFormat sets the format new jobs are written in.
+// Jobs already stored in other formats remain readable.
+func (d *Driver) SetFormat(format dgqueue.JobFormat) {
+	d.encoding.Format = format
+}
+
+// SetCompression sets how the payloads of new jobs are compressed.
+// Compressed jobs already stored remain readable either way.
+func (d *Driver) SetCompression(compression dgqueue.Compression) {
+	d.encoding.Compression = compression
+}
+
+// SetDecodeLimits sets the limits stored jobs are checked against.
+func (d *Driver) SetDecodeLimits(limits dgqueue.DecodeLimits) {
+	d.encoding.Limits = limits
+}
+
+// SetSigningKeys sets the keys jobs are signed and checked with; see
+// dgqueue.JobEncoding.SigningKeys.
+func (d *Driver) SetSigningKeys(keys ...[]byte) {
+	d.encoding.SigningKeys = keys
+}
+
+// recover creates the buckets and requeues jobs with no ready key. Jobs that
+// can't be decoded are moved to the failed bucket as they are.
+func (d *Driver) recover() error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{jobsBucket, indexBucket, queuesBucket, failedBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		index := tx.Bucket(indexBucket)
+		var orphaned []*queue.Job
+		var poisoned [][]byte
+		err := tx.Bucket(jobsBucket).ForEach(func(id, data []byte) error {
+			if index.Get(id) != nil {
+				return nil
+			}
+			job, err := dgqueue.UnmarshalJob(data)
+			if err != nil {
+				poisoned = append(poisoned, id)
+				return nil
+			}
+			orphaned = append(orphaned, job)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, job := range orphaned {
+			if err := enqueue(tx, job.ID, job.Queue, job.AvailableAt); err != nil {
+				return err
+			}
+		}
+		for _, id := range poisoned {
+			if err := setAside(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// setAside moves a stored job that can't be decoded to the failed bucket as
+// it is, so it is neither lost nor requeued forever.
+func setAside(tx *bolt.Tx, id []byte) error {
+	jobs := tx.Bucket(jobsBucket)
+	data := bytes.Clone(jobs.Get(id))
+	if data == nil {
+		return nil
+	}
+	if err := dequeue(tx, string(id)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(failedBucket).Put(id, data); err != nil {
+		return err
+	}
+	return jobs.Delete(id)
+}
+
+// readyKey orders jobs by availability, then by insertion.
+func readyKey(availableAt time.Time, seq uint64) []byte {
+	var nanos uint64
+	if availableAt.After(time.Unix(0, 0)) {
+		nanos = uint64(availableAt.UnixNano())
+	}
+
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, nanos)
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+// enqueue adds a stored job to its queue's ready bucket.
+func enqueue(tx *bolt.Tx, id, queueName string, availableAt time.Time) error {
+	bucket, err := tx.Bucket(queuesBucket).CreateBucketIfNotExists([]byte(queueName))
+	if err != nil {
+		return err
+	}
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	key := readyKey(availableAt, seq)
+	if err := bucket.Put(key, []byte(id)); err != nil {
+		return err
+	}
+
+	// Index entry is the queue name, a zero byte, then the ready key
+	entry := append(append([]byte(queueName), 0), key...)
+	return tx.Bucket(indexBucket).Put([]byte(id), entry)
+}
+
+// dequeue removes a job from its ready bucket, if it is waiting.
+func dequeue(tx *bolt.Tx, id string) error {
+	index := tx.Bucket(indexBucket)
+	entry := index.Get([]byte(id))
+	if entry == nil {
+		return nil
+	}
+
+	sep := bytes.IndexByte(entry, 0)
+	if bucket := tx.Bucket(queuesBucket).Bucket(entry[:sep]); bucket != nil {
+		if err := bucket.Delete(entry[sep+1:]); err != nil {
+			return err
+		}
+	}
+	return index.Delete([]byte(id))
+}
+
+// Push pushes a job to the queue.
+// Pushing a job whose ID is already stored replaces it.
+func (d *Driver) Push(ctx context.Context, job *queue.Job) error {
+	data, err := d.encoding.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		if err := dequeue(tx, job.ID); err != nil {
+			return err
+		}
+		if err := tx.Bucket(jobsBucket).Put([]byte(job.ID), data); err != nil {
+			return err
+		}
+		return enqueue(tx, job.ID, job.Queue, job.AvailableAt)
+	})
+}
+
+// Pop reserves and returns the next available job in the queue.
+func (d *Driver) Pop(ctx context.Context, queueName string) (*queue.Job, error) {
+	var id, data []byte
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(queuesBucket).Bucket([]byte(queueName))
+		if bucket == nil {
+			return dgqueue.ErrQueueEmpty
+		}
+
+		key, value := bucket.Cursor().First()
+		if key == nil || int64(binary.BigEndian.Uint64(key)) > time.Now().UnixNano() {
+			return dgqueue.ErrQueueEmpty
+		}
+
+		if err := dequeue(tx, string(value)); err != nil {
+			return err
+		}
+		// Copy out; bbolt values are only valid inside the transaction
+		id = bytes.Clone(value)
+		data = bytes.Clone(tx.Bucket(jobsBucket).Get(value))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := d.encoding.Unmarshal(data)
+	if err != nil {
+		// Set aside jobs that can never be decoded instead of recovering them forever
+		d.db.Update(func(tx *bolt.Tx) error {
+			return setAside(tx, id)
+		})
+		return nil, err
+	}
+	return job, nil
+}
+
+// Delete deletes a job.
+func (d *Driver) Delete(ctx context.Context, jobID string) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		if err := dequeue(tx, jobID); err != nil {
+			return err
+		}
+		return tx.Bucket(jobsBucket).Delete([]byte(jobID))
+	})
+}
+
+// Retry stores the job again, available at its AvailableAt.
+func (d *Driver) Retry(ctx context.Context, job *queue.Job) error {
+	return d.Push(ctx, job)
+}
+
+// Failed moves a job to the failed bucket.
+func (d *Driver) Failed(ctx context.Context, job *queue.Job) error {
+	data, err := d.encoding.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		if err := dequeue(tx, job.ID); err != nil {
+			return err
+		}
+		if err := tx.Bucket(jobsBucket).Delete([]byte(job.ID)); err != nil {
+			return err
+		}
+		return tx.Bucket(failedBucket).Put([]byte(job.ID), data)
+	})
+}
+
+// BackendID names the database file.
+func (d *Driver) BackendID() string {
+	path, err := filepath.Abs(d.db.Path())
+	if err != nil {
+		path = d.db.Path()
+	}
+	return "bbolt://" + path
+}
+
+// MigrateJobs hands every waiting and failed job to fn, in queue order,
+// removing each one fn accepts. Jobs being processed and jobs that can't be
+// decoded are left in place.
+func (d *Driver) MigrateJobs(ctx context.Context, fn func(job *queue.Job, failed bool) error) error {
+	var waiting, failed [][]byte
+	err := d.db.View(func(tx *bolt.Tx) error {
+		queues := tx.Bucket(queuesBucket)
+		err := queues.ForEach(func(name, value []byte) error {
+			if value != nil {
+				return nil
+			}
+			return queues.Bucket(name).ForEach(func(_, id []byte) error {
+				waiting = append(waiting, bytes.Clone(id))
+				return nil
+			})
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(failedBucket).ForEach(func(id, _ []byte) error {
+			failed = append(failed, bytes.Clone(id))
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range waiting {
+		if err := d.migrateJob(jobsBucket, id, false, fn); err != nil {
+			return err
+		}
+	}
+	for _, id := range failed {
+		if err := d.migrateJob(failedBucket, id, true, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateJob hands the job stored under id to fn, removing it once fn accepts it.
+func (d *Driver) migrateJob(bucket, id []byte, failed bool, fn func(job *queue.Job, failed bool) error) error {
+	var data []byte
+	d.db.View(func(tx *bolt.Tx) error {
+		data = bytes.Clone(tx.Bucket(bucket).Get(id))
+		return nil
+	})
+	if data == nil {
+		// Taken since the jobs were listed
+		return nil
+	}
+
+	job, err := d.encoding.Unmarshal(data)
+	if err != nil {
+		return nil
+	}
+	if err := fn(job, failed); err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		if err := dequeue(tx, string(id)); err != nil {
+			return err
+		}
+		return tx.Bucket(bucket).Delete(id)
+	})
+}
+
+// Get retrieves a job by ID from the jobs or failed bucket.
+func (d *Driver) Get(ctx context.Context, jobID string) (*queue.Job, error) {
+	var data []byte
+	d.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(jobsBucket).Get([]byte(jobID))
+		if value == nil {
+			value = tx.Bucket(failedBucket).Get([]byte(jobID))
+		}
+		data = append([]byte(nil), value...)
+		return nil
+	})
+	if len(data) == 0 {
+		return nil, dgqueue.ErrJobNotFound
+	}
+
+	return d.encoding.Unmarshal(data)
+}
+
+// Size returns the number of waiting jobs in the queue, including delayed ones.
+func (d *Driver) Size(ctx context.Context, queueName string) (int64, error) {
+	var size int64
+	err := d.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(queuesBucket).Bucket([]byte(queueName)); bucket != nil {
+			size = int64(bucket.Stats().KeyN)
+		}
+		return nil
+	})
+	return size, err
+}
+
+// Close closes the database if the driver opened it.
+func (d *Driver) Close() error {
+	if !d.ownsDB || d.db == nil {
+		return nil
+	}
+	return d.db.Close()
+}
@@ -0,0 +1,263 @@
+package bbolt
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	bolt "go.etcd.io/bbolt"
+)
+
+func setupBoltDriver(t *testing.T) *Driver {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "queue.bolt"), 0o600, nil)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	driver, err := NewDriverWithDB(db)
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	return driver
+}
+
+func TestBoltDriver_PushPop(t *testing.T) {
+	driver := setupBoltDriver(t)
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("test-job", map[string]string{"key": "value"})
+	if err := driver.Push(ctx, job); err != nil {
+		t.Fatalf("Failed to push job: %v", err)
+	}
+
+	popped, err := driver.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Failed to pop job: %v", err)
+	}
+	if popped.ID != job.ID || popped.Name != job.Name {
+		t.Errorf("Expected job %s/%s, got %s/%s", job.ID, job.Name, popped.ID, popped.Name)
+	}
+
+	// Reserved jobs aren't popped again
+	if _, err := driver.Pop(ctx, "default"); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+		t.Errorf("Expected ErrQueueEmpty, got %v", err)
+	}
+
+	// But remain visible until deleted
+	if _, err := driver.Get(ctx, job.ID); err != nil {
+		t.Errorf("Expected reserved job to be found, got %v", err)
+	}
+	driver.Delete(ctx, job.ID)
+	if _, err := driver.Get(ctx, job.ID); !errors.Is(err, dgqueue.ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound after delete, got %v", err)
+	}
+}
+
+func TestBoltDriver_DelayedJob(t *testing.T) {
+	driver := setupBoltDriver(t)
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("delayed-job", "payload")
+	dgqueue.WithDelay(job, time.Hour)
+	driver.Push(ctx, job)
+
+	if _, err := driver.Pop(ctx, "default"); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+		t.Errorf("Expected delayed job to be unavailable, got %v", err)
+	}
+
+	size, _ := driver.Size(ctx, "default")
+	if size != 1 {
+		t.Errorf("Expected size 1, got %d", size)
+	}
+}
+
+func TestBoltDriver_RetryAndFailed(t *testing.T) {
+	driver := setupBoltDriver(t)
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("flaky-job", "payload")
+	driver.Push(ctx, job)
+	popped, _ := driver.Pop(ctx, "default")
+
+	// Retry releases the reservation
+	if err := driver.Retry(ctx, popped); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	popped, err := driver.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Expected retried job to be popped, got %v", err)
+	}
+
+	dgqueue.MarkFailed(popped, errors.New("boom"))
+	if err := driver.Failed(ctx, popped); err != nil {
+		t.Fatalf("Failed failed: %v", err)
+	}
+
+	size, _ := driver.Size(ctx, "default")
+	if size != 0 {
+		t.Errorf("Expected empty queue, got %d", size)
+	}
+	failed, err := driver.Get(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Expected failed job to be found, got %v", err)
+	}
+	if failed.Error != "boom" {
+		t.Errorf("Expected error 'boom', got %q", failed.Error)
+	}
+}
+
+func TestBoltDriver_ConcurrentPop(t *testing.T) {
+	driver := setupBoltDriver(t)
+	ctx := context.Background()
+
+	for i := 0; i < 50; i++ {
+		driver.Push(ctx, dgqueue.NewJob("job", i))
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := driver.Pop(ctx, "default")
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				seen[job.ID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != 50 {
+		t.Errorf("Expected 50 distinct jobs, got %d", len(seen))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("Job %s popped %d times", id, n)
+		}
+	}
+}
+
+func TestBoltDriver_PersistsAcrossRestarts(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.Driver = "bbolt"
+	cfg.Options["path"] = filepath.Join(t.TempDir(), "queue.bolt")
+	ctx := context.Background()
+
+	driver, err := NewDriver(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	job := dgqueue.NewJob("durable-job", "payload")
+	driver.Push(ctx, job)
+	driver.Close()
+
+	reopened, err := NewDriver(cfg)
+	if err != nil {
+		t.Fatalf("Failed to reopen driver: %v", err)
+	}
+	defer reopened.Close()
+
+	popped, err := reopened.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Expected job to survive restart, got %v", err)
+	}
+	if popped.ID != job.ID {
+		t.Errorf("Expected ID %s, got %s", job.ID, popped.ID)
+	}
+}
+
+func TestBoltDriver_RequeuesJobsInProgressOnReopen(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.Driver = "bbolt"
+	cfg.Options["path"] = filepath.Join(t.TempDir(), "queue.bolt")
+	ctx := context.Background()
+
+	driver, err := NewDriver(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	job := dgqueue.NewJob("interrupted-job", "payload")
+	driver.Push(ctx, job)
+	if _, err := driver.Pop(ctx, "default"); err != nil {
+		t.Fatalf("Failed to pop job: %v", err)
+	}
+	// Simulate a crash: the job is never deleted, retried or failed
+	driver.Close()
+
+	reopened, err := NewDriver(cfg)
+	if err != nil {
+		t.Fatalf("Failed to reopen driver: %v", err)
+	}
+	defer reopened.Close()
+
+	popped, err := reopened.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Expected interrupted job to be requeued, got %v", err)
+	}
+	if popped.ID != job.ID {
+		t.Errorf("Expected ID %s, got %s", job.ID, popped.ID)
+	}
+}
+
+func TestBoltDriver_SetsAsideUndecodableJobs(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "queue.bolt"), 0o600, nil)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	driver, err := NewDriverWithDB(db)
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	ctx := context.Background()
+
+	// One garbled job waiting, one garbled job left in processing
+	for _, id := range []string{"waiting", "processing"} {
+		job := dgqueue.NewJob("test-job", id)
+		job.ID = id
+		if err := driver.Push(ctx, job); err != nil {
+			t.Fatalf("Failed to push job: %v", err)
+		}
+		db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(jobsBucket).Put([]byte(id), []byte("garbage"))
+		})
+	}
+	db.Update(func(tx *bolt.Tx) error {
+		return dequeue(tx, "processing")
+	})
+
+	// Reopening sets aside the job in processing instead of failing
+	driver, err = NewDriverWithDB(db)
+	if err != nil {
+		t.Fatalf("Expected reopening to succeed, got %v", err)
+	}
+
+	if _, err := driver.Pop(ctx, "default"); err == nil {
+		t.Fatal("Expected the garbled job to fail to decode")
+	}
+	if _, err := driver.Pop(ctx, "default"); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+		t.Errorf("Expected the garbled job to be set aside, got %v", err)
+	}
+
+	db.View(func(tx *bolt.Tx) error {
+		for _, id := range []string{"waiting", "processing"} {
+			if tx.Bucket(jobsBucket).Get([]byte(id)) != nil || tx.Bucket(failedBucket).Get([]byte(id)) == nil {
+				t.Errorf("Expected job %s in the failed bucket", id)
+			}
+		}
+		return nil
+	})
+}
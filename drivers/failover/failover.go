@@ -0,0 +1,459 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/donnigundala/dg-core/contracts/queue"
+	dgqueue "github.com/donnigundala/dg-queue"
+)
+
+// Driver wraps a primary driver with a fallback, e.g. Redis with a local file
+// spool. Jobs that the primary fails to accept are pushed to the fallback
+// instead, so dispatchers keep working through an outage, and spooled jobs are
+// replayed to the primary once it accepts them again.
+// Workers always pop from the primary.
+type Driver struct {
+	primary  dgqueue.Driver
+	fallback dgqueue.Driver
+
+	mu      sync.Mutex
+	spooled map[string]struct{} // queues with jobs waiting in the fallback
+
+	replayMu  sync.Mutex
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func init() {
+	dgqueue.RegisterDriver("failover", NewDriver)
+}
+
+// Config represents the failover driver configuration.
+type Config struct {
+	// Primary is the driver jobs normally go to
+	Primary Target `mapstructure:"primary"`
+
+	// Fallback receives jobs while the primary is failing
+	Fallback Target `mapstructure:"fallback"`
+
+	// ReplayInterval is how often spooled jobs are replayed to the primary (default 5s)
+	ReplayInterval time.Duration `mapstructure:"replay_interval"`
+}
+
+// Target selects a registered driver and its options.
+type Target struct {
+	Driver  string                 `mapstructure:"driver"`
+	Options map[string]interface{} `mapstructure:"options"`
+}
+
+// NewDriver creates a new failover queue driver from the registered primary
+// and fallback drivers. Both driver packages must be imported.
+func NewDriver(config dgqueue.Config) (dgqueue.Driver, error) {
+	var failoverConfig Config
+	if err := config.Decode(&failoverConfig); err != nil {
+		return nil, err
+	}
+
+	if failoverConfig.Primary.Driver == "" || failoverConfig.Fallback.Driver == "" {
+		return nil, fmt.Errorf("%w: failover primary and fallback drivers are required", dgqueue.ErrInvalidConfig)
+	}
+	if failoverConfig.ReplayInterval <= 0 {
+		failoverConfig.ReplayInterval = 5 * time.Second
+	}
+
+	primary, err := open(config, failoverConfig.Primary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create primary driver: %w", err)
+	}
+	fallback, err := open(config, failoverConfig.Fallback)
+	if err != nil {
+		primary.Close()
+		return nil, fmt.Errorf("failed to create fallback driver: %w", err)
+	}
+
+	return NewDriverWithDrivers(primary, fallback, failoverConfig.ReplayInterval), nil
+}
+
+// open creates a target driver, sharing the rest of the queue config.
+func open(config dgqueue.Config, target Target) (dgqueue.Driver, error) {
+	factory, ok := dgqueue.LookupDriver(target.Driver)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s (is the driver package imported?)", dgqueue.ErrDriverNotFound, target.Driver)
+	}
+
+	config.Driver = target.Driver
+	config.Options = target.Options
+	if config.Options == nil {
+		config.Options = make(map[string]interface{})
+	}
+	return factory(config)
+}
+
+// NewDriverWithDrivers creates a new failover queue driver around existing
+// drivers, replaying spooled jobs every replayInterval (0 = only when Replay
+// is called). Queues the fallback already holds jobs for are replayed too,
+// if it implements dgqueue.QueueLister.
+func NewDriverWithDrivers(primary, fallback dgqueue.Driver, replayInterval time.Duration) *Driver {
+	d := &Driver{
+		primary:  primary,
+		fallback: fallback,
+		spooled:  make(map[string]struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	// Pick up jobs spooled before a restart
+	if lister, ok := fallback.(dgqueue.QueueLister); ok {
+		if queues, err := lister.Queues(context.Background()); err == nil {
+			for _, name := range queues {
+				d.spooled[name] = struct{}{}
+			}
+		}
+	}
+
+	if replayInterval > 0 {
+		go d.replayLoop(replayInterval)
+	} else {
+		close(d.done)
+	}
+	return d
+}
+
+// Degraded reports whether jobs are waiting in the fallback.
+func (d *Driver) Degraded() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.spooled) > 0
+}
+
+// Push pushes a job to the primary, or to the fallback if the primary fails.
+func (d *Driver) Push(ctx context.Context, job *queue.Job) error {
+	err := d.primary.Push(ctx, job)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	return d.spool(ctx, job, err)
+}
+
+// spool pushes a job the primary rejected to the fallback.
+func (d *Driver) spool(ctx context.Context, job *queue.Job, primaryErr error) error {
+	if err := d.fallback.Push(ctx, job); err != nil {
+		return errors.Join(primaryErr, err)
+	}
+
+	d.mu.Lock()
+	d.spooled[job.Queue] = struct{}{}
+	d.mu.Unlock()
+	return nil
+}
+
+// Replay moves spooled jobs that are ready back to the primary, returning how
+// many were moved. It stops at the first job the primary rejects.
+func (d *Driver) Replay(ctx context.Context) (int, error) {
+	d.replayMu.Lock()
+	defer d.replayMu.Unlock()
+
+	d.mu.Lock()
+	queues := make([]string, 0, len(d.spooled))
+	for name := range d.spooled {
+		queues = append(queues, name)
+	}
+	d.mu.Unlock()
+	sort.Strings(queues)
+
+	replayed := 0
+	for _, name := range queues {
+		for {
+			job, err := d.fallback.Pop(ctx, name)
+			if errors.Is(err, dgqueue.ErrQueueEmpty) {
+				d.forgetIfEmpty(ctx, name)
+				break
+			}
+			if err != nil {
+				return replayed, err
+			}
+
+			if err := d.primary.Push(ctx, job); err != nil {
+				// Keep the job spooled until the primary recovers
+				d.fallback.Retry(ctx, job)
+				return replayed, err
+			}
+			d.fallback.Delete(ctx, job.ID)
+			replayed++
+		}
+	}
+	return replayed, nil
+}
+
+// forgetIfEmpty stops tracking a queue once the fallback holds none of its
+// jobs, delayed ones included. spool adds a queue only after pushing, so a
+// concurrent spool is either counted here or re-adds the queue afterwards.
+func (d *Driver) forgetIfEmpty(ctx context.Context, queueName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if size, err := d.fallback.Size(ctx, queueName); err == nil && size == 0 {
+		delete(d.spooled, queueName)
+	}
+}
+
+// replayLoop replays spooled jobs every interval until Close.
+func (d *Driver) replayLoop(interval time.Duration) {
+	defer close(d.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if d.Degraded() {
+				d.Replay(context.Background())
+			}
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// Pop pops a job from the primary.
+func (d *Driver) Pop(ctx context.Context, queueName string) (*queue.Job, error) {
+	return d.primary.Pop(ctx, queueName)
+}
+
+// PopN pops up to n jobs from the primary, in one round trip if it is a
+// dgqueue.BatchPopper.
+func (d *Driver) PopN(ctx context.Context, queueName string, n int) ([]*queue.Job, error) {
+	if popper, ok := d.primary.(dgqueue.BatchPopper); ok {
+		return popper.PopN(ctx, queueName, n)
+	}
+
+	var jobs []*queue.Job
+	for len(jobs) < n {
+		job, err := d.primary.Pop(ctx, queueName)
+		if err != nil {
+			if len(jobs) > 0 {
+				return jobs, nil
+			}
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Notify subscribes to the primary's push notifications. Jobs spooled to the
+// fallback are announced when they're replayed to the primary.
+func (d *Driver) Notify(ctx context.Context) (<-chan string, error) {
+	if notifier, ok := d.primary.(dgqueue.Notifier); ok {
+		return notifier.Notify(ctx)
+	}
+	return nil, fmt.Errorf("%w: %T doesn't send push notifications", dgqueue.ErrNotSupported, d.primary)
+}
+
+// Heartbeat refreshes the primary's held jobs, if it is a
+// dgqueue.StalledJobRecoverer.
+func (d *Driver) Heartbeat(ctx context.Context, ttl time.Duration) error {
+	if recoverer, ok := d.primary.(dgqueue.StalledJobRecoverer); ok {
+		return recoverer.Heartbeat(ctx, ttl)
+	}
+	return nil
+}
+
+// RecoverStalled requeues the primary's stalled jobs, if it is a
+// dgqueue.StalledJobRecoverer. Jobs are only popped from the primary, so the
+// fallback never holds any.
+func (d *Driver) RecoverStalled(ctx context.Context) (int64, error) {
+	if recoverer, ok := d.primary.(dgqueue.StalledJobRecoverer); ok {
+		return recoverer.RecoverStalled(ctx)
+	}
+	return 0, nil
+}
+
+// ReapOrphaned takes over the primary's orphaned jobs, if it is a
+// dgqueue.OrphanReaper.
+func (d *Driver) ReapOrphaned(ctx context.Context, olderThan time.Duration) ([]*queue.Job, error) {
+	if reaper, ok := d.primary.(dgqueue.OrphanReaper); ok {
+		return reaper.ReapOrphaned(ctx, olderThan)
+	}
+	return nil, nil
+}
+
+// PruneFailed prunes the failed stores of the primary and the fallback, each
+// of which may hold failed jobs, if they are dgqueue.FailedPruners.
+func (d *Driver) PruneFailed(ctx context.Context, cutoff time.Time, keep int, archive func(*queue.Job) error) (int64, error) {
+	var pruned int64
+	for _, driver := range []dgqueue.Driver{d.primary, d.fallback} {
+		pruner, ok := driver.(dgqueue.FailedPruner)
+		if !ok {
+			continue
+		}
+		n, err := pruner.PruneFailed(ctx, cutoff, keep, archive)
+		pruned += n
+		if err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, nil
+}
+
+// RegisterInstance records the instance in the primary's registry, if it is
+// a dgqueue.InstanceRegistry.
+func (d *Driver) RegisterInstance(ctx context.Context, info dgqueue.InstanceInfo, ttl time.Duration) error {
+	if registry, ok := d.primary.(dgqueue.InstanceRegistry); ok {
+		return registry.RegisterInstance(ctx, info, ttl)
+	}
+	return nil
+}
+
+// UnregisterInstance removes the instance from the primary's registry, if it
+// is a dgqueue.InstanceRegistry.
+func (d *Driver) UnregisterInstance(ctx context.Context, id string) error {
+	if registry, ok := d.primary.(dgqueue.InstanceRegistry); ok {
+		return registry.UnregisterInstance(ctx, id)
+	}
+	return nil
+}
+
+// Instances returns the instances in the primary's registry. It returns
+// dgqueue.ErrNotSupported if the primary doesn't keep one.
+func (d *Driver) Instances(ctx context.Context) ([]dgqueue.InstanceInfo, error) {
+	if registry, ok := d.primary.(dgqueue.InstanceRegistry); ok {
+		return registry.Instances(ctx)
+	}
+	return nil, fmt.Errorf("%w: %T doesn't keep an instance registry", dgqueue.ErrNotSupported, d.primary)
+}
+
+// TakeToken takes a token from the primary's bucket. It returns
+// dgqueue.ErrNotSupported if the primary doesn't keep buckets.
+func (d *Driver) TakeToken(ctx context.Context, key string, n int, per time.Duration) (time.Duration, error) {
+	if limiter, ok := d.primary.(dgqueue.RateLimiter); ok {
+		return limiter.TakeToken(ctx, key, n, per)
+	}
+	return 0, fmt.Errorf("%w: %T doesn't keep rate limit buckets", dgqueue.ErrNotSupported, d.primary)
+}
+
+// AcquireSlot takes a slot of the primary's semaphore. It returns
+// dgqueue.ErrNotSupported if the primary doesn't keep semaphores.
+func (d *Driver) AcquireSlot(ctx context.Context, key, holder string, limit int, ttl time.Duration) (bool, error) {
+	if limiter, ok := d.primary.(dgqueue.ConcurrencyLimiter); ok {
+		return limiter.AcquireSlot(ctx, key, holder, limit, ttl)
+	}
+	return false, fmt.Errorf("%w: %T doesn't keep semaphores", dgqueue.ErrNotSupported, d.primary)
+}
+
+// ReleaseSlot releases a slot of the primary's semaphore.
+func (d *Driver) ReleaseSlot(ctx context.Context, key, holder string) error {
+	if limiter, ok := d.primary.(dgqueue.ConcurrencyLimiter); ok {
+		return limiter.ReleaseSlot(ctx, key, holder)
+	}
+	return nil
+}
+
+// SaveBatch saves the batch's status in the primary, if it is a
+// dgqueue.BatchStore.
+func (d *Driver) SaveBatch(ctx context.Context, status *dgqueue.BatchStatus, ttl time.Duration) error {
+	if store, ok := d.primary.(dgqueue.BatchStore); ok {
+		return store.SaveBatch(ctx, status, ttl)
+	}
+	return nil
+}
+
+// Batch returns the batch status saved in the primary. It returns
+// dgqueue.ErrNotSupported if the primary doesn't store batches.
+func (d *Driver) Batch(ctx context.Context, id string) (*dgqueue.BatchStatus, error) {
+	if store, ok := d.primary.(dgqueue.BatchStore); ok {
+		return store.Batch(ctx, id)
+	}
+	return nil, fmt.Errorf("%w: %T doesn't store batches", dgqueue.ErrNotSupported, d.primary)
+}
+
+// Delete deletes a job from the primary.
+func (d *Driver) Delete(ctx context.Context, jobID string) error {
+	return d.primary.Delete(ctx, jobID)
+}
+
+// Retry pushes a job back to the primary, or to the fallback if the primary fails.
+func (d *Driver) Retry(ctx context.Context, job *queue.Job) error {
+	err := d.primary.Retry(ctx, job)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	return d.spool(ctx, job, err)
+}
+
+// Failed moves a job to the primary's failed store, or the fallback's if the primary fails.
+func (d *Driver) Failed(ctx context.Context, job *queue.Job) error {
+	err := d.primary.Failed(ctx, job)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	if fallbackErr := d.fallback.Failed(ctx, job); fallbackErr != nil {
+		return errors.Join(err, fallbackErr)
+	}
+	return nil
+}
+
+// Capabilities returns the primary's capabilities, which Delete and Get rely on.
+func (d *Driver) Capabilities() dgqueue.Capabilities {
+	return dgqueue.DriverCapabilities(d.primary)
+}
+
+// Get retrieves a job from the primary, or from the fallback if it is spooled there.
+func (d *Driver) Get(ctx context.Context, jobID string) (*queue.Job, error) {
+	job, err := d.primary.Get(ctx, jobID)
+	if err == nil {
+		return job, nil
+	}
+	if spooled, fallbackErr := d.fallback.Get(ctx, jobID); fallbackErr == nil {
+		return spooled, nil
+	}
+	return nil, err
+}
+
+// Size returns the number of jobs in the queue on the primary and in the fallback.
+func (d *Driver) Size(ctx context.Context, queueName string) (int64, error) {
+	primarySize, err := d.primary.Size(ctx, queueName)
+	if err != nil {
+		return 0, err
+	}
+	fallbackSize, err := d.fallback.Size(ctx, queueName)
+	if err != nil {
+		return 0, err
+	}
+	return primarySize + fallbackSize, nil
+}
+
+// Ping checks the primary is reachable, or failing that the fallback, since
+// jobs are still accepted while the fallback is. Drivers that don't implement
+// dgqueue.HealthChecker are assumed reachable.
+func (d *Driver) Ping(ctx context.Context) error {
+	err := ping(ctx, d.primary)
+	if err == nil {
+		return nil
+	}
+	if fallbackErr := ping(ctx, d.fallback); fallbackErr != nil {
+		return errors.Join(err, fallbackErr)
+	}
+	return nil
+}
+
+func ping(ctx context.Context, driver dgqueue.Driver) error {
+	if checker, ok := driver.(dgqueue.HealthChecker); ok {
+		return checker.Ping(ctx)
+	}
+	return nil
+}
+
+// Close stops replaying and closes both drivers.
+func (d *Driver) Close() error {
+	d.closeOnce.Do(func() { close(d.stop) })
+	<-d.done
+	return errors.Join(d.primary.Close(), d.fallback.Close())
+}
@@ -0,0 +1,222 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+)
+
+var errDown = errors.New("connection refused")
+
+// flakyDriver is a memory driver whose Push can be made to fail.
+type flakyDriver struct {
+	dgqueue.Driver
+	down atomic.Bool
+}
+
+func (d *flakyDriver) Push(ctx context.Context, job *dgqueue.Job) error {
+	if d.down.Load() {
+		return errDown
+	}
+	return d.Driver.Push(ctx, job)
+}
+
+func (d *flakyDriver) Ping(ctx context.Context) error {
+	if d.down.Load() {
+		return errDown
+	}
+	return nil
+}
+
+func newMemoryDriver(t *testing.T) dgqueue.Driver {
+	driver, err := memory.NewDriver(dgqueue.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create memory driver: %v", err)
+	}
+	return driver
+}
+
+func setupFailoverDriver(t *testing.T) (*Driver, *flakyDriver, dgqueue.Driver) {
+	primary := &flakyDriver{Driver: newMemoryDriver(t)}
+	fallback := newMemoryDriver(t)
+	driver := NewDriverWithDrivers(primary, fallback, 0)
+	t.Cleanup(func() { driver.Close() })
+	return driver, primary, fallback
+}
+
+func TestFailoverDriver_SpoolsWhilePrimaryIsDown(t *testing.T) {
+	driver, primary, fallback := setupFailoverDriver(t)
+	ctx := context.Background()
+
+	primary.down.Store(true)
+	job := dgqueue.NewJob("send-email", "payload")
+	if err := driver.Push(ctx, job); err != nil {
+		t.Fatalf("Expected push to fail over, got %v", err)
+	}
+
+	if !driver.Degraded() {
+		t.Error("Expected driver to report degraded")
+	}
+	if size, _ := fallback.Size(ctx, "default"); size != 1 {
+		t.Errorf("Expected job spooled to fallback, got size %d", size)
+	}
+	if size, _ := driver.Size(ctx, "default"); size != 1 {
+		t.Errorf("Expected size to include spooled jobs, got %d", size)
+	}
+	if _, err := driver.Get(ctx, job.ID); err != nil {
+		t.Errorf("Expected spooled job to be found, got %v", err)
+	}
+}
+
+func TestFailoverDriver_ReplaysWhenPrimaryRecovers(t *testing.T) {
+	driver, primary, fallback := setupFailoverDriver(t)
+	ctx := context.Background()
+
+	primary.down.Store(true)
+	for i := 0; i < 3; i++ {
+		driver.Push(ctx, dgqueue.NewJob("send-email", i))
+	}
+
+	// Still down: nothing moves
+	if replayed, err := driver.Replay(ctx); !errors.Is(err, errDown) || replayed != 0 {
+		t.Errorf("Expected replay to stop on primary error, got %d (%v)", replayed, err)
+	}
+	if size, _ := fallback.Size(ctx, "default"); size != 3 {
+		t.Errorf("Expected jobs to stay spooled, got %d", size)
+	}
+
+	primary.down.Store(false)
+	replayed, err := driver.Replay(ctx)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if replayed != 3 {
+		t.Errorf("Expected 3 replayed jobs, got %d", replayed)
+	}
+	if driver.Degraded() {
+		t.Error("Expected driver to recover")
+	}
+	if size, _ := primary.Size(ctx, "default"); size != 3 {
+		t.Errorf("Expected 3 jobs on the primary, got %d", size)
+	}
+}
+
+func TestFailoverDriver_BackgroundReplay(t *testing.T) {
+	primary := &flakyDriver{Driver: newMemoryDriver(t)}
+	driver := NewDriverWithDrivers(primary, newMemoryDriver(t), 10*time.Millisecond)
+	defer driver.Close()
+	ctx := context.Background()
+
+	primary.down.Store(true)
+	driver.Push(ctx, dgqueue.NewJob("send-email", "payload"))
+	primary.down.Store(false)
+
+	deadline := time.Now().Add(time.Second)
+	for driver.Degraded() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected spooled job to be replayed in the background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := primary.Pop(ctx, "default"); err != nil {
+		t.Errorf("Expected replayed job on the primary, got %v", err)
+	}
+}
+
+func TestFailoverDriver_BothDown(t *testing.T) {
+	primary := &flakyDriver{Driver: newMemoryDriver(t)}
+	fallback := &flakyDriver{Driver: newMemoryDriver(t)}
+	driver := NewDriverWithDrivers(primary, fallback, 0)
+	defer driver.Close()
+
+	primary.down.Store(true)
+	fallback.down.Store(true)
+	if err := driver.Push(context.Background(), dgqueue.NewJob("send-email", nil)); !errors.Is(err, errDown) {
+		t.Errorf("Expected error when both drivers fail, got %v", err)
+	}
+}
+
+func TestNewDriver_FromConfig(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.Driver = "failover"
+	cfg.Options["primary"] = map[string]interface{}{"driver": "memory"}
+	cfg.Options["fallback"] = map[string]interface{}{"driver": "memory"}
+
+	driver, err := NewDriver(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	defer driver.Close()
+
+	if err := driver.Push(context.Background(), dgqueue.NewJob("send-email", nil)); err != nil {
+		t.Errorf("Expected push to succeed, got %v", err)
+	}
+}
+
+func TestNewDriver_RequiresTargets(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.Options["primary"] = map[string]interface{}{"driver": "memory"}
+
+	if _, err := NewDriver(cfg); !errors.Is(err, dgqueue.ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig, got %v", err)
+	}
+
+	cfg.Options["fallback"] = map[string]interface{}{"driver": "missing"}
+	if _, err := NewDriver(cfg); !errors.Is(err, dgqueue.ErrDriverNotFound) {
+		t.Errorf("Expected ErrDriverNotFound, got %v", err)
+	}
+}
+
+func TestFailoverDriver_Ping(t *testing.T) {
+	ctx := context.Background()
+	primary := &flakyDriver{Driver: newMemoryDriver(t)}
+	fallback := &flakyDriver{Driver: newMemoryDriver(t)}
+	driver := NewDriverWithDrivers(primary, fallback, 0)
+	defer driver.Close()
+
+	if err := driver.Ping(ctx); err != nil {
+		t.Errorf("Expected healthy driver, got %v", err)
+	}
+
+	// Still accepting jobs through the fallback
+	primary.down.Store(true)
+	if err := driver.Ping(ctx); err != nil {
+		t.Errorf("Expected fallback to keep driver healthy, got %v", err)
+	}
+
+	fallback.down.Store(true)
+	if err := driver.Ping(ctx); !errors.Is(err, errDown) {
+		t.Errorf("Expected errDown with both drivers down, got %v", err)
+	}
+}
+
+func TestFailoverDriver_PopN(t *testing.T) {
+	ctx := context.Background()
+
+	// A batch-popping primary and one that only pops singly
+	for _, primary := range []dgqueue.Driver{newMemoryDriver(t), &flakyDriver{Driver: newMemoryDriver(t)}} {
+		driver := NewDriverWithDrivers(primary, newMemoryDriver(t), 0)
+		defer driver.Close()
+
+		for i := 0; i < 3; i++ {
+			driver.Push(ctx, dgqueue.NewJob("job", i))
+		}
+
+		jobs, err := driver.PopN(ctx, "default", 2)
+		if err != nil || len(jobs) != 2 {
+			t.Fatalf("Expected 2 jobs, got %d (%v)", len(jobs), err)
+		}
+		jobs, err = driver.PopN(ctx, "default", 5)
+		if err != nil || len(jobs) != 1 {
+			t.Fatalf("Expected 1 job, got %d (%v)", len(jobs), err)
+		}
+		if _, err := driver.PopN(ctx, "default", 5); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+			t.Errorf("Expected ErrQueueEmpty, got %v", err)
+		}
+	}
+}
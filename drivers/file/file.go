@@ -0,0 +1,484 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/donnigundala/dg-core/contracts/queue"
+	dgqueue "github.com/donnigundala/dg-queue"
+)
+
+// Spool subdirectories.
+const (
+	delayedDir    = ".delayed"
+	processingDir = ".processing"
+	failedDir     = ".failed"
+	tmpDir        = ".tmp"
+)
+
+// Driver is a filesystem spool queue driver.
+// Each job is one file named "<unix nanos>-<job id>.job", so a directory
+// listing is in processing order and queued payloads can be inspected by hand.
+//
+//	<path>/<queue>/              ready jobs, by AvailableAt
+//	<path>/<queue>/.delayed/     delayed jobs, by AvailableAt
+//	<path>/<queue>/.processing/  jobs being processed
+//	<path>/.failed/              permanently failed jobs, by failure time
+//
+// Files are written to a temporary file and renamed into place, and jobs move
+// between directories by rename, so a crash never leaves a partial job behind.
+// Jobs left in processing are requeued when the driver is created, so only one
+// process should use a spool directory.
+type Driver struct {
+	path     string
+	encoding dgqueue.JobEncoding
+
+	mu       sync.Mutex
+	inFlight map[string]string // job ID -> processing file
+}
+
+func init() {
+	dgqueue.RegisterDriver("file", NewDriver)
+}
+
+// Config represents the file driver configuration.
+type Config struct {
+	// Path is the spool directory, created if missing (default "<prefix>_spool")
+	Path string `mapstructure:"path"`
+}
+
+// NewDriver creates a new file queue driver.
+func NewDriver(config dgqueue.Config) (dgqueue.Driver, error) {
+	var fileConfig Config
+	if err := config.Decode(&fileConfig); err != nil {
+		return nil, err
+	}
+
+	encoding, err := dgqueue.NewJobEncoding(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if fileConfig.Path == "" {
+		fileConfig.Path = config.Prefix + "_spool"
+	}
+
+	driver, err := NewDriverWithPath(fileConfig.Path)
+	if err != nil {
+		return nil, err
+	}
+	driver.encoding = encoding
+
+	return driver, nil
+}
+
+// NewDriverWithPath creates a new file queue driver spooling to path,
+// requeuing jobs that were being processed when it was last used.
+func NewDriverWithPath(path string) (*Driver, error) {
+	for _, dir := range []string{path, filepath.Join(path, failedDir), filepath.Join(path, tmpDir)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create spool directory: %w", err)
+		}
+	}
+
+	driver := &Driver{
+		path:     path,
+		encoding: dgqueue.JobEncoding{Format: dgqueue.FormatJSON},
+		inFlight: make(map[string]string),
+	}
+
+	if err := driver.recover(); err != nil {
+		return nil, fmt.Errorf("failed to recover spool: %w", err)
+	}
+	return driver, nil
+}
+
+// SetFormat sets the format new jobs are written in.
+// Jobs already stored in other formats remain readable.
+func (d *Driver) SetFormat(format dgqueue.JobFormat) {
+	d.encoding.Format = format
+}
+
+// SetCompression sets how the payloads of new jobs are compressed.
+// Compressed jobs already stored remain readable either way.
+func (d *Driver) SetCompression(compression dgqueue.Compression) {
+	d.encoding.Compression = compression
+}
+
+// SetDecodeLimits sets the limits stored jobs are checked against.
+func (d *Driver) SetDecodeLimits(limits dgqueue.DecodeLimits) {
+	d.encoding.Limits = limits
+}
+
+// SetSigningKeys sets the keys jobs are signed and checked with; see
+// dgqueue.JobEncoding.SigningKeys.
+func (d *Driver) SetSigningKeys(keys ...[]byte) {
+	d.encoding.SigningKeys = keys
+}
+
+// recover moves jobs left in processing back to their ready directories.
+func (d *Driver) recover() error {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		processing := filepath.Join(d.path, entry.Name(), processingDir)
+		names, err := jobFiles(processing)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := os.Rename(filepath.Join(processing, name), filepath.Join(d.path, entry.Name(), name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Push writes a job to the queue, or to its delayed directory until AvailableAt.
+// Pushing a job this driver popped releases its processing file.
+func (d *Driver) Push(ctx context.Context, job *queue.Job) error {
+	if err := checkName(job.Queue, "queue name"); err != nil {
+		return err
+	}
+	if err := checkName(job.ID, "job ID"); err != nil {
+		return err
+	}
+
+	dir := d.queueDir(job.Queue)
+	if job.AvailableAt.After(time.Now()) {
+		dir = filepath.Join(dir, delayedDir)
+	}
+	if err := d.write(dir, fileName(job.AvailableAt, job.ID), job); err != nil {
+		return err
+	}
+	return d.ack(job.ID)
+}
+
+// write atomically writes a job file into dir.
+func (d *Driver) write(dir, name string, job *queue.Job) error {
+	data, err := d.encoding.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(d.path, tmpDir), "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+// Pop moves the first ready job into processing and returns it.
+func (d *Driver) Pop(ctx context.Context, queueName string) (*queue.Job, error) {
+	if err := checkName(queueName, "queue name"); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dir := d.queueDir(queueName)
+	if err := d.promote(dir); err != nil {
+		return nil, err
+	}
+
+	names, err := jobFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, dgqueue.ErrQueueEmpty
+	}
+
+	processing := filepath.Join(dir, processingDir)
+	if err := os.MkdirAll(processing, 0o755); err != nil {
+		return nil, err
+	}
+
+	name := names[0]
+	path := filepath.Join(processing, name)
+	if err := os.Rename(filepath.Join(dir, name), path); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	job, err := d.encoding.Unmarshal(data)
+	if err != nil {
+		// Set aside jobs that can never be decoded instead of retrying them forever
+		os.Rename(path, filepath.Join(d.path, failedDir, name))
+		return nil, err
+	}
+
+	d.inFlight[job.ID] = path
+	return job, nil
+}
+
+// promote moves due delayed jobs into the ready directory.
+func (d *Driver) promote(dir string) error {
+	delayed := filepath.Join(dir, delayedDir)
+	names, err := jobFiles(delayed)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixNano()
+	for _, name := range names {
+		if at, ok := availableAt(name); !ok || at > now {
+			// Files are sorted by AvailableAt, so the rest aren't due either
+			break
+		}
+		if err := os.Rename(filepath.Join(delayed, name), filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ack removes the processing file of a job this driver popped.
+func (d *Driver) ack(jobID string) error {
+	d.mu.Lock()
+	path, ok := d.inFlight[jobID]
+	delete(d.inFlight, jobID)
+	d.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Delete removes a completed job's processing file.
+func (d *Driver) Delete(ctx context.Context, jobID string) error {
+	return d.ack(jobID)
+}
+
+// Retry writes the job again, delayed until its AvailableAt.
+func (d *Driver) Retry(ctx context.Context, job *queue.Job) error {
+	return d.Push(ctx, job)
+}
+
+// Failed writes the job to the failed directory and releases its processing file.
+func (d *Driver) Failed(ctx context.Context, job *queue.Job) error {
+	if err := checkName(job.ID, "job ID"); err != nil {
+		return err
+	}
+	if err := d.write(filepath.Join(d.path, failedDir), fileName(time.Now(), job.ID), job); err != nil {
+		return err
+	}
+	return d.ack(job.ID)
+}
+
+// BackendID names the spool directory.
+func (d *Driver) BackendID() string {
+	path, err := filepath.Abs(d.path)
+	if err != nil {
+		path = d.path
+	}
+	return "file://" + path
+}
+
+// MigrateJobs hands every ready, delayed and failed job to fn, removing each
+// one fn accepts. Jobs in processing and files that can't be decoded are left
+// in place.
+func (d *Driver) MigrateJobs(ctx context.Context, fn func(job *queue.Job, failed bool) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		dir := d.queueDir(entry.Name())
+		for _, jobs := range []string{dir, filepath.Join(dir, delayedDir)} {
+			if err := d.migrateDir(jobs, false, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return d.migrateDir(filepath.Join(d.path, failedDir), true, fn)
+}
+
+// migrateDir hands the jobs in dir to fn, removing each one it accepts.
+func (d *Driver) migrateDir(dir string, failed bool, fn func(job *queue.Job, failed bool) error) error {
+	names, err := jobFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		job, err := d.encoding.Unmarshal(data)
+		if err != nil {
+			continue
+		}
+		if err := fn(job, failed); err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Capabilities reports that Delete only releases jobs this driver popped.
+func (d *Driver) Capabilities() dgqueue.Capabilities {
+	return dgqueue.Capabilities{Get: true}
+}
+
+// Get retrieves a job by ID, whether ready, delayed, processing or failed.
+func (d *Driver) Get(ctx context.Context, jobID string) (*queue.Job, error) {
+	if checkName(jobID, "job ID") != nil {
+		return nil, dgqueue.ErrJobNotFound
+	}
+
+	suffix := "-" + jobID + ".job"
+	for _, pattern := range []string{
+		filepath.Join(d.path, "*", "*"+suffix), // ready and failed
+		filepath.Join(d.path, "*", delayedDir, "*"+suffix),
+		filepath.Join(d.path, "*", processingDir, "*"+suffix),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		sort.Strings(matches)
+		data, err := os.ReadFile(matches[len(matches)-1])
+		if err != nil {
+			return nil, err
+		}
+		return d.encoding.Unmarshal(data)
+	}
+	return nil, dgqueue.ErrJobNotFound
+}
+
+// Size returns the number of ready and delayed jobs in the queue.
+func (d *Driver) Size(ctx context.Context, queueName string) (int64, error) {
+	if err := checkName(queueName, "queue name"); err != nil {
+		return 0, err
+	}
+
+	dir := d.queueDir(queueName)
+	ready, err := jobFiles(dir)
+	if err != nil {
+		return 0, err
+	}
+	delayed, err := jobFiles(filepath.Join(dir, delayedDir))
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(ready) + len(delayed)), nil
+}
+
+// Ping checks the spool directory still exists.
+func (d *Driver) Ping(ctx context.Context) error {
+	_, err := os.Stat(filepath.Join(d.path, tmpDir))
+	return err
+}
+
+// Close is a no-op; every write is already on disk.
+func (d *Driver) Close() error {
+	return nil
+}
+
+func (d *Driver) queueDir(queueName string) string {
+	return filepath.Join(d.path, queueName)
+}
+
+// fileName names a job file so names sort by t.
+func fileName(t time.Time, jobID string) string {
+	var nanos int64
+	if t.After(time.Unix(0, 0)) {
+		nanos = t.UnixNano()
+	}
+	return fmt.Sprintf("%020d-%s.job", nanos, jobID)
+}
+
+// availableAt parses the time prefix of a job file name.
+func availableAt(name string) (int64, bool) {
+	prefix, _, ok := strings.Cut(name, "-")
+	if !ok {
+		return 0, false
+	}
+	nanos, err := strconv.ParseInt(prefix, 10, 64)
+	return nanos, err == nil
+}
+
+// jobFiles returns the sorted job file names in dir; a missing dir has none.
+func jobFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".job") {
+			names = append(names, entry.Name())
+		}
+	}
+	// ReadDir already sorts by name
+	return names, nil
+}
+
+// checkName rejects names that aren't safe as a single path element or glob.
+func checkName(name, what string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\*?[`) {
+		return fmt.Errorf("file driver: invalid %s %q", what, name)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+)
+
+func setupFileDriver(t *testing.T) *Driver {
+	driver, err := NewDriverWithPath(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	return driver
+}
+
+func TestFileDriver_PushPop(t *testing.T) {
+	driver := setupFileDriver(t)
+	ctx := context.Background()
+
+	first := dgqueue.NewJob("first-job", map[string]string{"key": "value"})
+	second := dgqueue.NewJob("second-job", nil)
+	second.AvailableAt = first.AvailableAt.Add(time.Millisecond)
+	driver.Push(ctx, second)
+	driver.Push(ctx, first)
+
+	// Jobs come out in AvailableAt order
+	popped, err := driver.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Failed to pop job: %v", err)
+	}
+	if popped.ID != first.ID {
+		t.Errorf("Expected first job %s, got %s", first.ID, popped.ID)
+	}
+
+	// Processing jobs can still be found until deleted
+	if _, err := driver.Get(ctx, first.ID); err != nil {
+		t.Errorf("Expected processing job to be found, got %v", err)
+	}
+	driver.Delete(ctx, first.ID)
+	if _, err := driver.Get(ctx, first.ID); !errors.Is(err, dgqueue.ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound after delete, got %v", err)
+	}
+
+	size, _ := driver.Size(ctx, "default")
+	if size != 1 {
+		t.Errorf("Expected size 1, got %d", size)
+	}
+}
+
+func TestFileDriver_DelayedJob(t *testing.T) {
+	driver := setupFileDriver(t)
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("delayed-job", "payload")
+	dgqueue.WithDelay(job, 50*time.Millisecond)
+	driver.Push(ctx, job)
+
+	if _, err := os.Stat(filepath.Join(driver.path, "default", delayedDir, fileName(job.AvailableAt, job.ID))); err != nil {
+		t.Errorf("Expected job file in the delayed directory, got %v", err)
+	}
+	if _, err := driver.Pop(ctx, "default"); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+		t.Errorf("Expected delayed job to be unavailable, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	popped, err := driver.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Expected due job to be popped, got %v", err)
+	}
+	if popped.ID != job.ID {
+		t.Errorf("Expected ID %s, got %s", job.ID, popped.ID)
+	}
+}
+
+func TestFileDriver_RetryAndFailed(t *testing.T) {
+	driver := setupFileDriver(t)
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("flaky-job", "payload")
+	driver.Push(ctx, job)
+	popped, _ := driver.Pop(ctx, "default")
+
+	if err := driver.Retry(ctx, popped); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	popped, err := driver.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Expected retried job to be popped, got %v", err)
+	}
+
+	dgqueue.MarkFailed(popped, errors.New("boom"))
+	if err := driver.Failed(ctx, popped); err != nil {
+		t.Fatalf("Failed failed: %v", err)
+	}
+
+	processing, _ := jobFiles(filepath.Join(driver.path, "default", processingDir))
+	if len(processing) != 0 {
+		t.Errorf("Expected no processing files, got %v", processing)
+	}
+	failed, err := driver.Get(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Expected failed job to be found, got %v", err)
+	}
+	if failed.Error != "boom" {
+		t.Errorf("Expected error 'boom', got %q", failed.Error)
+	}
+}
+
+func TestFileDriver_ConcurrentPop(t *testing.T) {
+	driver := setupFileDriver(t)
+	ctx := context.Background()
+
+	for i := 0; i < 50; i++ {
+		driver.Push(ctx, dgqueue.NewJob("job", i))
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := driver.Pop(ctx, "default")
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				seen[job.ID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != 50 {
+		t.Errorf("Expected 50 distinct jobs, got %d", len(seen))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("Job %s popped %d times", id, n)
+		}
+	}
+}
+
+func TestFileDriver_RequeuesJobsInProgressOnRestart(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.Driver = "file"
+	cfg.Options["path"] = t.TempDir()
+	ctx := context.Background()
+
+	driver, err := NewDriver(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	job := dgqueue.NewJob("interrupted-job", "payload")
+	driver.Push(ctx, job)
+	driver.Pop(ctx, "default")
+	// Simulate a crash: the job is never deleted, retried or failed
+
+	restarted, err := NewDriver(cfg)
+	if err != nil {
+		t.Fatalf("Failed to restart driver: %v", err)
+	}
+	popped, err := restarted.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Expected interrupted job to be requeued, got %v", err)
+	}
+	if popped.ID != job.ID {
+		t.Errorf("Expected ID %s, got %s", job.ID, popped.ID)
+	}
+}
+
+func TestFileDriver_RejectsUnsafeNames(t *testing.T) {
+	driver := setupFileDriver(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"", "../escape", ".delayed", "a/b", "q*"} {
+		job := dgqueue.NewJob("job", nil)
+		job.Queue = name
+		if err := driver.Push(ctx, job); err == nil {
+			t.Errorf("Expected queue name %q to be rejected", name)
+		}
+	}
+}
+
+func TestFileDriver_Ping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool")
+	driver, err := NewDriverWithPath(path)
+	if err != nil {
+		t.Fatalf("Failed to create file driver: %v", err)
+	}
+
+	if err := driver.Ping(context.Background()); err != nil {
+		t.Errorf("Expected healthy spool, got %v", err)
+	}
+
+	os.RemoveAll(path)
+	if err := driver.Ping(context.Background()); err == nil {
+		t.Error("Expected error once the spool directory is gone")
+	}
+}
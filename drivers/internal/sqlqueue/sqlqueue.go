@@ -0,0 +1,150 @@
+// Package sqlqueue holds the parts of the SQL queue drivers that don't depend
+// on the database: reserving rows, archiving failed rows, and tracking the
+// jobs a driver holds.
+package sqlqueue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/donnigundala/dg-core/contracts/queue"
+	dgqueue "github.com/donnigundala/dg-queue"
+)
+
+// SetAside moves a row that can't be decoded out of the jobs table, within
+// the transaction that reserved it.
+type SetAside func(ctx context.Context, tx *sql.Tx, id, queue string, data []byte) error
+
+// Reserve runs query, which reserves rows and returns their id, queue and
+// data, in a transaction, and returns their jobs in the order Pop returns
+// them, or ErrQueueEmpty if there are none. Rows that can't be decoded are
+// passed to setAside in the same transaction, so they aren't reserved again
+// and again.
+func Reserve(ctx context.Context, db *sql.DB, encoding dgqueue.JobEncoding, setAside SetAside, query string, args ...any) ([]*queue.Job, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	type reservedRow struct {
+		id    string
+		queue string
+		data  []byte
+	}
+	var reserved []reservedRow
+
+	// Read every row before writing, as the transaction has one connection
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var row reservedRow
+		if err := rows.Scan(&row.id, &row.queue, &row.data); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		reserved = append(reserved, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var jobs []*queue.Job
+	var decodeErr error
+	for _, row := range reserved {
+		job, err := encoding.Unmarshal(row.data)
+		if err == nil {
+			jobs = append(jobs, job)
+			continue
+		}
+		if err := setAside(ctx, tx, row.id, row.queue, row.data); err != nil {
+			return nil, err
+		}
+		decodeErr = fmt.Errorf("%w: undecodable job %s moved to the failed table: %w", dgqueue.ErrInvalidPayload, row.id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		return nil, dgqueue.ErrQueueEmpty
+	}
+
+	// RETURNING doesn't preserve the subquery's order
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if !jobs[i].AvailableAt.Equal(jobs[j].AvailableAt) {
+			return jobs[i].AvailableAt.Before(jobs[j].AvailableAt)
+		}
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs, nil
+}
+
+// ArchiveRows passes the jobs in rows of id and data to archive, returning
+// the IDs of the rows to remove: those archived, and those that can't be
+// decoded. On an error, it returns the IDs handled before it.
+func ArchiveRows(rows *sql.Rows, encoding dgqueue.JobEncoding, archive func(*queue.Job) error) ([]string, error) {
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return ids, err
+		}
+		if job, err := encoding.Unmarshal(data); err == nil && archive != nil {
+			if err := archive(job); err != nil {
+				return ids, err
+			}
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Held is the set of jobs a driver reserved, which its heartbeat keeps
+// reserved. The zero value is empty and ready to use.
+type Held struct {
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+// Hold records jobs the driver reserved.
+func (h *Held) Hold(jobs ...*queue.Job) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.ids == nil {
+		h.ids = make(map[string]struct{})
+	}
+	for _, job := range jobs {
+		h.ids[job.ID] = struct{}{}
+	}
+}
+
+// Release forgets a job the driver no longer holds.
+func (h *Held) Release(jobID string) {
+	h.mu.Lock()
+	delete(h.ids, jobID)
+	h.mu.Unlock()
+}
+
+// IDs returns the IDs of the jobs held, in no particular order.
+func (h *Held) IDs() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ids := make([]string, 0, len(h.ids))
+	for id := range h.ids {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -2,7 +2,10 @@ package memory
 
 import (
 	"context"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/donnigundala/dg-core/contracts/queue"
 	dgqueue "github.com/donnigundala/dg-queue"
@@ -12,7 +15,27 @@ import (
 type Driver struct {
 	queues map[string][]*queue.Job
 	failed map[string]*queue.Job
+	served map[string]time.Time
+	paused bool
 	mu     sync.RWMutex
+
+	// instances holds registered managers and when their record expires
+	instances map[string]registeredInstance
+
+	// batches holds saved batch statuses and when they expire
+	batches map[string]savedBatch
+}
+
+// registeredInstance is an instance registry record.
+type registeredInstance struct {
+	info      dgqueue.InstanceInfo
+	expiresAt time.Time
+}
+
+// savedBatch is a saved batch status.
+type savedBatch struct {
+	status    *dgqueue.BatchStatus
+	expiresAt time.Time
 }
 
 func init() {
@@ -24,22 +47,33 @@ func NewDriver(config dgqueue.Config) (dgqueue.Driver, error) {
 	return &Driver{
 		queues: make(map[string][]*queue.Job),
 		failed: make(map[string]*queue.Job),
+		served: make(map[string]time.Time),
+
+		instances: make(map[string]registeredInstance),
+		batches:   make(map[string]savedBatch),
 	}, nil
 }
 
-// Push pushes a job to the queue.
+// Push pushes a job to the queue, behind jobs of equal or higher priority.
 func (d *Driver) Push(ctx context.Context, job *queue.Job) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if d.queues[job.Queue] == nil {
-		d.queues[job.Queue] = make([]*queue.Job, 0)
-	}
-
-	d.queues[job.Queue] = append(d.queues[job.Queue], job)
+	d.enqueue(job)
 	return nil
 }
 
+// enqueue inserts a job after every job of equal or higher priority, so the
+// queue stays ordered by priority and FIFO among equals. The caller must hold d.mu.
+func (d *Driver) enqueue(job *queue.Job) {
+	jobs := d.queues[job.Queue]
+	priority := dgqueue.GetPriority(job)
+	i := sort.Search(len(jobs), func(i int) bool {
+		return dgqueue.GetPriority(jobs[i]) < priority
+	})
+	d.queues[job.Queue] = slices.Insert(jobs, i, job)
+}
+
 // Pop pops a job from the queue.
 func (d *Driver) Pop(ctx context.Context, queueName string) (*queue.Job, error) {
 	d.mu.Lock()
@@ -50,7 +84,7 @@ func (d *Driver) Pop(ctx context.Context, queueName string) (*queue.Job, error)
 		return nil, dgqueue.ErrQueueEmpty
 	}
 
-	// Find first available job
+	// Find the first available job; the queue is ordered by priority
 	for i, job := range jobs {
 		if dgqueue.IsAvailable(job) {
 			// Remove from queue
@@ -87,6 +121,28 @@ func (d *Driver) Delete(ctx context.Context, jobID string) error {
 	return dgqueue.ErrJobNotFound
 }
 
+// PopN pops up to n available jobs from the queue, highest priority first.
+func (d *Driver) PopN(ctx context.Context, queueName string, n int) ([]*queue.Job, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var popped []*queue.Job
+	remaining := d.queues[queueName][:0]
+	for _, job := range d.queues[queueName] {
+		if len(popped) < n && dgqueue.IsAvailable(job) {
+			popped = append(popped, job)
+			continue
+		}
+		remaining = append(remaining, job)
+	}
+
+	if len(popped) == 0 {
+		return nil, dgqueue.ErrQueueEmpty
+	}
+	d.queues[queueName] = remaining
+	return popped, nil
+}
+
 // Retry retries a failed job.
 func (d *Driver) Retry(ctx context.Context, job *queue.Job) error {
 	d.mu.Lock()
@@ -97,11 +153,7 @@ func (d *Driver) Retry(ctx context.Context, job *queue.Job) error {
 	job.Error = ""
 
 	// Push back to queue
-	if d.queues[job.Queue] == nil {
-		d.queues[job.Queue] = make([]*queue.Job, 0)
-	}
-
-	d.queues[job.Queue] = append(d.queues[job.Queue], job)
+	d.enqueue(job)
 	return nil
 }
 
@@ -114,6 +166,31 @@ func (d *Driver) Failed(ctx context.Context, job *queue.Job) error {
 	return nil
 }
 
+// MigrateJobs hands every waiting and failed job to fn, removing each one fn accepts.
+func (d *Driver) MigrateJobs(ctx context.Context, fn func(job *queue.Job, failed bool) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for name, jobs := range d.queues {
+		for len(jobs) > 0 {
+			if err := fn(jobs[0], false); err != nil {
+				d.queues[name] = jobs
+				return err
+			}
+			jobs = jobs[1:]
+		}
+		delete(d.queues, name)
+	}
+
+	for id, job := range d.failed {
+		if err := fn(job, true); err != nil {
+			return err
+		}
+		delete(d.failed, id)
+	}
+	return nil
+}
+
 // Get gets a job by ID.
 func (d *Driver) Get(ctx context.Context, jobID string) (*queue.Job, error) {
 	d.mu.RLock()
@@ -148,6 +225,174 @@ func (d *Driver) Size(ctx context.Context, queueName string) (int64, error) {
 	return 0, nil
 }
 
+// OldestJobAge returns how long the next job Pop would return has been waiting.
+func (d *Driver) OldestJobAge(ctx context.Context, queueName string) (time.Duration, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, job := range d.queues[queueName] {
+		if dgqueue.IsAvailable(job) {
+			return dgqueue.WaitTime(job, time.Now()), nil
+		}
+	}
+	return 0, nil
+}
+
+// Queues returns the names of queues holding jobs.
+func (d *Driver) Queues(ctx context.Context) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	names := make([]string, 0, len(d.queues))
+	for name, jobs := range d.queues {
+		if len(jobs) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// MarkServed records that the queues are served for the next ttl.
+func (d *Driver) MarkServed(ctx context.Context, queues []string, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	for _, name := range queues {
+		d.served[name] = expiresAt
+	}
+	return nil
+}
+
+// IsServed reports whether the queue was marked served within its ttl.
+func (d *Driver) IsServed(ctx context.Context, queueName string) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	expiresAt, ok := d.served[queueName]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+// PruneFailed removes failed jobs that failed before cutoff or aren't among
+// the newest keep, oldest first, archiving each first if archive is set.
+func (d *Driver) PruneFailed(ctx context.Context, cutoff time.Time, keep int, archive func(*queue.Job) error) (int64, error) {
+	d.mu.RLock()
+	jobs := make([]*queue.Job, 0, len(d.failed))
+	for _, job := range d.failed {
+		jobs = append(jobs, job)
+	}
+	d.mu.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return dgqueue.FailedTime(jobs[i]).Before(dgqueue.FailedTime(jobs[j]))
+	})
+
+	var pruned int64
+	for i, job := range jobs {
+		expired := !cutoff.IsZero() && dgqueue.FailedTime(job).Before(cutoff)
+		excess := keep > 0 && len(jobs)-i > keep
+		if !expired && !excess {
+			break
+		}
+		if archive != nil {
+			if err := archive(job); err != nil {
+				return pruned, err
+			}
+		}
+
+		d.mu.Lock()
+		if d.failed[job.ID] == job {
+			delete(d.failed, job.ID)
+			pruned++
+		}
+		d.mu.Unlock()
+	}
+	return pruned, nil
+}
+
+// RegisterInstance records the instance for the next ttl.
+func (d *Driver) RegisterInstance(ctx context.Context, info dgqueue.InstanceInfo, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.instances[info.ID] = registeredInstance{info: info, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// UnregisterInstance removes the instance's record.
+func (d *Driver) UnregisterInstance(ctx context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.instances, id)
+	return nil
+}
+
+// Instances returns the instances registered within their ttl.
+func (d *Driver) Instances(ctx context.Context) ([]dgqueue.InstanceInfo, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	now := time.Now()
+	instances := make([]dgqueue.InstanceInfo, 0, len(d.instances))
+	for _, registered := range d.instances {
+		if now.Before(registered.expiresAt) {
+			instances = append(instances, registered.info)
+		}
+	}
+	return instances, nil
+}
+
+// SaveBatch saves a copy of the batch's status for the next ttl.
+func (d *Driver) SaveBatch(ctx context.Context, status *dgqueue.BatchStatus, ttl time.Duration) error {
+	saved := status.Snapshot()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Drop expired batches as new ones arrive
+	now := time.Now()
+	if _, exists := d.batches[status.ID]; !exists {
+		for id, batch := range d.batches {
+			if !now.Before(batch.expiresAt) {
+				delete(d.batches, id)
+			}
+		}
+	}
+	d.batches[status.ID] = savedBatch{status: saved, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// Batch returns a copy of the status saved under id.
+func (d *Driver) Batch(ctx context.Context, id string) (*dgqueue.BatchStatus, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	batch, ok := d.batches[id]
+	if !ok || !time.Now().Before(batch.expiresAt) {
+		return nil, dgqueue.ErrBatchNotFound
+	}
+	return batch.status.Snapshot(), nil
+}
+
+// SetPaused sets or clears the global pause flag.
+func (d *Driver) SetPaused(ctx context.Context, paused bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.paused = paused
+	return nil
+}
+
+// IsPaused reports whether the global pause flag is set.
+func (d *Driver) IsPaused(ctx context.Context) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.paused, nil
+}
+
 // Close closes the driver.
 func (d *Driver) Close() error {
 	d.mu.Lock()
@@ -155,5 +400,7 @@ func (d *Driver) Close() error {
 
 	d.queues = make(map[string][]*queue.Job)
 	d.failed = make(map[string]*queue.Job)
+	d.served = make(map[string]time.Time)
+	d.instances = make(map[string]registeredInstance)
 	return nil
 }
@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"testing"
+	"time"
 
 	dgqueue "github.com/donnigundala/dg-queue"
 )
@@ -29,6 +30,88 @@ func TestMemoryDriver_PushPop(t *testing.T) {
 	}
 }
 
+func TestMemoryDriver_PriorityOrder(t *testing.T) {
+	driver, _ := NewDriver(dgqueue.DefaultConfig())
+	ctx := context.Background()
+
+	delayedHigh := dgqueue.WithPriority(dgqueue.NewJob("delayed-high", nil), dgqueue.PriorityHigh)
+	dgqueue.WithDelay(delayedHigh, time.Hour)
+
+	for _, job := range []*dgqueue.Job{
+		dgqueue.NewJob("normal-1", nil),
+		dgqueue.WithPriority(dgqueue.NewJob("low", nil), dgqueue.PriorityLow),
+		delayedHigh,
+		dgqueue.WithPriority(dgqueue.NewJob("high-1", nil), dgqueue.PriorityHigh),
+		dgqueue.NewJob("normal-2", nil),
+		dgqueue.WithPriority(dgqueue.NewJob("high-2", nil), dgqueue.PriorityHigh),
+	} {
+		driver.Push(ctx, job)
+	}
+
+	// Highest priority first, FIFO among equals, skipping the delayed job
+	for _, want := range []string{"high-1", "high-2", "normal-1", "normal-2", "low"} {
+		popped, err := driver.Pop(ctx, "default")
+		if err != nil {
+			t.Fatalf("Pop failed: %v", err)
+		}
+		if popped.Name != want {
+			t.Errorf("Expected %s, got %s", want, popped.Name)
+		}
+	}
+	if _, err := driver.Pop(ctx, "default"); err != dgqueue.ErrQueueEmpty {
+		t.Errorf("Expected only the delayed job left, got %v", err)
+	}
+}
+
+func TestMemoryDriver_PopN(t *testing.T) {
+	driver, _ := NewDriver(dgqueue.DefaultConfig())
+	d := driver.(*Driver)
+	ctx := context.Background()
+
+	if _, err := d.PopN(ctx, "default", 5); err != dgqueue.ErrQueueEmpty {
+		t.Errorf("Expected ErrQueueEmpty, got %v", err)
+	}
+
+	delayed := dgqueue.WithDelay(dgqueue.NewJob("delayed", nil), time.Hour)
+	for _, job := range []*dgqueue.Job{
+		dgqueue.NewJob("normal-1", nil),
+		delayed,
+		dgqueue.WithPriority(dgqueue.NewJob("high", nil), dgqueue.PriorityHigh),
+		dgqueue.NewJob("normal-2", nil),
+	} {
+		driver.Push(ctx, job)
+	}
+
+	// Jobs come out in Pop order, capped at n
+	jobs, err := d.PopN(ctx, "default", 2)
+	if err != nil {
+		t.Fatalf("PopN failed: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Name != "high" || jobs[1].Name != "normal-1" {
+		t.Fatalf("Expected [high normal-1], got %v", jobNames(jobs))
+	}
+
+	// The delayed job is left behind
+	jobs, err = d.PopN(ctx, "default", 10)
+	if err != nil {
+		t.Fatalf("PopN failed: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Name != "normal-2" {
+		t.Errorf("Expected [normal-2], got %v", jobNames(jobs))
+	}
+	if size, _ := driver.Size(ctx, "default"); size != 1 {
+		t.Errorf("Expected the delayed job to remain, got size %d", size)
+	}
+}
+
+func jobNames(jobs []*dgqueue.Job) []string {
+	names := make([]string, len(jobs))
+	for i, job := range jobs {
+		names[i] = job.Name
+	}
+	return names
+}
+
 func TestMemoryDriver_Delete(t *testing.T) {
 	driver, _ := NewDriver(dgqueue.DefaultConfig())
 	ctx := context.Background()
@@ -113,3 +196,74 @@ func TestMemoryDriver_Size(t *testing.T) {
 		t.Errorf("Expected size 3, got %d", size)
 	}
 }
+
+func TestMemoryDriver_Paused(t *testing.T) {
+	driver, _ := NewDriver(dgqueue.DefaultConfig())
+	ctx := context.Background()
+	d := driver.(*Driver)
+
+	paused, _ := d.IsPaused(ctx)
+	if paused {
+		t.Error("Expected driver not to be paused initially")
+	}
+
+	d.SetPaused(ctx, true)
+	paused, _ = d.IsPaused(ctx)
+	if !paused {
+		t.Error("Expected driver to be paused")
+	}
+}
+
+func TestMemoryDriver_OldestJobAge(t *testing.T) {
+	driver, _ := NewDriver(dgqueue.DefaultConfig())
+	ctx := context.Background()
+	ager := driver.(dgqueue.OldestJobAger)
+
+	age, err := ager.OldestJobAge(ctx, "default")
+	if err != nil || age != 0 {
+		t.Fatalf("Expected 0 age for empty queue, got %v (%v)", age, err)
+	}
+
+	now := time.Now()
+	delayed := dgqueue.NewJob("delayed", nil)
+	dgqueue.WithDelay(delayed, time.Hour)
+	driver.Push(ctx, delayed)
+
+	for _, waited := range []time.Duration{30 * time.Minute, time.Minute} {
+		job := dgqueue.NewJob("ready", nil)
+		job.AvailableAt = now.Add(-waited)
+		driver.Push(ctx, job)
+	}
+
+	// The delayed job isn't ready; the first ready job is the head
+	age, err = ager.OldestJobAge(ctx, "default")
+	if err != nil {
+		t.Fatalf("OldestJobAge failed: %v", err)
+	}
+	if age < 30*time.Minute || age > 31*time.Minute {
+		t.Errorf("Expected age of about 30m, got %v", age)
+	}
+}
+
+func TestMemoryDriver_Instances(t *testing.T) {
+	driver, _ := NewDriver(dgqueue.DefaultConfig())
+	ctx := context.Background()
+	d := driver.(*Driver)
+
+	d.RegisterInstance(ctx, dgqueue.InstanceInfo{ID: "a", Queues: []string{"default"}}, time.Hour)
+	d.RegisterInstance(ctx, dgqueue.InstanceInfo{ID: "b"}, time.Millisecond)
+	d.RegisterInstance(ctx, dgqueue.InstanceInfo{ID: "c"}, time.Hour)
+	d.UnregisterInstance(ctx, "c")
+	time.Sleep(5 * time.Millisecond)
+
+	instances, err := d.Instances(ctx)
+	if err != nil {
+		t.Fatalf("Instances failed: %v", err)
+	}
+	if len(instances) != 1 || instances[0].ID != "a" {
+		t.Fatalf("Expected only instance a to be registered, got %v", instances)
+	}
+	if len(instances[0].Queues) != 1 || instances[0].Queues[0] != "default" {
+		t.Errorf("Expected queues [default], got %v", instances[0].Queues)
+	}
+}
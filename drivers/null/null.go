@@ -0,0 +1,67 @@
+package null
+
+import (
+	"context"
+
+	"github.com/donnigundala/dg-core/contracts/queue"
+	dgqueue "github.com/donnigundala/dg-queue"
+)
+
+// Driver is a queue driver that discards every job.
+// Dispatching succeeds without side effects and workers never receive a job,
+// which disables queues in CI or preview environments without changing app code.
+type Driver struct{}
+
+func init() {
+	dgqueue.RegisterDriver("null", NewDriver)
+}
+
+// NewDriver creates a new null queue driver.
+func NewDriver(config dgqueue.Config) (dgqueue.Driver, error) {
+	return &Driver{}, nil
+}
+
+// Push discards the job.
+func (d *Driver) Push(ctx context.Context, job *queue.Job) error {
+	return nil
+}
+
+// Pop always reports an empty queue.
+func (d *Driver) Pop(ctx context.Context, queueName string) (*queue.Job, error) {
+	return nil, dgqueue.ErrQueueEmpty
+}
+
+// Delete does nothing.
+func (d *Driver) Delete(ctx context.Context, jobID string) error {
+	return nil
+}
+
+// Retry discards the job.
+func (d *Driver) Retry(ctx context.Context, job *queue.Job) error {
+	return nil
+}
+
+// Failed discards the job.
+func (d *Driver) Failed(ctx context.Context, job *queue.Job) error {
+	return nil
+}
+
+// Capabilities reports that nothing is stored, since jobs are discarded.
+func (d *Driver) Capabilities() dgqueue.Capabilities {
+	return dgqueue.Capabilities{}
+}
+
+// Get always reports the job as not found.
+func (d *Driver) Get(ctx context.Context, jobID string) (*queue.Job, error) {
+	return nil, dgqueue.ErrJobNotFound
+}
+
+// Size always returns 0.
+func (d *Driver) Size(ctx context.Context, queueName string) (int64, error) {
+	return 0, nil
+}
+
+// Close does nothing.
+func (d *Driver) Close() error {
+	return nil
+}
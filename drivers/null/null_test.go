@@ -0,0 +1,46 @@
+package null
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+)
+
+func TestNullDriver_DiscardsJobs(t *testing.T) {
+	driver := &Driver{}
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("test-job", "payload")
+	if err := driver.Push(ctx, job); err != nil {
+		t.Fatalf("Expected Push to succeed, got %v", err)
+	}
+	if _, err := driver.Pop(ctx, "default"); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+		t.Errorf("Expected ErrQueueEmpty, got %v", err)
+	}
+	if _, err := driver.Get(ctx, job.ID); !errors.Is(err, dgqueue.ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+	if size, _ := driver.Size(ctx, "default"); size != 0 {
+		t.Errorf("Expected size 0, got %d", size)
+	}
+}
+
+func TestNullDriver_Bootstrap(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.Driver = "null"
+
+	manager, err := dgqueue.Bootstrap(cfg)
+	if err != nil {
+		t.Fatalf("Expected registered null driver, got %v", err)
+	}
+
+	job, err := manager.Dispatch(context.Background(), "send-email", map[string]string{"to": "user@example.com"})
+	if err != nil {
+		t.Fatalf("Expected dispatch to succeed, got %v", err)
+	}
+	if job == nil || job.ID == "" {
+		t.Errorf("Expected dispatch to return the job")
+	}
+}
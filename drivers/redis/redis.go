@@ -2,20 +2,60 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/donnigundala/dg-core/contracts/queue"
 	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
 // Driver is a Redis queue driver.
+// Pop moves each job into a processing list owned by the driver instance, where
+// it stays until the job is deleted, retried, failed or pushed again. If the
+// instance stops heartbeating, RecoverStalled on another instance moves its
+// processing lists back to their queues, or ReapOrphaned takes them over.
 type Driver struct {
-	client *redis.Client
-	prefix string
+	client    redis.UniversalClient
+	prefix    string
+	hashTags  bool
+	encoding  dgqueue.JobEncoding
+	maxJobAge time.Duration
+	promotion *promotionLimiter
+	notify    bool
+
+	// backend names the server or cluster for BackendID, when known from the config
+	backend string
+
+	// instanceID names this instance's processing lists and heartbeat
+	instanceID   string
+	heartbeatTTL atomic.Int64
+	lastBeat     atomic.Int64
+
+	mu       sync.Mutex
+	inFlight map[string]inFlightJob
 }
 
+// inFlightJob is a popped job awaiting acknowledgement.
+type inFlightJob struct {
+	queue string
+	data  []byte
+}
+
+// defaultHeartbeatTTL is used until the manager sets a heartbeat interval.
+const defaultHeartbeatTTL = 90 * time.Second
+
 func init() {
 	dgqueue.RegisterDriver("redis", NewDriver)
 }
@@ -23,8 +63,57 @@ func init() {
 // Config represents the Redis driver configuration.
 type Config struct {
 	Addr     string `mapstructure:"addr"`
+	Username string `mapstructure:"username"` // ACL user (Redis 6+)
 	Password string `mapstructure:"password"`
 	DB       int    `mapstructure:"db"`
+
+	// TLS enables TLS; it is implied by any of the TLS file options
+	TLS bool `mapstructure:"tls"`
+
+	// TLSCertFile and TLSKeyFile are a client certificate for mutual TLS
+	TLSCertFile string `mapstructure:"tls_cert_file"`
+	TLSKeyFile  string `mapstructure:"tls_key_file"`
+
+	// TLSCAFile verifies the server against this CA bundle instead of the system roots
+	TLSCAFile string `mapstructure:"tls_ca_file"`
+
+	// TLSInsecureSkipVerify skips server certificate verification (testing only)
+	TLSInsecureSkipVerify bool `mapstructure:"tls_insecure_skip_verify"`
+
+	// DialTimeout, ReadTimeout and WriteTimeout override the go-redis defaults
+	// (5s, 3s and ReadTimeout) when set
+	DialTimeout  time.Duration `mapstructure:"dial_timeout"`
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+
+	// PoolSize is the maximum number of connections per node (default 10 per CPU)
+	PoolSize int `mapstructure:"pool_size"`
+
+	// Addrs are the Sentinel addresses when MasterName is set, or the seed
+	// node addresses when Cluster is true (default: Addr)
+	Addrs []string `mapstructure:"addrs"`
+
+	// MasterName is the Sentinel master set; setting it connects through Sentinel
+	MasterName string `mapstructure:"master_name"`
+
+	// SentinelPassword authenticates with the Sentinels, if different from Password
+	SentinelPassword string `mapstructure:"sentinel_password"`
+
+	// Cluster connects to a Redis Cluster
+	Cluster bool `mapstructure:"cluster"`
+
+	// MaxJobAge expires delayed jobs that have no explicit TTL this long
+	// after they were created (0 = never)
+	MaxJobAge time.Duration `mapstructure:"max_job_age"`
+
+	// MaxPromotionRate caps how many due delayed jobs each driver instance moves
+	// to the ready queue per second, so a large backlog trickles in (0 = unlimited)
+	MaxPromotionRate int `mapstructure:"max_promotion_rate"`
+
+	// Notify publishes the queue of each pushed job, so managers using this
+	// driver fetch it right away instead of at the next poll. Enable it on
+	// both producers and workers.
+	Notify bool `mapstructure:"notify"`
 }
 
 // NewDriver creates a new Redis queue driver.
@@ -34,67 +123,308 @@ func NewDriver(config dgqueue.Config) (dgqueue.Driver, error) {
 		return nil, err
 	}
 
-	if redisConfig.Addr == "" {
-		redisConfig.Addr = "localhost:6379"
+	encoding, err := dgqueue.NewJobEncoding(config)
+	if err != nil {
+		return nil, err
 	}
 
-	options := &redis.Options{
-		Addr:     redisConfig.Addr,
-		Password: redisConfig.Password,
-		DB:       redisConfig.DB,
+	client, err := newClient(redisConfig)
+	if err != nil {
+		return nil, err
 	}
 
-	client := redis.NewClient(options)
-
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &Driver{
-		client: client,
-		prefix: config.Prefix,
-	}, nil
+	driver := NewDriverWithClient(client, config.Prefix)
+	driver.hashTags = redisConfig.Cluster
+	driver.encoding = encoding
+	driver.maxJobAge = redisConfig.MaxJobAge
+	driver.promotion = newPromotionLimiter(redisConfig.MaxPromotionRate)
+	driver.notify = redisConfig.Notify
+	driver.backend = backendName(redisConfig)
+	return driver, nil
+}
+
+// backendName names the server, Sentinel master set or cluster the configuration connects to.
+func backendName(config Config) string {
+	addrs := config.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{config.Addr}
+	}
+	switch {
+	case config.MasterName != "":
+		return fmt.Sprintf("redis-sentinel://%s/%d", config.MasterName, config.DB)
+	case config.Cluster:
+		sorted := slices.Clone(addrs)
+		sort.Strings(sorted)
+		return fmt.Sprintf("redis-cluster://%s", strings.Join(sorted, ","))
+	default:
+		return fmt.Sprintf("redis://%s/%d", config.Addr, config.DB)
+	}
+}
+
+// newClient creates a standalone, Sentinel or Cluster client for the configuration.
+func newClient(config Config) (redis.UniversalClient, error) {
+	if config.Addr == "" {
+		config.Addr = "localhost:6379"
+	}
+	addrs := config.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{config.Addr}
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case config.MasterName != "" && config.Cluster:
+		return nil, fmt.Errorf("%w: redis master_name and cluster are mutually exclusive", dgqueue.ErrInvalidConfig)
+	case config.MasterName != "":
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       config.MasterName,
+			SentinelAddrs:    addrs,
+			SentinelPassword: config.SentinelPassword,
+			Username:         config.Username,
+			Password:         config.Password,
+			DB:               config.DB,
+			TLSConfig:        tlsConfig,
+			DialTimeout:      config.DialTimeout,
+			ReadTimeout:      config.ReadTimeout,
+			WriteTimeout:     config.WriteTimeout,
+			PoolSize:         config.PoolSize,
+		}), nil
+	case config.Cluster:
+		if config.DB != 0 {
+			return nil, fmt.Errorf("%w: redis cluster only supports db 0", dgqueue.ErrInvalidConfig)
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        addrs,
+			Username:     config.Username,
+			Password:     config.Password,
+			TLSConfig:    tlsConfig,
+			DialTimeout:  config.DialTimeout,
+			ReadTimeout:  config.ReadTimeout,
+			WriteTimeout: config.WriteTimeout,
+			PoolSize:     config.PoolSize,
+		}), nil
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:         config.Addr,
+			Username:     config.Username,
+			Password:     config.Password,
+			DB:           config.DB,
+			TLSConfig:    tlsConfig,
+			DialTimeout:  config.DialTimeout,
+			ReadTimeout:  config.ReadTimeout,
+			WriteTimeout: config.WriteTimeout,
+			PoolSize:     config.PoolSize,
+		}), nil
+	}
+}
+
+// newTLSConfig builds the TLS settings, or returns nil when TLS is off.
+func newTLSConfig(config Config) (*tls.Config, error) {
+	if !config.TLS && config.TLSCertFile == "" && config.TLSKeyFile == "" && config.TLSCAFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.TLSInsecureSkipVerify,
+	}
+
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: redis client certificate: %v", dgqueue.ErrInvalidConfig, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if config.TLSCAFile != "" {
+		pem, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: redis CA file: %v", dgqueue.ErrInvalidConfig, err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: redis CA file %s has no certificates", dgqueue.ErrInvalidConfig, config.TLSCAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	return tlsConfig, nil
 }
 
-// NewDriverWithClient creates a new Redis queue driver with an existing client.
-func NewDriverWithClient(client *redis.Client, prefix string) *Driver {
-	return &Driver{
-		client: client,
-		prefix: prefix,
+// NewDriverWithClient creates a new Redis queue driver with an existing client,
+// which may be a *redis.Client, a Sentinel failover client or a *redis.ClusterClient.
+func NewDriverWithClient(client redis.UniversalClient, prefix string) *Driver {
+	_, cluster := client.(*redis.ClusterClient)
+	driver := &Driver{
+		client:     client,
+		prefix:     prefix,
+		hashTags:   cluster,
+		encoding:   dgqueue.JobEncoding{Format: dgqueue.FormatJSON},
+		instanceID: uuid.New().String(),
+		inFlight:   make(map[string]inFlightJob),
 	}
+	driver.heartbeatTTL.Store(int64(defaultHeartbeatTTL))
+	return driver
+}
+
+// SetMaxJobAge sets how long delayed jobs without an explicit TTL are kept.
+func (d *Driver) SetMaxJobAge(maxAge time.Duration) {
+	d.maxJobAge = maxAge
+}
+
+// SetMaxPromotionRate caps how many delayed jobs are promoted per second (0 = unlimited).
+func (d *Driver) SetMaxPromotionRate(perSecond int) {
+	d.promotion = newPromotionLimiter(perSecond)
+}
+
+// SetNotify sets whether pushes are published to managers; see Config.Notify.
+func (d *Driver) SetNotify(enabled bool) {
+	d.notify = enabled
+}
+
+// SetDecodeLimits sets the limits popped jobs are checked against.
+func (d *Driver) SetDecodeLimits(limits dgqueue.DecodeLimits) {
+	d.encoding.Limits = limits
+}
+
+// SetSigningKeys sets the keys jobs are signed and checked with; see
+// dgqueue.JobEncoding.SigningKeys.
+func (d *Driver) SetSigningKeys(keys ...[]byte) {
+	d.encoding.SigningKeys = keys
+}
+
+// SetFormat sets the format new jobs are written in.
+// Jobs already stored in other formats remain readable.
+func (d *Driver) SetFormat(format dgqueue.JobFormat) {
+	d.encoding.Format = format
+}
+
+// SetCompression sets how the payloads of new jobs are compressed.
+// Compressed jobs already stored remain readable either way.
+func (d *Driver) SetCompression(compression dgqueue.Compression) {
+	d.encoding.Compression = compression
 }
 
 // Push pushes a job to the queue.
+// Pushing a job this instance popped releases it from the processing list.
 func (d *Driver) Push(ctx context.Context, job *queue.Job) error {
-	data, err := dgqueue.MarshalJob(job)
+	if err := d.push(ctx, job); err != nil {
+		return err
+	}
+	return d.ack(ctx, job.ID)
+}
+
+func (d *Driver) push(ctx context.Context, job *queue.Job) error {
+	data, err := d.encoding.Marshal(job)
 	if err != nil {
 		return err
 	}
+	priority := clampPriority(dgqueue.GetPriority(job))
 
 	// If job has delay, add to delayed queue (sorted set)
 	if job.Delay > 0 || !dgqueue.IsAvailable(job) {
 		score := float64(job.AvailableAt.Unix())
-		return d.client.ZAdd(ctx, d.delayedKey(job.Queue), redis.Z{
-			Score:  score,
-			Member: data,
-		}).Err()
+		expiresAt, expires := dgqueue.ExpiresAt(job)
+		if !expires && d.maxJobAge > 0 {
+			expiresAt, expires = job.CreatedAt.Add(d.maxJobAge), true
+		}
+
+		pipe := d.client.TxPipeline()
+		pipe.ZAdd(ctx, d.delayedKey(job.Queue), redis.Z{Score: score, Member: data})
+		if expires {
+			// Index the expiry so PurgeExpired can remove it if never promoted
+			pipe.ZAdd(ctx, d.expiringKey(job.Queue), redis.Z{Score: unixSeconds(expiresAt), Member: data})
+		}
+		if priority != dgqueue.PriorityNormal {
+			// Remember the priority so promotion can rank the job
+			pipe.HSet(ctx, d.prioritiesKey(job.Queue), string(data), priority)
+		}
+		_, err := pipe.Exec(ctx)
+		return err
 	}
 
-	// Otherwise, push to regular queue (list)
-	return d.client.RPush(ctx, d.queueKey(job.Queue), data).Err()
+	// Otherwise, push to regular queue (list), or rank it by priority
+	if priority == dgqueue.PriorityNormal {
+		err = d.client.RPush(ctx, d.queueKey(job.Queue), data).Err()
+	} else {
+		err = prioritizeScript.Run(ctx, d.client,
+			[]string{d.prioritizedKey(job.Queue), d.sequenceKey(job.Queue)},
+			data, priority,
+		).Err()
+	}
+	if err == nil && d.notify {
+		// Best-effort; managers still poll
+		d.client.Publish(ctx, d.pushedChannel(), job.Queue)
+	}
+	return err
 }
 
-// Pop pops a job from the queue.
+// Notify subscribes to the queues of pushed jobs. Pushes are only published
+// by drivers with Config.Notify set.
+func (d *Driver) Notify(ctx context.Context) (<-chan string, error) {
+	if !d.notify {
+		return nil, fmt.Errorf("%w: redis push notifications are disabled", dgqueue.ErrNotSupported)
+	}
+
+	pubsub := d.client.Subscribe(ctx, d.pushedChannel())
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	pushed := make(chan string, 64)
+	go func() {
+		defer close(pushed)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case pushed <- msg.Payload:
+				default:
+					// The manager has a fetch pending already
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return pushed, nil
+}
+
+// Pop moves the next job into this instance's processing list and returns it.
 func (d *Driver) Pop(ctx context.Context, queueName string) (*queue.Job, error) {
 	// First, check delayed queue and move available jobs
 	d.moveDelayedJobs(ctx, queueName)
 
-	// Pop from regular queue
-	data, err := d.client.LPop(ctx, d.queueKey(queueName)).Bytes()
+	// Make sure other instances see the job as held before taking it
+	if err := d.keepAlive(ctx); err != nil {
+		return nil, err
+	}
+
+	processing := d.processingKey(queueName)
+	data, err := popScript.Run(ctx, d.client,
+		[]string{d.prioritizedKey(queueName), d.queueKey(queueName), processing},
+	).Text()
 	if err == redis.Nil {
 		return nil, dgqueue.ErrQueueEmpty
 	}
@@ -102,37 +432,478 @@ func (d *Driver) Pop(ctx context.Context, queueName string) (*queue.Job, error)
 		return nil, err
 	}
 
-	return dgqueue.UnmarshalJob(data)
+	job, err := d.encoding.Unmarshal([]byte(data))
+	if err != nil {
+		return nil, d.setAside(ctx, processing, data, err)
+	}
+
+	d.mu.Lock()
+	d.inFlight[job.ID] = inFlightJob{queue: queueName, data: []byte(data)}
+	d.mu.Unlock()
+	return job, nil
+}
+
+// PopN moves up to n jobs into this instance's processing list in one round
+// trip and returns them.
+func (d *Driver) PopN(ctx context.Context, queueName string, n int) ([]*queue.Job, error) {
+	d.moveDelayedJobs(ctx, queueName)
+
+	if err := d.keepAlive(ctx); err != nil {
+		return nil, err
+	}
+
+	processing := d.processingKey(queueName)
+	popped, err := popNScript.Run(ctx, d.client,
+		[]string{d.prioritizedKey(queueName), d.queueKey(queueName), processing},
+		n,
+	).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*queue.Job, 0, len(popped))
+	var decodeErr error
+	for _, data := range popped {
+		job, err := d.encoding.Unmarshal([]byte(data))
+		if err != nil {
+			decodeErr = d.setAside(ctx, processing, data, err)
+			continue
+		}
+
+		d.mu.Lock()
+		d.inFlight[job.ID] = inFlightJob{queue: queueName, data: []byte(data)}
+		d.mu.Unlock()
+		jobs = append(jobs, job)
+	}
+
+	if len(jobs) == 0 {
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		return nil, dgqueue.ErrQueueEmpty
+	}
+	return jobs, nil
+}
+
+// setAside moves an entry that can't be decoded from a processing list to the
+// failed list as it is, so it is kept for inspection instead of being
+// recovered forever, and returns the decode error wrapped in
+// dgqueue.ErrInvalidPayload.
+func (d *Driver) setAside(ctx context.Context, processing, data string, err error) error {
+	// The keys may be on different cluster nodes; a crash in between at worst
+	// leaves a copy in both
+	if pushErr := d.client.RPush(ctx, d.failedKey(), data).Err(); pushErr == nil {
+		d.client.LRem(ctx, processing, 1, data)
+	}
+	return fmt.Errorf("%w: undecodable job moved to the failed list: %w", dgqueue.ErrInvalidPayload, err)
+}
+
+// ack removes a job this instance popped from its processing list.
+func (d *Driver) ack(ctx context.Context, jobID string) error {
+	d.mu.Lock()
+	held, ok := d.inFlight[jobID]
+	delete(d.inFlight, jobID)
+	d.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	return d.client.LRem(ctx, d.processingKey(held.queue), 1, held.data).Err()
+}
+
+// Heartbeat marks this instance's processing lists as alive for the next ttl,
+// or for the default of 90 seconds if ttl isn't positive.
+func (d *Driver) Heartbeat(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		// A heartbeat without expiry would keep stalled jobs held forever
+		ttl = defaultHeartbeatTTL
+	}
+	d.heartbeatTTL.Store(int64(ttl))
+	now := time.Now()
+	if err := d.client.Set(ctx, d.heartbeatKey(d.instanceID), 1, ttl).Err(); err != nil {
+		return err
+	}
+	if err := d.client.HSet(ctx, d.heartbeatsKey(), d.instanceID, now.UnixNano()).Err(); err != nil {
+		return err
+	}
+	d.lastBeat.Store(now.UnixNano())
+	return nil
+}
+
+// keepAlive refreshes the heartbeat when a third of its ttl has passed, so an
+// instance that keeps popping is never mistaken for a stopped one.
+func (d *Driver) keepAlive(ctx context.Context) error {
+	ttl := time.Duration(d.heartbeatTTL.Load())
+	if time.Since(time.Unix(0, d.lastBeat.Load())) < ttl/3 {
+		return nil
+	}
+	return d.Heartbeat(ctx, ttl)
+}
+
+// RecoverStalled moves jobs in the processing lists of instances whose
+// heartbeat expired back to the front of their queues.
+func (d *Driver) RecoverStalled(ctx context.Context) (int64, error) {
+	lists, err := d.stalledLists(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var recovered int64
+	for _, list := range lists {
+		// Move entries one at a time, so each is either held or queued
+		for {
+			err := d.client.LMove(ctx, list.key, d.queueKey(list.queue), "RIGHT", "LEFT").Err()
+			if err == redis.Nil {
+				break
+			}
+			if err != nil {
+				return recovered, err
+			}
+			recovered++
+		}
+	}
+	return recovered, nil
+}
+
+// ReapOrphaned moves the jobs in the processing lists of instances that
+// haven't heartbeated for olderThan into this instance's processing lists,
+// and returns them.
+func (d *Driver) ReapOrphaned(ctx context.Context, olderThan time.Duration) ([]*queue.Job, error) {
+	lists, err := d.stalledLists(ctx, olderThan)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*queue.Job
+	for _, list := range lists {
+		processing := d.processingKey(list.queue)
+		for {
+			data, err := d.client.LMove(ctx, list.key, processing, "RIGHT", "RIGHT").Result()
+			if err == redis.Nil {
+				break
+			}
+			if err != nil {
+				return jobs, err
+			}
+
+			job, err := d.encoding.Unmarshal([]byte(data))
+			if err != nil {
+				d.setAside(ctx, processing, data, err)
+				continue
+			}
+			d.mu.Lock()
+			d.inFlight[job.ID] = inFlightJob{queue: list.queue, data: []byte(data)}
+			d.mu.Unlock()
+			jobs = append(jobs, job)
+		}
+		d.client.HDel(ctx, d.heartbeatsKey(), list.owner)
+	}
+	return jobs, nil
+}
+
+// processingList is another instance's processing list for a queue.
+type processingList struct {
+	key   string
+	queue string
+	owner string
+}
+
+// stalledLists returns the processing lists of instances whose heartbeat
+// expired and whose last heartbeat is at least olderThan ago.
+func (d *Driver) stalledLists(ctx context.Context, olderThan time.Duration) ([]processingList, error) {
+	prefix := fmt.Sprintf("%s:queues:", d.prefix)
+
+	var keys []string
+	err := d.scan(ctx, prefix+"*:processing:*", func(key string) {
+		keys = append(keys, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var lists []processingList
+	for _, key := range keys {
+		rest := strings.TrimPrefix(key, prefix)
+		sep := strings.LastIndex(rest, ":processing:")
+		if sep < 0 {
+			continue
+		}
+		name, owner := rest[:sep], rest[sep+len(":processing:"):]
+		if owner == d.instanceID {
+			continue
+		}
+		if d.hashTags {
+			name = strings.TrimSuffix(strings.TrimPrefix(name, "{"), "}")
+		}
+
+		alive, err := d.client.Exists(ctx, d.heartbeatKey(owner)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if alive > 0 {
+			continue
+		}
+
+		if olderThan > 0 {
+			last, err := d.client.HGet(ctx, d.heartbeatsKey(), owner).Int64()
+			if err != nil && err != redis.Nil {
+				return nil, err
+			}
+			if err == nil && time.Since(time.Unix(0, last)) < olderThan {
+				continue
+			}
+		}
+		lists = append(lists, processingList{key: key, queue: name, owner: owner})
+	}
+	return lists, nil
 }
 
 // moveDelayedJobs moves delayed jobs that are now available to the regular queue.
 func (d *Driver) moveDelayedJobs(ctx context.Context, queueName string) {
-	now := float64(time.Now().Unix())
+	// Drop expired delayed jobs before promoting due ones
+	d.PurgeExpired(ctx, queueName)
+
+	now := time.Now()
+
+	// Reserve the promotions allowed right now, returning any left unused
+	allowed := d.promotion.reserve(now)
+	if allowed == 0 {
+		return
+	}
+
+	// Move due jobs (score <= now), earliest first, in one atomic step so
+	// instances polling the same queue never promote a job twice
+	moved, err := promoteScript.Run(ctx, d.client,
+		[]string{
+			d.delayedKey(queueName), d.queueKey(queueName), d.expiringKey(queueName),
+			d.prioritiesKey(queueName), d.prioritizedKey(queueName), d.sequenceKey(queueName),
+		},
+		float64(now.Unix()), max(allowed, 0),
+	).Int64()
+	if err != nil {
+		moved = 0
+	}
+	if allowed > 0 {
+		d.promotion.refund(allowed - moved)
+	}
+}
+
+// maxPriority bounds priorities so ranked scores stay exact in a float64.
+const maxPriority = 1 << 20
+
+func clampPriority(priority int) int {
+	return min(max(priority, -maxPriority), maxPriority)
+}
+
+// prioritizeLua defines prioritize, which ranks a job in the prioritized set
+// by priority, then by a per-queue sequence so equal priorities stay FIFO.
+// Higher priorities get lower scores; only non-normal priorities are ranked,
+// so scores below zero sort ahead of the ready list and scores above after it.
+const prioritizeLua = `
+local function prioritize(prioritized, sequence, member, priority)
+	local seq = redis.call('INCR', sequence) % 4294967296
+	redis.call('ZADD', prioritized, -tonumber(priority) * 4294967296 + seq, member)
+end
+`
+
+// prioritizeScript ranks ARGV[1] with priority ARGV[2] in the prioritized set.
+var prioritizeScript = redis.NewScript(prioritizeLua + `
+prioritize(KEYS[1], KEYS[2], ARGV[1], ARGV[2])
+return 1
+`)
+
+// popLua defines pop, which moves the next job into the processing list: a
+// higher-priority job from the prioritized set, else the head of the ready
+// list, else a lower-priority job. It returns false if there is none.
+const popLua = `
+local function pop(prioritized, ready, processing)
+	local head = redis.call('ZRANGE', prioritized, 0, 0, 'WITHSCORES')
+	if head[1] and tonumber(head[2]) < 0 then
+		redis.call('ZREM', prioritized, head[1])
+		redis.call('RPUSH', processing, head[1])
+		return head[1]
+	end
+	local job = redis.call('LMOVE', ready, processing, 'LEFT', 'RIGHT')
+	if job then
+		return job
+	end
+	if head[1] then
+		redis.call('ZREM', prioritized, head[1])
+		redis.call('RPUSH', processing, head[1])
+		return head[1]
+	end
+	return false
+end
+`
+
+// popScript pops the next job of the prioritized set KEYS[1] and ready list
+// KEYS[2] into the processing list KEYS[3].
+var popScript = redis.NewScript(popLua + `
+return pop(KEYS[1], KEYS[2], KEYS[3])
+`)
+
+// popNScript pops up to ARGV[1] jobs like popScript.
+var popNScript = redis.NewScript(popLua + `
+local jobs = {}
+for i = 1, tonumber(ARGV[1]) do
+	local job = pop(KEYS[1], KEYS[2], KEYS[3])
+	if not job then
+		break
+	end
+	jobs[i] = job
+end
+return jobs
+`)
+
+// takeTokenScript takes a token from the bucket hash KEYS[1], holding up to
+// ARGV[2] tokens refilled over ARGV[3] microseconds, at time ARGV[1]. It
+// returns 0, or the microseconds until a token is available. Idle buckets
+// are full again after ARGV[3], so they expire then.
+var takeTokenScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local per = tonumber(ARGV[3])
+local rate = capacity / per
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or capacity
+local updated = tonumber(state[2]) or now
+if now > updated then
+	tokens = math.min(capacity, tokens + (now - updated) * rate)
+	updated = now
+end
+
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(updated))
+redis.call('PEXPIRE', KEYS[1], math.ceil(per / 1000))
+return wait
+`)
+
+// acquireSlotScript adds holder ARGV[2] to the semaphore set KEYS[1], scored
+// by when its slot expires, if fewer than ARGV[3] unexpired slots are held at
+// time ARGV[1]. Slots last ARGV[4] milliseconds. It returns 1 if the slot was
+// acquired, or already held by the holder, and 0 otherwise.
+var acquireSlotScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local ttl = tonumber(ARGV[4])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+if not redis.call('ZSCORE', KEYS[1], ARGV[2]) and redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], now + ttl, ARGV[2])
+local expires = redis.call('PTTL', KEYS[1])
+if expires < ttl then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// promoteScript moves up to ARGV[2] jobs (0 = all) scored at or before ARGV[1]
+// from the delayed set to the ready list, or to the prioritized set if the
+// priorities hash KEYS[4] ranks them, dropping them from the expiring index.
+var promoteScript = redis.NewScript(prioritizeLua + `
+local due
+if tonumber(ARGV[2]) > 0 then
+	due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+else
+	due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+end
+for _, member in ipairs(due) do
+	local priority = redis.call('HGET', KEYS[4], member)
+	if priority then
+		prioritize(KEYS[5], KEYS[6], member, priority)
+		redis.call('HDEL', KEYS[4], member)
+	else
+		redis.call('RPUSH', KEYS[2], member)
+	end
+	redis.call('ZREM', KEYS[1], member)
+	redis.call('ZREM', KEYS[3], member)
+end
+return #due
+`)
+
+// promotionLimiter is a token bucket limiting delayed-job promotions per second.
+// A nil limiter allows unlimited promotions.
+type promotionLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newPromotionLimiter(perSecond int) *promotionLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &promotionLimiter{rate: float64(perSecond), tokens: float64(perSecond)}
+}
+
+// reserve takes every whole token available at now and returns how many were
+// taken, or -1 if promotions are unlimited.
+func (l *promotionLimiter) reserve(now time.Time) int64 {
+	if l == nil {
+		return -1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Refill for the elapsed time, holding at most one second's worth
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.rate, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
 
-	// Get all jobs with score <= now
-	results, err := d.client.ZRangeByScoreWithScores(ctx, d.delayedKey(queueName), &redis.ZRangeBy{
+	taken := math.Floor(l.tokens)
+	l.tokens -= taken
+	return int64(taken)
+}
+
+// refund returns unused reserved tokens.
+func (l *promotionLimiter) refund(n int64) {
+	if l == nil || n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = math.Min(l.rate, l.tokens+float64(n))
+}
+
+// PurgeExpired removes delayed jobs whose TTL has passed, returning how many were removed.
+// It runs on every Pop and can also be called periodically for queues that are rarely polled.
+func (d *Driver) PurgeExpired(ctx context.Context, queueName string) (int64, error) {
+	members, err := d.client.ZRangeByScore(ctx, d.expiringKey(queueName), &redis.ZRangeBy{
 		Min: "-inf",
-		Max: fmt.Sprintf("%f", now),
+		Max: fmt.Sprintf("%f", unixSeconds(time.Now())),
 	}).Result()
+	if err != nil || len(members) == 0 {
+		return 0, err
+	}
 
-	if err != nil || len(results) == 0 {
-		return
+	expired := make([]interface{}, len(members))
+	for i, member := range members {
+		expired[i] = member
 	}
 
-	// Move jobs to regular queue
-	pipe := d.client.Pipeline()
-	for _, result := range results {
-		pipe.RPush(ctx, d.queueKey(queueName), result.Member)
-		pipe.ZRem(ctx, d.delayedKey(queueName), result.Member)
+	pipe := d.client.TxPipeline()
+	removed := pipe.ZRem(ctx, d.delayedKey(queueName), expired...)
+	pipe.ZRem(ctx, d.expiringKey(queueName), expired...)
+	pipe.HDel(ctx, d.prioritiesKey(queueName), members...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
 	}
-	pipe.Exec(ctx)
+	return removed.Val(), nil
 }
 
-// Delete deletes a job from the queue.
+// Delete removes a completed job from this instance's processing list.
 func (d *Driver) Delete(ctx context.Context, jobID string) error {
-	// For simplicity, we don't track individual jobs in Redis
-	// Jobs are deleted when popped
-	return nil
+	return d.ack(ctx, jobID)
 }
 
 // Retry pushes a job back to the queue for retry.
@@ -142,17 +913,145 @@ func (d *Driver) Retry(ctx context.Context, job *queue.Job) error {
 
 // Failed moves a job to the failed queue.
 func (d *Driver) Failed(ctx context.Context, job *queue.Job) error {
-	data, err := dgqueue.MarshalJob(job)
+	data, err := d.encoding.Marshal(job)
 	if err != nil {
 		return err
 	}
 
-	return d.client.RPush(ctx, d.failedKey(), data).Err()
+	if err := d.client.RPush(ctx, d.failedKey(), data).Err(); err != nil {
+		return err
+	}
+	return d.ack(ctx, job.ID)
+}
+
+// BackendID names the Redis server and database, and the key prefix.
+func (d *Driver) BackendID() string {
+	backend := d.backend
+	if backend == "" {
+		switch client := d.client.(type) {
+		case *redis.ClusterClient:
+			addrs := slices.Clone(client.Options().Addrs)
+			sort.Strings(addrs)
+			backend = fmt.Sprintf("redis-cluster://%s", strings.Join(addrs, ","))
+		case *redis.Client:
+			options := client.Options()
+			backend = fmt.Sprintf("redis://%s/%d", options.Addr, options.DB)
+		default:
+			backend = fmt.Sprintf("redis://%p", d.client)
+		}
+	}
+	return backend + "/" + d.prefix
 }
 
-// Get retrieves a job by ID (not supported in Redis driver).
+// migrateBatch is how many entries MigrateJobs reads at a time.
+const migrateBatch = 100
+
+// MigrateJobs hands every ready, delayed and failed job to fn, removing each
+// one fn accepts. Jobs in processing lists and entries that can't be decoded
+// are left in place.
+func (d *Driver) MigrateJobs(ctx context.Context, fn func(job *queue.Job, failed bool) error) error {
+	queues, err := d.Queues(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range queues {
+		ready, prioritized, delayed := d.queueKey(name), d.prioritizedKey(name), d.delayedKey(name)
+		err := d.migrate(ctx, false, fn,
+			func(start, stop int64) ([]string, error) {
+				return d.client.LRange(ctx, ready, start, stop).Result()
+			},
+			func(pipe redis.Pipeliner, member string) {
+				pipe.LRem(ctx, ready, 1, member)
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		err = d.migrate(ctx, false, fn,
+			func(start, stop int64) ([]string, error) {
+				return d.client.ZRange(ctx, prioritized, start, stop).Result()
+			},
+			func(pipe redis.Pipeliner, member string) {
+				pipe.ZRem(ctx, prioritized, member)
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		err = d.migrate(ctx, false, fn,
+			func(start, stop int64) ([]string, error) {
+				return d.client.ZRange(ctx, delayed, start, stop).Result()
+			},
+			func(pipe redis.Pipeliner, member string) {
+				pipe.ZRem(ctx, delayed, member)
+				pipe.ZRem(ctx, d.expiringKey(name), member)
+				pipe.HDel(ctx, d.prioritiesKey(name), member)
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	failed := d.failedKey()
+	return d.migrate(ctx, true, fn,
+		func(start, stop int64) ([]string, error) {
+			return d.client.LRange(ctx, failed, start, stop).Result()
+		},
+		func(pipe redis.Pipeliner, member string) {
+			pipe.LRem(ctx, failed, 1, member)
+		},
+	)
+}
+
+// migrate hands the jobs read in order from a list or sorted set to fn,
+// removing each one it accepts.
+func (d *Driver) migrate(
+	ctx context.Context,
+	failed bool,
+	fn func(job *queue.Job, failed bool) error,
+	read func(start, stop int64) ([]string, error),
+	remove func(pipe redis.Pipeliner, member string),
+) error {
+	// Entries left in place stay at the front
+	var skipped int64
+	for {
+		entries, err := read(skipped, skipped+migrateBatch-1)
+		if err != nil || len(entries) == 0 {
+			return err
+		}
+
+		for _, data := range entries {
+			job, err := d.encoding.Unmarshal([]byte(data))
+			if err != nil {
+				skipped++
+				continue
+			}
+			if err := fn(job, failed); err != nil {
+				return err
+			}
+
+			pipe := d.client.TxPipeline()
+			remove(pipe, data)
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Capabilities reports that jobs can't be looked up by ID, and that Delete
+// only releases jobs this driver popped.
+func (d *Driver) Capabilities() dgqueue.Capabilities {
+	return dgqueue.Capabilities{}
+}
+
+// Get is not supported; Redis lists can't be searched by job ID.
 func (d *Driver) Get(ctx context.Context, jobID string) (*queue.Job, error) {
-	return nil, fmt.Errorf("Get not supported in Redis driver")
+	return nil, fmt.Errorf("%w: redis driver can't look up jobs by ID", dgqueue.ErrNotSupported)
 }
 
 // Size returns the number of jobs in the queue.
@@ -162,31 +1061,430 @@ func (d *Driver) Size(ctx context.Context, queueName string) (int64, error) {
 		return 0, err
 	}
 
+	prioritizedSize, err := d.client.ZCard(ctx, d.prioritizedKey(queueName)).Result()
+	if err != nil {
+		return 0, err
+	}
+
 	delayedSize, err := d.client.ZCard(ctx, d.delayedKey(queueName)).Result()
 	if err != nil {
 		return 0, err
 	}
 
-	return regularSize + delayedSize, nil
+	return regularSize + prioritizedSize + delayedSize, nil
+}
+
+// OldestJobAge returns how long the oldest ready job has been waiting, including
+// due delayed jobs that haven't been promoted to the ready list yet.
+func (d *Driver) OldestJobAge(ctx context.Context, queueName string) (time.Duration, error) {
+	now := time.Now()
+	var oldest time.Duration
+
+	head, err := d.client.LIndex(ctx, d.queueKey(queueName), 0).Bytes()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	if err == nil {
+		job, err := d.encoding.Unmarshal(head)
+		if err != nil {
+			return 0, err
+		}
+		oldest = dgqueue.WaitTime(job, now)
+	}
+
+	// The lowest score is the delayed job that became due first
+	due, err := d.client.ZRangeByScore(ctx, d.delayedKey(queueName), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   fmt.Sprintf("%f", float64(now.Unix())),
+		Count: 1,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(due) > 0 {
+		job, err := d.encoding.Unmarshal([]byte(due[0]))
+		if err != nil {
+			return 0, err
+		}
+		if wait := dgqueue.WaitTime(job, now); wait > oldest {
+			oldest = wait
+		}
+	}
+
+	return oldest, nil
+}
+
+// Claim records a deduplication key, reporting whether it was newly claimed.
+func (d *Driver) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return d.client.SetNX(ctx, d.dedupKey(key), 1, ttl).Result()
+}
+
+// Release removes a deduplication key.
+func (d *Driver) Release(ctx context.Context, key string) error {
+	return d.client.Del(ctx, d.dedupKey(key)).Err()
 }
 
-// Close closes the Redis connection.
+// Exists reports whether a deduplication key is claimed.
+func (d *Driver) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := d.client.Exists(ctx, d.dedupKey(key)).Result()
+	return n > 0, err
+}
+
+// Queues returns the names of queues holding ready or delayed jobs.
+func (d *Driver) Queues(ctx context.Context) ([]string, error) {
+	prefix := fmt.Sprintf("%s:queues:", d.prefix)
+	seen := make(map[string]struct{})
+
+	err := d.scan(ctx, prefix+"*", func(key string) {
+		name := strings.TrimPrefix(key, prefix)
+		if strings.Contains(name, ":processing:") || strings.HasSuffix(name, ":sequence") {
+			// Jobs being processed don't make a queue unserved, and the
+			// sequence counter outlives the jobs it ranked
+			return
+		}
+		name = strings.TrimSuffix(name, ":delayed")
+		name = strings.TrimSuffix(name, ":expiring")
+		name = strings.TrimSuffix(name, ":prioritized")
+		name = strings.TrimSuffix(name, ":priorities")
+		if d.hashTags {
+			name = strings.TrimSuffix(strings.TrimPrefix(name, "{"), "}")
+		}
+		seen[name] = struct{}{}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// scan calls fn for each key matching pattern, on every master in a cluster.
+func (d *Driver) scan(ctx context.Context, pattern string, fn func(key string)) error {
+	cluster, ok := d.client.(*redis.ClusterClient)
+	if !ok {
+		return scanNode(ctx, d.client, pattern, fn)
+	}
+
+	var mu sync.Mutex
+	return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		return scanNode(ctx, node, pattern, func(key string) {
+			mu.Lock()
+			defer mu.Unlock()
+			fn(key)
+		})
+	})
+}
+
+func scanNode(ctx context.Context, client redis.Cmdable, pattern string, fn func(key string)) error {
+	iter := client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		fn(iter.Val())
+	}
+	return iter.Err()
+}
+
+// MarkServed records that an instance serves the queues for the next ttl.
+func (d *Driver) MarkServed(ctx context.Context, queues []string, ttl time.Duration) error {
+	pipe := d.client.Pipeline()
+	for _, name := range queues {
+		pipe.Set(ctx, d.consumerKey(name), 1, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IsServed reports whether any instance marked the queue served within its ttl.
+func (d *Driver) IsServed(ctx context.Context, queueName string) (bool, error) {
+	n, err := d.client.Exists(ctx, d.consumerKey(queueName)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// PruneFailed removes failed jobs that failed before cutoff or aren't among
+// the newest keep, oldest first, archiving each first if archive is set.
+// Entries that can't be decoded are removed without archiving.
+func (d *Driver) PruneFailed(ctx context.Context, cutoff time.Time, keep int, archive func(*queue.Job) error) (int64, error) {
+	const batch = 100
+	key := d.failedKey()
+
+	var pruned int64
+	for {
+		size, err := d.client.LLen(ctx, key).Result()
+		if err != nil {
+			return pruned, err
+		}
+		// The list is in failure order, so only its head can be pruned
+		entries, err := d.client.LRange(ctx, key, 0, batch-1).Result()
+		if err != nil {
+			return pruned, err
+		}
+
+		removed := 0
+		for i, data := range entries {
+			job, decodeErr := d.encoding.Unmarshal([]byte(data))
+			expired := decodeErr != nil || (!cutoff.IsZero() && dgqueue.FailedTime(job).Before(cutoff))
+			excess := keep > 0 && size-int64(i) > int64(keep)
+			if !expired && !excess {
+				return pruned, nil
+			}
+			if archive != nil && decodeErr == nil {
+				if err := archive(job); err != nil {
+					return pruned, err
+				}
+			}
+
+			n, err := d.client.LRem(ctx, key, 1, data).Result()
+			if err != nil {
+				return pruned, err
+			}
+			pruned += n
+			removed++
+		}
+		if removed < batch {
+			return pruned, nil
+		}
+	}
+}
+
+// RegisterInstance records the instance for the next ttl.
+func (d *Driver) RegisterInstance(ctx context.Context, info dgqueue.InstanceInfo, ttl time.Duration) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return d.client.Set(ctx, d.registryKey(info.ID), data, ttl).Err()
+}
+
+// UnregisterInstance removes the instance's record.
+func (d *Driver) UnregisterInstance(ctx context.Context, id string) error {
+	return d.client.Del(ctx, d.registryKey(id)).Err()
+}
+
+// Instances returns the instances registered within their ttl.
+func (d *Driver) Instances(ctx context.Context) ([]dgqueue.InstanceInfo, error) {
+	var keys []string
+	if err := d.scan(ctx, d.registryKey("*"), func(key string) {
+		keys = append(keys, key)
+	}); err != nil {
+		return nil, err
+	}
+
+	// One GET per key, as records may live on different cluster nodes
+	pipe := d.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	instances := make([]dgqueue.InstanceInfo, 0, len(keys))
+	for _, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if err == redis.Nil {
+			// Expired since the scan
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var info dgqueue.InstanceInfo
+		if err := json.Unmarshal(data, &info); err != nil {
+			return nil, err
+		}
+		instances = append(instances, info)
+	}
+	return instances, nil
+}
+
+// TakeToken takes a token from the bucket named key, shared by every instance
+// using the same Redis. Buckets refill by the clock of the instance taking a
+// token, so keep instance clocks in sync.
+func (d *Driver) TakeToken(ctx context.Context, key string, n int, per time.Duration) (time.Duration, error) {
+	wait, err := takeTokenScript.Run(ctx, d.client,
+		[]string{d.rateLimitKey(key)},
+		time.Now().UnixMicro(), n, per.Microseconds(),
+	).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(wait) * time.Microsecond, nil
+}
+
+// AcquireSlot takes one of limit slots of the semaphore named key for holder,
+// shared by every instance using the same Redis.
+func (d *Driver) AcquireSlot(ctx context.Context, key, holder string, limit int, ttl time.Duration) (bool, error) {
+	acquired, err := acquireSlotScript.Run(ctx, d.client,
+		[]string{d.funnelKey(key)},
+		time.Now().UnixMilli(), holder, limit, ttl.Milliseconds(),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return acquired == 1, nil
+}
+
+// ReleaseSlot releases holder's slot of the semaphore named key.
+func (d *Driver) ReleaseSlot(ctx context.Context, key, holder string) error {
+	return d.client.ZRem(ctx, d.funnelKey(key), holder).Err()
+}
+
+// SaveBatch saves the batch's status for the next ttl.
+func (d *Driver) SaveBatch(ctx context.Context, status *dgqueue.BatchStatus, ttl time.Duration) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return d.client.Set(ctx, d.batchKey(status.ID), data, ttl).Err()
+}
+
+// Batch returns the status saved under id.
+func (d *Driver) Batch(ctx context.Context, id string) (*dgqueue.BatchStatus, error) {
+	data, err := d.client.Get(ctx, d.batchKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, dgqueue.ErrBatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var status dgqueue.BatchStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// SetPaused sets or clears the global pause flag shared by all instances.
+func (d *Driver) SetPaused(ctx context.Context, paused bool) error {
+	if paused {
+		return d.client.Set(ctx, d.pausedKey(), 1, 0).Err()
+	}
+	return d.client.Del(ctx, d.pausedKey()).Err()
+}
+
+// IsPaused reports whether the global pause flag is set.
+func (d *Driver) IsPaused(ctx context.Context) (bool, error) {
+	n, err := d.client.Exists(ctx, d.pausedKey()).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Ping checks the Redis server is reachable.
+func (d *Driver) Ping(ctx context.Context) error {
+	return d.client.Ping(ctx).Err()
+}
+
+// Close clears this instance's heartbeat, so any jobs it still holds can be
+// recovered right away, and closes the Redis connection.
 func (d *Driver) Close() error {
 	if d.client == nil {
 		return nil
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	d.client.Del(ctx, d.heartbeatKey(d.instanceID))
+	d.client.HDel(ctx, d.heartbeatsKey(), d.instanceID)
+
 	return d.client.Close()
 }
 
 // Helper methods for key generation
 func (d *Driver) queueKey(name string) string {
-	return fmt.Sprintf("%s:queues:%s", d.prefix, name)
+	return fmt.Sprintf("%s:queues:%s", d.prefix, d.slot(name))
 }
 
 func (d *Driver) delayedKey(name string) string {
-	return fmt.Sprintf("%s:queues:%s:delayed", d.prefix, name)
+	return fmt.Sprintf("%s:queues:%s:delayed", d.prefix, d.slot(name))
+}
+
+func (d *Driver) expiringKey(name string) string {
+	return fmt.Sprintf("%s:queues:%s:expiring", d.prefix, d.slot(name))
+}
+
+func (d *Driver) prioritizedKey(name string) string {
+	return fmt.Sprintf("%s:queues:%s:prioritized", d.prefix, d.slot(name))
+}
+
+func (d *Driver) prioritiesKey(name string) string {
+	return fmt.Sprintf("%s:queues:%s:priorities", d.prefix, d.slot(name))
+}
+
+func (d *Driver) sequenceKey(name string) string {
+	return fmt.Sprintf("%s:queues:%s:sequence", d.prefix, d.slot(name))
+}
+
+// slot wraps a queue name in a hash tag on clusters, so a queue's keys share
+// a hash slot and can be updated in one transaction.
+func (d *Driver) slot(name string) string {
+	if d.hashTags {
+		return "{" + name + "}"
+	}
+	return name
+}
+
+func (d *Driver) processingKey(name string) string {
+	return fmt.Sprintf("%s:queues:%s:processing:%s", d.prefix, d.slot(name), d.instanceID)
+}
+
+func (d *Driver) heartbeatKey(instanceID string) string {
+	return fmt.Sprintf("%s:instances:%s", d.prefix, instanceID)
+}
+
+// heartbeatsKey is a hash of each instance's last heartbeat, which outlives
+// the expiring heartbeat keys.
+func (d *Driver) heartbeatsKey() string {
+	return fmt.Sprintf("%s:heartbeats", d.prefix)
 }
 
 func (d *Driver) failedKey() string {
 	return fmt.Sprintf("%s:failed", d.prefix)
 }
+
+func (d *Driver) pausedKey() string {
+	return fmt.Sprintf("%s:paused", d.prefix)
+}
+
+func (d *Driver) registryKey(instanceID string) string {
+	return fmt.Sprintf("%s:registry:%s", d.prefix, instanceID)
+}
+
+func (d *Driver) batchKey(batchID string) string {
+	return fmt.Sprintf("%s:batch:%s", d.prefix, batchID)
+}
+
+func (d *Driver) rateLimitKey(key string) string {
+	return fmt.Sprintf("%s:ratelimit:%s", d.prefix, key)
+}
+
+func (d *Driver) funnelKey(key string) string {
+	return fmt.Sprintf("%s:funnel:%s", d.prefix, key)
+}
+
+func (d *Driver) consumerKey(name string) string {
+	return fmt.Sprintf("%s:consumers:%s", d.prefix, name)
+}
+
+func (d *Driver) pushedChannel() string {
+	return fmt.Sprintf("%s:pushed", d.prefix)
+}
+
+func (d *Driver) dedupKey(key string) string {
+	return fmt.Sprintf("%s:dedup:%s", d.prefix, key)
+}
+
+// unixSeconds converts t to fractional Unix seconds with millisecond precision.
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
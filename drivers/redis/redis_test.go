@@ -2,6 +2,19 @@ package redis
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -95,6 +108,125 @@ func TestRedisDriver_DelayedJob(t *testing.T) {
 	}
 }
 
+func TestRedisDriver_PriorityOrder(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	normal1 := dgqueue.NewJob("normal-1", nil)
+	low := dgqueue.WithPriority(dgqueue.NewJob("low", nil), dgqueue.PriorityLow)
+	high1 := dgqueue.WithPriority(dgqueue.NewJob("high-1", nil), dgqueue.PriorityHigh)
+	normal2 := dgqueue.NewJob("normal-2", nil)
+	high2 := dgqueue.WithPriority(dgqueue.NewJob("high-2", nil), dgqueue.PriorityHigh)
+	urgent := dgqueue.WithPriority(dgqueue.NewJob("urgent", nil), 100)
+
+	// A due delayed job is ranked by priority when promoted
+	urgent.Delay = time.Second
+	urgent.AvailableAt = time.Now().Add(-time.Second)
+
+	for _, job := range []*dgqueue.Job{normal1, low, high1, normal2, high2, urgent} {
+		if err := driver.Push(ctx, job); err != nil {
+			t.Fatalf("Failed to push %s: %v", job.Name, err)
+		}
+	}
+	if size, _ := driver.Size(ctx, "default"); size != 6 {
+		t.Errorf("Expected size 6, got %d", size)
+	}
+
+	for _, want := range []string{"urgent", "high-1", "high-2", "normal-1", "normal-2", "low"} {
+		popped, err := driver.Pop(ctx, "default")
+		if err != nil {
+			t.Fatalf("Failed to pop %s: %v", want, err)
+		}
+		if popped.Name != want {
+			t.Errorf("Expected %s, got %s", want, popped.Name)
+		}
+		if err := driver.Delete(ctx, popped.ID); err != nil {
+			t.Fatalf("Failed to delete %s: %v", want, err)
+		}
+	}
+
+	// The sequence counter doesn't make the empty queue look populated
+	if queues, _ := driver.Queues(ctx); len(queues) != 0 {
+		t.Errorf("Expected no queues, got %v", queues)
+	}
+}
+
+func TestRedisDriver_Notify(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := driver.Notify(ctx); !errors.Is(err, dgqueue.ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported while disabled, got %v", err)
+	}
+
+	driver.SetNotify(true)
+	pushed, err := driver.Notify(ctx)
+	if err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	// Delayed jobs aren't announced until they're due
+	driver.Push(ctx, dgqueue.WithDelay(dgqueue.NewJob("later", nil), time.Hour))
+	driver.Push(ctx, dgqueue.WithQueue(dgqueue.NewJob("now", nil), "emails"))
+
+	select {
+	case queue := <-pushed:
+		if queue != "emails" {
+			t.Errorf("Expected emails, got %s", queue)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a push notification")
+	}
+
+	cancel()
+	for range pushed {
+	}
+}
+
+func TestRedisDriver_PopN(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	if _, err := driver.PopN(ctx, "default", 5); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+		t.Errorf("Expected ErrQueueEmpty, got %v", err)
+	}
+
+	for _, job := range []*dgqueue.Job{
+		dgqueue.NewJob("normal-1", nil),
+		dgqueue.WithPriority(dgqueue.NewJob("low", nil), dgqueue.PriorityLow),
+		dgqueue.WithPriority(dgqueue.NewJob("high", nil), dgqueue.PriorityHigh),
+		dgqueue.NewJob("normal-2", nil),
+	} {
+		if err := driver.Push(ctx, job); err != nil {
+			t.Fatalf("Failed to push %s: %v", job.Name, err)
+		}
+	}
+
+	// Jobs come out in Pop order, capped at n
+	var names []string
+	for _, n := range []int{3, 3} {
+		jobs, err := driver.PopN(ctx, "default", n)
+		if err != nil {
+			t.Fatalf("PopN failed: %v", err)
+		}
+		for _, job := range jobs {
+			names = append(names, job.Name)
+			driver.Delete(ctx, job.ID)
+		}
+	}
+	want := []string{"high", "normal-1", "normal-2", "low"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Expected %v, got %v", want, names)
+	}
+	if _, err := driver.PopN(ctx, "default", 5); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+		t.Errorf("Expected ErrQueueEmpty, got %v", err)
+	}
+}
+
 func TestRedisDriver_Failed(t *testing.T) {
 	driver := setupRedisDriver(t)
 	defer driver.Close()
@@ -212,3 +344,799 @@ func TestRedisDriver_NewDriverWithClient(t *testing.T) {
 		t.Errorf("Expected ID %s, got %s", job.ID, popped.ID)
 	}
 }
+
+func TestRedisDriver_ClaimRelease(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	claimed, err := driver.Claim(ctx, "batch:1:0", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to claim key: %v", err)
+	}
+	if !claimed {
+		t.Error("Expected first claim to succeed")
+	}
+
+	claimed, _ = driver.Claim(ctx, "batch:1:0", time.Minute)
+	if claimed {
+		t.Error("Expected second claim to be rejected")
+	}
+	if exists, err := driver.Exists(ctx, "batch:1:0"); err != nil || !exists {
+		t.Errorf("Expected the claimed key to exist, got %v (%v)", exists, err)
+	}
+
+	if err := driver.Release(ctx, "batch:1:0"); err != nil {
+		t.Fatalf("Failed to release key: %v", err)
+	}
+
+	claimed, _ = driver.Claim(ctx, "batch:1:0", time.Minute)
+	if !claimed {
+		t.Error("Expected claim after release to succeed")
+	}
+}
+
+func TestRedisDriver_GlobalPause(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	// Second instance sharing the same Redis
+	other := NewDriverWithClient(redis.NewClient(&redis.Options{Addr: "localhost:6379"}), "test_queue")
+	defer other.Close()
+
+	var mu sync.Mutex
+	processed := 0
+	handler := func(ctx context.Context, job *dgqueue.Job) error {
+		mu.Lock()
+		processed++
+		mu.Unlock()
+		return nil
+	}
+
+	m1 := dgqueue.New(dgqueue.DefaultConfig())
+	m1.SetDriver(driver)
+	m1.Worker("paused-job", 1, handler)
+	m2 := dgqueue.New(dgqueue.DefaultConfig())
+	m2.SetDriver(other)
+	m2.Worker("paused-job", 1, handler)
+
+	if err := m1.SetGlobalPause(ctx, true); err != nil {
+		t.Fatalf("Failed to set global pause: %v", err)
+	}
+	defer driver.SetPaused(ctx, false)
+
+	m1.Start()
+	defer m1.Stop(ctx)
+	m2.Start()
+	defer m2.Stop(ctx)
+
+	for i := 0; i < 4; i++ {
+		m2.Dispatch(ctx, "paused-job", i)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	mu.Lock()
+	if processed != 0 {
+		t.Errorf("Expected no jobs processed while paused, got %d", processed)
+	}
+	mu.Unlock()
+
+	if err := m2.SetGlobalPause(ctx, false); err != nil {
+		t.Fatalf("Failed to clear global pause: %v", err)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		done := processed == 4
+		mu.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Errorf("Expected 4 jobs processed after resume, got %d", processed)
+}
+
+func TestRedisDriver_MixedFormats(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	// Written by an instance still on JSON
+	jsonJob := dgqueue.NewJob("json-job", "payload")
+	driver.Push(ctx, jsonJob)
+
+	// Written after switching to msgpack
+	driver.SetFormat(dgqueue.FormatMsgpack)
+	msgpackJob := dgqueue.NewJob("msgpack-job", "payload")
+	driver.Push(ctx, msgpackJob)
+
+	for _, expected := range []string{jsonJob.ID, msgpackJob.ID} {
+		popped, err := driver.Pop(ctx, "default")
+		if err != nil {
+			t.Fatalf("Failed to pop job: %v", err)
+		}
+		if popped.ID != expected {
+			t.Errorf("Expected ID %s, got %s", expected, popped.ID)
+		}
+	}
+}
+
+func TestRedisDriver_MixedCompression(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+	report := strings.Repeat("region,total\n", 200)
+
+	// Written by an instance without compression
+	plainJob := dgqueue.NewJob("report", report)
+	driver.Push(ctx, plainJob)
+
+	// Written after enabling it
+	compression, _ := dgqueue.ParseCompression("gzip", 1024)
+	driver.SetCompression(compression)
+	compressedJob := dgqueue.NewJob("report", report)
+	driver.Push(ctx, compressedJob)
+
+	for _, expected := range []string{plainJob.ID, compressedJob.ID} {
+		popped, err := driver.Pop(ctx, "default")
+		if err != nil {
+			t.Fatalf("Failed to pop job: %v", err)
+		}
+		if popped.ID != expected || popped.Payload != report {
+			t.Errorf("Expected job %s with the original payload, got %s", expected, popped.ID)
+		}
+	}
+}
+
+func TestRedisDriver_SigningKeys(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+	driver.SetSigningKeys([]byte("secret"))
+
+	signed := dgqueue.NewJob("charge-card", map[string]interface{}{"amount": 100})
+	driver.Push(ctx, signed)
+
+	// Written straight to Redis by someone without the key
+	forged := dgqueue.NewJob("charge-card", map[string]interface{}{"amount": 900})
+	data, _ := dgqueue.MarshalJob(forged)
+	driver.client.LPush(ctx, driver.queueKey("default"), data)
+
+	expected := map[string]interface{}{signed.ID: nil, forged.ID: dgqueue.ReasonInvalidSignature}
+	for range expected {
+		popped, err := driver.Pop(ctx, "default")
+		if err != nil {
+			t.Fatalf("Failed to pop job: %v", err)
+		}
+		if reason := popped.Metadata[dgqueue.MetadataDeadLetterReason]; reason != expected[popped.ID] {
+			t.Errorf("Expected job %s marked %v, got %v", popped.ID, expected[popped.ID], reason)
+		}
+	}
+}
+
+func TestRedisDriver_DelayedJobTTL(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("expiring-job", "payload")
+	dgqueue.WithDelay(job, time.Hour)
+	dgqueue.WithTTL(job, 500*time.Millisecond)
+
+	if err := driver.Push(ctx, job); err != nil {
+		t.Fatalf("Failed to push delayed job: %v", err)
+	}
+
+	time.Sleep(700 * time.Millisecond)
+
+	// The job was never popped, periodic cleanup still removes it
+	removed, err := driver.PurgeExpired(ctx, "default")
+	if err != nil {
+		t.Fatalf("Failed to purge expired jobs: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 expired job removed, got %d", removed)
+	}
+
+	size, _ := driver.Size(ctx, "default")
+	if size != 0 {
+		t.Errorf("Expected empty queue after expiry, got %d", size)
+	}
+}
+
+func TestRedisDriver_MaxJobAge(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	driver.SetMaxJobAge(500 * time.Millisecond)
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("old-job", "payload")
+	dgqueue.WithDelay(job, time.Hour)
+	driver.Push(ctx, job)
+
+	time.Sleep(700 * time.Millisecond)
+
+	// Pop runs the cleanup as well
+	if _, err := driver.Pop(ctx, "default"); err != dgqueue.ErrQueueEmpty {
+		t.Errorf("Expected empty queue, got %v", err)
+	}
+
+	count, _ := driver.client.ZCard(ctx, driver.delayedKey("default")).Result()
+	if count != 0 {
+		t.Errorf("Expected delayed set to be empty, got %d", count)
+	}
+}
+
+func TestPromotionLimiter(t *testing.T) {
+	if got := newPromotionLimiter(0).reserve(time.Now()); got != -1 {
+		t.Errorf("Expected unlimited promotions, got %d", got)
+	}
+
+	limiter := newPromotionLimiter(10)
+	start := time.Now()
+
+	// Starts with a full second's worth
+	if got := limiter.reserve(start); got != 10 {
+		t.Errorf("Expected initial burst of 10, got %d", got)
+	}
+	if got := limiter.reserve(start); got != 0 {
+		t.Errorf("Expected no tokens left, got %d", got)
+	}
+
+	// Refills at the configured rate
+	if got := limiter.reserve(start.Add(300 * time.Millisecond)); got != 3 {
+		t.Errorf("Expected 3 tokens after 300ms, got %d", got)
+	}
+
+	// Unused reservations are returned, never exceeding the burst
+	limiter.refund(3)
+	if got := limiter.reserve(start.Add(10 * time.Second)); got != 10 {
+		t.Errorf("Expected refill capped at 10, got %d", got)
+	}
+}
+
+func TestRedisDriver_PromotionRate(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	driver.SetMaxPromotionRate(20)
+	ctx := context.Background()
+
+	// Seed a backlog that all comes due at once
+	for i := 0; i < 100; i++ {
+		job := dgqueue.NewJob("due-job", i)
+		dgqueue.WithDelay(job, time.Second)
+		job.AvailableAt = time.Now().Add(-time.Second)
+		driver.Push(ctx, job)
+	}
+
+	start := time.Now()
+	popped := 0
+	for time.Since(start) < 2*time.Second {
+		if _, err := driver.Pop(ctx, "default"); err == nil {
+			popped++
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// An initial burst of 20 plus roughly 20 per second after that
+	if popped < 40 || popped > 65 {
+		t.Errorf("Expected roughly 60 jobs promoted in 2s at 20/s, got %d", popped)
+	}
+}
+
+func TestNewClient_Topologies(t *testing.T) {
+	standalone, err := newClient(Config{Addr: "localhost:6379"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer standalone.Close()
+	if _, ok := standalone.(*redis.Client); !ok {
+		t.Errorf("Expected *redis.Client, got %T", standalone)
+	}
+
+	sentinel, err := newClient(Config{MasterName: "mymaster", Addrs: []string{"localhost:26379"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer sentinel.Close()
+	if _, ok := sentinel.(*redis.Client); !ok {
+		t.Errorf("Expected Sentinel failover *redis.Client, got %T", sentinel)
+	}
+
+	cluster, err := newClient(Config{Cluster: true, Addrs: []string{"localhost:7000", "localhost:7001"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer cluster.Close()
+	if _, ok := cluster.(*redis.ClusterClient); !ok {
+		t.Errorf("Expected *redis.ClusterClient, got %T", cluster)
+	}
+}
+
+func TestNewClient_InvalidTopology(t *testing.T) {
+	invalid := []Config{
+		{MasterName: "mymaster", Cluster: true},
+		{Cluster: true, DB: 1},
+	}
+	for _, config := range invalid {
+		if _, err := newClient(config); !errors.Is(err, dgqueue.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %+v, got %v", config, err)
+		}
+	}
+}
+
+func TestNewClient_Options(t *testing.T) {
+	client, err := newClient(Config{
+		Addr:         "localhost:6379",
+		Username:     "queue",
+		Password:     "secret",
+		TLS:          true,
+		DialTimeout:  time.Second,
+		ReadTimeout:  2 * time.Second,
+		WriteTimeout: 3 * time.Second,
+		PoolSize:     7,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	options := client.(*redis.Client).Options()
+	if options.Username != "queue" || options.Password != "secret" {
+		t.Errorf("Expected ACL credentials, got %q/%q", options.Username, options.Password)
+	}
+	if options.TLSConfig == nil {
+		t.Error("Expected TLS to be enabled")
+	}
+	if options.DialTimeout != time.Second || options.ReadTimeout != 2*time.Second || options.WriteTimeout != 3*time.Second {
+		t.Errorf("Expected configured timeouts, got %v/%v/%v", options.DialTimeout, options.ReadTimeout, options.WriteTimeout)
+	}
+	if options.PoolSize != 7 {
+		t.Errorf("Expected pool size 7, got %d", options.PoolSize)
+	}
+
+	plain, err := newClient(Config{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer plain.Close()
+	if plain.(*redis.Client).Options().TLSConfig != nil {
+		t.Error("Expected TLS to be disabled by default")
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir)
+
+	tlsConfig, err := newTLSConfig(Config{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSCAFile: certFile})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 {
+		t.Errorf("Expected client certificate, got %d", len(tlsConfig.Certificates))
+	}
+	if tlsConfig.RootCAs == nil {
+		t.Error("Expected CA pool")
+	}
+
+	garbage := filepath.Join(dir, "garbage.pem")
+	os.WriteFile(garbage, []byte("not a certificate"), 0o600)
+
+	invalid := []Config{
+		{TLSCAFile: filepath.Join(dir, "missing.pem")},
+		{TLSCAFile: garbage},
+		{TLSCertFile: certFile},
+	}
+	for _, config := range invalid {
+		if _, err := newTLSConfig(config); !errors.Is(err, dgqueue.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %+v, got %v", config, err)
+		}
+	}
+}
+
+// writeCertificate writes a self-signed certificate and its key to dir.
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestRedisDriver_ClusterKeysShareSlot(t *testing.T) {
+	cluster := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"localhost:7000"}})
+	defer cluster.Close()
+
+	driver := NewDriverWithClient(cluster, "app")
+	if key := driver.queueKey("emails"); key != "app:queues:{emails}" {
+		t.Errorf("Expected hash-tagged key, got %s", key)
+	}
+	if key := driver.delayedKey("emails"); key != "app:queues:{emails}:delayed" {
+		t.Errorf("Expected hash-tagged delayed key, got %s", key)
+	}
+
+	// Standalone keys are unchanged
+	standalone := NewDriverWithClient(redis.NewClient(&redis.Options{}), "app")
+	defer standalone.Close()
+	if key := standalone.queueKey("emails"); key != "app:queues:emails" {
+		t.Errorf("Expected plain key, got %s", key)
+	}
+}
+
+func TestRedisDriver_RecoverStalled(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("interrupted-job", "payload")
+	driver.Push(ctx, job)
+	if _, err := driver.Pop(ctx, "default"); err != nil {
+		t.Fatalf("Failed to pop job: %v", err)
+	}
+
+	// A second instance leaves jobs held by a live instance alone
+	other := NewDriverWithClient(driver.client, driver.prefix)
+	if recovered, err := other.RecoverStalled(ctx); err != nil || recovered != 0 {
+		t.Fatalf("Expected nothing recovered while heartbeating, got %d (%v)", recovered, err)
+	}
+
+	// Simulate a crash: the heartbeat expires without the job being acked
+	driver.client.Del(ctx, driver.heartbeatKey(driver.instanceID))
+
+	recovered, err := other.RecoverStalled(ctx)
+	if err != nil {
+		t.Fatalf("Failed to recover: %v", err)
+	}
+	if recovered != 1 {
+		t.Errorf("Expected 1 recovered job, got %d", recovered)
+	}
+
+	popped, err := other.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Expected recovered job to be popped, got %v", err)
+	}
+	if popped.ID != job.ID {
+		t.Errorf("Expected ID %s, got %s", job.ID, popped.ID)
+	}
+}
+
+func TestRedisDriver_ReapOrphaned(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("crashed-job", "payload")
+	driver.Push(ctx, job)
+	if _, err := driver.Pop(ctx, "default"); err != nil {
+		t.Fatalf("Failed to pop job: %v", err)
+	}
+
+	// A crash leaves the last heartbeat behind once the heartbeat key expires
+	driver.client.Del(ctx, driver.heartbeatKey(driver.instanceID))
+
+	other := NewDriverWithClient(driver.client, driver.prefix)
+	if jobs, err := other.ReapOrphaned(ctx, time.Hour); err != nil || len(jobs) != 0 {
+		t.Fatalf("Expected nothing reaped before olderThan passed, got %d (%v)", len(jobs), err)
+	}
+
+	jobs, err := other.ReapOrphaned(ctx, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("Failed to reap: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Fatalf("Expected the crashed job to be reaped, got %v", jobs)
+	}
+
+	// The reaping instance holds it now, until it is retried
+	held, _ := driver.client.LLen(ctx, other.processingKey("default")).Result()
+	if held != 1 {
+		t.Fatalf("Expected the reaper to hold 1 job, got %d", held)
+	}
+	if err := other.Retry(ctx, jobs[0]); err != nil {
+		t.Fatalf("Failed to retry: %v", err)
+	}
+	held, _ = driver.client.LLen(ctx, other.processingKey("default")).Result()
+	if held != 0 {
+		t.Errorf("Expected the retry to release the job, got %d held", held)
+	}
+	if size, _ := other.Size(ctx, "default"); size != 1 {
+		t.Errorf("Expected the job back in the queue, got %d", size)
+	}
+}
+
+func TestRedisDriver_Instances(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	started := time.Now().Truncate(time.Second)
+	info := dgqueue.InstanceInfo{
+		ID:        "worker-1",
+		Hostname:  "host-a",
+		PID:       42,
+		Queues:    []string{"default", "emails"},
+		Workers:   map[string]int{"send-email": 4},
+		StartedAt: started,
+	}
+	if err := driver.RegisterInstance(ctx, info, time.Minute); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	driver.RegisterInstance(ctx, dgqueue.InstanceInfo{ID: "worker-2"}, time.Minute)
+	if ttl := driver.client.TTL(ctx, driver.registryKey("worker-1")).Val(); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Expected the record to expire within a minute, got %v", ttl)
+	}
+
+	if err := driver.UnregisterInstance(ctx, "worker-2"); err != nil {
+		t.Fatalf("Failed to unregister: %v", err)
+	}
+
+	instances, err := driver.Instances(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list instances: %v", err)
+	}
+	if len(instances) != 1 {
+		t.Fatalf("Expected 1 instance, got %d", len(instances))
+	}
+	got := instances[0]
+	if got.ID != "worker-1" || got.Hostname != "host-a" || got.PID != 42 || !got.StartedAt.Equal(started) {
+		t.Errorf("Expected %+v, got %+v", info, got)
+	}
+	if !slices.Equal(got.Queues, info.Queues) || got.Workers["send-email"] != 4 {
+		t.Errorf("Expected queues %v and workers %v, got %v and %v", info.Queues, info.Workers, got.Queues, got.Workers)
+	}
+}
+
+func TestRedisDriver_PruneFailed(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	var ids []string
+	for _, age := range []time.Duration{48 * time.Hour, 3 * time.Hour, 2 * time.Hour, time.Hour} {
+		job := dgqueue.NewJob("import", nil)
+		failedAt := time.Now().Add(-age)
+		job.FailedAt = &failedAt
+		driver.Failed(ctx, job)
+		ids = append(ids, job.ID)
+	}
+	driver.client.RPush(ctx, driver.failedKey(), "not a job")
+
+	var archived []string
+	archive := func(job *dgqueue.Job) error {
+		archived = append(archived, job.ID)
+		return nil
+	}
+
+	// Age alone stops at the first job young enough
+	pruned, err := driver.PruneFailed(ctx, time.Now().Add(-24*time.Hour), 0, archive)
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if pruned != 1 || !slices.Equal(archived, ids[:1]) {
+		t.Errorf("Expected the expired job pruned, got %d pruned and %v archived", pruned, archived)
+	}
+
+	// Count keeps the newest entries, dropping undecodable ones only when in excess
+	pruned, err = driver.PruneFailed(ctx, time.Time{}, 2, archive)
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if pruned != 2 || !slices.Equal(archived, ids[:3]) {
+		t.Errorf("Expected 2 more pruned, got %d pruned and %v archived", pruned, archived)
+	}
+	if size := driver.client.LLen(ctx, driver.failedKey()).Val(); size != 2 {
+		t.Errorf("Expected 2 failed entries kept, got %d", size)
+	}
+}
+
+func TestRedisDriver_TakeToken(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		wait, err := driver.TakeToken(ctx, "charge", 2, time.Second)
+		if err != nil {
+			t.Fatalf("Failed to take token: %v", err)
+		}
+		if wait != 0 {
+			t.Fatalf("Expected token %d to be available, got wait %v", i+1, wait)
+		}
+	}
+
+	wait, err := driver.TakeToken(ctx, "charge", 2, time.Second)
+	if err != nil {
+		t.Fatalf("Failed to take token: %v", err)
+	}
+	if wait <= 0 || wait > 500*time.Millisecond {
+		t.Errorf("Expected to wait up to 500ms for the next token, got %v", wait)
+	}
+
+	// Buckets are independent and expire once they would be full again
+	if wait, _ := driver.TakeToken(ctx, "refund", 2, time.Second); wait != 0 {
+		t.Errorf("Expected another bucket to be full, got wait %v", wait)
+	}
+	if ttl := driver.client.PTTL(ctx, driver.rateLimitKey("charge")).Val(); ttl <= 0 || ttl > time.Second {
+		t.Errorf("Expected the bucket to expire within a second, got %v", ttl)
+	}
+}
+
+func TestRedisDriver_AcquireSlot(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	for _, holder := range []string{"job-1", "job-2"} {
+		if acquired, err := driver.AcquireSlot(ctx, "generate-report", holder, 2, time.Minute); err != nil || !acquired {
+			t.Fatalf("Expected %s to acquire a slot, got %v (%v)", holder, acquired, err)
+		}
+	}
+	if acquired, _ := driver.AcquireSlot(ctx, "generate-report", "job-3", 2, time.Minute); acquired {
+		t.Error("Expected job-3 to be over the limit")
+	}
+	if acquired, _ := driver.AcquireSlot(ctx, "generate-report", "job-1", 2, time.Minute); !acquired {
+		t.Error("Expected job-1 to keep its slot")
+	}
+
+	if err := driver.ReleaseSlot(ctx, "generate-report", "job-1"); err != nil {
+		t.Fatalf("Failed to release slot: %v", err)
+	}
+	if acquired, _ := driver.AcquireSlot(ctx, "generate-report", "job-3", 2, time.Minute); !acquired {
+		t.Error("Expected job-3 to take the released slot")
+	}
+
+	// Slots of crashed holders expire
+	driver.AcquireSlot(ctx, "import", "crashed", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if acquired, _ := driver.AcquireSlot(ctx, "import", "job-4", 1, time.Minute); !acquired {
+		t.Error("Expected the expired slot to be freed")
+	}
+}
+
+func TestRedisDriver_Batch(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	status := &dgqueue.BatchStatus{ID: "import-42", Total: 3, Processed: 3, JobIDs: []string{"a", "b", "c"}}
+	if err := driver.SaveBatch(ctx, status, time.Minute); err != nil {
+		t.Fatalf("Failed to save batch: %v", err)
+	}
+
+	saved, err := driver.Batch(ctx, "import-42")
+	if err != nil {
+		t.Fatalf("Failed to get batch: %v", err)
+	}
+	if saved.Total != 3 || saved.Processed != 3 || len(saved.JobIDs) != 3 {
+		t.Errorf("Expected the saved status, got %+v", saved)
+	}
+	if ttl := driver.client.TTL(ctx, driver.batchKey("import-42")).Val(); ttl <= 0 {
+		t.Errorf("Expected the status to expire, got ttl %v", ttl)
+	}
+
+	if _, err := driver.Batch(ctx, "missing"); !errors.Is(err, dgqueue.ErrBatchNotFound) {
+		t.Errorf("Expected ErrBatchNotFound, got %v", err)
+	}
+}
+
+func TestRedisDriver_DeleteAcksProcessing(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("test-job", "payload")
+	driver.Push(ctx, job)
+	driver.Pop(ctx, "default")
+
+	held, _ := driver.client.LLen(ctx, driver.processingKey("default")).Result()
+	if held != 1 {
+		t.Fatalf("Expected 1 job held while processing, got %d", held)
+	}
+
+	if err := driver.Delete(ctx, job.ID); err != nil {
+		t.Fatalf("Failed to delete job: %v", err)
+	}
+	held, _ = driver.client.LLen(ctx, driver.processingKey("default")).Result()
+	if held != 0 {
+		t.Errorf("Expected processing list to be empty, got %d", held)
+	}
+}
+
+func TestRedisDriver_ConcurrentPromotion(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	// Fifty jobs that are already due
+	for i := 0; i < 50; i++ {
+		data, _ := dgqueue.MarshalJob(dgqueue.NewJob("due-job", i))
+		driver.client.ZAdd(ctx, driver.delayedKey("default"), redis.Z{Score: 0, Member: data})
+	}
+
+	// Several instances promote the same queue at once
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		instance := NewDriverWithClient(driver.client, driver.prefix)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instance.moveDelayedJobs(ctx, "default")
+		}()
+	}
+	wg.Wait()
+
+	ready, _ := driver.client.LLen(ctx, driver.queueKey("default")).Result()
+	if ready != 50 {
+		t.Errorf("Expected each job promoted exactly once (50), got %d", ready)
+	}
+	delayed, _ := driver.client.ZCard(ctx, driver.delayedKey("default")).Result()
+	if delayed != 0 {
+		t.Errorf("Expected delayed set to be empty, got %d", delayed)
+	}
+}
+
+func TestRedisDriver_HeartbeatWithoutTTL(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	if err := driver.Heartbeat(ctx, 0); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+
+	ttl, err := driver.client.TTL(ctx, driver.heartbeatKey(driver.instanceID)).Result()
+	if err != nil {
+		t.Fatalf("Failed to read heartbeat TTL: %v", err)
+	}
+	if ttl <= 0 || ttl > defaultHeartbeatTTL {
+		t.Errorf("Expected the heartbeat to expire within %v, got TTL %v", defaultHeartbeatTTL, ttl)
+	}
+}
+
+func TestRedisDriver_SetsAsideUndecodableJobs(t *testing.T) {
+	driver := setupRedisDriver(t)
+	defer driver.Close()
+	ctx := context.Background()
+
+	driver.client.RPush(ctx, driver.queueKey("default"), "garbage")
+
+	if _, err := driver.Pop(ctx, "default"); !errors.Is(err, dgqueue.ErrInvalidPayload) {
+		t.Fatalf("Expected ErrInvalidPayload, got %v", err)
+	}
+
+	failed, _ := driver.client.LRange(ctx, driver.failedKey(), 0, -1).Result()
+	if len(failed) != 1 || failed[0] != "garbage" {
+		t.Errorf("Expected the raw entry in the failed list, got %v", failed)
+	}
+	if n, _ := driver.client.LLen(ctx, driver.processingKey("default")).Result(); n != 0 {
+		t.Errorf("Expected the processing list to be empty, got %d entries", n)
+	}
+}
@@ -0,0 +1,592 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/donnigundala/dg-core/contracts/queue"
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/internal/sqlqueue"
+	_ "modernc.org/sqlite" // registers the pure-Go "sqlite" database/sql driver
+)
+
+// Driver is an SQLite queue driver for embedded and single-binary apps.
+// Jobs are rows in a jobs table; Pop reserves the next available row in a
+// single UPDATE ... RETURNING statement, which SQLite executes atomically,
+// so concurrent workers in the process never receive the same job.
+// Reserved rows stay in the table until the job is deleted, retried or failed.
+// While the driver heartbeats, it refreshes reserved_at on the rows it holds;
+// RecoverStalled releases rows whose reservation went stale, such as those of
+// a process that died while sharing the database file.
+type Driver struct {
+	db          *sql.DB
+	ownsDB      bool
+	table       string
+	failedTable string
+	batchTable  string
+	encoding    dgqueue.JobEncoding
+
+	// visibility is the heartbeat ttl; reservations older than it are stale
+	visibility atomic.Int64
+
+	held sqlqueue.Held // jobs this driver reserved
+}
+
+func init() {
+	dgqueue.RegisterDriver("sqlite", NewDriver)
+}
+
+// Config represents the SQLite driver configuration.
+type Config struct {
+	// Path is the database file, created if missing (default "<prefix>.db")
+	Path string `mapstructure:"path"`
+
+	// Table is the jobs table (default "<prefix>_jobs")
+	Table string `mapstructure:"table"`
+
+	// FailedTable is the table permanently failed jobs are moved to
+	// (default "<table>_failed")
+	FailedTable string `mapstructure:"failed_table"`
+}
+
+// identifier matches table names safe to interpolate into SQL.
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// NewDriver creates a new SQLite queue driver, creating the database file and
+// tables if needed.
+func NewDriver(config dgqueue.Config) (dgqueue.Driver, error) {
+	var sqliteConfig Config
+	if err := config.Decode(&sqliteConfig); err != nil {
+		return nil, err
+	}
+
+	encoding, err := dgqueue.NewJobEncoding(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if sqliteConfig.Path == "" {
+		sqliteConfig.Path = config.Prefix + ".db"
+	}
+	if sqliteConfig.Table == "" {
+		sqliteConfig.Table = config.Prefix + "_jobs"
+	}
+
+	db, err := Open(sqliteConfig.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := newDriver(db, sqliteConfig.Table, sqliteConfig.FailedTable)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	driver.ownsDB = true
+	driver.encoding = encoding
+
+	if err := driver.Migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open SQLite database %s: %w", sqliteConfig.Path, err)
+	}
+
+	return driver, nil
+}
+
+// Open opens an SQLite database configured for queue use: WAL journaling,
+// a busy timeout, and a single connection so writers in the process queue up
+// instead of failing with SQLITE_BUSY. Use ":memory:" for a throwaway database.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// NewDriverWithDB creates a new SQLite queue driver with an existing database
+// handle, such as one returned by Open. The tables must exist; call Migrate to
+// create them. Close does not close a shared handle.
+func NewDriverWithDB(db *sql.DB, table string) (*Driver, error) {
+	return newDriver(db, table, "")
+}
+
+func newDriver(db *sql.DB, table, failedTable string) (*Driver, error) {
+	if failedTable == "" {
+		failedTable = table + "_failed"
+	}
+	for _, name := range []string{table, failedTable} {
+		if !identifier.MatchString(name) {
+			return nil, fmt.Errorf("%w: invalid sqlite table name %q", dgqueue.ErrInvalidConfig, name)
+		}
+	}
+
+	return &Driver{
+		db:          db,
+		table:       table,
+		failedTable: failedTable,
+		batchTable:  table + "_batches",
+		encoding:    dgqueue.JobEncoding{Format: dgqueue.FormatJSON},
+	}, nil
+}
+
+// SetFormat sets the format new jobs are written in.
+// Jobs already stored in other formats remain readable.
+func (d *Driver) SetFormat(format dgqueue.JobFormat) {
+	d.encoding.Format = format
+}
+
+// SetCompression sets how the payloads of new jobs are compressed.
+// Compressed jobs already stored remain readable either way.
+func (d *Driver) SetCompression(compression dgqueue.Compression) {
+	d.encoding.Compression = compression
+}
+
+// SetDecodeLimits sets the limits stored jobs are checked against.
+func (d *Driver) SetDecodeLimits(limits dgqueue.DecodeLimits) {
+	d.encoding.Limits = limits
+}
+
+// SetSigningKeys sets the keys jobs are signed and checked with; see
+// dgqueue.JobEncoding.SigningKeys.
+func (d *Driver) SetSigningKeys(keys ...[]byte) {
+	d.encoding.SigningKeys = keys
+}
+
+// Migrate creates the jobs, failed and batches tables if they don't exist.
+// Times are stored as Unix nanoseconds.
+func (d *Driver) Migrate(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			queue TEXT NOT NULL,
+			data BLOB NOT NULL,
+			available_at INTEGER NOT NULL,
+			reserved_at INTEGER,
+			created_at INTEGER NOT NULL
+		)`, d.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_pop_idx ON %s (queue, available_at) WHERE reserved_at IS NULL`, d.table, d.table),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			queue TEXT NOT NULL,
+			data BLOB NOT NULL,
+			failed_at INTEGER NOT NULL
+		)`, d.failedTable),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			data BLOB NOT NULL,
+			expires_at INTEGER NOT NULL
+		)`, d.batchTable),
+	}
+
+	for _, statement := range statements {
+		if _, err := d.db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Push pushes a job to the queue.
+// Pushing a job whose ID is already stored replaces it and releases any reservation.
+func (d *Driver) Push(ctx context.Context, job *queue.Job) error {
+	data, err := d.encoding.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id, queue, data, available_at, reserved_at, created_at)
+		VALUES (?, ?, ?, ?, NULL, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			queue = excluded.queue,
+			data = excluded.data,
+			available_at = excluded.available_at,
+			reserved_at = NULL`, d.table),
+		job.ID, job.Queue, data, job.AvailableAt.UnixNano(), job.CreatedAt.UnixNano())
+	if err == nil {
+		d.held.Release(job.ID)
+	}
+	return err
+}
+
+// Pop reserves and returns the next available job in the queue.
+func (d *Driver) Pop(ctx context.Context, queueName string) (*queue.Job, error) {
+	now := time.Now().UnixNano()
+
+	jobs, err := d.reserve(ctx, fmt.Sprintf(`
+		UPDATE %s SET reserved_at = ?
+		WHERE id = (
+			SELECT id FROM %s
+			WHERE queue = ? AND reserved_at IS NULL AND available_at <= ?
+			ORDER BY available_at, created_at
+			LIMIT 1
+		)
+		RETURNING id, queue, data`, d.table, d.table),
+		now, queueName, now)
+	if err != nil {
+		return nil, err
+	}
+	return jobs[0], nil
+}
+
+// PopN reserves and returns up to n available jobs in the queue.
+func (d *Driver) PopN(ctx context.Context, queueName string, n int) ([]*queue.Job, error) {
+	now := time.Now().UnixNano()
+
+	return d.reserve(ctx, fmt.Sprintf(`
+		UPDATE %s SET reserved_at = ?
+		WHERE id IN (
+			SELECT id FROM %s
+			WHERE queue = ? AND reserved_at IS NULL AND available_at <= ?
+			ORDER BY available_at, created_at
+			LIMIT ?
+		)
+		RETURNING id, queue, data`, d.table, d.table),
+		now, queueName, now, n)
+}
+
+// reserve runs a query reserving rows and returning their id, queue and data,
+// and holds the jobs it returns.
+func (d *Driver) reserve(ctx context.Context, query string, args ...any) ([]*queue.Job, error) {
+	jobs, err := sqlqueue.Reserve(ctx, d.db, d.encoding, d.setAside, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	d.held.Hold(jobs...)
+	return jobs, nil
+}
+
+// setAside moves a reserved row that can't be decoded to the failed table.
+func (d *Driver) setAside(ctx context.Context, tx *sql.Tx, id, queueName string, data []byte) error {
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, d.table), id); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id, queue, data, failed_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET queue = excluded.queue, data = excluded.data, failed_at = excluded.failed_at`, d.failedTable),
+		id, queueName, data, time.Now().UnixNano())
+	return err
+}
+
+// Delete deletes a job.
+func (d *Driver) Delete(ctx context.Context, jobID string) error {
+	_, err := d.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, d.table), jobID)
+	if err == nil {
+		d.held.Release(jobID)
+	}
+	return err
+}
+
+// Heartbeat refreshes the reservations of the jobs this driver holds, keeping
+// them from being recovered for the next ttl.
+func (d *Driver) Heartbeat(ctx context.Context, ttl time.Duration) error {
+	d.visibility.Store(int64(ttl))
+
+	// Stay well under SQLite's limit on bound variables, 999 before 3.32
+	const batch = 500
+
+	now := time.Now().UnixNano()
+	ids := d.held.IDs()
+	for len(ids) > 0 {
+		n := min(len(ids), batch)
+		args := make([]any, 1, n+1)
+		args[0] = now
+		for _, id := range ids[:n] {
+			args = append(args, id)
+		}
+		ids = ids[n:]
+
+		placeholders := strings.Repeat(", ?", n)[2:]
+		if _, err := d.db.ExecContext(ctx, fmt.Sprintf(
+			`UPDATE %s SET reserved_at = ? WHERE id IN (%s) AND reserved_at IS NOT NULL`, d.table, placeholders),
+			args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecoverStalled releases reservations not refreshed within the heartbeat
+// ttl, making those jobs available again. It does nothing until Heartbeat
+// has been called.
+func (d *Driver) RecoverStalled(ctx context.Context) (int64, error) {
+	ttl := time.Duration(d.visibility.Load())
+	if ttl <= 0 {
+		return 0, nil
+	}
+
+	result, err := d.db.ExecContext(ctx, fmt.Sprintf(
+		`UPDATE %s SET reserved_at = NULL WHERE reserved_at < ?`, d.table),
+		time.Now().Add(-ttl).UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ReapOrphaned reserves the rows whose reservation hasn't been refreshed for
+// olderThan to this driver and returns their jobs.
+func (d *Driver) ReapOrphaned(ctx context.Context, olderThan time.Duration) ([]*queue.Job, error) {
+	now := time.Now()
+	jobs, err := d.reserve(ctx, fmt.Sprintf(
+		`UPDATE %s SET reserved_at = ? WHERE reserved_at < ? RETURNING id, queue, data`, d.table),
+		now.UnixNano(), now.Add(-olderThan).UnixNano())
+	if errors.Is(err, dgqueue.ErrQueueEmpty) {
+		return nil, nil
+	}
+	return jobs, err
+}
+
+// Retry stores the job again, available at its AvailableAt.
+func (d *Driver) Retry(ctx context.Context, job *queue.Job) error {
+	return d.Push(ctx, job)
+}
+
+// Failed moves a job to the failed table.
+func (d *Driver) Failed(ctx context.Context, job *queue.Job) error {
+	data, err := d.encoding.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, d.table), job.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id, queue, data, failed_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET queue = excluded.queue, data = excluded.data, failed_at = excluded.failed_at`, d.failedTable),
+		job.ID, job.Queue, data, time.Now().UnixNano()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	d.held.Release(job.ID)
+	return nil
+}
+
+// BackendID names the database file and the jobs table.
+func (d *Driver) BackendID() string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// In-memory databases have no file, and are private to their handle
+	file := fmt.Sprintf("%p", d.db)
+	rows, err := d.db.QueryContext(ctx, `PRAGMA database_list`)
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var seq int
+			var name, path string
+			if rows.Scan(&seq, &name, &path) == nil && name == "main" && path != "" {
+				file = path
+			}
+		}
+	}
+	return "sqlite://" + file + "/" + d.table
+}
+
+// MigrateJobs hands every unreserved and failed job to fn, in the order they
+// would be popped, removing each one fn accepts. Rows that can't be decoded
+// are left in place.
+func (d *Driver) MigrateJobs(ctx context.Context, fn func(job *queue.Job, failed bool) error) error {
+	query := fmt.Sprintf(`SELECT id, data FROM %s WHERE reserved_at IS NULL ORDER BY available_at, created_at LIMIT ? OFFSET ?`, d.table)
+	if err := d.migrateRows(ctx, query, d.table, false, fn); err != nil {
+		return err
+	}
+	query = fmt.Sprintf(`SELECT id, data FROM %s ORDER BY failed_at LIMIT ? OFFSET ?`, d.failedTable)
+	return d.migrateRows(ctx, query, d.failedTable, true, fn)
+}
+
+// migrateRows hands the jobs read a page at a time by query, which takes a
+// limit and offset, to fn, deleting each one it accepts from table.
+func (d *Driver) migrateRows(ctx context.Context, query, table string, failed bool, fn func(job *queue.Job, failed bool) error) error {
+	const batch = 100
+
+	// Rows left in place stay at the front
+	skipped := 0
+	for {
+		type row struct {
+			id   string
+			data []byte
+		}
+		var page []row
+
+		// Read the page before deleting, as SQLite has a single connection
+		rows, err := d.db.QueryContext(ctx, query, batch, skipped)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.id, &r.data); err != nil {
+				rows.Close()
+				return err
+			}
+			page = append(page, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			return nil
+		}
+
+		for _, r := range page {
+			job, err := d.encoding.Unmarshal(r.data)
+			if err != nil {
+				skipped++
+				continue
+			}
+			if err := fn(job, failed); err != nil {
+				return err
+			}
+			if _, err := d.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, table), r.id); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// PruneFailed removes failed jobs that failed before cutoff or aren't among
+// the newest keep, oldest first, archiving each first if archive is set.
+// Rows that can't be decoded are removed without archiving.
+func (d *Driver) PruneFailed(ctx context.Context, cutoff time.Time, keep int, archive func(*queue.Job) error) (int64, error) {
+	const batch = 100
+
+	// A negative limit keeps every row, and no row failed before MinInt64
+	limit := -1
+	if keep > 0 {
+		limit = keep
+	}
+	before := int64(math.MinInt64)
+	if !cutoff.IsZero() {
+		before = cutoff.UnixNano()
+	}
+	query := fmt.Sprintf(`
+		SELECT id, data FROM %s
+		WHERE failed_at < ? OR id NOT IN (SELECT id FROM %s ORDER BY failed_at DESC LIMIT ?)
+		ORDER BY failed_at
+		LIMIT %d`, d.failedTable, d.failedTable, batch)
+
+	var pruned int64
+	for {
+		rows, err := d.db.QueryContext(ctx, query, before, limit)
+		if err != nil {
+			return pruned, err
+		}
+
+		// Rows archived before an error are still removed
+		ids, err := sqlqueue.ArchiveRows(rows, d.encoding, archive)
+		if len(ids) > 0 {
+			args := make([]any, len(ids))
+			for i, id := range ids {
+				args[i] = id
+			}
+			placeholders := strings.Repeat(", ?", len(ids))[2:]
+			result, deleteErr := d.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id IN (%s)`, d.failedTable, placeholders), args...)
+			if deleteErr != nil {
+				return pruned, deleteErr
+			}
+			n, _ := result.RowsAffected()
+			pruned += n
+		}
+		if err != nil || len(ids) < batch {
+			return pruned, err
+		}
+	}
+}
+
+// SaveBatch saves the batch's status for the next ttl, removing statuses
+// that have expired.
+func (d *Driver) SaveBatch(ctx context.Context, status *dgqueue.BatchStatus, ttl time.Duration) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if _, err := d.db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id, data, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET data = excluded.data, expires_at = excluded.expires_at`, d.batchTable),
+		status.ID, data, now.Add(ttl).UnixNano()); err != nil {
+		return err
+	}
+	_, err = d.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE expires_at <= ?`, d.batchTable), now.UnixNano())
+	return err
+}
+
+// Batch returns the status saved under id.
+func (d *Driver) Batch(ctx context.Context, id string) (*dgqueue.BatchStatus, error) {
+	var data []byte
+	err := d.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT data FROM %s WHERE id = ? AND expires_at > ?`, d.batchTable), id, time.Now().UnixNano()).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, dgqueue.ErrBatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var status dgqueue.BatchStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Get retrieves a job by ID from the jobs or failed table.
+func (d *Driver) Get(ctx context.Context, jobID string) (*queue.Job, error) {
+	var data []byte
+	err := d.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT data FROM %s WHERE id = ?
+		UNION ALL
+		SELECT data FROM %s WHERE id = ?
+		LIMIT 1`, d.table, d.failedTable), jobID, jobID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, dgqueue.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return d.encoding.Unmarshal(data)
+}
+
+// Size returns the number of unreserved jobs in the queue, including delayed ones.
+func (d *Driver) Size(ctx context.Context, queueName string) (int64, error) {
+	var size int64
+	err := d.db.QueryRowContext(ctx, fmt.Sprintf(
+		`SELECT COUNT(*) FROM %s WHERE queue = ? AND reserved_at IS NULL`, d.table),
+		queueName).Scan(&size)
+	return size, err
+}
+
+// Ping checks the database is reachable.
+func (d *Driver) Ping(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
+// Close closes the database handle if the driver opened it.
+func (d *Driver) Close() error {
+	if !d.ownsDB || d.db == nil {
+		return nil
+	}
+	return d.db.Close()
+}
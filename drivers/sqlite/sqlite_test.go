@@ -0,0 +1,512 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+)
+
+func setupSQLiteDriver(t *testing.T) *Driver {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	driver, err := NewDriverWithDB(db, "test_queue_jobs")
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	if err := driver.Migrate(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	return driver
+}
+
+func TestSQLiteDriver_PushPop(t *testing.T) {
+	driver := setupSQLiteDriver(t)
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("test-job", map[string]string{"key": "value"})
+	if err := driver.Push(ctx, job); err != nil {
+		t.Fatalf("Failed to push job: %v", err)
+	}
+
+	popped, err := driver.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Failed to pop job: %v", err)
+	}
+	if popped.ID != job.ID || popped.Name != job.Name {
+		t.Errorf("Expected job %s/%s, got %s/%s", job.ID, job.Name, popped.ID, popped.Name)
+	}
+
+	// Reserved jobs aren't popped again
+	if _, err := driver.Pop(ctx, "default"); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+		t.Errorf("Expected ErrQueueEmpty, got %v", err)
+	}
+
+	// But remain visible until deleted
+	if _, err := driver.Get(ctx, job.ID); err != nil {
+		t.Errorf("Expected reserved job to be found, got %v", err)
+	}
+	driver.Delete(ctx, job.ID)
+	if _, err := driver.Get(ctx, job.ID); !errors.Is(err, dgqueue.ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound after delete, got %v", err)
+	}
+}
+
+func TestSQLiteDriver_DelayedJob(t *testing.T) {
+	driver := setupSQLiteDriver(t)
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("delayed-job", "payload")
+	dgqueue.WithDelay(job, time.Hour)
+	driver.Push(ctx, job)
+
+	if _, err := driver.Pop(ctx, "default"); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+		t.Errorf("Expected delayed job to be unavailable, got %v", err)
+	}
+
+	size, _ := driver.Size(ctx, "default")
+	if size != 1 {
+		t.Errorf("Expected size 1, got %d", size)
+	}
+}
+
+func TestSQLiteDriver_PopN(t *testing.T) {
+	driver := setupSQLiteDriver(t)
+	ctx := context.Background()
+
+	if _, err := driver.PopN(ctx, "default", 5); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+		t.Errorf("Expected ErrQueueEmpty, got %v", err)
+	}
+
+	driver.Push(ctx, dgqueue.WithDelay(dgqueue.NewJob("delayed", nil), time.Hour))
+	for _, name := range []string{"first", "second", "third"} {
+		driver.Push(ctx, dgqueue.NewJob(name, nil))
+		time.Sleep(time.Millisecond)
+	}
+
+	// Jobs come out in Pop order, capped at n
+	jobs, err := driver.PopN(ctx, "default", 2)
+	if err != nil {
+		t.Fatalf("PopN failed: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Name != "first" || jobs[1].Name != "second" {
+		t.Fatalf("Expected [first second], got %d jobs", len(jobs))
+	}
+
+	// Reserved and delayed jobs are skipped
+	jobs, err = driver.PopN(ctx, "default", 10)
+	if err != nil {
+		t.Fatalf("PopN failed: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Name != "third" {
+		t.Errorf("Expected [third], got %d jobs", len(jobs))
+	}
+	if _, err := driver.PopN(ctx, "default", 10); !errors.Is(err, dgqueue.ErrQueueEmpty) {
+		t.Errorf("Expected ErrQueueEmpty, got %v", err)
+	}
+}
+
+func TestSQLiteDriver_RetryAndFailed(t *testing.T) {
+	driver := setupSQLiteDriver(t)
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("flaky-job", "payload")
+	driver.Push(ctx, job)
+	popped, _ := driver.Pop(ctx, "default")
+
+	// Retry releases the reservation
+	if err := driver.Retry(ctx, popped); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	popped, err := driver.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Expected retried job to be popped, got %v", err)
+	}
+
+	dgqueue.MarkFailed(popped, errors.New("boom"))
+	if err := driver.Failed(ctx, popped); err != nil {
+		t.Fatalf("Failed failed: %v", err)
+	}
+
+	size, _ := driver.Size(ctx, "default")
+	if size != 0 {
+		t.Errorf("Expected empty queue, got %d", size)
+	}
+	failed, err := driver.Get(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Expected failed job to be found, got %v", err)
+	}
+	if failed.Error != "boom" {
+		t.Errorf("Expected error 'boom', got %q", failed.Error)
+	}
+}
+
+func TestSQLiteDriver_ConcurrentPop(t *testing.T) {
+	driver := setupSQLiteDriver(t)
+	ctx := context.Background()
+
+	for i := 0; i < 50; i++ {
+		driver.Push(ctx, dgqueue.NewJob("job", i))
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := driver.Pop(ctx, "default")
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				seen[job.ID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != 50 {
+		t.Errorf("Expected 50 distinct jobs, got %d", len(seen))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("Job %s popped %d times", id, n)
+		}
+	}
+}
+
+func TestSQLiteDriver_RecoverStalled(t *testing.T) {
+	live := setupSQLiteDriver(t)
+	dead, err := NewDriverWithDB(live.db, "test_queue_jobs")
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	ctx := context.Background()
+
+	// Nothing is recovered before the first heartbeat sets the ttl
+	if recovered, err := live.RecoverStalled(ctx); err != nil || recovered != 0 {
+		t.Fatalf("Expected nothing recovered, got %d (%v)", recovered, err)
+	}
+
+	kept := dgqueue.NewJob("kept", nil)
+	lost := dgqueue.NewJob("lost", nil)
+	live.Push(ctx, kept)
+	if _, err := live.Pop(ctx, "default"); err != nil {
+		t.Fatalf("Failed to pop job: %v", err)
+	}
+	live.Push(ctx, lost)
+	if _, err := dead.Pop(ctx, "default"); err != nil {
+		t.Fatalf("Failed to pop job: %v", err)
+	}
+
+	// Only the live driver keeps heartbeating its reservation
+	ttl := 50 * time.Millisecond
+	time.Sleep(2 * ttl)
+	if err := live.Heartbeat(ctx, ttl); err != nil {
+		t.Fatalf("Failed to heartbeat: %v", err)
+	}
+
+	recovered, err := live.RecoverStalled(ctx)
+	if err != nil {
+		t.Fatalf("Failed to recover stalled jobs: %v", err)
+	}
+	if recovered != 1 {
+		t.Errorf("Expected 1 recovered job, got %d", recovered)
+	}
+
+	popped, err := live.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Expected the lost job to be available again, got %v", err)
+	}
+	if popped.ID != lost.ID {
+		t.Errorf("Expected ID %s, got %s", lost.ID, popped.ID)
+	}
+}
+
+func TestSQLiteDriver_ReapOrphaned(t *testing.T) {
+	reaper := setupSQLiteDriver(t)
+	dead, err := NewDriverWithDB(reaper.db, "test_queue_jobs")
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	ctx := context.Background()
+
+	job := dgqueue.NewJob("crashed", nil)
+	reaper.Push(ctx, job)
+	if _, err := dead.Pop(ctx, "default"); err != nil {
+		t.Fatalf("Failed to pop job: %v", err)
+	}
+
+	if jobs, err := reaper.ReapOrphaned(ctx, time.Hour); err != nil || len(jobs) != 0 {
+		t.Fatalf("Expected no orphans yet, got %d (%v)", len(jobs), err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	jobs, err := reaper.ReapOrphaned(ctx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to reap: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Fatalf("Expected the crashed job to be reaped, got %v", jobs)
+	}
+	reaped := jobs[0]
+
+	// The reaper now holds the job, so it isn't reaped twice
+	if err := reaper.Heartbeat(ctx, time.Hour); err != nil {
+		t.Fatalf("Failed to heartbeat: %v", err)
+	}
+	if jobs, err := reaper.ReapOrphaned(ctx, 10*time.Millisecond); err != nil || len(jobs) != 0 {
+		t.Fatalf("Expected the reaped job to stay held, got %d (%v)", len(jobs), err)
+	}
+
+	// Retrying it makes it available again
+	if err := reaper.Retry(ctx, reaped); err != nil {
+		t.Fatalf("Failed to retry job: %v", err)
+	}
+	if size, _ := reaper.Size(ctx, "default"); size != 1 {
+		t.Errorf("Expected the job back in the queue, got %d", size)
+	}
+}
+
+func TestSQLiteDriver_PruneFailed(t *testing.T) {
+	driver := setupSQLiteDriver(t)
+	ctx := context.Background()
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		job := dgqueue.NewJob("import", nil)
+		driver.Failed(ctx, job)
+		ids = append(ids, job.ID)
+		time.Sleep(time.Millisecond)
+	}
+
+	var archived []string
+	archive := func(job *dgqueue.Job) error {
+		archived = append(archived, job.ID)
+		return nil
+	}
+
+	// Nothing failed before the cutoff and no count limit
+	if pruned, err := driver.PruneFailed(ctx, time.Now().Add(-time.Hour), 0, archive); err != nil || pruned != 0 {
+		t.Fatalf("Expected nothing pruned, got %d (%v)", pruned, err)
+	}
+
+	pruned, err := driver.PruneFailed(ctx, time.Time{}, 1, archive)
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if pruned != 3 {
+		t.Errorf("Expected 3 pruned, got %d", pruned)
+	}
+	if len(archived) != 3 || archived[0] != ids[0] || archived[2] != ids[2] {
+		t.Errorf("Expected the oldest 3 archived in order, got %v", archived)
+	}
+	if _, err := driver.Get(ctx, ids[3]); err != nil {
+		t.Errorf("Expected the newest failed job kept, got %v", err)
+	}
+
+	// Everything failed before now
+	if pruned, err := driver.PruneFailed(ctx, time.Now(), 0, nil); err != nil || pruned != 1 {
+		t.Errorf("Expected the last job pruned, got %d (%v)", pruned, err)
+	}
+}
+
+func TestSQLiteDriver_Batch(t *testing.T) {
+	driver := setupSQLiteDriver(t)
+	ctx := context.Background()
+
+	status := &dgqueue.BatchStatus{ID: "import-42", Total: 3, Processed: 2, JobIDs: []string{"a", "b"}, InProgress: true}
+	if err := driver.SaveBatch(ctx, status, time.Minute); err != nil {
+		t.Fatalf("Failed to save batch: %v", err)
+	}
+	status.Processed = 3
+	status.InProgress = false
+	if err := driver.SaveBatch(ctx, status, time.Minute); err != nil {
+		t.Fatalf("Failed to save batch: %v", err)
+	}
+
+	saved, err := driver.Batch(ctx, "import-42")
+	if err != nil {
+		t.Fatalf("Failed to get batch: %v", err)
+	}
+	if saved.Processed != 3 || saved.InProgress || len(saved.JobIDs) != 2 {
+		t.Errorf("Expected the latest status, got %+v", saved)
+	}
+
+	// Expired statuses are gone
+	driver.SaveBatch(ctx, &dgqueue.BatchStatus{ID: "expired"}, -time.Second)
+	if _, err := driver.Batch(ctx, "expired"); !errors.Is(err, dgqueue.ErrBatchNotFound) {
+		t.Errorf("Expected ErrBatchNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteDriver_PersistsAcrossRestarts(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.Driver = "sqlite"
+	cfg.Options["path"] = filepath.Join(t.TempDir(), "queue.db")
+	ctx := context.Background()
+
+	driver, err := NewDriver(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	job := dgqueue.NewJob("durable-job", "payload")
+	driver.Push(ctx, job)
+	driver.Close()
+
+	reopened, err := NewDriver(cfg)
+	if err != nil {
+		t.Fatalf("Failed to reopen driver: %v", err)
+	}
+	defer reopened.Close()
+
+	popped, err := reopened.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Expected job to survive restart, got %v", err)
+	}
+	if popped.ID != job.ID {
+		t.Errorf("Expected ID %s, got %s", job.ID, popped.ID)
+	}
+}
+
+func TestNewDriverWithDB_InvalidTable(t *testing.T) {
+	for _, table := range []string{"", "jobs; DROP TABLE users", "1jobs"} {
+		if _, err := NewDriverWithDB(nil, table); !errors.Is(err, dgqueue.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %q, got %v", table, err)
+		}
+	}
+}
+
+func TestSQLiteDriver_Ping(t *testing.T) {
+	driver := setupSQLiteDriver(t)
+
+	if err := driver.Ping(context.Background()); err != nil {
+		t.Errorf("Expected healthy database, got %v", err)
+	}
+
+	driver.db.Close()
+	if err := driver.Ping(context.Background()); err == nil {
+		t.Error("Expected error from a closed database")
+	}
+}
+
+func TestSQLiteDriver_MigrateJobs(t *testing.T) {
+	driver := setupSQLiteDriver(t)
+	ctx := context.Background()
+
+	held := dgqueue.NewJob("test-job", "held")
+	ready := dgqueue.NewJob("test-job", "ready")
+	delayed := dgqueue.WithDelay(dgqueue.NewJob("test-job", "delayed"), time.Hour)
+	failed := dgqueue.NewJob("test-job", "failed")
+	for _, job := range []*dgqueue.Job{held, ready, delayed} {
+		if err := driver.Push(ctx, job); err != nil {
+			t.Fatalf("Failed to push job: %v", err)
+		}
+	}
+	if err := driver.Failed(ctx, failed); err != nil {
+		t.Fatalf("Failed to fail job: %v", err)
+	}
+	if _, err := driver.Pop(ctx, "default"); err != nil {
+		t.Fatalf("Failed to pop job: %v", err)
+	}
+
+	migrated := make(map[string]bool)
+	err := driver.MigrateJobs(ctx, func(job *dgqueue.Job, isFailed bool) error {
+		migrated[job.ID] = isFailed
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to migrate jobs: %v", err)
+	}
+
+	if len(migrated) != 3 || migrated[ready.ID] || migrated[delayed.ID] || !migrated[failed.ID] {
+		t.Errorf("Expected the ready, delayed and failed jobs to be migrated, got %v", migrated)
+	}
+	if _, ok := migrated[held.ID]; ok {
+		t.Error("Expected the held job to stay in place")
+	}
+	if _, err := driver.Get(ctx, ready.ID); !errors.Is(err, dgqueue.ErrJobNotFound) {
+		t.Errorf("Expected migrated jobs to be removed, got %v", err)
+	}
+	if _, err := driver.Get(ctx, held.ID); err != nil {
+		t.Errorf("Expected the held job to remain, got %v", err)
+	}
+}
+
+func TestSQLiteDriver_SetsAsideUndecodableJobs(t *testing.T) {
+	driver := setupSQLiteDriver(t)
+	ctx := context.Background()
+
+	garbled := dgqueue.NewJob("test-job", "garbled")
+	good := dgqueue.NewJob("test-job", "good")
+	for _, job := range []*dgqueue.Job{garbled, good} {
+		if err := driver.Push(ctx, job); err != nil {
+			t.Fatalf("Failed to push job: %v", err)
+		}
+	}
+	if _, err := driver.db.ExecContext(ctx, `UPDATE test_queue_jobs SET data = 'garbage' WHERE id = ?`, garbled.ID); err != nil {
+		t.Fatalf("Failed to garble job: %v", err)
+	}
+
+	if _, err := driver.Pop(ctx, "default"); !errors.Is(err, dgqueue.ErrInvalidPayload) {
+		t.Fatalf("Expected ErrInvalidPayload, got %v", err)
+	}
+	popped, err := driver.Pop(ctx, "default")
+	if err != nil {
+		t.Fatalf("Expected the good job after the garbled one, got %v", err)
+	}
+	if popped.ID != good.ID {
+		t.Errorf("Expected ID %s, got %s", good.ID, popped.ID)
+	}
+
+	// The garbled row moved to the failed table, and isn't held
+	var count int
+	driver.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM test_queue_jobs_failed WHERE id = ?`, garbled.ID).Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected the garbled job in the failed table, got %d rows", count)
+	}
+	if ids := driver.held.IDs(); len(ids) != 1 || ids[0] != good.ID {
+		t.Errorf("Expected only the good job held, got %v", ids)
+	}
+}
+
+func TestSQLiteDriver_HeartbeatManyJobs(t *testing.T) {
+	driver := setupSQLiteDriver(t)
+	ctx := context.Background()
+
+	// More held jobs than SQLite binds in one statement
+	const n = 1200
+	for i := 0; i < n; i++ {
+		if err := driver.Push(ctx, dgqueue.NewJob("test-job", i)); err != nil {
+			t.Fatalf("Failed to push job: %v", err)
+		}
+	}
+	if _, err := driver.PopN(ctx, "default", n); err != nil {
+		t.Fatalf("Failed to pop jobs: %v", err)
+	}
+
+	// Age every reservation past the ttl rather than sleeping through it
+	ttl := 5 * time.Second
+	if _, err := driver.db.ExecContext(ctx, `UPDATE test_queue_jobs SET reserved_at = ?`,
+		time.Now().Add(-time.Hour).UnixNano()); err != nil {
+		t.Fatalf("Failed to age reservations: %v", err)
+	}
+	if err := driver.Heartbeat(ctx, ttl); err != nil {
+		t.Fatalf("Failed to heartbeat: %v", err)
+	}
+	if recovered, err := driver.RecoverStalled(ctx); err != nil || recovered != 0 {
+		t.Errorf("Expected every reservation refreshed, got %d recovered (%v)", recovered, err)
+	}
+}
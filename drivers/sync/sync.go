@@ -0,0 +1,90 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/donnigundala/dg-core/contracts/queue"
+	dgqueue "github.com/donnigundala/dg-queue"
+)
+
+// Driver is a queue driver that runs each job inline when it is pushed.
+// Dispatch returns the handler's error, delays are ignored and failed jobs are
+// not retried, which keeps local development and simple scripts predictable.
+// The driver must be attached with Manager.SetDriver (or Bootstrap) so it can
+// reach the registered workers.
+type Driver struct {
+	mu  sync.RWMutex
+	run dgqueue.JobRunner
+}
+
+// errUnbound is returned when a job is pushed before the driver is attached to a manager.
+var errUnbound = errors.New("sync driver is not attached to a queue manager")
+
+func init() {
+	dgqueue.RegisterDriver("sync", NewDriver)
+}
+
+// NewDriver creates a new sync queue driver.
+func NewDriver(config dgqueue.Config) (dgqueue.Driver, error) {
+	return &Driver{}, nil
+}
+
+// BindRunner sets the runner jobs are executed with.
+func (d *Driver) BindRunner(run dgqueue.JobRunner) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.run = run
+}
+
+// Push runs the job immediately and returns its handler's error.
+func (d *Driver) Push(ctx context.Context, job *queue.Job) error {
+	d.mu.RLock()
+	run := d.run
+	d.mu.RUnlock()
+	if run == nil {
+		return errUnbound
+	}
+	return run(ctx, job)
+}
+
+// Pop always reports an empty queue; jobs never wait.
+func (d *Driver) Pop(ctx context.Context, queueName string) (*queue.Job, error) {
+	return nil, dgqueue.ErrQueueEmpty
+}
+
+// Delete does nothing.
+func (d *Driver) Delete(ctx context.Context, jobID string) error {
+	return nil
+}
+
+// Retry does nothing; failed jobs are not retried.
+func (d *Driver) Retry(ctx context.Context, job *queue.Job) error {
+	return nil
+}
+
+// Failed does nothing; the failure was returned to the dispatcher.
+func (d *Driver) Failed(ctx context.Context, job *queue.Job) error {
+	return nil
+}
+
+// Capabilities reports that nothing is stored, since jobs are run on push.
+func (d *Driver) Capabilities() dgqueue.Capabilities {
+	return dgqueue.Capabilities{}
+}
+
+// Get always reports the job as not found; jobs aren't stored.
+func (d *Driver) Get(ctx context.Context, jobID string) (*queue.Job, error) {
+	return nil, dgqueue.ErrJobNotFound
+}
+
+// Size always returns 0.
+func (d *Driver) Size(ctx context.Context, queueName string) (int64, error) {
+	return 0, nil
+}
+
+// Close does nothing.
+func (d *Driver) Close() error {
+	return nil
+}
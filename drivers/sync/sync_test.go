@@ -0,0 +1,148 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+)
+
+func newSyncManager(t *testing.T) *dgqueue.Manager {
+	cfg := dgqueue.DefaultConfig()
+	cfg.Driver = "sync"
+
+	manager, err := dgqueue.Bootstrap(cfg)
+	if err != nil {
+		t.Fatalf("Expected registered sync driver, got %v", err)
+	}
+	return manager
+}
+
+func TestSyncDriver_RunsJobOnDispatch(t *testing.T) {
+	manager := newSyncManager(t)
+
+	var received interface{}
+	manager.Worker("send-email", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		received = job.Payload
+		return nil
+	})
+
+	job, err := manager.Dispatch(context.Background(), "send-email", "user@example.com")
+	if err != nil {
+		t.Fatalf("Expected dispatch to succeed, got %v", err)
+	}
+
+	// The handler already ran, without Start
+	if received != "user@example.com" {
+		t.Errorf("Expected handler to receive payload, got %v", received)
+	}
+	if job.CompletedAt == nil {
+		t.Errorf("Expected job to be marked completed")
+	}
+}
+
+func TestSyncDriver_ReturnsHandlerError(t *testing.T) {
+	manager := newSyncManager(t)
+
+	calls := 0
+	manager.Worker("flaky", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		calls++
+		return errors.New("boom")
+	})
+
+	if _, err := manager.Dispatch(context.Background(), "flaky", nil); err == nil || err.Error() != "boom" {
+		t.Errorf("Expected handler error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected one attempt, got %d", calls)
+	}
+}
+
+func TestSyncDriver_AppliesMiddleware(t *testing.T) {
+	manager := newSyncManager(t)
+
+	var order []string
+	manager.Use(func(next dgqueue.WorkerFunc) dgqueue.WorkerFunc {
+		return func(ctx context.Context, job *dgqueue.Job) error {
+			order = append(order, "middleware")
+			return next(ctx, job)
+		}
+	})
+	manager.Worker("task", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		order = append(order, "handler")
+		return nil
+	})
+
+	manager.Dispatch(context.Background(), "task", nil)
+	if len(order) != 2 || order[0] != "middleware" || order[1] != "handler" {
+		t.Errorf("Expected middleware then handler, got %v", order)
+	}
+}
+
+func TestSyncDriver_RunsDeferredJobAgain(t *testing.T) {
+	manager := newSyncManager(t)
+
+	deferrals := 0
+	manager.Use(func(next dgqueue.WorkerFunc) dgqueue.WorkerFunc {
+		return func(ctx context.Context, job *dgqueue.Job) error {
+			if deferrals < 2 {
+				deferrals++
+				job.AvailableAt = time.Now().Add(10 * time.Millisecond)
+				return dgqueue.ErrJobDeferred
+			}
+			return next(ctx, job)
+		}
+	})
+	var attempts int
+	manager.Worker("task", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		attempts = job.Attempts
+		return nil
+	})
+
+	if _, err := manager.Dispatch(context.Background(), "task", nil); err != nil {
+		t.Fatalf("Expected the deferred job to run once available, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected deferrals not to use attempts, got attempt %d", attempts)
+	}
+}
+
+func TestSyncDriver_SkipsProcessedIdempotencyKey(t *testing.T) {
+	manager := newSyncManager(t)
+
+	calls := 0
+	manager.Worker("charge", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		calls++
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		job := dgqueue.WithIdempotencyKey(manager.NewJob("charge", nil), "order-42")
+		if err := manager.Enqueue(context.Background(), job); err != nil {
+			t.Fatalf("Expected dispatch to succeed, got %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the processed key to be skipped, got %d calls", calls)
+	}
+	if duplicates := manager.MetricsSnapshot().Duplicates; duplicates != 1 {
+		t.Errorf("Expected 1 duplicate, got %d", duplicates)
+	}
+}
+
+func TestSyncDriver_UnknownWorker(t *testing.T) {
+	manager := newSyncManager(t)
+
+	if _, err := manager.Dispatch(context.Background(), "missing", nil); !errors.Is(err, dgqueue.ErrWorkerNotFound) {
+		t.Errorf("Expected ErrWorkerNotFound, got %v", err)
+	}
+}
+
+func TestSyncDriver_Unbound(t *testing.T) {
+	driver := &Driver{}
+	if err := driver.Push(context.Background(), dgqueue.NewJob("task", nil)); !errors.Is(err, errUnbound) {
+		t.Errorf("Expected errUnbound, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package dgqueue
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+)
+
+// FormatSigned prefixes a job signed by JobEncoding: the byte is followed by
+// the HMAC-SHA256 of the job as written in its own format, then the job.
+const FormatSigned JobFormat = 0x0f
+
+// ReasonInvalidSignature marks jobs dead-lettered because they were not
+// signed with any of the reader's signing keys.
+const ReasonInvalidSignature = "invalid_signature"
+
+// JobEncoding is how a driver writes jobs and checks the jobs it reads.
+type JobEncoding struct {
+	// Format is the format jobs are written in
+	Format JobFormat
+
+	// Compression compresses the payloads of written jobs
+	Compression Compression
+
+	// Limits bound the structure of read jobs
+	Limits DecodeLimits
+
+	// SigningKeys sign written jobs with the first key. Read jobs must be
+	// signed with one of them, so keys can be rotated by adding the new key
+	// first and removing the old one once its jobs are drained. Empty
+	// disables signing.
+	SigningKeys [][]byte
+}
+
+// NewJobEncoding returns the encoding set by the config's Serializer,
+// Compression, payload limits and SigningKeys.
+func NewJobEncoding(config Config) (JobEncoding, error) {
+	format, err := ParseJobFormat(config.Serializer)
+	if err != nil {
+		return JobEncoding{}, err
+	}
+	compression, err := ParseCompression(config.Compression, config.CompressionThreshold)
+	if err != nil {
+		return JobEncoding{}, err
+	}
+
+	encoding := JobEncoding{
+		Format:      format,
+		Compression: compression,
+		Limits: DecodeLimits{
+			MaxDepth: config.MaxPayloadDepth,
+			MaxKeys:  config.MaxPayloadKeys,
+		},
+	}
+	for _, key := range config.SigningKeys {
+		if key == "" {
+			return JobEncoding{}, fmt.Errorf("%w: empty signing key", ErrInvalidConfig)
+		}
+		encoding.SigningKeys = append(encoding.SigningKeys, []byte(key))
+	}
+	return encoding, nil
+}
+
+// Marshal marshals the job with MarshalJobWith, signing it if signing keys
+// are set.
+func (e JobEncoding) Marshal(j *Job) ([]byte, error) {
+	data, err := MarshalJobWith(j, e.Format, e.Compression)
+	if err != nil || len(e.SigningKeys) == 0 {
+		return data, err
+	}
+
+	signed := make([]byte, 0, 1+sha256.Size+len(data))
+	signed = append(signed, byte(FormatSigned))
+	signed = append(signed, sign(e.SigningKeys[0], data)...)
+	return append(signed, data...), nil
+}
+
+// Unmarshal unmarshals a job with UnmarshalJobWithLimits. If signing keys
+// are set and the job isn't signed with one of them, it is returned with
+// MetadataDeadLetterReason set to ReasonInvalidSignature, and the manager
+// dead-letters it without running it. That reason is kept even once the job
+// is signed, so replaying a rejected job rejects it again.
+func (e JobEncoding) Unmarshal(data []byte) (*Job, error) {
+	job, err := UnmarshalJobWithLimits(data, e.Limits)
+	if err != nil || len(e.SigningKeys) == 0 {
+		return job, err
+	}
+	if !e.verify(data) {
+		WithMetadata(job, MetadataDeadLetterReason, ReasonInvalidSignature)
+	}
+	return job, nil
+}
+
+// verify reports whether data is signed with one of the signing keys.
+func (e JobEncoding) verify(data []byte) bool {
+	if len(data) < 1+sha256.Size || JobFormat(data[0]) != FormatSigned {
+		return false
+	}
+	mac, signed := data[1:1+sha256.Size], data[1+sha256.Size:]
+	for _, key := range e.SigningKeys {
+		if hmac.Equal(mac, sign(key, signed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sign returns the HMAC-SHA256 of data under key.
+func sign(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// unsigned returns data without its signature, if it is signed.
+func unsigned(data []byte) ([]byte, error) {
+	if len(data) == 0 || JobFormat(data[0]) != FormatSigned {
+		return data, nil
+	}
+	if len(data) < 1+sha256.Size {
+		return nil, fmt.Errorf("%w: truncated signature", ErrInvalidPayload)
+	}
+	return data[1+sha256.Size:], nil
+}
@@ -0,0 +1,90 @@
+package dgqueue
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestJobEncoding_Signing(t *testing.T) {
+	config := DefaultConfig()
+	config.SigningKeys = []string{"current-key"}
+	encoding, err := NewJobEncoding(config)
+	if err != nil {
+		t.Fatalf("Failed to create encoding: %v", err)
+	}
+
+	job := NewJob("charge-card", map[string]interface{}{"amount": 100.0})
+	data, err := encoding.Marshal(job)
+	if err != nil {
+		t.Fatalf("Failed to marshal job: %v", err)
+	}
+	if JobFormat(data[0]) != FormatSigned {
+		t.Fatalf("Expected signed job, got format byte %d", data[0])
+	}
+
+	decoded, err := encoding.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal job: %v", err)
+	}
+	if _, ok := decoded.Metadata[MetadataDeadLetterReason]; ok || decoded.ID != job.ID {
+		t.Errorf("Expected a verified job, got %v", decoded.Metadata)
+	}
+
+	// Readers without keys still decode signed jobs
+	if decoded, err := UnmarshalJob(data); err != nil || decoded.ID != job.ID {
+		t.Errorf("Expected signed job to decode without keys, got %v", err)
+	}
+}
+
+func TestJobEncoding_RejectsUnverifiedJobs(t *testing.T) {
+	signer := JobEncoding{Format: FormatJSON, SigningKeys: [][]byte{[]byte("current-key")}}
+	job := NewJob("charge-card", map[string]interface{}{"amount": 100.0})
+	signed, _ := signer.Marshal(job)
+	unsignedJob, _ := MarshalJob(job)
+	foreign, _ := JobEncoding{Format: FormatJSON, SigningKeys: [][]byte{[]byte("other-key")}}.Marshal(job)
+
+	tampered := bytes.Replace(signed, []byte(`"amount":100`), []byte(`"amount":900`), 1)
+	if bytes.Equal(tampered, signed) {
+		t.Fatal("Expected to tamper with the amount")
+	}
+
+	cases := map[string][]byte{"unsigned": unsignedJob, "foreign": foreign, "tampered": tampered}
+	for name, data := range cases {
+		decoded, err := signer.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("%s: failed to unmarshal job: %v", name, err)
+		}
+		if decoded.Metadata[MetadataDeadLetterReason] != ReasonInvalidSignature {
+			t.Errorf("%s: expected job marked %s, got %v", name, ReasonInvalidSignature, decoded.Metadata)
+		}
+	}
+}
+
+func TestJobEncoding_KeyRotation(t *testing.T) {
+	old := JobEncoding{Format: FormatMsgpack, SigningKeys: [][]byte{[]byte("old-key")}}
+	rotated := JobEncoding{Format: FormatMsgpack, SigningKeys: [][]byte{[]byte("new-key"), []byte("old-key")}}
+	job := NewJob("charge-card", nil)
+
+	// Jobs signed with the old key verify while it is still listed
+	data, _ := old.Marshal(job)
+	decoded, err := rotated.Unmarshal(data)
+	if err != nil || decoded.Metadata[MetadataDeadLetterReason] != nil {
+		t.Errorf("Expected job signed with the old key to verify, got %v, %v", decoded, err)
+	}
+
+	// New jobs are signed with the first key
+	data, _ = rotated.Marshal(job)
+	decoded, _ = old.Unmarshal(data)
+	if decoded.Metadata[MetadataDeadLetterReason] != ReasonInvalidSignature {
+		t.Errorf("Expected job signed with the new key to fail with only the old one")
+	}
+}
+
+func TestNewJobEncoding_EmptyKey(t *testing.T) {
+	config := DefaultConfig()
+	config.SigningKeys = []string{""}
+	if _, err := NewJobEncoding(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for an empty signing key, got %v", err)
+	}
+}
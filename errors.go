@@ -1,19 +1,85 @@
 package dgqueue
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Common queue errors.
 var (
-	ErrJobNotFound    = errors.New("job not found")
-	ErrQueueNotFound  = errors.New("queue not found")
-	ErrWorkerNotFound = errors.New("worker not found")
-	ErrJobTimeout     = errors.New("job timeout")
-	ErrMaxAttempts    = errors.New("max attempts exceeded")
-	ErrInvalidCron    = errors.New("invalid cron expression")
-	ErrQueueStopped   = errors.New("queue is stopped")
-	ErrInvalidPayload = errors.New("invalid payload")
-	ErrDriverNotFound = errors.New("driver not found")
-	ErrInvalidConfig  = errors.New("invalid configuration")
+	ErrJobNotFound     = errors.New("job not found")
+	ErrQueueNotFound   = errors.New("queue not found")
+	ErrWorkerNotFound  = errors.New("worker not found")
+	ErrWorkerExists    = errors.New("worker already registered")
+	ErrJobTimeout      = errors.New("job timeout")
+	ErrMaxAttempts     = errors.New("max attempts exceeded")
+	ErrInvalidCron     = errors.New("invalid cron expression")
+	ErrQueueStopped    = errors.New("queue is stopped")
+	ErrInvalidPayload  = errors.New("invalid payload")
+	ErrDriverNotFound  = errors.New("driver not found")
+	ErrManagerNotFound = errors.New("queue manager not found")
+	ErrInvalidConfig   = errors.New("invalid configuration")
+	ErrNotSupported    = errors.New("operation not supported by driver")
+	ErrTooManyBatches  = errors.New("too many concurrent batches")
+	ErrBatchNotFound   = errors.New("batch not found")
+	ErrStartDeadline   = errors.New("start deadline exceeded")
+	ErrJobOrphaned     = errors.New("job orphaned by a stopped worker")
+	ErrJobPanicked     = errors.New("job panicked")
+	ErrBadSignature    = errors.New("job signature invalid")
+	ErrPayloadVersion  = errors.New("unknown payload version")
+	// ErrJobDeferred can be returned by handlers and middleware to push the job
+	// back to its queue without using up an attempt.
+	ErrJobDeferred = errors.New("job deferred")
+	// ErrNoRetry can be returned by handlers, usually through Unretryable, to
+	// fail the job permanently without using up its remaining attempts.
+	ErrNoRetry = errors.New("job failed permanently")
 	// ErrQueueEmpty is returned when the queue is empty.
 	ErrQueueEmpty = errors.New("queue is empty")
 )
+
+// Unretryable marks err as a permanent failure, such as invalid input, so the
+// job goes straight to the dead letter queue instead of being retried. The
+// result keeps err's message and matches both err and ErrNoRetry with
+// errors.Is. It returns nil if err is nil.
+func Unretryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return unretryableError{err: err}
+}
+
+// unretryableError is an error marked with Unretryable.
+type unretryableError struct {
+	err error
+}
+
+func (e unretryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e unretryableError) Unwrap() []error {
+	return []error{e.err, ErrNoRetry}
+}
+
+// PanicError is the error of a job whose handler panicked. The job fails as if
+// the handler had returned it, and its stack is recorded in the job's
+// MetadataPanicStack. It matches ErrJobPanicked, and the panic value if that
+// is an error, with errors.Is.
+type PanicError struct {
+	// Value is the value passed to panic
+	Value interface{}
+
+	// Stack is the stack of the panicking goroutine
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrJobPanicked, e.Value)
+}
+
+func (e *PanicError) Unwrap() []error {
+	if err, ok := e.Value.(error); ok {
+		return []error{ErrJobPanicked, err}
+	}
+	return []error{ErrJobPanicked}
+}
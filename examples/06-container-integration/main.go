@@ -60,7 +60,10 @@ func main() {
 
 	// Register a worker to see it working
 	q.Worker("send-email", 1, func(ctx context.Context, job *dgqueue.Job) error {
-		payload := job.Payload.(map[string]interface{})
+		payload, err := dgqueue.PayloadMap(job)
+		if err != nil {
+			return err
+		}
 		fmt.Printf("[Worker] Sending %s email to %s\n", payload["type"], payload["email"])
 		return nil
 	})
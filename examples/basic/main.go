@@ -7,7 +7,7 @@ import (
 	"time"
 
 	queue "github.com/donnigundala/dg-queue"
-	"github.com/donnigundala/dg-queue/drivers/memory"
+	_ "github.com/donnigundala/dg-queue/drivers/memory"
 )
 
 type EmailJob struct {
@@ -18,13 +18,17 @@ type EmailJob struct {
 
 func main() {
 	// Create queue with memory driver
-	q := queue.New(queue.DefaultConfig())
-	d, _ := memory.NewDriver(queue.DefaultConfig())
-	q.SetDriver(d)
+	q, err := queue.Bootstrap(queue.DefaultConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Register worker
 	q.Worker("send-email", 5, func(ctx context.Context, job *queue.Job) error {
-		email := job.Payload.(map[string]interface{})
+		email, err := queue.PayloadMap(job)
+		if err != nil {
+			return err
+		}
 		fmt.Printf("[Worker] Sending email to: %s\n", email["to"])
 		fmt.Printf("[Worker] Subject: %s\n", email["subject"])
 
@@ -0,0 +1,124 @@
+package dgqueue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_InlineExecutionTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HandlerExecution = HandlerInline
+	cfg.MaxAttempts = 1
+
+	m := New(cfg)
+	m.SetDriver(emptyDriver{})
+	pool := &workerPool{
+		name:        "slow-job",
+		concurrency: 1,
+		handler: func(ctx context.Context, job *Job) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	job := NewJob("slow-job", nil)
+	job.Timeout = 20 * time.Millisecond
+	job.MaxAttempts = 1
+
+	start := time.Now()
+	m.processJob(pool, job)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, ErrJobTimeout.Error(), job.Error)
+	assert.Equal(t, int64(1), m.MetricsSnapshot().Failed)
+	assert.Equal(t, int64(1), m.MetricsSnapshot().DeadLettered)
+}
+
+func TestManager_InlineExecutionFinishedLate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HandlerExecution = HandlerInline
+
+	m := New(cfg)
+	m.SetDriver(emptyDriver{})
+	pool := &workerPool{
+		name:        "stubborn-job",
+		concurrency: 1,
+		handler: func(ctx context.Context, job *Job) error {
+			// Ignores ctx and returns after its deadline
+			time.Sleep(30 * time.Millisecond)
+			return nil
+		},
+	}
+
+	job := NewJob("stubborn-job", nil)
+	job.Timeout = 10 * time.Millisecond
+	m.processJob(pool, job)
+
+	assert.Equal(t, ErrJobTimeout.Error(), job.Error)
+	assert.Nil(t, job.CompletedAt)
+}
+
+func TestManager_InlineExecutionSuccess(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HandlerExecution = HandlerInline
+
+	m := New(cfg)
+	m.SetDriver(emptyDriver{})
+	pool := &workerPool{
+		name:        "fast-job",
+		concurrency: 1,
+		handler:     func(ctx context.Context, job *Job) error { return nil },
+	}
+
+	job := NewJob("fast-job", nil)
+	m.processJob(pool, job)
+
+	assert.NotNil(t, job.CompletedAt)
+	assert.Equal(t, int64(1), m.MetricsSnapshot().Succeeded)
+}
+
+// benchmarkExecution processes b.N jobs across 8 concurrent workers.
+func benchmarkExecution(b *testing.B, mode string) {
+	cfg := DefaultConfig()
+	cfg.HandlerExecution = mode
+
+	m := New(cfg)
+	m.SetDriver(emptyDriver{})
+	pool := &workerPool{
+		name:        "bench-job",
+		concurrency: 8,
+		handler:     func(ctx context.Context, job *Job) error { return nil },
+	}
+
+	jobs := make(chan *Job, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < pool.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				m.processJob(pool, job)
+			}
+		}()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		jobs <- NewJob("bench-job", i)
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func BenchmarkProcessJob_Goroutine(b *testing.B) {
+	benchmarkExecution(b, HandlerGoroutine)
+}
+
+func BenchmarkProcessJob_Inline(b *testing.B) {
+	benchmarkExecution(b, HandlerInline)
+}
@@ -0,0 +1,60 @@
+package dgqueue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// fairnessDelay is how long a job deferred by FairnessMiddleware waits before
+// it is available again, so workers don't keep popping it while its tenant is
+// at the limit.
+const fairnessDelay = 100 * time.Millisecond
+
+// FairnessMiddleware limits how many jobs of a single tenant run at once, so a
+// burst from one tenant can't monopolize workers shared with others.
+// Jobs over the limit return ErrJobDeferred and go back to the queue without
+// using an attempt, delayed by a moment. Jobs with an empty tenant key are not
+// limited.
+// The limit applies across every worker the middleware is used on.
+func FairnessMiddleware(tenantKey func(*Job) string, maxConcurrentPerTenant int) Middleware {
+	var mu sync.Mutex
+	running := make(map[string]int)
+
+	return func(next WorkerFunc) WorkerFunc {
+		return func(ctx context.Context, job *Job) error {
+			tenant := tenantKey(job)
+			if tenant == "" || maxConcurrentPerTenant <= 0 {
+				return next(ctx, job)
+			}
+
+			mu.Lock()
+			if running[tenant] >= maxConcurrentPerTenant {
+				mu.Unlock()
+				job.AvailableAt = time.Now().Add(fairnessDelay)
+				return fmt.Errorf("%w: tenant %s at concurrency limit", ErrJobDeferred, tenant)
+			}
+			running[tenant]++
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				if running[tenant]--; running[tenant] == 0 {
+					delete(running, tenant)
+				}
+				mu.Unlock()
+			}()
+
+			return next(ctx, job)
+		}
+	}
+}
+
+// TenantFromMetadata returns a tenant key function reading a string job metadata value.
+func TenantFromMetadata(key string) func(*Job) string {
+	return func(job *Job) string {
+		tenant, _ := job.Metadata[key].(string)
+		return tenant
+	}
+}
@@ -0,0 +1,129 @@
+package dgqueue_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFairnessMiddleware(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+	manager.Use(dgqueue.FairnessMiddleware(dgqueue.TenantFromMetadata("tenant"), 2))
+
+	var mu sync.Mutex
+	running := make(map[string]int)
+	maxRunning := make(map[string]int)
+	completed := make(map[string]int)
+	completedAtFirstB := -1
+
+	manager.Worker("tenant-job", 4, func(ctx context.Context, job *dgqueue.Job) error {
+		tenant := job.Metadata["tenant"].(string)
+
+		mu.Lock()
+		running[tenant]++
+		maxRunning[tenant] = max(maxRunning[tenant], running[tenant])
+		if tenant == "b" && completedAtFirstB < 0 {
+			completedAtFirstB = completed["a"]
+		}
+		mu.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+
+		mu.Lock()
+		running[tenant]--
+		completed[tenant]++
+		mu.Unlock()
+		return nil
+	})
+
+	// A burst from tenant A, queued ahead of a few jobs from tenant B
+	ctx := context.Background()
+	for i, tenant := range []string{"a", "a", "a", "a", "a", "a", "a", "a", "a", "a", "b", "b", "b"} {
+		job := manager.NewJob("tenant-job", i)
+		dgqueue.WithMetadata(job, "tenant", tenant)
+		assert.NoError(t, manager.Enqueue(ctx, job))
+	}
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return completed["a"] == 10 && completed["b"] == 3
+	}, 5*time.Second, 20*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.LessOrEqual(t, maxRunning["a"], 2, "Expected tenant A to be capped")
+	assert.Less(t, completedAtFirstB, 5, "Expected tenant B to start before A's burst finished")
+}
+
+func TestFairnessMiddleware_DelaysDeferredJobs(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := dgqueue.FairnessMiddleware(dgqueue.TenantFromMetadata("tenant"), 1)(func(ctx context.Context, job *dgqueue.Job) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	newJob := func() *dgqueue.Job {
+		return dgqueue.WithMetadata(dgqueue.NewJob("tenant-job", nil), "tenant", "a")
+	}
+	go handler(context.Background(), newJob())
+	<-started
+	defer close(release)
+
+	// The job goes back delayed rather than ready to be popped again at once
+	job := newJob()
+	before := time.Now()
+	err := handler(context.Background(), job)
+	assert.ErrorIs(t, err, dgqueue.ErrJobDeferred)
+	assert.True(t, job.AvailableAt.After(before), "Expected the deferred job to be delayed")
+}
+
+func TestFairnessMiddleware_DeferDoesNotUseAttempts(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	calls := 0
+	manager.Use(func(next dgqueue.WorkerFunc) dgqueue.WorkerFunc {
+		return func(ctx context.Context, job *dgqueue.Job) error {
+			calls++
+			if calls == 1 {
+				return dgqueue.ErrJobDeferred
+			}
+			return next(ctx, job)
+		}
+	})
+
+	done := make(chan int, 1)
+	manager.Worker("deferred-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		done <- job.Attempts
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+	manager.Dispatch(ctx, "deferred-job", nil)
+
+	select {
+	case attempts := <-done:
+		assert.Equal(t, 1, attempts, "Expected the deferral not to count as an attempt")
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected deferred job to run again")
+	}
+}
@@ -0,0 +1,92 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Funnel returns middleware that runs at most limit jobs named name at once,
+// such as report generation that would overload a database. Jobs over the
+// limit return ErrJobDeferred and go back to the queue without using an
+// attempt, delayed by Config.PollInterval. Jobs with other names are not
+// limited.
+//
+// Drivers implementing ConcurrencyLimiter enforce the limit across every
+// instance using the backend, with slots expiring after twice the job's
+// timeout in case their holder crashes; otherwise each manager enforces it
+// on its own.
+func (m *Manager) Funnel(name string, limit int) Middleware {
+	local := &funnelSlots{}
+
+	return func(next WorkerFunc) WorkerFunc {
+		return func(ctx context.Context, job *Job) error {
+			if job.Name != name || limit <= 0 {
+				return next(ctx, job)
+			}
+
+			release, acquired, err := m.acquireSlot(ctx, local, job, limit)
+			if err != nil {
+				m.logError("Failed to acquire funnel slot", err, "job_id", job.ID, "job_name", job.Name)
+			}
+			if !acquired {
+				job.AvailableAt = m.clock.Now().Add(m.config.PollInterval)
+				return fmt.Errorf("%w: %s at concurrency limit", ErrJobDeferred, name)
+			}
+			defer release()
+
+			return next(ctx, job)
+		}
+	}
+}
+
+// acquireSlot takes a slot of the driver's semaphore named after the job, or
+// of local if the driver doesn't keep semaphores. The returned function
+// releases the slot.
+func (m *Manager) acquireSlot(ctx context.Context, local *funnelSlots, job *Job, limit int) (func(), bool, error) {
+	m.mu.RLock()
+	limiter, ok := m.driver.(ConcurrencyLimiter)
+	m.mu.RUnlock()
+
+	if ok {
+		// Jobs can't run past their timeout; leave time to release the slot
+		acquired, err := limiter.AcquireSlot(ctx, job.Name, job.ID, limit, 2*job.Timeout)
+		if !errors.Is(err, ErrNotSupported) {
+			release := func() {
+				if err := limiter.ReleaseSlot(context.WithoutCancel(ctx), job.Name, job.ID); err != nil {
+					m.logError("Failed to release funnel slot", err, "job_id", job.ID, "job_name", job.Name)
+				}
+			}
+			return release, acquired && err == nil, err
+		}
+	}
+
+	if !local.acquire(limit) {
+		return nil, false, nil
+	}
+	return local.release, true, nil
+}
+
+// funnelSlots is a process-local semaphore.
+type funnelSlots struct {
+	mu      sync.Mutex
+	running int
+}
+
+func (s *funnelSlots) acquire(limit int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running >= limit {
+		return false
+	}
+	s.running++
+	return true
+}
+
+func (s *funnelSlots) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+}
@@ -0,0 +1,89 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Funnel(t *testing.T) {
+	clk := newFakeClock()
+	m := New(DefaultConfig())
+	m.SetDriver(emptyDriver{})
+	m.clock = clk
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	handler := m.Funnel("generate-report", 1)(func(ctx context.Context, job *Job) error {
+		if job.Name == "generate-report" {
+			started <- struct{}{}
+			<-finish
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, handler(ctx, NewJob("generate-report", nil)))
+	}()
+	<-started
+
+	// The second report waits for the first, other jobs don't
+	job := NewJob("generate-report", nil)
+	assert.ErrorIs(t, handler(ctx, job), ErrJobDeferred)
+	assert.Equal(t, clk.Now().Add(m.config.PollInterval), job.AvailableAt)
+	assert.NoError(t, handler(ctx, NewJob("send-email", nil)))
+
+	close(finish)
+	wg.Wait()
+
+	go func() { <-started }()
+	assert.NoError(t, handler(ctx, NewJob("generate-report", nil)))
+}
+
+// funnelDriver keeps shared semaphores with every slot taken.
+type funnelDriver struct {
+	emptyDriver
+	err      error
+	released []string
+}
+
+func (d *funnelDriver) AcquireSlot(ctx context.Context, key, holder string, limit int, ttl time.Duration) (bool, error) {
+	return d.err == nil && key == "import", d.err
+}
+
+func (d *funnelDriver) ReleaseSlot(ctx context.Context, key, holder string) error {
+	d.released = append(d.released, holder)
+	return nil
+}
+
+func TestManager_FunnelSharedSemaphore(t *testing.T) {
+	driver := &funnelDriver{}
+	m := New(DefaultConfig())
+	m.SetDriver(driver)
+
+	handler := func(ctx context.Context, job *Job) error { return nil }
+	ctx := context.Background()
+
+	// The driver's slots are taken
+	assert.ErrorIs(t, m.Funnel("generate-report", 5)(handler)(ctx, NewJob("generate-report", nil)), ErrJobDeferred)
+
+	// Acquired slots are released once the job ends
+	job := NewJob("import", nil)
+	assert.NoError(t, m.Funnel("import", 5)(handler)(ctx, job))
+	assert.Equal(t, []string{job.ID}, driver.released)
+
+	driver.err = errors.New("connection refused")
+	assert.ErrorIs(t, m.Funnel("import", 5)(handler)(ctx, NewJob("import", nil)), ErrJobDeferred)
+
+	// Failover drivers without semaphores fall back to a local one
+	driver.err = ErrNotSupported
+	assert.NoError(t, m.Funnel("import", 5)(handler)(ctx, NewJob("import", nil)))
+}
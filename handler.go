@@ -0,0 +1,112 @@
+package dgqueue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Handler is implemented by self-handling job types.
+// The job value itself is the payload: it is serialized on dispatch and
+// decoded back into a fresh value before Handle is called.
+type Handler interface {
+	// Name returns the job name used for routing
+	Name() string
+
+	// Handle processes the job
+	Handle(ctx context.Context) error
+}
+
+// Queueable is another name for Handler, for job structs dispatched with
+// Manager.DispatchJob.
+type Queueable = Handler
+
+// RegisterJob registers a worker for the job type T.
+// Each job's payload is decoded into a new T whose Handle method is invoked.
+// A payload that doesn't decode fails the job with ErrInvalidPayload, without
+// retrying it.
+func RegisterJob[T Handler](m *Manager, concurrency int, opts ...WorkerOption) error {
+	var handler T
+	return m.registerJob(reflect.TypeOf(&handler).Elem(), concurrency, opts)
+}
+
+// RegisterJobs registers a worker for the type of each job, routed by its
+// Name, with Config.Workers concurrency. The jobs are only used for their
+// type: each dispatched job is decoded into a new value of it. A nil job
+// returns ErrInvalidConfig naming its index.
+//
+//	q.RegisterJobs(&SendEmail{}, &ResizeImage{})
+func (m *Manager) RegisterJobs(jobs ...Handler) error {
+	for i, job := range jobs {
+		if job == nil {
+			return fmt.Errorf("%w: job %d is nil", ErrInvalidConfig, i)
+		}
+		if err := m.registerJob(reflect.TypeOf(job), 0, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerJob registers a worker for the job type typ.
+func (m *Manager) registerJob(typ reflect.Type, concurrency int, opts []WorkerOption) error {
+	name := newHandler(typ).Elem().Interface().(Handler).Name()
+
+	return m.WorkerWith(name, concurrency, func(ctx context.Context, job *Job) error {
+		handler := newHandler(typ)
+		if err := decodePayload(job.Payload, handler.Interface()); err != nil {
+			return Unretryable(err)
+		}
+		return handler.Elem().Interface().(Handler).Handle(ctx)
+	}, opts...)
+}
+
+// RegisterWorker registers a worker for the job name whose handler receives
+// the payload decoded into a T, so it works the same whether the driver kept
+// the original value or round-tripped it through JSON. A payload that doesn't
+// decode fails the job with ErrInvalidPayload, without retrying it.
+func RegisterWorker[T any](m *Manager, name string, concurrency int, handler func(ctx context.Context, payload T) error, opts ...WorkerOption) error {
+	return m.WorkerWith(name, concurrency, func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := decodePayload(job.Payload, &payload); err != nil {
+			return Unretryable(err)
+		}
+		return handler(ctx, payload)
+	}, opts...)
+}
+
+// DispatchJob dispatches a self-handling job immediately.
+func DispatchJob[T Handler](ctx context.Context, m *Manager, obj T) (*Job, error) {
+	return m.DispatchJob(ctx, obj)
+}
+
+// DispatchJob dispatches a self-handling job immediately under its Name, with
+// the job itself as the payload.
+func (m *Manager) DispatchJob(ctx context.Context, job Queueable) (*Job, error) {
+	return m.Dispatch(ctx, job.Name(), job)
+}
+
+// newHandler returns a pointer to a usable zero value of the handler type
+// typ, allocating the underlying struct when typ is a pointer type.
+func newHandler(typ reflect.Type) reflect.Value {
+	handler := reflect.New(typ)
+	if typ.Kind() == reflect.Ptr {
+		handler.Elem().Set(reflect.New(typ.Elem()))
+	}
+	return handler
+}
+
+// decodePayload decodes a job payload into target.
+// Payloads may arrive as the original value (memory driver) or as generic
+// JSON maps (serializing drivers), so both are normalized through JSON.
+func decodePayload(payload interface{}, target interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package dgqueue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+var handledEmails = make(chan SendEmailJob, 10)
+
+// SendEmailJob is a self-handling job used in tests.
+type SendEmailJob struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+func (j *SendEmailJob) Name() string {
+	return "send-email-job"
+}
+
+func (j *SendEmailJob) Handle(ctx context.Context) error {
+	handledEmails <- *j
+	return nil
+}
+
+func TestRegisterJob_DispatchJob(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	err := dgqueue.RegisterJob[*SendEmailJob](manager, 1)
+	assert.NoError(t, err)
+
+	err = manager.Start()
+	assert.NoError(t, err)
+	defer manager.Stop(context.Background())
+
+	job, err := dgqueue.DispatchJob(context.Background(), manager, &SendEmailJob{
+		To:      "user@example.com",
+		Subject: "Welcome",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "send-email-job", job.Name)
+
+	select {
+	case handled := <-handledEmails:
+		assert.Equal(t, "user@example.com", handled.To)
+		assert.Equal(t, "Welcome", handled.Subject)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected job to be handled")
+	}
+}
+
+func TestRegisterJob_DecodesGenericPayload(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	err := dgqueue.RegisterJob[*SendEmailJob](manager, 1)
+	assert.NoError(t, err)
+
+	err = manager.Start()
+	assert.NoError(t, err)
+	defer manager.Stop(context.Background())
+
+	// Simulate a payload that went through JSON serialization
+	_, err = manager.Dispatch(context.Background(), "send-email-job", map[string]interface{}{
+		"to":      "other@example.com",
+		"subject": "Hello",
+	})
+	assert.NoError(t, err)
+
+	select {
+	case handled := <-handledEmails:
+		assert.Equal(t, "other@example.com", handled.To)
+		assert.Equal(t, "Hello", handled.Subject)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected job to be handled")
+	}
+}
+
+func TestRegisterWorker(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	handled := make(chan SendEmailJob, 2)
+	err := dgqueue.RegisterWorker(manager, "welcome-email", 1, func(ctx context.Context, email SendEmailJob) error {
+		handled <- email
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = manager.Start()
+	assert.NoError(t, err)
+	defer manager.Stop(context.Background())
+
+	// The original value and a payload that went through JSON decode alike
+	_, err = manager.Dispatch(context.Background(), "welcome-email", SendEmailJob{To: "user@example.com", Subject: "Welcome"})
+	assert.NoError(t, err)
+	_, err = manager.Dispatch(context.Background(), "welcome-email", map[string]interface{}{
+		"to":      "other@example.com",
+		"subject": "Hello",
+	})
+	assert.NoError(t, err)
+
+	for _, want := range []SendEmailJob{
+		{To: "user@example.com", Subject: "Welcome"},
+		{To: "other@example.com", Subject: "Hello"},
+	} {
+		select {
+		case email := <-handled:
+			assert.Equal(t, want, email)
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected job to be handled")
+		}
+	}
+}
+
+func TestRegisterWorker_InvalidPayloadNotRetried(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxAttempts = 3
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	exhausted := make(chan *dgqueue.Job, 1)
+	manager.OnRetryExhausted(func(job *dgqueue.Job) {
+		exhausted <- job
+	})
+	err := dgqueue.RegisterWorker(manager, "welcome-email", 1, func(ctx context.Context, email SendEmailJob) error {
+		t.Error("Expected the handler not to be called")
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = manager.Start()
+	assert.NoError(t, err)
+	defer manager.Stop(context.Background())
+
+	_, err = manager.Dispatch(context.Background(), "welcome-email", "not an email")
+	assert.NoError(t, err)
+
+	select {
+	case job := <-exhausted:
+		assert.Equal(t, 1, job.Attempts)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the job to fail without retrying")
+	}
+}
+
+// ResizeImageJob is a self-handling job with value receivers, used in tests.
+type ResizeImageJob struct {
+	Path  string `json:"path"`
+	Width int    `json:"width"`
+}
+
+var resizedImages = make(chan ResizeImageJob, 10)
+
+func (j ResizeImageJob) Name() string {
+	return "resize-image-job"
+}
+
+func (j ResizeImageJob) Handle(ctx context.Context) error {
+	resizedImages <- j
+	return nil
+}
+
+func TestManager_RegisterJobs(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	err := manager.RegisterJobs(&SendEmailJob{}, ResizeImageJob{})
+	assert.NoError(t, err)
+
+	err = manager.Start()
+	assert.NoError(t, err)
+	defer manager.Stop(context.Background())
+
+	job, err := manager.DispatchJob(context.Background(), &SendEmailJob{To: "user@example.com", Subject: "Welcome"})
+	assert.NoError(t, err)
+	assert.Equal(t, "send-email-job", job.Name)
+	_, err = manager.DispatchJob(context.Background(), ResizeImageJob{Path: "avatar.png", Width: 64})
+	assert.NoError(t, err)
+
+	select {
+	case handled := <-handledEmails:
+		assert.Equal(t, SendEmailJob{To: "user@example.com", Subject: "Welcome"}, handled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected email job to be handled")
+	}
+	select {
+	case resized := <-resizedImages:
+		assert.Equal(t, ResizeImageJob{Path: "avatar.png", Width: 64}, resized)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected resize job to be handled")
+	}
+}
+
+func TestManager_RegisterJobsNil(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+
+	err := manager.RegisterJobs(&SendEmailJob{}, nil)
+	assert.ErrorIs(t, err, dgqueue.ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "job 1")
+}
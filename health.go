@@ -0,0 +1,49 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+)
+
+// Health is a point-in-time report of the manager's driver and workers.
+type Health struct {
+	// Healthy is true when the driver is reachable and, if workers are
+	// registered and enabled, the manager is running
+	Healthy bool
+
+	// Running reports whether the manager has been started and not stopped
+	Running bool
+
+	// DriverErr is the error from pinging the driver; drivers that don't
+	// implement HealthChecker are assumed reachable
+	DriverErr error
+
+	// Workers is a snapshot of every worker pool, keyed by job name
+	Workers map[string]PoolStat
+}
+
+// Health checks the driver and reports the state of the manager's workers,
+// for readiness and liveness probes.
+func (m *Manager) Health(ctx context.Context) Health {
+	m.mu.RLock()
+	driver := m.driver
+	running := m.running
+	workerEnabled := m.config.WorkerEnabled
+	m.mu.RUnlock()
+
+	health := Health{
+		Running: running,
+		Workers: m.PoolStats(),
+	}
+
+	switch checker, ok := driver.(HealthChecker); {
+	case driver == nil:
+		health.DriverErr = errors.New("queue driver not set")
+	case ok:
+		health.DriverErr = checker.Ping(ctx)
+	}
+
+	processing := running || !workerEnabled || len(health.Workers) == 0
+	health.Healthy = health.DriverErr == nil && processing
+	return health
+}
@@ -0,0 +1,57 @@
+package dgqueue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// pingDriver is a memory driver whose Ping returns err.
+type pingDriver struct {
+	dgqueue.Driver
+	err error
+}
+
+func (d *pingDriver) Ping(ctx context.Context) error { return d.err }
+
+func TestManager_Health(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	ctx := context.Background()
+
+	// No driver
+	health := manager.Health(ctx)
+	assert.False(t, health.Healthy)
+	assert.Error(t, health.DriverErr)
+
+	d, _ := memory.NewDriver(cfg)
+	driver := &pingDriver{Driver: d}
+	manager.SetDriver(driver)
+
+	// Dispatch-only managers are healthy without running
+	health = manager.Health(ctx)
+	assert.True(t, health.Healthy)
+	assert.False(t, health.Running)
+
+	// Registered workers need the manager running
+	assert.NoError(t, manager.Worker("send-email", 2, func(ctx context.Context, job *dgqueue.Job) error { return nil }))
+	health = manager.Health(ctx)
+	assert.False(t, health.Healthy)
+	assert.Equal(t, 2, health.Workers["send-email"].Concurrency)
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+	health = manager.Health(ctx)
+	assert.True(t, health.Healthy)
+	assert.True(t, health.Running)
+
+	// An unreachable backend is unhealthy
+	driver.err = errors.New("connection refused")
+	health = manager.Health(ctx)
+	assert.False(t, health.Healthy)
+	assert.Equal(t, driver.err, health.DriverErr)
+}
@@ -30,6 +30,22 @@ func MustResolve(app foundation.Application) Queue {
 	return queue
 }
 
+// ResolveLifecycle resolves the queue from the application container as a Lifecycle,
+// so it can be started and stopped through an interface.
+func ResolveLifecycle(app foundation.Application) (Lifecycle, error) {
+	instance, err := app.Make("queue")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve queue: %w", err)
+	}
+
+	lifecycle, ok := instance.(Lifecycle)
+	if !ok {
+		return nil, fmt.Errorf("resolved instance does not implement Lifecycle")
+	}
+
+	return lifecycle, nil
+}
+
 // Injectable provides a convenient way to inject queue dependencies.
 // Include this struct in your services to easily access the queue.
 type Injectable struct {
@@ -46,3 +62,13 @@ func NewInjectable(app foundation.Application) *Injectable {
 func (i *Injectable) Queue() Queue {
 	return MustResolve(i.app)
 }
+
+// Lifecycle returns the queue manager's Start/Stop controls.
+// Panics if queue cannot be resolved.
+func (i *Injectable) Lifecycle() Lifecycle {
+	lifecycle, err := ResolveLifecycle(i.app)
+	if err != nil {
+		panic(err)
+	}
+	return lifecycle
+}
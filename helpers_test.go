@@ -1,6 +1,7 @@
 package dgqueue
 
 import (
+	"context"
 	"testing"
 
 	"github.com/donnigundala/dg-core/foundation"
@@ -78,3 +79,51 @@ func TestInjectable_Panic(t *testing.T) {
 		inject.Queue()
 	})
 }
+
+func TestResolveLifecycle(t *testing.T) {
+	app := foundation.New(".")
+	manager := New(DefaultConfig())
+	manager.SetDriver(emptyDriver{})
+	manager.config.WorkerEnabled = true
+
+	app.Instance("queue", manager)
+
+	lifecycle, err := ResolveLifecycle(app)
+	assert.NoError(t, err)
+	assert.NoError(t, lifecycle.Start())
+	assert.True(t, manager.running)
+
+	assert.NoError(t, lifecycle.Stop(context.Background()))
+	assert.False(t, manager.running)
+}
+
+func TestResolveLifecycle_Error(t *testing.T) {
+	app := foundation.New(".")
+
+	_, err := ResolveLifecycle(app)
+	assert.Error(t, err)
+
+	app.Instance("queue", "not a queue")
+	_, err = ResolveLifecycle(app)
+	assert.Error(t, err)
+}
+
+func TestInjectable_Lifecycle(t *testing.T) {
+	app := foundation.New(".")
+	manager := New(DefaultConfig())
+	manager.SetDriver(emptyDriver{})
+
+	app.Instance("queue", manager)
+
+	inject := NewInjectable(app)
+	assert.NotPanics(t, func() {
+		assert.NoError(t, inject.Lifecycle().Stop(context.Background()))
+	})
+}
+
+func TestQueueServiceProvider_ShutdownWithoutQueue(t *testing.T) {
+	app := foundation.New(".")
+
+	provider := &QueueServiceProvider{}
+	assert.NoError(t, provider.Shutdown(app))
+}
@@ -0,0 +1,88 @@
+package dgqueue
+
+import "context"
+
+// WithIdempotencyKey makes workers skip the job if another job with the same
+// key was processed successfully within Config.IdempotencyTTL. Unlike dispatch
+// deduplication, which stops duplicates from being queued, this guards the
+// handler against redeliveries, such as a job requeued after its worker
+// crashed. Keys are recorded in the manager's DedupStore, so managers sharing
+// a store share processed keys.
+func WithIdempotencyKey(j *Job, key string) *Job {
+	j.Metadata[MetadataIdempotencyKey] = key
+	return j
+}
+
+// IdempotencyKey returns the job's idempotency key, if it has one.
+func IdempotencyKey(j *Job) (string, bool) {
+	if j.Metadata == nil {
+		return "", false
+	}
+	key, ok := j.Metadata[MetadataIdempotencyKey].(string)
+	return key, ok && key != ""
+}
+
+// processedKey namespaces idempotency keys apart from dispatch dedup keys.
+func processedKey(key string) string {
+	return "processed:" + key
+}
+
+// alreadyProcessed reports whether a job with the job's idempotency key has
+// been processed. Store errors are logged and the job runs.
+func (m *Manager) alreadyProcessed(job *Job) bool {
+	key, ok := IdempotencyKey(job)
+	if !ok {
+		return false
+	}
+
+	// The key is recorded once the handler succeeds, so a worker that crashes
+	// mid-job doesn't leave its redelivery marked as processed
+	ctx := context.Background()
+	if checker, ok := m.dedup.(DedupChecker); ok {
+		processed, err := checker.Exists(ctx, processedKey(key))
+		if err != nil {
+			m.logError("Failed to check idempotency key", err, "job_id", job.ID, "job_name", job.Name)
+			return false
+		}
+		return processed
+	}
+
+	// Other stores can only test a key by claiming it, so give the claim back.
+	// Another instance checking the key meanwhile sees it as processed.
+	claimed, err := m.dedup.Claim(ctx, processedKey(key), m.config.IdempotencyTTL)
+	if err != nil {
+		m.logError("Failed to check idempotency key", err, "job_id", job.ID, "job_name", job.Name)
+		return false
+	}
+	if !claimed {
+		return true
+	}
+	if err := m.dedup.Release(ctx, processedKey(key)); err != nil {
+		m.logError("Failed to check idempotency key", err, "job_id", job.ID, "job_name", job.Name)
+	}
+	return false
+}
+
+// recordProcessed records the idempotency key of a job that succeeded.
+func (m *Manager) recordProcessed(job *Job) {
+	key, ok := IdempotencyKey(job)
+	if !ok {
+		return
+	}
+	if _, err := m.dedup.Claim(context.Background(), processedKey(key), m.config.IdempotencyTTL); err != nil {
+		m.logError("Failed to record idempotency key", err, "job_id", job.ID, "job_name", job.Name)
+	}
+}
+
+// skipDuplicate completes a job whose idempotency key was already processed
+// without running its handler.
+func (m *Manager) skipDuplicate(job *Job) {
+	key, _ := IdempotencyKey(job)
+	m.logInfo("Job already processed, skipping", "job_id", job.ID, "job_name", job.Name, "idempotency_key", key)
+	m.stats.duplicates.Add(1)
+	MarkCompleted(job)
+	m.batchJobDone(job, true)
+	if err := m.driver.Delete(context.Background(), job.ID); err != nil {
+		m.logError("Failed to delete duplicate job", err, "job_id", job.ID, "job_name", job.Name)
+	}
+}
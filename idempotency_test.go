@@ -0,0 +1,96 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_IdempotencyKey(t *testing.T) {
+	driver := &retryingDriver{}
+	m := New(DefaultConfig())
+	m.SetDriver(driver)
+
+	runs := 0
+	fail := true
+	pool := &workerPool{
+		name:        "charge",
+		concurrency: 1,
+		handler: func(ctx context.Context, job *Job) error {
+			runs++
+			if fail {
+				return errors.New("gateway unavailable")
+			}
+			return nil
+		},
+	}
+	newJob := func() *Job {
+		return WithIdempotencyKey(m.NewJob("charge", nil), "order-42")
+	}
+
+	// A failed attempt doesn't record the key
+	m.processJob(pool, newJob())
+	assert.Equal(t, 1, runs)
+	assert.Len(t, driver.retried, 1)
+
+	fail = false
+	m.processJob(pool, newJob())
+	assert.Equal(t, 2, runs)
+
+	// Redelivery of the processed key skips the handler
+	duplicate := newJob()
+	m.processJob(pool, duplicate)
+	assert.Equal(t, 2, runs)
+	assert.Equal(t, 0, duplicate.Attempts)
+	assert.NotNil(t, duplicate.CompletedAt)
+	assert.Equal(t, int64(1), m.MetricsSnapshot().Duplicates)
+
+	// Jobs without a key always run
+	m.processJob(pool, m.NewJob("charge", nil))
+	m.processJob(pool, m.NewJob("charge", nil))
+	assert.Equal(t, 4, runs)
+}
+
+// claimCountingStore is a memory DedupStore counting claims.
+type claimCountingStore struct {
+	*memoryDedupStore
+	claims int
+}
+
+func (s *claimCountingStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.claims++
+	return s.memoryDedupStore.Claim(ctx, key, ttl)
+}
+
+func TestManager_IdempotencyCheckDoesNotClaim(t *testing.T) {
+	m := New(DefaultConfig())
+	m.SetDriver(&retryingDriver{})
+	store := &claimCountingStore{memoryDedupStore: NewMemoryDedupStore().(*memoryDedupStore)}
+	m.dedup = store
+
+	pool := &workerPool{
+		name:        "charge",
+		concurrency: 1,
+		handler:     func(ctx context.Context, job *Job) error { return nil },
+	}
+
+	// Only recording the processed key claims it
+	m.processJob(pool, WithIdempotencyKey(m.NewJob("charge", nil), "order-42"))
+	m.processJob(pool, WithIdempotencyKey(m.NewJob("charge", nil), "order-42"))
+	assert.Equal(t, 1, store.claims)
+	assert.Equal(t, int64(1), m.MetricsSnapshot().Duplicates)
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	job := NewJob("charge", nil)
+	_, ok := IdempotencyKey(job)
+	assert.False(t, ok)
+
+	WithIdempotencyKey(job, "order-42")
+	key, ok := IdempotencyKey(job)
+	assert.True(t, ok)
+	assert.Equal(t, "order-42", key)
+}
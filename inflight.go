@@ -0,0 +1,105 @@
+package dgqueue
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// InFlightJob describes a job a worker of this manager is processing.
+type InFlightJob struct {
+	// ID, Name and Queue identify the job
+	ID    string
+	Name  string
+	Queue string
+
+	// Pool is the worker pool running the job: its name, prefixed with
+	// "queue/" for workers registered with WorkerOn
+	Pool string
+
+	// Worker is the index of the worker within its pool
+	Worker int
+
+	// StartedAt is when the handler started
+	StartedAt time.Time
+
+	// HeartbeatAt is when the driver last refreshed the job's reservation,
+	// zero if it hasn't since the job started or the driver doesn't hold jobs
+	HeartbeatAt time.Time
+}
+
+// InFlight returns the jobs this manager's workers are processing, oldest
+// first. A job held longer than Config.VisibilityTimeout without a heartbeat
+// is requeued by drivers implementing StalledJobRecoverer.
+func (m *Manager) InFlight() []InFlightJob {
+	var heartbeat time.Time
+	if nanos := m.lastHeartbeat.Load(); nanos > 0 {
+		heartbeat = time.Unix(0, nanos)
+	}
+
+	m.mu.RLock()
+	var jobs []InFlightJob
+	for key, pool := range m.workers {
+		pool.inFlight.Range(func(_, value any) bool {
+			job := value.(InFlightJob)
+			job.Pool = key
+			if heartbeat.After(job.StartedAt) {
+				job.HeartbeatAt = heartbeat
+			}
+			jobs = append(jobs, job)
+			return true
+		})
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(jobs[j].StartedAt)
+	})
+	return jobs
+}
+
+// visibilityTimeout returns how long held jobs stay reserved without a
+// heartbeat.
+func (m *Manager) visibilityTimeout() time.Duration {
+	if m.config.VisibilityTimeout > 0 {
+		return m.config.VisibilityTimeout
+	}
+	return 3 * m.config.StalledCheckInterval
+}
+
+// reapAfter returns how long a held job goes without a heartbeat before it is
+// orphaned.
+func (m *Manager) reapAfter() time.Duration {
+	return max(time.Duration(float64(m.config.Timeout)*m.config.ReapFactor), m.visibilityTimeout())
+}
+
+// reapOrphaned takes over jobs held by stopped instances and fails their lost
+// attempt, retrying them or moving them to the dead letter queue.
+func (m *Manager) reapOrphaned(ctx context.Context, reaper OrphanReaper) {
+	jobs, err := reaper.ReapOrphaned(ctx, m.reapAfter())
+	if err != nil {
+		m.logError("Failed to reap orphaned jobs", err)
+	}
+
+	for _, job := range jobs {
+		// The stored job predates the attempt that was running
+		job.Attempts++
+		job.StartedAt = nil
+		m.stats.processed.Add(1)
+		m.stats.failed.Add(1)
+		MarkFailed(job, ErrJobOrphaned)
+
+		if m.shouldRetry(job, ErrJobOrphaned, m.retryBackoff(job)) {
+			m.logWarn("Job orphaned by a stopped worker, retrying", "job_id", job.ID, "job_name", job.Name, "attempt", job.Attempts)
+			if err := m.driver.Retry(ctx, job); err != nil {
+				m.logError("Failed to retry orphaned job", err, "job_id", job.ID, "job_name", job.Name)
+			}
+			m.stats.retried.Add(1)
+			continue
+		}
+
+		m.logError("Job orphaned permanently", ErrJobOrphaned, "job_id", job.ID, "job_name", job.Name, "attempts", job.Attempts)
+		m.retriesExhausted(ctx, job)
+		m.moveToDeadLetter(ctx, job)
+	}
+}
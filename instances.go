@@ -0,0 +1,117 @@
+package dgqueue
+
+import (
+	"context"
+	"os"
+	"sort"
+	"time"
+)
+
+// InstanceInfo describes a running manager instance, as recorded in drivers
+// implementing InstanceRegistry.
+type InstanceInfo struct {
+	// ID identifies the manager; it is generated by New
+	ID string `json:"id"`
+
+	// Hostname and PID identify the process running the manager
+	Hostname string `json:"hostname"`
+	PID      int    `json:"pid"`
+
+	// Queues are the queues the manager serves, and Paused those of them
+	// paused with Pause
+	Queues []string `json:"queues"`
+	Paused []string `json:"paused,omitempty"`
+
+	// Workers maps each worker pool to its concurrency. Pools registered with
+	// WorkerOn are keyed "queue/name"
+	Workers map[string]int `json:"workers"`
+
+	// StartedAt is when the manager started, and HeartbeatAt when it last
+	// registered itself
+	StartedAt   time.Time `json:"started_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+}
+
+// InstanceID returns the ID this manager registers itself under.
+func (m *Manager) InstanceID() string {
+	return m.instanceID
+}
+
+// Instances returns the manager instances registered with the driver, sorted
+// by hostname and PID. Running managers register themselves every
+// Config.RegisterInterval. It returns ErrNotSupported if the driver doesn't
+// keep an instance registry.
+func (m *Manager) Instances(ctx context.Context) ([]InstanceInfo, error) {
+	m.mu.RLock()
+	registry, ok := m.driver.(InstanceRegistry)
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	instances, err := registry.Instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		if instances[i].Hostname != instances[j].Hostname {
+			return instances[i].Hostname < instances[j].Hostname
+		}
+		if instances[i].PID != instances[j].PID {
+			return instances[i].PID < instances[j].PID
+		}
+		return instances[i].ID < instances[j].ID
+	})
+	return instances, nil
+}
+
+// instanceInfo describes this manager for the instance registry.
+func (m *Manager) instanceInfo() InstanceInfo {
+	hostname, _ := os.Hostname()
+
+	m.mu.RLock()
+	workers := make(map[string]int, len(m.workers))
+	for key, pool := range m.workers {
+		workers[key] = pool.concurrency
+	}
+	startedAt := m.startedAt
+	m.mu.RUnlock()
+
+	return InstanceInfo{
+		ID:          m.instanceID,
+		Hostname:    hostname,
+		PID:         os.Getpid(),
+		Queues:      m.servedQueues(),
+		Paused:      m.PausedQueues(),
+		Workers:     workers,
+		StartedAt:   startedAt,
+		HeartbeatAt: m.clock.Now(),
+	}
+}
+
+// registerInstance records this manager in the driver's instance registry.
+func (m *Manager) registerInstance(ctx context.Context) {
+	registry, ok := m.driver.(InstanceRegistry)
+	if !ok {
+		return
+	}
+
+	// Stay registered across a missed heartbeat or two
+	ttl := 3 * m.config.RegisterInterval
+	if err := registry.RegisterInstance(ctx, m.instanceInfo(), ttl); err != nil {
+		m.logError("Failed to register instance", err)
+	}
+}
+
+// unregisterInstance removes this manager from the driver's instance registry
+// as it stops, even if ctx has already ended.
+func (m *Manager) unregisterInstance(ctx context.Context) {
+	registry, ok := m.driver.(InstanceRegistry)
+	if !ok || m.config.RegisterInterval <= 0 {
+		return
+	}
+
+	if err := registry.UnregisterInstance(context.WithoutCancel(ctx), m.instanceID); err != nil {
+		m.logError("Failed to unregister instance", err)
+	}
+}
@@ -0,0 +1,82 @@
+package dgqueue_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// sharedDriver is a driver shared by several managers, which outlives Stop.
+type sharedDriver struct {
+	*memory.Driver
+}
+
+func (sharedDriver) Close() error { return nil }
+
+func TestManager_Instances(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.RegisterInterval = time.Hour
+	d, _ := memory.NewDriver(cfg)
+	shared := sharedDriver{d.(*memory.Driver)}
+
+	web := dgqueue.New(cfg)
+	web.SetDriver(shared)
+	web.Worker("email", 2, func(ctx context.Context, job *dgqueue.Job) error { return nil })
+
+	reportsCfg := cfg
+	reportsCfg.ServeQueues = []string{"reports"}
+	reports := dgqueue.New(reportsCfg)
+	reports.SetDriver(shared)
+	reports.WorkerOn("reports", "render", 4, func(ctx context.Context, job *dgqueue.Job) error { return nil })
+	reports.Pause("reports")
+
+	ctx := context.Background()
+	instances, err := web.Instances(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, instances, "managers register once started")
+
+	assert.NoError(t, web.Start())
+	defer web.Stop(ctx)
+	assert.NoError(t, reports.Start())
+
+	assert.Eventually(t, func() bool {
+		instances, _ := web.Instances(ctx)
+		return len(instances) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	instances, _ = web.Instances(ctx)
+	byID := map[string]dgqueue.InstanceInfo{}
+	for _, instance := range instances {
+		assert.Equal(t, os.Getpid(), instance.PID)
+		assert.NotEmpty(t, instance.Hostname)
+		assert.False(t, instance.StartedAt.IsZero())
+		assert.False(t, instance.HeartbeatAt.Before(instance.StartedAt))
+		byID[instance.ID] = instance
+	}
+	assert.Equal(t, []string{"default"}, byID[web.InstanceID()].Queues)
+	assert.Equal(t, map[string]int{"email": 2}, byID[web.InstanceID()].Workers)
+	assert.Equal(t, []string{"reports"}, byID[reports.InstanceID()].Queues)
+	assert.Equal(t, []string{"reports"}, byID[reports.InstanceID()].Paused)
+	assert.Equal(t, map[string]int{"reports/render": 4}, byID[reports.InstanceID()].Workers)
+
+	// Stopped managers unregister right away
+	assert.NoError(t, reports.Stop(ctx))
+	instances, _ = web.Instances(ctx)
+	assert.Len(t, instances, 1)
+	assert.Equal(t, web.InstanceID(), instances[0].ID)
+}
+
+func TestManager_InstancesNotSupported(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	// Only the Driver methods, without the memory driver's registry
+	manager.SetDriver(struct{ dgqueue.Driver }{d})
+
+	_, err := manager.Instances(context.Background())
+	assert.ErrorIs(t, err, dgqueue.ErrNotSupported)
+}
@@ -1,13 +1,77 @@
 package dgqueue
 
 import (
-	"encoding/json"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/donnigundala/dg-core/contracts/queue"
 	"github.com/google/uuid"
 )
 
+// JobFormat identifies the serialization format of a stored job.
+// Serialized jobs start with their format byte so readers can decode any
+// format regardless of the one they write, allowing gradual migrations.
+type JobFormat byte
+
+const (
+	// FormatJSON is stored without a prefix: its leading '{' identifies it,
+	// so jobs written before format bytes existed remain readable.
+	FormatJSON JobFormat = '{'
+	// FormatGob is encoding/gob. Custom payload types must be gob.Register'ed.
+	FormatGob JobFormat = 0x01
+	// FormatMsgpack is MessagePack.
+	FormatMsgpack JobFormat = 0x02
+	// FormatProtobuf is Protocol Buffers; see protobufCodec for what it keeps typed.
+	FormatProtobuf JobFormat = 0x03
+)
+
+const (
+	// MetadataExpiresAt is the job metadata key holding the job's expiry time.
+	MetadataExpiresAt = "expires_at"
+	// MetadataStartDeadline is the job metadata key holding the latest time the job may start.
+	MetadataStartDeadline = "start_deadline"
+	// MetadataDeadLetterReason is the job metadata key explaining why the
+	// manager dead-lettered a job without running its handler.
+	MetadataDeadLetterReason = "dead_letter_reason"
+	// MetadataRawPayload is the job metadata key marking a payload of opaque bytes.
+	MetadataRawPayload = "raw_payload"
+	// MetadataBatchID is the job metadata key holding the ID of the batch that dispatched the job.
+	MetadataBatchID = "batch_id"
+	// MetadataPanicStack is the job metadata key holding the stack of the handler
+	// panic its last attempt failed with.
+	MetadataPanicStack = "panic_stack"
+	// MetadataCompression is the job metadata key naming the algorithm a stored
+	// job's payload was compressed with.
+	MetadataCompression = "compression"
+	// MetadataIdempotencyKey is the job metadata key holding the job's idempotency key.
+	MetadataIdempotencyKey = "idempotency_key"
+)
+
+// ReasonStartDeadlineExceeded marks jobs dropped because their start deadline passed.
+const ReasonStartDeadlineExceeded = "start_deadline_exceeded"
+
+func init() {
+	// Generic payload shapes carried in interface{} fields
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+// ParseJobFormat parses a format name (json, gob, msgpack, protobuf, or the
+// name of a codec registered with RegisterCodec). An empty name selects JSON.
+func ParseJobFormat(name string) (JobFormat, error) {
+	if name == "" {
+		return FormatJSON, nil
+	}
+	codec, ok := lookupCodecName(name)
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown serializer %q", ErrInvalidConfig, name)
+	}
+	return codec.Format(), nil
+}
+
 // NewJob creates a new job.
 func NewJob(name string, payload interface{}) *Job {
 	now := time.Now()
@@ -54,15 +118,85 @@ func WithDelay(j *Job, delay time.Duration) *Job {
 
 // WithMetadata adds metadata to the job.
 func WithMetadata(j *Job, key string, value interface{}) *Job {
+	if j.Metadata == nil {
+		j.Metadata = make(map[string]interface{})
+	}
 	j.Metadata[key] = value
 	return j
 }
 
+// WithTTL makes the job expire ttl after it was created.
+// Drivers may discard expired jobs before they are processed.
+func WithTTL(j *Job, ttl time.Duration) *Job {
+	j.Metadata[MetadataExpiresAt] = j.CreatedAt.Add(ttl).Format(time.RFC3339Nano)
+	return j
+}
+
+// ExpiresAt returns the job's expiry time, if it has one.
+func ExpiresAt(j *Job) (time.Time, bool) {
+	return metadataTime(j, MetadataExpiresAt)
+}
+
+// WithStartDeadline drops the job if it hasn't started by t.
+// The dispatcher dead-letters such jobs when it pops them, with
+// MetadataDeadLetterReason set to ReasonStartDeadlineExceeded.
+func WithStartDeadline(j *Job, t time.Time) *Job {
+	j.Metadata[MetadataStartDeadline] = t.Format(time.RFC3339Nano)
+	return j
+}
+
+// StartDeadline returns the job's start deadline, if it has one.
+func StartDeadline(j *Job) (time.Time, bool) {
+	return metadataTime(j, MetadataStartDeadline)
+}
+
+// metadataTime reads a time stored in job metadata, either as a time.Time
+// or as an RFC 3339 string (the form it takes after serialization).
+func metadataTime(j *Job, key string) (time.Time, bool) {
+	if j.Metadata == nil {
+		return time.Time{}, false
+	}
+
+	switch v := j.Metadata[key].(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+// IsExpired returns true if the job has an expiry time that has passed.
+func IsExpired(j *Job) bool {
+	expiresAt, ok := ExpiresAt(j)
+	return ok && !time.Now().Before(expiresAt)
+}
+
 // IsAvailable returns true if the job is available for processing.
 func IsAvailable(j *Job) bool {
 	return time.Now().After(j.AvailableAt) || time.Now().Equal(j.AvailableAt)
 }
 
+// WaitTime returns how long an available job has been waiting to run at now.
+// Jobs that aren't available yet have a wait time of 0.
+func WaitTime(j *Job, now time.Time) time.Duration {
+	if wait := now.Sub(j.AvailableAt); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// FailedTime returns when the job last failed, or when it was last updated
+// if it was never marked failed.
+func FailedTime(j *Job) time.Time {
+	if j.FailedAt != nil {
+		return *j.FailedAt
+	}
+	return j.UpdatedAt
+}
+
 // CanRetry returns true if the job can be retried.
 func CanRetry(j *Job) bool {
 	return j.Attempts < j.MaxAttempts
@@ -83,7 +217,8 @@ func MarkCompleted(j *Job) {
 	j.UpdatedAt = now
 }
 
-// MarkFailed marks the job as failed.
+// MarkFailed marks the job as failed. If err is a *PanicError its stack is
+// recorded in MetadataPanicStack.
 func MarkFailed(j *Job, err error) {
 	now := time.Now()
 	j.FailedAt = &now
@@ -91,20 +226,87 @@ func MarkFailed(j *Job, err error) {
 	if err != nil {
 		j.Error = err.Error()
 	}
+
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		if j.Metadata == nil {
+			j.Metadata = make(map[string]interface{})
+		}
+		j.Metadata[MetadataPanicStack] = string(panicErr.Stack)
+	} else if _, ok := j.Metadata[MetadataPanicStack]; ok {
+		delete(j.Metadata, MetadataPanicStack)
+	}
 }
 
 // MarshalJob marshals the job to JSON.
 func MarshalJob(j *Job) ([]byte, error) {
-	return json.Marshal(j)
+	return MarshalJobAs(j, FormatJSON)
 }
 
-// UnmarshalJob unmarshals a job from JSON.
+// MarshalJobAs marshals the job in the given format, prefixed by its format byte.
+func MarshalJobAs(j *Job, format JobFormat) ([]byte, error) {
+	codec, ok := LookupCodec(format)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown job format %d", ErrInvalidConfig, format)
+	}
+	data, err := codec.Marshal(j)
+	if err != nil || format == FormatJSON {
+		return data, err
+	}
+	return append([]byte{byte(format)}, data...), nil
+}
+
+// UnmarshalJob unmarshals a job, detecting its format from the leading byte
+// and decompressing its payload if it was compressed.
 func UnmarshalJob(data []byte) (*Job, error) {
+	job, _, err := unmarshalJob(data)
+	return job, err
+}
+
+// unmarshalJob unmarshals a job like UnmarshalJob, also reporting whether its
+// payload was compressed.
+func unmarshalJob(data []byte) (*Job, bool, error) {
+	data, err := unsigned(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) == 0 {
+		return nil, false, ErrInvalidPayload
+	}
+
 	var job queue.Job
-	if err := json.Unmarshal(data, &job); err != nil {
-		return nil, err
+	codec := codecFor(data)
+	if codec.Format() != FormatJSON {
+		data = data[1:]
+	}
+	if err := codec.Unmarshal(data, &job); err != nil {
+		return nil, false, err
+	}
+	compressed, err := decompressPayload(&job)
+	if err != nil {
+		return nil, compressed, err
+	}
+	if err := restoreRawPayload(&job); err != nil {
+		return nil, compressed, err
+	}
+	return &job, compressed, nil
+}
+
+// restoreRawPayload turns the base64 string JSON stores for a raw payload back into bytes.
+func restoreRawPayload(j *Job) error {
+	if raw, _ := j.Metadata[MetadataRawPayload].(bool); !raw {
+		return nil
+	}
+	encoded, ok := j.Payload.(string)
+	if !ok {
+		return nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: raw payload: %v", ErrInvalidPayload, err)
 	}
-	return &job, nil
+	j.Payload = raw
+	return nil
 }
 
 // GetJobStatus returns the current status of the job.
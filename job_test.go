@@ -1,10 +1,14 @@
 package dgqueue
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestJob_NewJob(t *testing.T) {
@@ -235,3 +239,195 @@ func TestJob_Serialization(t *testing.T) {
 		t.Errorf("MaxAttempts mismatch: expected %d, got %d", job.MaxAttempts, unmarshaled.MaxAttempts)
 	}
 }
+
+func TestJob_SerializationFormats(t *testing.T) {
+	for _, format := range []JobFormat{FormatJSON, FormatGob, FormatMsgpack, FormatProtobuf} {
+		job := NewJob("test-job", map[string]interface{}{"email": "test@example.com"})
+		WithMetadata(job, "tenant", "acme")
+
+		data, err := MarshalJobAs(job, format)
+		if err != nil {
+			t.Fatalf("Failed to marshal job as %d: %v", format, err)
+		}
+		if JobFormat(data[0]) != format {
+			t.Errorf("Expected format byte %d, got %d", format, data[0])
+		}
+
+		// Decoding detects the format regardless of what the reader writes
+		decoded, err := UnmarshalJob(data)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal job written as %d: %v", format, err)
+		}
+		if decoded.ID != job.ID || decoded.Name != job.Name {
+			t.Errorf("Job mismatch after %d round trip", format)
+		}
+		payload, ok := decoded.Payload.(map[string]interface{})
+		if !ok || payload["email"] != "test@example.com" {
+			t.Errorf("Payload mismatch after %d round trip: %v", format, decoded.Payload)
+		}
+		if decoded.Metadata["tenant"] != "acme" {
+			t.Errorf("Metadata mismatch after %d round trip: %v", format, decoded.Metadata)
+		}
+		if !decoded.CreatedAt.Equal(job.CreatedAt) {
+			t.Errorf("CreatedAt mismatch after %d round trip", format)
+		}
+	}
+}
+
+func TestJob_ProtobufMessagePayload(t *testing.T) {
+	job := NewJob("test-job", wrapperspb.Int64(1<<60+1))
+	WithMaxAttempts(job, 5)
+	now := time.Now()
+	job.StartedAt = &now
+
+	data, err := MarshalJobAs(job, FormatProtobuf)
+	if err != nil {
+		t.Fatalf("Failed to marshal job: %v", err)
+	}
+	decoded, err := UnmarshalJob(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal job: %v", err)
+	}
+
+	// The message comes back as its own type, without float rounding
+	payload, ok := decoded.Payload.(*wrapperspb.Int64Value)
+	if !ok || payload.GetValue() != 1<<60+1 {
+		t.Errorf("Payload mismatch after round trip: %v", decoded.Payload)
+	}
+	if decoded.MaxAttempts != 5 || decoded.Timeout != job.Timeout {
+		t.Errorf("Job mismatch after round trip: %+v", decoded)
+	}
+	if decoded.StartedAt == nil || !decoded.StartedAt.Equal(now) || decoded.CompletedAt != nil {
+		t.Errorf("Timestamps mismatch after round trip: %v, %v", decoded.StartedAt, decoded.CompletedAt)
+	}
+}
+
+// upperCodec is a custom codec storing jobs as JSON with an upper-cased name.
+type upperCodec struct{}
+
+func (upperCodec) Name() string      { return "upper" }
+func (upperCodec) Format() JobFormat { return 0x10 }
+
+func (upperCodec) Marshal(j *Job) ([]byte, error) {
+	clone := *j
+	clone.Name = strings.ToUpper(j.Name)
+	return json.Marshal(&clone)
+}
+
+func (upperCodec) Unmarshal(data []byte, j *Job) error {
+	return json.Unmarshal(data, j)
+}
+
+// reservedCodec is a custom codec claiming a reserved format byte.
+type reservedCodec struct{ upperCodec }
+
+func (reservedCodec) Format() JobFormat { return FormatMsgpack }
+
+func TestJob_RegisterCodec(t *testing.T) {
+	if err := RegisterCodec(upperCodec{}); err != nil {
+		t.Fatalf("Failed to register codec: %v", err)
+	}
+	if err := RegisterCodec(reservedCodec{}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for a reserved format, got %v", err)
+	}
+	if codec, _ := LookupCodec(FormatMsgpack); codec.Name() != "msgpack" {
+		t.Errorf("Expected the built-in codec to stay registered, got %s", codec.Name())
+	}
+
+	format, err := ParseJobFormat("upper")
+	if err != nil || format != 0x10 {
+		t.Fatalf("ParseJobFormat(upper) = %d, %v", format, err)
+	}
+
+	data, err := MarshalJobAs(NewJob("test-job", nil), format)
+	if err != nil {
+		t.Fatalf("Failed to marshal job: %v", err)
+	}
+	decoded, err := UnmarshalJob(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal job: %v", err)
+	}
+	if decoded.Name != "TEST-JOB" {
+		t.Errorf("Expected the custom codec to decode the job, got name %q", decoded.Name)
+	}
+
+	if _, err := MarshalJobAs(NewJob("test-job", nil), 0x11); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for an unregistered format, got %v", err)
+	}
+}
+
+func TestJob_UnmarshalLegacyJSON(t *testing.T) {
+	job := NewJob("legacy-job", "payload")
+	data, _ := json.Marshal(job)
+
+	decoded, err := UnmarshalJob(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal unprefixed JSON: %v", err)
+	}
+	if decoded.ID != job.ID {
+		t.Errorf("Expected ID %s, got %s", job.ID, decoded.ID)
+	}
+}
+
+func TestJob_ParseJobFormat(t *testing.T) {
+	cases := map[string]JobFormat{"": FormatJSON, "json": FormatJSON, "gob": FormatGob, "msgpack": FormatMsgpack, "protobuf": FormatProtobuf}
+	for name, expected := range cases {
+		format, err := ParseJobFormat(name)
+		if err != nil || format != expected {
+			t.Errorf("ParseJobFormat(%q) = %d, %v", name, format, err)
+		}
+	}
+
+	if _, err := ParseJobFormat("xml"); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for unknown format, got %v", err)
+	}
+}
+
+func TestJob_WithTTL(t *testing.T) {
+	job := NewJob("test", "payload")
+	if _, ok := ExpiresAt(job); ok {
+		t.Error("Expected job without TTL to have no expiry")
+	}
+	if IsExpired(job) {
+		t.Error("Expected job without TTL not to expire")
+	}
+
+	WithTTL(job, time.Hour)
+	expiresAt, ok := ExpiresAt(job)
+	if !ok || !expiresAt.Equal(job.CreatedAt.Add(time.Hour)) {
+		t.Errorf("Expected expiry at CreatedAt+1h, got %v", expiresAt)
+	}
+	if IsExpired(job) {
+		t.Error("Expected job not to be expired yet")
+	}
+
+	WithTTL(job, -time.Second)
+	if !IsExpired(job) {
+		t.Error("Expected job to be expired")
+	}
+}
+
+func TestJob_WithStartDeadline(t *testing.T) {
+	job := NewJob("test", "payload")
+	if _, ok := StartDeadline(job); ok {
+		t.Error("Expected job without start deadline to have none")
+	}
+
+	deadline := time.Now().Add(time.Hour)
+	WithStartDeadline(job, deadline)
+
+	// The deadline survives serialization
+	data, err := MarshalJob(job)
+	if err != nil {
+		t.Fatalf("Failed to marshal job: %v", err)
+	}
+	decoded, err := UnmarshalJob(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal job: %v", err)
+	}
+
+	got, ok := StartDeadline(decoded)
+	if !ok || !got.Equal(deadline) {
+		t.Errorf("Expected start deadline %v, got %v", deadline, got)
+	}
+}
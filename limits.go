@@ -0,0 +1,142 @@
+package dgqueue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DecodeLimits bounds the structure of jobs accepted by UnmarshalJobWithLimits,
+// so payloads from untrusted producers can't exhaust memory or stack while decoding.
+// A zero value disables a limit.
+type DecodeLimits struct {
+	// MaxDepth is the maximum nesting of objects and arrays, counting the job itself as 1
+	MaxDepth int
+
+	// MaxKeys is the maximum number of keys in any single object. The job's own
+	// fields are counted too, so values below about 20 reject every job.
+	MaxKeys int
+}
+
+// enabled reports whether any limit is set.
+func (l DecodeLimits) enabled() bool {
+	return l.MaxDepth > 0 || l.MaxKeys > 0
+}
+
+// UnmarshalJobWithLimits unmarshals a job like UnmarshalJob, rejecting it with
+// ErrInvalidPayload if it exceeds the limits. JSON is checked with a streaming
+// scan before it is decoded; payloads in other formats, and compressed ones,
+// are checked after decoding.
+func UnmarshalJobWithLimits(data []byte, limits DecodeLimits) (*Job, error) {
+	data, err := unsigned(data)
+	if err != nil {
+		return nil, err
+	}
+
+	scanned := false
+	if limits.enabled() && len(data) > 0 && codecFor(data).Format() == FormatJSON {
+		if err := scanJSONLimits(data, limits); err != nil {
+			return nil, err
+		}
+		scanned = true
+	}
+
+	job, compressed, err := unmarshalJob(data)
+	if err != nil || !limits.enabled() || (scanned && !compressed) {
+		return job, err
+	}
+	if err := checkValueLimits(job.Payload, 2, limits); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// jsonFrame tracks an open JSON object or array during a scan.
+type jsonFrame struct {
+	object    bool
+	keys      int
+	expectKey bool
+}
+
+// scanJSONLimits walks the JSON tokens without building values.
+func scanJSONLimits(data []byte, limits DecodeLimits) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []*jsonFrame
+
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			if top != nil && top.object {
+				top.expectKey = true
+			}
+			stack = append(stack, &jsonFrame{object: token == json.Delim('{'), expectKey: true})
+			if limits.MaxDepth > 0 && len(stack) > limits.MaxDepth {
+				return fmt.Errorf("%w: nesting exceeds max depth %d", ErrInvalidPayload, limits.MaxDepth)
+			}
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+		default:
+			if top == nil || !top.object {
+				continue
+			}
+			if !top.expectKey {
+				top.expectKey = true
+				continue
+			}
+			top.expectKey = false
+			top.keys++
+			if limits.MaxKeys > 0 && top.keys > limits.MaxKeys {
+				return fmt.Errorf("%w: object exceeds max keys %d", ErrInvalidPayload, limits.MaxKeys)
+			}
+		}
+	}
+}
+
+// checkValueLimits walks a decoded payload found at the given depth.
+func checkValueLimits(value interface{}, depth int, limits DecodeLimits) error {
+	var children []interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		children = make([]interface{}, 0, len(v))
+		for _, child := range v {
+			children = append(children, child)
+		}
+	case map[interface{}]interface{}:
+		children = make([]interface{}, 0, len(v))
+		for _, child := range v {
+			children = append(children, child)
+		}
+	case []interface{}:
+		children = v
+	default:
+		return nil
+	}
+
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return fmt.Errorf("%w: nesting exceeds max depth %d", ErrInvalidPayload, limits.MaxDepth)
+	}
+	if _, isSlice := value.([]interface{}); !isSlice && limits.MaxKeys > 0 && len(children) > limits.MaxKeys {
+		return fmt.Errorf("%w: object exceeds max keys %d", ErrInvalidPayload, limits.MaxKeys)
+	}
+	for _, child := range children {
+		if err := checkValueLimits(child, depth+1, limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package dgqueue
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// nestedPayload returns a JSON-decoded payload nested depth levels deep.
+func nestedPayload(depth int) interface{} {
+	var payload interface{} = "leaf"
+	for i := 0; i < depth; i++ {
+		payload = map[string]interface{}{"next": payload}
+	}
+	return payload
+}
+
+func TestUnmarshalJobWithLimits_Depth(t *testing.T) {
+	limits := DecodeLimits{MaxDepth: 10}
+
+	for _, format := range []JobFormat{FormatJSON, FormatMsgpack} {
+		t.Run(fmt.Sprintf("format %d", format), func(t *testing.T) {
+			// Job (1) + 9 nested payload objects fits exactly
+			data, err := MarshalJobAs(NewJob("nested", nestedPayload(9)), format)
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if _, err := UnmarshalJobWithLimits(data, limits); err != nil {
+				t.Errorf("Expected payload within depth to decode, got %v", err)
+			}
+
+			data, err = MarshalJobAs(NewJob("nested", nestedPayload(10)), format)
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if _, err := UnmarshalJobWithLimits(data, limits); !errors.Is(err, ErrInvalidPayload) {
+				t.Errorf("Expected ErrInvalidPayload, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJobWithLimits_DeepArrayBomb(t *testing.T) {
+	data := []byte(`{"id":"1","name":"bomb","payload":` + strings.Repeat("[", 100000) + strings.Repeat("]", 100000) + `}`)
+
+	if _, err := UnmarshalJobWithLimits(data, DecodeLimits{MaxDepth: 32}); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload, got %v", err)
+	}
+}
+
+func TestUnmarshalJobWithLimits_Keys(t *testing.T) {
+	payload := make(map[string]interface{})
+	for i := 0; i < 50; i++ {
+		payload[fmt.Sprintf("key%d", i)] = i
+	}
+	data, err := MarshalJob(NewJob("wide", payload))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	if _, err := UnmarshalJobWithLimits(data, DecodeLimits{MaxKeys: 50}); err != nil {
+		t.Errorf("Expected payload within key limit to decode, got %v", err)
+	}
+	if _, err := UnmarshalJobWithLimits(data, DecodeLimits{MaxKeys: 49}); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload, got %v", err)
+	}
+}
+
+func TestUnmarshalJobWithLimits_Unlimited(t *testing.T) {
+	data, err := MarshalJob(NewJob("nested", nestedPayload(200)))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	if _, err := UnmarshalJobWithLimits(data, DecodeLimits{}); err != nil {
+		t.Errorf("Expected zero limits to accept any payload, got %v", err)
+	}
+}
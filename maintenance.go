@@ -0,0 +1,143 @@
+package dgqueue
+
+import (
+	"context"
+	"time"
+)
+
+// maintenanceTask is a periodic background task run while the manager is started.
+// Each task runs on its own goroutine, so a slow task never delays the
+// dispatcher or the other tasks.
+type maintenanceTask struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context)
+
+	// immediate runs the task once at start instead of waiting an interval first
+	immediate bool
+}
+
+// maintenanceTasks returns the tasks enabled by the config and supported by
+// the current driver and dedup store. A zero interval disables a task.
+func (m *Manager) maintenanceTasks() []maintenanceTask {
+	var tasks []maintenanceTask
+
+	if purger, ok := m.driver.(ExpiredPurger); ok && m.config.PurgeExpiredInterval > 0 {
+		tasks = append(tasks, maintenanceTask{
+			name:     "purge-expired",
+			interval: m.config.PurgeExpiredInterval,
+			run: func(ctx context.Context) {
+				for _, queue := range m.servedQueues() {
+					if _, err := purger.PurgeExpired(ctx, queue); err != nil {
+						m.logError("Failed to purge expired jobs", err, "queue", queue)
+					}
+				}
+			},
+		})
+	}
+
+	if _, ok := m.driver.(FailedPruner); ok && m.config.FailedPruneInterval > 0 &&
+		(m.config.FailedMaxAge > 0 || m.config.FailedMaxCount > 0) {
+		tasks = append(tasks, maintenanceTask{
+			name:     "prune-failed",
+			interval: m.config.FailedPruneInterval,
+			run: func(ctx context.Context) {
+				pruned, err := m.PruneFailed(ctx)
+				if err != nil {
+					m.logError("Failed to prune failed jobs", err)
+				}
+				if pruned > 0 {
+					m.logInfo("Pruned failed jobs", "count", pruned)
+				}
+			},
+		})
+	}
+
+	if sweeper, ok := m.dedup.(dedupSweeper); ok && m.config.DedupSweepInterval > 0 {
+		tasks = append(tasks, maintenanceTask{
+			name:     "dedup-sweep",
+			interval: m.config.DedupSweepInterval,
+			run: func(ctx context.Context) {
+				sweeper.sweep(m.clock.Now())
+			},
+		})
+	}
+
+	if _, ok := m.driver.(QueueLister); ok && m.config.OrphanCheckInterval > 0 {
+		tasks = append(tasks, maintenanceTask{
+			name:      "orphan-check",
+			interval:  m.config.OrphanCheckInterval,
+			run:       m.checkOrphanQueues,
+			immediate: true,
+		})
+	}
+
+	if recoverer, ok := m.driver.(StalledJobRecoverer); ok && m.config.StalledCheckInterval > 0 {
+		tasks = append(tasks, maintenanceTask{
+			name:     "recover-stalled",
+			interval: m.config.StalledCheckInterval,
+			run: func(ctx context.Context) {
+				if err := recoverer.Heartbeat(ctx, m.visibilityTimeout()); err != nil {
+					m.logError("Failed to heartbeat held jobs", err)
+				} else {
+					m.lastHeartbeat.Store(m.clock.Now().UnixNano())
+				}
+				if reaper, ok := m.driver.(OrphanReaper); ok && m.config.ReapFactor > 0 {
+					m.reapOrphaned(ctx, reaper)
+					return
+				}
+				recovered, err := recoverer.RecoverStalled(ctx)
+				if err != nil {
+					m.logError("Failed to recover stalled jobs", err)
+				}
+				if recovered > 0 {
+					m.logWarn("Requeued jobs held by a stopped instance", "count", recovered)
+				}
+			},
+			immediate: true,
+		})
+	}
+
+	if _, ok := m.driver.(InstanceRegistry); ok && m.config.RegisterInterval > 0 {
+		tasks = append(tasks, maintenanceTask{
+			name:      "register-instance",
+			interval:  m.config.RegisterInterval,
+			run:       m.registerInstance,
+			immediate: true,
+		})
+	}
+
+	if m.config.AutoscaleInterval > 0 && m.hasAutoscaledPools() {
+		tasks = append(tasks, m.autoscaleTask())
+	}
+
+	return tasks
+}
+
+// startMaintenance starts a goroutine per task. Callers must hold m.mu.
+func (m *Manager) startMaintenance(tasks []maintenanceTask) {
+	for _, task := range tasks {
+		m.wg.Add(1)
+		go m.runMaintenance(context.Background(), task, m.stopChan)
+	}
+}
+
+// runMaintenance runs the task every interval until stop is closed.
+func (m *Manager) runMaintenance(ctx context.Context, task maintenanceTask, stop <-chan struct{}) {
+	defer m.wg.Done()
+
+	if task.immediate {
+		task.run(ctx)
+	}
+
+	for {
+		select {
+		case <-m.clock.After(task.interval):
+			task.run(ctx)
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
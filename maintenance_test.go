@@ -0,0 +1,254 @@
+package dgqueue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// purgingDriver is an empty driver that counts PurgeExpired calls per queue.
+type purgingDriver struct {
+	emptyDriver
+	mu     sync.Mutex
+	purged map[string]int
+}
+
+func (d *purgingDriver) PurgeExpired(ctx context.Context, queue string) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.purged[queue]++
+	return 0, nil
+}
+
+func (d *purgingDriver) count(queue string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.purged[queue]
+}
+
+func TestManager_MaintenanceIntervals(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WorkerEnabled = true
+	cfg.ServeQueues = []string{"emails", "reports"}
+	cfg.PurgeExpiredInterval = 30 * time.Second
+	cfg.DedupSweepInterval = 2 * time.Minute
+
+	clk := newFakeClock()
+	driver := &purgingDriver{purged: make(map[string]int)}
+	m := New(cfg)
+	m.SetDriver(driver)
+	m.clock = clk
+
+	assert.NoError(t, m.Start())
+	assert.Eventually(t, func() bool {
+		return driver.count("emails") >= 5 && driver.count("reports") >= 5
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, m.Stop(context.Background()))
+
+	waits := make(map[time.Duration]int)
+	for _, w := range clk.Waits() {
+		waits[w]++
+	}
+	assert.Greater(t, waits[30*time.Second], 0, "Expected purge to wait its interval")
+	assert.Greater(t, waits[2*time.Minute], 0, "Expected dedup sweep to wait its interval")
+}
+
+func TestManager_MaintenanceDisabled(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PurgeExpiredInterval = 0
+	cfg.DedupSweepInterval = 0
+
+	m := New(cfg)
+	m.SetDriver(&purgingDriver{purged: make(map[string]int)})
+	assert.Empty(t, m.maintenanceTasks())
+
+	// Drivers without PurgeExpired get no purge task
+	m = New(DefaultConfig())
+	m.SetDriver(emptyDriver{})
+	for _, task := range m.maintenanceTasks() {
+		assert.NotEqual(t, "purge-expired", task.name)
+	}
+}
+
+func TestManager_MaintenanceTasksDontStarve(t *testing.T) {
+	m := New(DefaultConfig())
+	m.clock = newFakeClock()
+
+	release := make(chan struct{})
+	var fastRuns atomic.Int64
+	tasks := []maintenanceTask{
+		{name: "slow", interval: time.Minute, run: func(ctx context.Context) { <-release }},
+		{name: "fast", interval: time.Second, run: func(ctx context.Context) { fastRuns.Add(1) }},
+	}
+
+	m.mu.Lock()
+	m.startMaintenance(tasks)
+	m.mu.Unlock()
+
+	// The slow task is stuck, but the fast one keeps running
+	assert.Eventually(t, func() bool {
+		return fastRuns.Load() >= 10
+	}, time.Second, time.Millisecond)
+
+	close(m.stopChan)
+	close(release)
+	m.wg.Wait()
+}
+
+func TestMemoryDedupStore_Sweep(t *testing.T) {
+	store := NewMemoryDedupStore().(*memoryDedupStore)
+	ctx := context.Background()
+
+	store.Claim(ctx, "short", time.Millisecond)
+	store.Claim(ctx, "long", time.Hour)
+	store.Claim(ctx, "forever", 0)
+
+	assert.Equal(t, 1, store.sweep(time.Now().Add(time.Second)))
+	assert.Len(t, store.keys, 2)
+}
+
+// recoveringDriver is an empty driver that records stalled-job maintenance.
+type recoveringDriver struct {
+	emptyDriver
+	heartbeats atomic.Int64
+	recoveries atomic.Int64
+	ttl        atomic.Int64
+}
+
+func (d *recoveringDriver) Heartbeat(ctx context.Context, ttl time.Duration) error {
+	d.ttl.Store(int64(ttl))
+	d.heartbeats.Add(1)
+	return nil
+}
+
+func (d *recoveringDriver) RecoverStalled(ctx context.Context) (int64, error) {
+	d.recoveries.Add(1)
+	return 0, nil
+}
+
+func TestManager_RecoverStalled(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StalledCheckInterval = 20 * time.Second
+
+	driver := &recoveringDriver{}
+	m := New(cfg)
+	m.SetDriver(driver)
+	m.clock = newFakeClock()
+
+	assert.NoError(t, m.Start())
+	assert.Eventually(t, func() bool {
+		return driver.heartbeats.Load() >= 2 && driver.recoveries.Load() >= 2
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, m.Stop(context.Background()))
+
+	// Heartbeats outlive a few missed checks
+	assert.Equal(t, int64(time.Minute), driver.ttl.Load())
+}
+
+func TestManager_VisibilityTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StalledCheckInterval = 20 * time.Second
+	cfg.VisibilityTimeout = 5 * time.Minute
+
+	driver := &recoveringDriver{}
+	m := New(cfg)
+	m.SetDriver(driver)
+	m.clock = newFakeClock()
+
+	assert.NoError(t, m.Start())
+	assert.Eventually(t, func() bool {
+		return driver.heartbeats.Load() >= 1
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, m.Stop(context.Background()))
+
+	assert.Equal(t, int64(5*time.Minute), driver.ttl.Load())
+}
+
+// reapingDriver is a recoveringDriver that hands out orphaned jobs once and
+// records what the manager does with them.
+type reapingDriver struct {
+	recoveringDriver
+	mu        sync.Mutex
+	orphans   []*Job
+	olderThan time.Duration
+	retried   []*Job
+	failed    []*Job
+}
+
+func (d *reapingDriver) ReapOrphaned(ctx context.Context, olderThan time.Duration) ([]*Job, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	jobs := d.orphans
+	d.orphans = nil
+	d.olderThan = olderThan
+	return jobs, nil
+}
+
+func (d *reapingDriver) Retry(ctx context.Context, job *Job) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.retried = append(d.retried, job)
+	return nil
+}
+
+func (d *reapingDriver) Failed(ctx context.Context, job *Job) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.failed = append(d.failed, job)
+	return nil
+}
+
+func TestManager_ReapOrphaned(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StalledCheckInterval = 20 * time.Second
+	cfg.Timeout = time.Minute
+	cfg.MaxAttempts = 3
+
+	fresh := NewJob("crashy", nil)
+	fresh.MaxAttempts = 3
+	poison := NewJob("crashy", nil)
+	poison.MaxAttempts = 3
+	poison.Attempts = 2
+
+	driver := &reapingDriver{orphans: []*Job{fresh, poison}}
+	m := New(cfg)
+	m.SetDriver(driver)
+	m.clock = newFakeClock()
+
+	var exhausted atomic.Int64
+	m.OnRetryExhausted(func(*Job) { exhausted.Add(1) })
+
+	assert.NoError(t, m.Start())
+	assert.Eventually(t, func() bool {
+		driver.mu.Lock()
+		defer driver.mu.Unlock()
+		return len(driver.retried)+len(driver.failed) == 2
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, m.Stop(context.Background()))
+
+	// Reaping replaces plain recovery, after Timeout times ReapFactor
+	assert.Zero(t, driver.recoveries.Load())
+	assert.Equal(t, 2*time.Minute, driver.olderThan)
+
+	// The lost attempt counts: retried while attempts remain, else dead-lettered
+	assert.Equal(t, []*Job{fresh}, driver.retried)
+	assert.Equal(t, 1, fresh.Attempts)
+	assert.Equal(t, ErrJobOrphaned.Error(), fresh.Error)
+	assert.Equal(t, []*Job{poison}, driver.failed)
+	assert.Equal(t, 3, poison.Attempts)
+	assert.Equal(t, int64(1), exhausted.Load())
+	assert.Equal(t, int64(1), m.MetricsSnapshot().DeadLettered)
+}
+
+func TestManager_ReapAfterVisibilityTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Timeout = time.Second
+	cfg.VisibilityTimeout = time.Hour
+
+	// Jobs are never reaped before their holder's reservation lapses
+	assert.Equal(t, time.Hour, New(cfg).reapAfter())
+}
@@ -2,10 +2,21 @@ package dgqueue
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"math/rand"
+	"os"
+	"runtime/debug"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 )
@@ -25,57 +36,233 @@ func RegisterDriver(name string, factory DriverFactory) {
 	globalDrivers[name] = factory
 }
 
+// LookupDriver returns the driver factory registered under name.
+func LookupDriver(name string) (DriverFactory, bool) {
+	globalDriversMu.RLock()
+	defer globalDriversMu.RUnlock()
+	factory, ok := globalDrivers[name]
+	return factory, ok
+}
+
+// DeadLetterHandler is invoked when a job has permanently failed.
+// Returning an error makes the manager fall back to the driver's failed store.
+type DeadLetterHandler func(ctx context.Context, job *Job) error
+
 // Manager is the main queue manager implementation.
 type Manager struct {
-	config     Config
-	driver     Driver
-	workers    map[string]*workerPool
-	middleware []Middleware
-	running    bool
-	stopChan   chan struct{}
-	wg         sync.WaitGroup
-	mu         sync.RWMutex
+	config      Config
+	driver      Driver
+	workers     map[string]*workerPool // keyed by poolKey
+	removed     map[string]struct{}    // job names whose worker was removed
+	paused      map[string]struct{}    // queues paused with Pause
+	policies    map[string]RetryPolicy // set with SetRetryPolicy
+	validators  map[string]PayloadValidator
+	migrations  map[string]map[int]PayloadMigration // by job name, then version migrated from
+	middleware  []Middleware
+	deadLetter  DeadLetterHandler
+	archiver    FailedArchiver
+	onExhausted func(*Job)
+	dedup       DedupStore
+	batchSlots  chan struct{}
+	retrySlots  chan struct{}
+	throughput  *throughputWindow
+	metricsSink MetricsSink
+	running     bool
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
+	mu          sync.RWMutex
+	clock       clock
+	stats       jobCounters
+	logOutput   io.Writer // fallback log destination when no Logger is set
+
+	// pushes counts dispatches to the current driver, which Reload waits for
+	// before moving jobs off it. Reload replaces it along with the driver.
+	pushes *sync.WaitGroup
+
+	// workerQueues are the queues of workers registered with WorkerOn
+	workerQueues atomic.Pointer[[]string]
+
+	// lastHeartbeat is when held jobs were last heartbeated (Unix nanoseconds)
+	lastHeartbeat atomic.Int64
+
+	// batches tracks the outcome of jobs from batches dispatched by this manager
+	batches sync.Map // batch ID -> *batchTracker
+
+	// instanceID identifies this manager in the driver's instance registry
+	instanceID string
+	startedAt  time.Time
 
 	// Observability
 	metricQueueDepth    metric.Int64ObservableGauge
 	metricActiveWorkers metric.Int64ObservableGauge
+	metricBusyWorkers   metric.Int64ObservableGauge
 	metricJobProcessed  metric.Int64Counter
 	metricJobDuration   metric.Float64Histogram
+	metricJobWaitTime   metric.Float64Histogram
+	metricJobDeferred   metric.Int64Counter
+	metricJobExhausted  metric.Int64Counter
+	metricThroughput    metric.Float64ObservableGauge
+	metricOldestAge     metric.Float64ObservableGauge
+	metricQueueOrphaned metric.Int64Counter
+	depth               depthCache // queue sizes observed for queue.depth
 }
 
 // workerPool represents a pool of workers for a specific job type.
 type workerPool struct {
 	name        string
+	queue       string // empty for workers registered on every queue
 	concurrency int
-	handler     WorkerFunc
+	handler     WorkerFunc // behind the worker's own middleware
+	wrapped     atomic.Pointer[WorkerFunc]
 	jobs        chan *Job
+	lanes       []chan *Job // per-worker channels for ordered pools
 	stopChan    chan struct{}
 	wg          sync.WaitGroup
+	busy        atomic.Int64
+	inFlight    sync.Map // job ID -> InFlightJob
+
+	// Autoscaled pools only; see AutoscaledWorker
+	minConcurrency int
+	maxConcurrency int
+	shrink         chan struct{} // each value stops one worker
+	avgDuration    atomic.Int64  // nanoseconds
 }
 
 // New creates a new queue manager.
 func New(config Config) *Manager {
 	return &Manager{
-		config:     config,
-		workers:    make(map[string]*workerPool),
-		middleware: make([]Middleware, 0),
-		stopChan:   make(chan struct{}),
+		config:      config,
+		workers:     make(map[string]*workerPool),
+		removed:     make(map[string]struct{}),
+		paused:      make(map[string]struct{}),
+		policies:    make(map[string]RetryPolicy),
+		validators:  make(map[string]PayloadValidator),
+		migrations:  make(map[string]map[int]PayloadMigration),
+		middleware:  make([]Middleware, 0),
+		stopChan:    make(chan struct{}),
+		clock:       realClock{},
+		instanceID:  uuid.New().String(),
+		logOutput:   os.Stdout,
+		dedup:       NewMemoryDedupStore(),
+		batchSlots:  newBatchSlots(config),
+		retrySlots:  newRetrySlots(config),
+		throughput:  newThroughputWindow(config.ThroughputWindow),
+		metricsSink: NoopMetricsSink{},
+		pushes:      new(sync.WaitGroup),
 	}
 }
 
 // SetDriver sets the queue driver.
+// If the driver implements DedupStore it is also used for deduplication, and
+// if it implements RunnerBinder it is bound to the manager's workers.
 func (m *Manager) SetDriver(driver Driver) {
 	m.driver = driver
+	if store, ok := driver.(DedupStore); ok {
+		m.dedup = store
+	}
+	if binder, ok := driver.(RunnerBinder); ok {
+		binder.BindRunner(m.runNow)
+	}
 }
 
-// Dispatch dispatches a job immediately.
-func (m *Manager) Dispatch(ctx context.Context, name string, payload interface{}) (*Job, error) {
+// SetDedupStore sets the store used to deduplicate dispatches.
+func (m *Manager) SetDedupStore(store DedupStore) {
+	m.dedup = store
+}
+
+// SetDeadLetterHandler sets the handler invoked for permanently failed jobs.
+// Pass nil to restore the default behavior of moving jobs to the driver's failed store.
+func (m *Manager) SetDeadLetterHandler(handler DeadLetterHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deadLetter = handler
+}
+
+// OnRetryExhausted sets a hook called when a job fails after its last attempt,
+// just before it is dead-lettered. Pass nil to remove the hook.
+func (m *Manager) OnRetryExhausted(hook func(*Job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onExhausted = hook
+}
+
+// NewJob creates a job using the manager's default queue, attempts and timeout.
+// Customize it with the With* helpers and dispatch it with Enqueue.
+func (m *Manager) NewJob(name string, payload interface{}) *Job {
+	// Reload replaces the config under the lock
+	m.mu.RLock()
 	job := NewJob(name, payload)
 	job.Queue = m.config.DefaultQueue
 	job.MaxAttempts = m.config.MaxAttempts
 	job.Timeout = m.config.Timeout
+	m.mu.RUnlock()
 
-	if err := m.driver.Push(ctx, job); err != nil {
+	if policy, ok := m.RetryPolicy(name); ok {
+		if policy.MaxAttempts > 0 {
+			job.MaxAttempts = policy.MaxAttempts
+		}
+		if policy.Timeout > 0 {
+			job.Timeout = policy.Timeout
+		}
+	}
+	if version := m.payloadVersion(name); version > 1 {
+		WithPayloadVersion(job, version)
+	}
+	return job
+}
+
+// Enqueue pushes a prepared job to the driver, in a queue.dispatch span
+// whose trace context is stored in the job so its processing joins the trace.
+// A delay longer than a DelayLimiter driver supports is clamped, so the job
+// reports the effective AvailableAt rather than the requested one.
+func (m *Manager) Enqueue(ctx context.Context, job *Job) error {
+	if err := m.validate(job); err != nil {
+		return err
+	}
+
+	// Reload swaps the driver under the lock, then waits for the pushes
+	// already counted against the old one before moving its jobs
+	m.mu.RLock()
+	driver, pushes := m.driver, m.pushes
+	pushes.Add(1)
+	m.mu.RUnlock()
+	defer pushes.Done()
+
+	// Clamp to what the driver can actually schedule
+	if limiter, ok := driver.(DelayLimiter); ok {
+		if maxDelay := limiter.MaxDelay(); maxDelay > 0 && job.AvailableAt.Sub(job.CreatedAt) > maxDelay {
+			WithDelay(job, maxDelay)
+		}
+	}
+
+	ctx, span := startDispatchSpan(ctx, job)
+	defer span.End()
+
+	if err := driver.Push(ctx, job); err != nil {
+		failSpan(span, err)
+		return err
+	}
+	return nil
+}
+
+// Dispatch dispatches a job immediately.
+func (m *Manager) Dispatch(ctx context.Context, name string, payload interface{}) (*Job, error) {
+	job := m.NewJob(name, payload)
+
+	if err := m.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// DispatchRaw dispatches a job whose payload is already serialized.
+// The bytes are stored as-is and handlers receive the same []byte back,
+// so relays can forward payloads without decoding and re-encoding them.
+func (m *Manager) DispatchRaw(ctx context.Context, name string, payload []byte) (*Job, error) {
+	job := WithMetadata(m.NewJob(name, payload), MetadataRawPayload, true)
+
+	if err := m.Enqueue(ctx, job); err != nil {
 		return nil, err
 	}
 
@@ -83,55 +270,193 @@ func (m *Manager) Dispatch(ctx context.Context, name string, payload interface{}
 }
 
 // DispatchAfter dispatches a job with a delay.
+// The returned job's AvailableAt is the effective time the driver scheduled it for.
 func (m *Manager) DispatchAfter(ctx context.Context, name string, payload interface{}, delay time.Duration) (*Job, error) {
-	job := NewJob(name, payload)
-	job.Queue = m.config.DefaultQueue
-	job.MaxAttempts = m.config.MaxAttempts
-	job.Timeout = m.config.Timeout
-	WithDelay(job, delay)
+	job := WithDelay(m.NewJob(name, payload), delay)
 
-	if err := m.driver.Push(ctx, job); err != nil {
+	// Enqueue and drivers may adjust AvailableAt during Push; the job reflects what was stored
+	if err := m.Enqueue(ctx, job); err != nil {
 		return nil, err
 	}
 
 	return job, nil
 }
 
-// DispatchBatch dispatches multiple jobs as a batch.
-func (m *Manager) DispatchBatch(name string, config BatchConfig, items interface{}, mapper BatchMapper) error {
-	// TODO: Implement batch processing
-	return fmt.Errorf("batch processing not yet implemented")
+// DispatchAtNextCron dispatches a one-off job delayed until the next occurrence
+// of a standard 5-field cron expression. No recurring schedule is registered;
+// use dg-scheduler for that.
+func (m *Manager) DispatchAtNextCron(ctx context.Context, name string, payload interface{}, cronExpr string) (*Job, error) {
+	schedule, err := cron.ParseStandard(cronExpr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCron, err)
+	}
+
+	job := m.NewJob(name, payload)
+	WithDelay(job, schedule.Next(job.CreatedAt).Sub(job.CreatedAt))
+
+	if err := m.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// DispatchBatch dispatches multiple jobs as a batch; see Batch.DispatchBatch.
+// Use NewBatch for the streaming and mapping variants.
+func (m *Manager) DispatchBatch(ctx context.Context, name string, items []interface{}, config BatchConfig) (*BatchStatus, error) {
+	return NewBatch(m).DispatchBatch(ctx, name, items, config)
 }
 
-// Worker registers a worker for a job name.
+// Worker registers a worker for a job name, replacing any registered before.
+// On a running manager the pool starts right away, and a replaced pool stops
+// once its in-flight jobs finish, sending its buffered jobs back to the queue.
 func (m *Manager) Worker(name string, concurrency int, handler WorkerFunc) error {
+	return m.WorkerWith(name, concurrency, handler)
+}
+
+// WorkerWith registers a worker like Worker, customized by opts. Worker's
+// signature is fixed by the Queue interface, so options are passed here.
+func (m *Manager) WorkerWith(name string, concurrency int, handler WorkerFunc, opts ...WorkerOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.installPool(m.newWorkerPool("", name, concurrency, handler, opts))
+	return nil
+}
+
+// AddWorker registers a worker for a job name like Worker, but returns
+// ErrWorkerExists instead of replacing a registered worker. Together with
+// RemoveWorker it lets plugins manage their handlers on a running manager.
+func (m *Manager) AddWorker(name string, concurrency int, handler WorkerFunc, opts ...WorkerOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.workers[name]; exists {
+		return fmt.Errorf("%w: %s", ErrWorkerExists, name)
+	}
+	m.installPool(m.newWorkerPool("", name, concurrency, handler, opts))
+	return nil
+}
+
+// WorkerOn registers a worker for a job name on one queue, with its own pool,
+// so each queue gets an isolated concurrency budget. Jobs on that queue go to
+// it rather than to a worker registered with Worker, which still handles the
+// name on other queues. Unless Config.ServeQueues is set, the manager also
+// serves the queue. Its PoolStats entry is keyed "queue/name".
+func (m *Manager) WorkerOn(queue, name string, concurrency int, handler WorkerFunc, opts ...WorkerOption) error {
+	if queue == "" {
+		return fmt.Errorf("%w: worker %q needs a queue", ErrInvalidConfig, name)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.installPool(m.newWorkerPool(queue, name, concurrency, handler, opts))
+	return nil
+}
+
+// newWorkerPool creates a pool for the job name on the queue, or on every
+// queue if queue is empty, running handler behind the middleware in opts.
+// Callers must hold m.mu.
+func (m *Manager) newWorkerPool(queue, name string, concurrency int, handler WorkerFunc, opts []WorkerOption) *workerPool {
 	if concurrency <= 0 {
 		concurrency = m.config.Workers
 	}
 
-	// Apply middleware
-	finalHandler := handler
-	for i := len(m.middleware) - 1; i >= 0; i-- {
-		finalHandler = m.middleware[i](finalHandler)
+	var options workerOptions
+	for _, opt := range opts {
+		opt(&options)
 	}
 
-	m.workers[name] = &workerPool{
+	return &workerPool{
 		name:        name,
+		queue:       queue,
 		concurrency: concurrency,
-		handler:     finalHandler,
+		handler:     chain(handler, options.middleware),
 		jobs:        make(chan *Job, concurrency*2),
 		stopChan:    make(chan struct{}),
 	}
+}
 
-	return nil
+// installPool registers the pool behind the manager's middleware, starting it
+// if the manager is running. A pool it replaces is stopped in the background.
+// Callers must hold m.mu.
+func (m *Manager) installPool(pool *workerPool) {
+	key := poolKey(pool.queue, pool.name)
+	old := m.workers[key]
+
+	m.wrapHandler(pool)
+	delete(m.removed, pool.name)
+	m.workers[key] = pool
+	m.updateWorkerQueues()
+
+	if !m.running {
+		return
+	}
+	m.startWorkerPool(pool)
+
+	if old != nil {
+		close(old.stopChan)
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			old.wg.Wait()
+			m.requeuePool(context.Background(), old, m.driver)
+		}()
+	}
+}
+
+// poolKey returns the key of the pool for the job name on the queue.
+func poolKey(queue, name string) string {
+	if queue == "" {
+		return name
+	}
+	return queue + "/" + name
+}
+
+// poolFor returns the pool that runs the job: the worker registered for its
+// queue, else the worker for its name.
+func (m *Manager) poolFor(job *Job) (*workerPool, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.poolForName(job.Queue, job.Name)
 }
 
-// Use adds middleware to the queue.
+// poolForName returns the pool for the job name on the queue: the one
+// registered on the queue, else the one registered on every queue. Callers
+// must hold m.mu.
+func (m *Manager) poolForName(queue, name string) (*workerPool, bool) {
+	if pool, ok := m.workers[poolKey(queue, name)]; ok {
+		return pool, true
+	}
+	pool, ok := m.workers[name]
+	return pool, ok
+}
+
+// updateWorkerQueues records the queues workers are registered on, so
+// servedQueues can read them without m.mu. Callers must hold m.mu.
+func (m *Manager) updateWorkerQueues() {
+	var queues []string
+	for _, pool := range m.workers {
+		if pool.queue != "" && !slices.Contains(queues, pool.queue) {
+			queues = append(queues, pool.queue)
+		}
+	}
+	sort.Strings(queues)
+	m.workerQueues.Store(&queues)
+}
+
+// Use adds middleware to the queue. It applies to every worker, whether
+// registered before or after, outside the worker's own middleware.
 func (m *Manager) Use(middleware Middleware) Queue {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.middleware = append(m.middleware, middleware)
+	for _, pool := range m.workers {
+		m.wrapHandler(pool)
+	}
 	return m
 }
 
@@ -153,6 +478,7 @@ func (m *Manager) Start() error {
 	// Recreate stopChan for safe restart
 	m.stopChan = make(chan struct{})
 	m.running = true
+	m.startedAt = m.clock.Now()
 
 	// Start workers
 	for _, worker := range m.workers {
@@ -165,6 +491,9 @@ func (m *Manager) Start() error {
 	m.wg.Add(1)
 	go m.dispatchJobs(context.Background())
 
+	// Start background maintenance
+	m.startMaintenance(m.maintenanceTasks())
+
 	m.logInfo("Queue manager started", "workers", len(m.workers))
 	return nil
 }
@@ -184,13 +513,18 @@ func (m *Manager) Stop(ctx context.Context) error {
 	m.mu.Unlock()
 
 	// Stop all workers
-	for _, worker := range m.workers {
-		close(worker.stopChan)
-		worker.wg.Wait()
-	}
+	m.stopWorkerPools()
 
 	// Wait for dispatcher to finish
 	m.wg.Wait()
+	m.unregisterInstance(ctx)
+
+	// Jobs left in the pools were already popped; don't abandon them
+	if m.driver != nil {
+		if requeued := m.requeueBuffered(ctx, m.driver, m.driver); requeued > 0 {
+			m.logInfo("Requeued buffered jobs", "count", requeued)
+		}
+	}
 
 	// Close driver connection
 	if m.driver != nil {
@@ -204,9 +538,176 @@ func (m *Manager) Stop(ctx context.Context) error {
 	return nil
 }
 
+// Reload drains the running workers, swaps in a new config and driver, and
+// restarts the workers if the manager was running.
+// Unless both drivers share a backend, jobs buffered in worker pools and jobs
+// left on the old driver are moved to the new driver, each removed from the
+// old driver once pushed, before the old driver is closed. Delayed and failed
+// jobs are only moved if the old driver is a JobMigrator.
+func (m *Manager) Reload(ctx context.Context, config Config, driver Driver) error {
+	if driver == nil {
+		return fmt.Errorf("%w: driver is required", ErrInvalidConfig)
+	}
+
+	m.mu.Lock()
+	wasRunning := m.running
+	if wasRunning {
+		m.running = false
+		close(m.stopChan)
+	}
+	m.mu.Unlock()
+
+	m.logInfo("Queue manager reloading", "restart", wasRunning)
+
+	if wasRunning {
+		// Stop fetching first so nothing new lands in the pools, then let
+		// in-flight jobs finish against the old driver
+		m.wg.Wait()
+		m.stopWorkerPools()
+		m.unregisterInstance(ctx)
+	}
+
+	// Swap under the lock, so dispatches go to the new driver from here on
+	m.mu.Lock()
+	oldDriver, oldPushes := m.driver, m.pushes
+	m.pushes = new(sync.WaitGroup)
+	oldQueues := m.servedQueues()
+	m.config = config
+	m.batchSlots = newBatchSlots(config)
+	m.retrySlots = newRetrySlots(config)
+	if config.ThroughputWindow != m.throughput.window() {
+		m.throughput = newThroughputWindow(config.ThroughputWindow)
+	}
+	m.SetDriver(driver)
+	m.mu.Unlock()
+
+	// Dispatches that read the old driver land before its jobs move
+	oldPushes.Wait()
+
+	// Buffered jobs were already popped, so they go first
+	m.requeueBuffered(ctx, oldDriver, driver)
+	if oldDriver != nil && !sameBackend(oldDriver, driver) {
+		m.moveJobs(ctx, oldDriver, driver, oldQueues)
+	}
+
+	if oldDriver != nil && oldDriver != driver {
+		if err := oldDriver.Close(); err != nil {
+			m.logError("Failed to close old driver", err)
+		}
+	}
+
+	if wasRunning {
+		return m.Start()
+	}
+	return nil
+}
+
+// sameBackend reports whether two drivers store their jobs in the same place.
+func sameBackend(a, b Driver) bool {
+	if a == b {
+		return true
+	}
+	idA, okA := a.(BackendIdentifier)
+	idB, okB := b.(BackendIdentifier)
+	return okA && okB && idA.BackendID() == idB.BackendID()
+}
+
+// moveJobs moves the jobs stored by one driver to another. Drivers that aren't
+// JobMigrators only give up the ready jobs on the given queues.
+func (m *Manager) moveJobs(ctx context.Context, from, to Driver, queues []string) {
+	if migrator, ok := from.(JobMigrator); ok {
+		moved := 0
+		err := migrator.MigrateJobs(ctx, func(job *Job, failed bool) error {
+			push := to.Push
+			if failed {
+				push = to.Failed
+			}
+			if err := push(ctx, job); err != nil {
+				return fmt.Errorf("move job %s: %w", job.ID, err)
+			}
+			moved++
+			return nil
+		})
+		if err != nil {
+			m.logError("Failed to move jobs to new driver", err)
+		}
+		if moved > 0 {
+			m.logInfo("Moved jobs to new driver", "count", moved)
+		}
+		return
+	}
+
+	m.logWarn("Old driver can't hand over delayed and failed jobs; only ready jobs are moved")
+	moved := 0
+	for _, queue := range queues {
+		// Bound the move by the queue's size, so it ends even if jobs keep arriving
+		size, err := from.Size(ctx, queue)
+		if err != nil {
+			m.logError("Failed to size queue on old driver", err, "queue", queue)
+			continue
+		}
+		for ; size > 0; size-- {
+			job, err := from.Pop(ctx, queue)
+			if err != nil {
+				break
+			}
+			if err := to.Push(ctx, job); err != nil {
+				m.logError("Failed to move job to new driver", err, "job_id", job.ID, "job_name", job.Name)
+				// Hand it back, so the old backend still has it
+				if err := from.Retry(ctx, job); err != nil {
+					m.logError("Failed to return job to old driver", err, "job_id", job.ID, "job_name", job.Name)
+				}
+				continue
+			}
+			if err := from.Delete(ctx, job.ID); err != nil && !errors.Is(err, ErrJobNotFound) {
+				m.logError("Failed to remove moved job from old driver", err, "job_id", job.ID, "job_name", job.Name)
+			}
+			moved++
+		}
+	}
+	if moved > 0 {
+		m.logInfo("Moved jobs to new driver", "count", moved)
+	}
+}
+
+// SetGlobalPause sets or clears the driver-backed pause flag.
+// Every manager sharing the driver's backend stops fetching jobs while it is set.
+func (m *Manager) SetGlobalPause(ctx context.Context, paused bool) error {
+	pauser, ok := m.driver.(GlobalPauser)
+	if !ok {
+		return ErrNotSupported
+	}
+	if err := pauser.SetPaused(ctx, paused); err != nil {
+		return err
+	}
+
+	m.logInfo("Queue global pause changed", "paused", paused)
+	return nil
+}
+
+// GlobalPaused reports whether the driver-backed pause flag is set.
+func (m *Manager) GlobalPaused(ctx context.Context) (bool, error) {
+	pauser, ok := m.driver.(GlobalPauser)
+	if !ok {
+		return false, ErrNotSupported
+	}
+	return pauser.IsPaused(ctx)
+}
+
+// OldestJobAge returns how long the oldest ready job in the queue has been waiting.
+// It returns ErrNotSupported if the driver can't report job age.
+func (m *Manager) OldestJobAge(ctx context.Context, queue string) (time.Duration, error) {
+	ager, ok := m.driver.(OldestJobAger)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	return ager.OldestJobAge(ctx, queue)
+}
+
 // Status returns the status of a job.
+// It returns ErrNotSupported if the driver can't look jobs up by ID.
 func (m *Manager) Status(ctx context.Context, jobID string) (*JobStatus, error) {
-	job, err := m.driver.Get(ctx, jobID)
+	job, err := m.getJob(ctx, jobID)
 	if err != nil {
 		return nil, err
 	}
@@ -223,112 +724,522 @@ func (m *Manager) Status(ctx context.Context, jobID string) (*JobStatus, error)
 	}, nil
 }
 
+// statusRetryInterval is how often StatusWithin retries a lookup.
+const statusRetryInterval = 20 * time.Millisecond
+
+// StatusWithin is like Status but keeps retrying while the job is not found,
+// for up to maxWait. This tolerates drivers that are eventually consistent and
+// the brief window where a just-dispatched job moves between stores.
+func (m *Manager) StatusWithin(ctx context.Context, jobID string, maxWait time.Duration) (*JobStatus, error) {
+	deadline := m.clock.Now().Add(maxWait)
+
+	for {
+		status, err := m.Status(ctx, jobID)
+		if err == nil || !errors.Is(err, ErrJobNotFound) {
+			return status, err
+		}
+
+		remaining := deadline.Sub(m.clock.Now())
+		if remaining <= 0 {
+			return nil, err
+		}
+
+		select {
+		case <-m.clock.After(min(remaining, statusRetryInterval)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
 // Driver returns the underlying driver.
 func (m *Manager) Driver() Driver {
 	return m.driver
 }
 
+// Capabilities returns what the underlying driver supports.
+func (m *Manager) Capabilities() Capabilities {
+	return DriverCapabilities(m.driver)
+}
+
+// getJob looks a job up by ID, failing with ErrNotSupported if the driver can't.
+func (m *Manager) getJob(ctx context.Context, jobID string) (*Job, error) {
+	if !m.Capabilities().Get {
+		return nil, fmt.Errorf("%w: %T can't look up jobs by ID", ErrNotSupported, m.driver)
+	}
+	return m.driver.Get(ctx, jobID)
+}
+
 // startWorkerPool starts a worker pool.
 func (m *Manager) startWorkerPool(pool *workerPool) {
+	// Recreate stopChan for safe restart
+	pool.stopChan = make(chan struct{})
+
+	// Discard exits left pending from autoscaling before the pool stopped
+	for len(pool.shrink) > 0 {
+		<-pool.shrink
+	}
+
 	for i := 0; i < pool.concurrency; i++ {
 		pool.wg.Add(1)
 		go m.runWorker(pool, i)
 	}
 }
 
+// stopWorkerPools stops all worker pools and waits for in-flight jobs.
+func (m *Manager) stopWorkerPools() {
+	for _, worker := range m.workerPools() {
+		close(worker.stopChan)
+		worker.wg.Wait()
+	}
+}
+
+// workerPools returns the registered worker pools.
+func (m *Manager) workerPools() []*workerPool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pools := make([]*workerPool, 0, len(m.workers))
+	for _, pool := range m.workers {
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+// requeueBuffered pushes jobs buffered in worker pools, which were popped from
+// one driver, onto another, returning how many were requeued. Unless the
+// drivers share a backend, each job is then removed from the driver it was
+// popped from. Worker pools must be stopped.
+func (m *Manager) requeueBuffered(ctx context.Context, from, to Driver) int {
+	requeued := 0
+	for _, pool := range m.workerPools() {
+		switch {
+		case from == nil:
+			requeued += m.requeuePool(ctx, pool, to)
+			continue
+		case sameBackend(from, to):
+			// Pushing through the driver that popped them releases its hold
+			requeued += m.requeuePool(ctx, pool, from)
+			continue
+		}
+		requeued += m.movePool(ctx, pool, from, to)
+	}
+	return requeued
+}
+
+// movePool pushes jobs buffered in a stopped pool onto another driver than the
+// one they were popped from, removing them from that driver once pushed.
+func (m *Manager) movePool(ctx context.Context, pool *workerPool, from, to Driver) int {
+	ctx = context.WithoutCancel(ctx)
+
+	moved := 0
+	for _, jobs := range pool.channels() {
+		for len(jobs) > 0 {
+			job := <-jobs
+			if err := to.Push(ctx, job); err != nil {
+				m.logError("Failed to move buffered job to new driver", err, "job_id", job.ID, "job_name", job.Name)
+				if err := from.Push(ctx, job); err != nil {
+					m.logError("Failed to requeue buffered job", err, "job_id", job.ID, "job_name", job.Name)
+				}
+				continue
+			}
+			if err := from.Delete(ctx, job.ID); err != nil && !errors.Is(err, ErrJobNotFound) {
+				m.logError("Failed to remove moved job from old driver", err, "job_id", job.ID, "job_name", job.Name)
+			}
+			moved++
+		}
+	}
+	return moved
+}
+
+// requeuePool pushes jobs buffered in a stopped pool onto the given driver,
+// returning how many were requeued. The jobs were already popped, so the
+// pushes aren't canceled with ctx.
+func (m *Manager) requeuePool(ctx context.Context, pool *workerPool, driver Driver) int {
+	ctx = context.WithoutCancel(ctx)
+
+	requeued := 0
+	for _, jobs := range pool.channels() {
+		for len(jobs) > 0 {
+			job := <-jobs
+			if err := driver.Push(ctx, job); err != nil {
+				m.logError("Failed to requeue buffered job", err, "job_id", job.ID, "job_name", job.Name)
+				continue
+			}
+			requeued++
+		}
+	}
+	return requeued
+}
+
 // runWorker runs a single worker.
 func (m *Manager) runWorker(pool *workerPool, id int) {
 	defer pool.wg.Done()
 
+	// Ordered pools give each worker its own lane
+	jobs := pool.jobs
+	if pool.lanes != nil {
+		jobs = pool.lanes[id]
+	}
+
 	for {
+		// Once stopped, leave buffered jobs to be requeued rather than starting them
+		select {
+		case <-pool.stopChan:
+			return
+		default:
+		}
+
 		select {
-		case job := <-pool.jobs:
+		case job := <-jobs:
+			release, ok := m.acquireRetrySlot(job)
+			if !ok {
+				m.postponeRetry(job)
+				continue
+			}
+			pool.busy.Add(1)
+			start := m.clock.Now()
+			pool.inFlight.Store(job.ID, InFlightJob{
+				ID:        job.ID,
+				Name:      job.Name,
+				Queue:     job.Queue,
+				Worker:    id,
+				StartedAt: start,
+			})
 			m.processJob(pool, job)
+			pool.inFlight.Delete(job.ID)
+			pool.observeDuration(m.clock.Now().Sub(start))
+			pool.busy.Add(-1)
+			release()
+		case <-pool.shrink:
+			return
 		case <-pool.stopChan:
 			return
 		}
 	}
 }
 
-// processJob processes a single job.
+// newRetrySlots creates the semaphore limiting concurrent retries (nil = unlimited).
+func newRetrySlots(config Config) chan struct{} {
+	if config.MaxConcurrentRetries <= 0 {
+		return nil
+	}
+	return make(chan struct{}, config.MaxConcurrentRetries)
+}
+
+// retrySlotWait is how long a retry that found no free retry slot waits
+// before it is available again.
+const retrySlotWait = 100 * time.Millisecond
+
+// acquireRetrySlot reserves a retry slot for jobs that already ran, reporting
+// false if none is free. The returned function releases the slot.
+func (m *Manager) acquireRetrySlot(job *Job) (func(), bool) {
+	if job.Attempts == 0 {
+		return func() {}, true
+	}
+
+	m.mu.RLock()
+	slots := m.retrySlots
+	m.mu.RUnlock()
+
+	if slots == nil {
+		return func() {}, true
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+		return nil, false
+	}
+}
+
+// postponeRetry returns a retry that found no free retry slot to its queue
+// for retrySlotWait, so the worker moves on to other jobs instead of waiting.
+func (m *Manager) postponeRetry(job *Job) {
+	job.AvailableAt = m.clock.Now().Add(retrySlotWait)
+	if err := m.driver.Push(context.Background(), job); err != nil {
+		m.logError("Failed to postpone retry", err, "job_id", job.ID, "job_name", job.Name)
+	}
+}
+
+// processJob processes a single job popped from the driver, retrying or
+// dead-lettering it if it fails.
 func (m *Manager) processJob(pool *workerPool, job *Job) {
+	ctx, err := m.attemptJob(context.Background(), pool, job)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrJobDeferred):
+		m.deferJob(ctx, job)
+	default:
+		m.failJob(ctx, pool, job, err)
+	}
+}
+
+// attemptJob runs one attempt at a job in a queue.process span, bounded by the
+// job's timeout, recording its metrics and completing it if it succeeds. Jobs
+// whose idempotency key was already processed are completed without running.
+// It returns the handler's error, or ErrJobTimeout if the attempt timed out,
+// along with the context to handle the outcome under.
+func (m *Manager) attemptJob(ctx context.Context, pool *workerPool, job *Job) (context.Context, error) {
+	if m.alreadyProcessed(job) {
+		m.skipDuplicate(job)
+		return ctx, nil
+	}
 	MarkStarted(job)
 
-	// Create timeout context
-	ctx, cancel := context.WithTimeout(context.Background(), job.Timeout)
-	defer cancel()
+	ctx, span := startProcessSpan(ctx, job)
+	status, spanErr := spanStatusSuccess, error(nil)
+	defer func() { endProcessSpan(span, status, spanErr) }()
+
+	// The handler runs under the timeout; the outcome is handled after it
+	handlerCtx := ctx
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		handlerCtx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	timedOut, err := m.runHandler(handlerCtx, pool, job)
+	duration := time.Since(started)
+	if timedOut {
+		err = ErrJobTimeout
+	} else if errors.Is(err, ErrJobDeferred) {
+		status = spanStatusDeferred
+		return ctx, err
+	}
+
+	// Every outcome but a deferral is recorded
+	defer m.recordJobProcessed(ctx, pool, job, err, duration)
+
+	m.stats.processed.Add(1)
+	if err != nil {
+		status, spanErr = spanStatusFailed, err
+		if timedOut {
+			status = spanStatusTimeout
+		}
+		m.stats.failed.Add(1)
+		MarkFailed(job, err)
+		return ctx, err
+	}
+
+	m.stats.succeeded.Add(1)
+	MarkCompleted(job)
+	m.recordProcessed(job)
+	m.batchJobDone(job, true)
+	m.driver.Delete(ctx, job.ID)
+	m.completions().add(m.clock.Now())
+	return ctx, nil
+}
+
+// failJob retries a job whose attempt failed or timed out with backoff, or
+// dead-letters it once it can't be retried.
+func (m *Manager) failJob(ctx context.Context, pool *workerPool, job *Job, err error) {
+	timedOut := errors.Is(err, ErrJobTimeout)
+	if stack, ok := job.Metadata[MetadataPanicStack]; ok {
+		m.logErrorContext(ctx, "Job handler panicked", err, "job_id", job.ID, "job_name", job.Name, "stack", stack)
+	}
+
+	if m.shouldRetry(job, err, m.retryBackoff(job)) {
+		if timedOut {
+			m.logInfoContext(ctx, "Job timed out, retrying", "job_id", job.ID, "job_name", job.Name, "attempt", job.Attempts)
+		} else {
+			m.logInfoContext(ctx, "Job failed, retrying", "job_id", job.ID, "job_name", job.Name, "attempt", job.Attempts, "error", err)
+		}
+		m.driver.Retry(ctx, job)
+		m.stats.retried.Add(1)
+		return
+	}
+
+	if timedOut {
+		m.logErrorContext(ctx, "Job timed out permanently", err, "job_id", job.ID, "job_name", job.Name, "attempts", job.Attempts)
+	} else {
+		m.logErrorContext(ctx, "Job failed permanently", err, "job_id", job.ID, "job_name", job.Name, "attempts", job.Attempts)
+	}
+	m.retriesExhausted(ctx, job)
+	m.moveToDeadLetter(ctx, job)
+}
+
+// shouldRetry reports whether a failed job is retried, scheduling its next attempt
+// backoff from now (0 keeps its schedule). Errors matching ErrNoRetry are never
+// retried; otherwise Config.RetryDecider takes precedence over CanRetry and the
+// backoff.
+func (m *Manager) shouldRetry(job *Job, err error, backoff time.Duration) bool {
+	if errors.Is(err, ErrNoRetry) {
+		return false
+	}
+	if m.config.RetryDecider != nil {
+		retry, delay := m.config.RetryDecider(job, err)
+		if retry && delay > 0 {
+			m.retryAfter(job, delay)
+		}
+		return retry
+	}
+
+	if !CanRetry(job) {
+		return false
+	}
+	if backoff > 0 {
+		m.retryAfter(job, backoff)
+	}
+	return true
+}
+
+// retryAfter schedules a job's next attempt delay from now.
+func (m *Manager) retryAfter(job *Job, delay time.Duration) {
+	job.Delay = delay
+	job.AvailableAt = m.clock.Now().Add(delay)
+}
+
+// runHandler runs the job's handler under ctx's timeout, reporting whether
+// the job timed out.
+func (m *Manager) runHandler(ctx context.Context, pool *workerPool, job *Job) (bool, error) {
+	if m.config.HandlerExecution == HandlerInline {
+		// Cooperative: the handler must return once ctx is done. Finishing after
+		// the deadline counts as a timeout, as it would in a separate goroutine.
+		err := callHandler(ctx, pool, job)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return true, err
+		}
+		return false, err
+	}
+
+	// The handler runs on a copy, so one abandoned by a timeout can't race the
+	// manager failing the job; one that returns in time keeps its payload and
+	// metadata, which migrations update.
+	handled := *job
+	handled.Metadata = maps.Clone(job.Metadata)
 
-	// Run job with timeout
 	done := make(chan error, 1)
 	go func() {
-		done <- pool.handler(ctx, job)
+		done <- callHandler(ctx, pool, &handled)
 	}()
 
 	select {
 	case err := <-done:
-		if err != nil {
-			MarkFailed(job, err)
-			if CanRetry(job) {
-				m.logInfo("Job failed, retrying", "job_id", job.ID, "job_name", job.Name, "attempt", job.Attempts, "error", err)
-				// Retry with backoff
-				WithDelay(job, m.config.RetryDelay*time.Duration(job.Attempts))
-				m.driver.Retry(ctx, job)
-			} else {
-				m.logError("Job failed permanently", err, "job_id", job.ID, "job_name", job.Name, "attempts", job.Attempts)
-				// Move to dead letter queue
-				m.driver.Failed(ctx, job)
-			}
-		} else {
-			MarkCompleted(job)
-			m.driver.Delete(ctx, job.ID)
-		}
+		job.Payload, job.Metadata = handled.Payload, handled.Metadata
+		return false, err
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
 
-		// Record metrics
-		if m.metricJobProcessed != nil {
-			status := "success"
-			if err != nil {
-				status = "failed"
-			}
-			attrs := metric.WithAttributes(
-				attribute.String("queue.name", pool.name),
-				attribute.String("job.status", status),
-			)
-			m.metricJobProcessed.Add(ctx, 1, attrs)
-
-			duration := float64(time.Since(job.CreatedAt).Milliseconds()) // Or use start time of processing?
-			// job.CreatedAt is creation time. We usually want processing duration.
-			// Let's rely on standard "duration from start of handler".
-			// But wait, the previous code didn't capture start time separately.
-			// Let's assume we want end-to-end latency for now or modification.
-			// Actually better to just wrap the handler execution time.
-			// Re-reading code: 'done' channel waits for handler.
-			// I'll stick to job.CreatedAt for E2E latency or I'll assume approximate duration is ok.
-			// Let's use E2E latency (CreatedAt -> Now) as "duration" for now as it's more useful for queue lag.
-			m.metricJobDuration.Record(ctx, duration, attrs)
+// callHandler runs the pool's handler, turning a panic into a *PanicError so
+// it fails the job instead of crashing the process.
+func callHandler(ctx context.Context, pool *workerPool, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
 		}
-	case <-ctx.Done():
-		MarkFailed(job, ErrJobTimeout)
-		if CanRetry(job) {
-			m.logInfo("Job timed out, retrying", "job_id", job.ID, "job_name", job.Name, "attempt", job.Attempts)
-			m.driver.Retry(context.Background(), job)
-		} else {
-			m.logError("Job timed out permanently", ErrJobTimeout, "job_id", job.ID, "job_name", job.Name, "attempts", job.Attempts)
-			m.driver.Failed(context.Background(), job)
+	}()
+	return pool.run(ctx, job)
+}
+
+// deferJob pushes a job whose handler returned ErrJobDeferred back to its
+// queue without using up an attempt.
+func (m *Manager) deferJob(ctx context.Context, job *Job) {
+	job.Attempts--
+	job.StartedAt = nil
+
+	if err := m.driver.Push(ctx, job); err != nil {
+		m.logErrorContext(ctx, "Failed to requeue deferred job", err, "job_id", job.ID, "job_name", job.Name)
+	}
+	m.recordDeferred(ctx, job)
+}
+
+// recordDeferred counts a job pushed back to the queue without running.
+func (m *Manager) recordDeferred(ctx context.Context, job *Job) {
+	if m.metricJobDeferred != nil {
+		m.metricJobDeferred.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("queue.name", job.Queue),
+			attribute.String("job.name", job.Name),
+		))
+	}
+	m.sink().IncCounter("queue.job.deferred", 1, map[string]string{
+		"queue.name": job.Queue,
+		"job.name":   job.Name,
+	})
+}
+
+// retriesExhausted reports a job that failed its last attempt.
+func (m *Manager) retriesExhausted(ctx context.Context, job *Job) {
+	if m.metricJobExhausted != nil {
+		m.metricJobExhausted.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("queue.name", job.Queue),
+			attribute.String("job.name", job.Name),
+			attribute.String("job.priority_class", m.priorityClass(job)),
+		))
+	}
+	m.sink().IncCounter("queue.job.exhausted", 1, map[string]string{
+		"queue.name":         job.Queue,
+		"job.name":           job.Name,
+		"job.priority_class": m.priorityClass(job),
+	})
+
+	m.mu.RLock()
+	hook := m.onExhausted
+	m.mu.RUnlock()
+	if hook != nil {
+		hook(job)
+	}
+}
+
+// moveToDeadLetter hands a permanently failed job to the dead letter handler,
+// falling back to the driver's failed store if no handler is set or it fails.
+func (m *Manager) moveToDeadLetter(ctx context.Context, job *Job) {
+	m.stats.deadLettered.Add(1)
+	m.batchJobDone(job, false)
+
+	m.mu.RLock()
+	handler := m.deadLetter
+	m.mu.RUnlock()
+
+	if handler != nil {
+		err := handler(ctx, job)
+		if err == nil {
+			// Release the job from drivers that hold it until acknowledged
+			m.driver.Delete(ctx, job.ID)
+			return
 		}
+		m.logErrorContext(ctx, "Dead letter handler failed, falling back to driver", err, "job_id", job.ID, "job_name", job.Name)
+	}
+
+	if err := m.driver.Failed(ctx, job); err != nil {
+		m.logErrorContext(ctx, "Failed to move job to dead letter queue", err, "job_id", job.ID, "job_name", job.Name)
 	}
 }
 
+// priorityClass returns the metrics priority class of a job.
+func (m *Manager) priorityClass(job *Job) string {
+	if m.config.PriorityClass != nil {
+		return m.config.PriorityClass(GetPriority(job))
+	}
+	return DefaultPriorityClass(GetPriority(job))
+}
+
 // dispatchJobs dispatches jobs to workers.
 func (m *Manager) dispatchJobs(ctx context.Context) {
 	defer m.wg.Done()
 
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pushed := m.notifications(ctx)
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-m.clock.After(m.pollInterval()):
 			m.fetchAndDispatchJobs()
+		case queue, ok := <-pushed:
+			if !ok {
+				// Subscription lost; keep polling
+				pushed = nil
+				continue
+			}
+			served := m.serves(queue)
+			if m.drainNotifications(pushed) || served {
+				m.fetchAndDispatchJobs()
+			}
 		case <-m.stopChan:
 			return
 		case <-ctx.Done():
@@ -337,23 +1248,244 @@ func (m *Manager) dispatchJobs(ctx context.Context) {
 	}
 }
 
+// notifications subscribes to the driver's push notifications, or returns nil
+// if it doesn't support them, leaving the dispatcher to poll.
+func (m *Manager) notifications(ctx context.Context) <-chan string {
+	notifier, ok := m.driver.(Notifier)
+	if !ok {
+		return nil
+	}
+	pushed, err := notifier.Notify(ctx)
+	if err != nil {
+		if !errors.Is(err, ErrNotSupported) {
+			m.logError("Failed to subscribe to push notifications", err)
+		}
+		return nil
+	}
+	return pushed
+}
+
+// drainNotifications discards pending notifications, so a burst of pushes
+// triggers one fetch, and reports whether any was for a served queue.
+func (m *Manager) drainNotifications(pushed <-chan string) bool {
+	served := false
+	for {
+		select {
+		case queue, ok := <-pushed:
+			if !ok {
+				return served
+			}
+			served = served || m.serves(queue)
+		default:
+			return served
+		}
+	}
+}
+
+// serves reports whether the dispatcher polls the queue.
+func (m *Manager) serves(queue string) bool {
+	return slices.Contains(m.servedQueues(), queue)
+}
+
+// pollInterval returns the next poll interval with jitter applied.
+func (m *Manager) pollInterval() time.Duration {
+	interval := m.config.PollInterval
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+
+	jitter := m.config.PollJitter
+	if jitter <= 0 {
+		return interval
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	// Spread uniformly within [interval-jitter, interval+jitter], but never
+	// down to a busy loop: a jitter of 1 could otherwise reach 0
+	offset := float64(interval) * jitter * (2*rand.Float64() - 1)
+	return max(interval+time.Duration(offset), interval/10)
+}
+
+// servedQueues returns the queues this manager polls: Config.ServeQueues, or
+// the weighted queues heaviest first (or just the default queue) followed by
+// any other queues workers are registered on.
+func (m *Manager) servedQueues() []string {
+	if len(m.config.ServeQueues) > 0 {
+		return m.config.ServeQueues
+	}
+
+	var queues []string
+	if len(m.config.QueueWeights) == 0 {
+		queues = []string{m.config.DefaultQueue}
+	} else {
+		queues = make([]string, 0, len(m.config.QueueWeights))
+		for queue := range m.config.QueueWeights {
+			queues = append(queues, queue)
+		}
+		sort.Slice(queues, func(i, j int) bool {
+			wi, wj := m.queueWeight(queues[i]), m.queueWeight(queues[j])
+			if wi != wj {
+				return wi > wj
+			}
+			return queues[i] < queues[j]
+		})
+	}
+
+	if workerQueues := m.workerQueues.Load(); workerQueues != nil {
+		for _, queue := range *workerQueues {
+			if !slices.Contains(queues, queue) {
+				queues = append(queues, queue)
+			}
+		}
+	}
+	return queues
+}
+
+// queueWeight returns how many jobs the queue may pop per round.
+func (m *Manager) queueWeight(queue string) int {
+	return max(m.config.QueueWeights[queue], 1)
+}
+
 // fetchAndDispatchJobs fetches jobs from the driver and dispatches to workers.
 func (m *Manager) fetchAndDispatchJobs() {
 	ctx := context.Background()
+
+	// Honor the global pause flag; fail open if it can't be read
+	if paused, err := m.GlobalPaused(ctx); err == nil && paused {
+		return
+	}
+
+	queues := m.unpaused(m.servedQueues())
+	if m.config.DispatchStrategy != DispatchCapacity {
+		// One round: each queue pops up to its weight
+		for _, queue := range queues {
+			m.fetchAndDispatchN(ctx, queue, m.queueWeight(queue))
+		}
+		return
+	}
+
+	// Keep popping in weighted rounds while pools can take work, so jobs for
+	// pools with free capacity aren't held back behind jobs for a saturated
+	// pool. Each round splits the remaining budget by weight, so no queue takes
+	// all of it and batch-popping drivers fetch many jobs per round trip.
+	// Deferred jobs use up the budget too, bounding the work per poll.
+	budget := m.freeCapacity()
+	active := slices.Clone(queues)
+	for budget > 0 && len(active) > 0 {
+		total := 0
+		for _, queue := range active {
+			total += m.queueWeight(queue)
+		}
+
+		share := budget
+		next := active[:0]
+		for _, queue := range active {
+			weight := m.queueWeight(queue)
+			n := min(max(share*weight/total, weight), budget)
+			popped := m.fetchAndDispatchN(ctx, queue, n)
+			budget -= popped
+			if popped == n {
+				next = append(next, queue)
+			}
+			if budget == 0 {
+				break
+			}
+		}
+		active = next
+	}
+}
+
+// fetchAndDispatchN pops up to n jobs from the queue, in one round trip if the
+// driver is a BatchPopper, and returns how many were popped.
+func (m *Manager) fetchAndDispatchN(ctx context.Context, queue string, n int) int {
+	if popper, ok := m.driver.(BatchPopper); ok && n > 1 {
+		jobs, err := popper.PopN(ctx, queue, n)
+		if err != nil {
+			if errors.Is(err, ErrInvalidPayload) {
+				m.logError("Failed to decode popped job", err, "queue", queue)
+			}
+			return 0
+		}
+		for _, job := range jobs {
+			m.deliverJob(ctx, job)
+		}
+		return len(jobs)
+	}
+
+	for i := 0; i < n; i++ {
+		if !m.fetchAndDispatchFrom(ctx, queue) {
+			return i
+		}
+	}
+	return n
+}
+
+// freeCapacity returns how many more jobs the worker pools can buffer right now.
+func (m *Manager) freeCapacity() int {
+	free := 0
+	for _, stat := range m.PoolStats() {
+		free += stat.Capacity - stat.Buffered
+	}
+	return free
+}
+
+// fetchAndDispatchFrom pops one job from the queue and hands it to its worker pool.
+// It reports whether a job was popped.
+func (m *Manager) fetchAndDispatchFrom(ctx context.Context, queue string) bool {
 	// Pop ONE job at a time (not one per worker!)
-	job, err := m.driver.Pop(ctx, m.config.DefaultQueue)
+	job, err := m.driver.Pop(ctx, queue)
 	if err != nil {
+		if errors.Is(err, ErrInvalidPayload) {
+			m.logError("Failed to decode popped job", err, "queue", queue)
+		}
+		return false
+	}
+
+	m.deliverJob(ctx, job)
+	return true
+}
+
+// deliverJob hands a popped job to its worker pool, or requeues or
+// dead-letters it if it can't run.
+func (m *Manager) deliverJob(ctx context.Context, job *Job) {
+	// Jobs the driver couldn't verify never reach a handler
+	if reason, _ := job.Metadata[MetadataDeadLetterReason].(string); reason == ReasonInvalidSignature {
+		m.logError("Job signature is invalid", ErrBadSignature, "job_id", job.ID, "job_name", job.Name)
+		MarkFailed(job, ErrBadSignature)
+		m.moveToDeadLetter(ctx, job)
 		return
 	}
 
 	// Find the worker for this job
-	m.mu.RLock()
-	pool, exists := m.workers[job.Name]
-	m.mu.RUnlock()
+	pool, exists := m.poolFor(job)
 
 	if !exists {
-		// No worker registered for this job type -> dead letter queue
-		m.driver.Failed(ctx, job)
+		if pool = m.routeUnhandled(ctx, job); pool == nil {
+			return
+		}
+	}
+
+	// Jobs that can no longer start in time are dropped before reaching a worker
+	if deadline, ok := StartDeadline(job); ok && !m.clock.Now().Before(deadline) {
+		m.logInfo("Job missed its start deadline", "job_id", job.ID, "job_name", job.Name, "deadline", deadline)
+		MarkFailed(job, ErrStartDeadline)
+		WithMetadata(job, MetadataDeadLetterReason, ReasonStartDeadlineExceeded)
+		m.moveToDeadLetter(ctx, job)
+		return
+	}
+
+	// Ordered pools wait for the job's lane rather than deferring it,
+	// since pushing it back would let later jobs for the same key overtake it
+	if pool.lanes != nil {
+		select {
+		case pool.lane(job) <- job:
+		case <-m.stopChan:
+			if err := m.driver.Push(ctx, job); err != nil {
+				m.logError("Failed to requeue ordered job", err, "job_id", job.ID, "job_name", job.Name)
+			}
+		}
 		return
 	}
 
@@ -363,6 +1495,11 @@ func (m *Manager) fetchAndDispatchJobs() {
 		// Successfully dispatched
 	default:
 		// Worker pool is full, push job back to queue
-		m.driver.Push(ctx, job)
+		if err := m.driver.Push(ctx, job); err != nil {
+			m.logError("Failed to requeue deferred job", err, "job_id", job.ID, "job_name", job.Name)
+		}
+
+		// A rising deferred rate means the pool is under-provisioned
+		m.recordDeferred(ctx, job)
 	}
 }
@@ -1,6 +1,21 @@
 package dgqueue
 
-import "fmt"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log formats for Config.LogFormat.
+const (
+	// LogFormatText prints "[Queue] message key=value" lines
+	LogFormatText = "text"
+	// LogFormatJSON prints one JSON object per line
+	LogFormatJSON = "json"
+)
 
 // logInfo logs an informational message.
 func (m *Manager) logInfo(msg string, args ...interface{}) {
@@ -14,15 +29,40 @@ func (m *Manager) logInfo(msg string, args ...interface{}) {
 		// We don't want to spam stdout for every job
 		if msg == "Queue manager starting" || msg == "Queue manager started" ||
 			msg == "Queue manager stopping" || msg == "Queue manager stopped" {
-			fmt.Printf("[Queue] %s", msg)
+			if m.config.LogFormat == LogFormatJSON {
+				m.logJSON("info", msg, args)
+				return
+			}
+			fmt.Fprintf(m.logOutput, "[Queue] %s", msg)
 			for i := 0; i < len(args); i += 2 {
 				if i+1 < len(args) {
-					fmt.Printf(" %v=%v", args[i], args[i+1])
+					fmt.Fprintf(m.logOutput, " %v=%v", args[i], args[i+1])
 				}
 			}
-			fmt.Println()
+			fmt.Fprintln(m.logOutput)
+		}
+	}
+}
+
+// logWarn logs a warning that needs attention but isn't an error.
+func (m *Manager) logWarn(msg string, args ...interface{}) {
+	if m.config.Logger != nil {
+		fullArgs := append([]interface{}{"component", "queue"}, args...)
+		m.config.Logger.Warn(msg, fullArgs...)
+		return
+	}
+
+	if m.config.LogFormat == LogFormatJSON {
+		m.logJSON("warn", msg, args)
+		return
+	}
+	fmt.Fprintf(m.logOutput, "[Queue] WARN: %s", msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(m.logOutput, " %v=%v", args[i], args[i+1])
 		}
 	}
+	fmt.Fprintln(m.logOutput)
 }
 
 // logError logs an error message.
@@ -32,13 +72,76 @@ func (m *Manager) logError(msg string, err error, args ...interface{}) {
 		newArgs := append([]interface{}{"component", "queue", "error", err}, args...)
 		m.config.Logger.Error(msg, newArgs...)
 	} else {
+		if m.config.LogFormat == LogFormatJSON {
+			m.logJSON("error", msg, append([]interface{}{"error", err}, args...))
+			return
+		}
 		// Fallback to fmt.Printf
-		fmt.Printf("[Queue] ERROR: %s: %v", msg, err)
+		fmt.Fprintf(m.logOutput, "[Queue] ERROR: %s: %v", msg, err)
 		for i := 0; i < len(args); i += 2 {
 			if i+1 < len(args) {
-				fmt.Printf(" %v=%v", args[i], args[i+1])
+				fmt.Fprintf(m.logOutput, " %v=%v", args[i], args[i+1])
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(m.logOutput)
+	}
+}
+
+// logInfoContext logs an informational message with the trace of ctx, if any.
+func (m *Manager) logInfoContext(ctx context.Context, msg string, args ...interface{}) {
+	m.logInfo(msg, append(args, traceLogArgs(ctx)...)...)
+}
+
+// logErrorContext logs an error message with the trace of ctx, if any.
+func (m *Manager) logErrorContext(ctx context.Context, msg string, err error, args ...interface{}) {
+	m.logError(msg, err, append(args, traceLogArgs(ctx)...)...)
+}
+
+// traceLogArgs returns trace_id and span_id fields for the span carried by ctx,
+// so job logs can be cross-referenced with traces.
+func traceLogArgs(ctx context.Context) []interface{} {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []interface{}{
+		"trace_id", spanContext.TraceID().String(),
+		"span_id", spanContext.SpanID().String(),
+	}
+}
+
+// logJSON prints a fallback log entry as a single JSON line.
+func (m *Manager) logJSON(level, msg string, args []interface{}) {
+	entry := map[string]interface{}{
+		"time":      time.Now().Format(time.RFC3339Nano),
+		"level":     level,
+		"component": "queue",
+		"msg":       msg,
+	}
+	for i := 0; i+1 < len(args); i += 2 {
+		entry[fmt.Sprint(args[i])] = jsonLogValue(args[i+1])
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Fprintf(m.logOutput, "{\"level\":\"error\",\"component\":\"queue\",\"msg\":%q}\n", "failed to encode log entry: "+err.Error())
+		return
+	}
+	fmt.Fprintln(m.logOutput, string(data))
+}
+
+// jsonLogValue converts a log value into something encoding/json renders usefully.
+func jsonLogValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case error:
+		return val.Error()
+	case fmt.Stringer:
+		return val.String()
+	case nil, string, bool, int, int64, float64, []string:
+		return val
+	}
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Sprint(v)
 	}
+	return v
 }
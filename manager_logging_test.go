@@ -0,0 +1,103 @@
+package dgqueue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestManager_JSONFallbackLogging(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LogFormat = LogFormatJSON
+
+	var out bytes.Buffer
+	m := New(cfg)
+	m.logOutput = &out
+
+	m.logInfo("Queue manager started", "workers", 3)
+	m.logError("Failed to requeue deferred job", errors.New("boom"), "job_id", "abc", "job_name", "send-email")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var info map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &info))
+	assert.Equal(t, "info", info["level"])
+	assert.Equal(t, "queue", info["component"])
+	assert.Equal(t, "Queue manager started", info["msg"])
+	assert.Equal(t, float64(3), info["workers"])
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "error", entry["level"])
+	assert.Equal(t, "queue", entry["component"])
+	assert.Equal(t, "boom", entry["error"])
+	assert.Equal(t, "abc", entry["job_id"])
+	assert.Equal(t, "send-email", entry["job_name"])
+}
+
+func TestManager_TextFallbackLogging(t *testing.T) {
+	var out bytes.Buffer
+	m := New(DefaultConfig())
+	m.logOutput = &out
+
+	m.logError("Failed to close driver", errors.New("boom"), "queue", "default")
+	assert.Equal(t, "[Queue] ERROR: Failed to close driver: boom queue=default\n", out.String())
+
+	// Routine messages stay quiet without a logger
+	out.Reset()
+	m.logInfo("Job failed, retrying", "job_id", "abc")
+	assert.Empty(t, out.String())
+}
+
+// spanContext returns a context carrying a sampled remote span.
+func spanContext(t *testing.T) (context.Context, trace.SpanContext) {
+	t.Helper()
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	assert.NoError(t, err)
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	return trace.ContextWithSpanContext(context.Background(), spanContext), spanContext
+}
+
+func TestManager_LogsIncludeTraceContext(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LogFormat = LogFormatJSON
+
+	var out bytes.Buffer
+	m := New(cfg)
+	m.logOutput = &out
+	m.SetDriver(emptyDriver{})
+	m.deadLetter = func(ctx context.Context, job *Job) error { return errors.New("store down") }
+
+	ctx, spanContext := spanContext(t)
+	m.moveToDeadLetter(ctx, NewJob("send-email", nil))
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "Dead letter handler failed, falling back to driver", entry["msg"])
+	assert.Equal(t, spanContext.TraceID().String(), entry["trace_id"])
+	assert.Equal(t, spanContext.SpanID().String(), entry["span_id"])
+}
+
+func TestManager_LogsWithoutTraceContext(t *testing.T) {
+	var out bytes.Buffer
+	m := New(DefaultConfig())
+	m.logOutput = &out
+
+	m.logErrorContext(context.Background(), "Failed to requeue deferred job", errors.New("boom"), "job_id", "abc")
+	assert.Equal(t, "[Queue] ERROR: Failed to requeue deferred job: boom job_id=abc\n", out.String())
+}
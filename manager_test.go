@@ -0,0 +1,1204 @@
+package dgqueue_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_DeadLetterHandler(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxAttempts = 2
+	cfg.RetryDelay = 10 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	deadLettered := make(chan *dgqueue.Job, 1)
+	manager.SetDeadLetterHandler(func(ctx context.Context, job *dgqueue.Job) error {
+		deadLettered <- job
+		return nil
+	})
+
+	manager.Worker("always-fails", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		return errors.New("boom")
+	})
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(context.Background())
+
+	job, err := manager.Dispatch(context.Background(), "always-fails", "payload")
+	assert.NoError(t, err)
+
+	select {
+	case failed := <-deadLettered:
+		assert.Equal(t, job.ID, failed.ID)
+		assert.Equal(t, 2, failed.Attempts)
+		assert.Equal(t, "boom", failed.Error)
+		assert.NotNil(t, failed.FailedAt)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected dead letter handler to be called")
+	}
+
+	// Handled by the dead letter handler, so not stored by the driver
+	_, err = d.Get(context.Background(), job.ID)
+	assert.ErrorIs(t, err, dgqueue.ErrJobNotFound)
+}
+
+func TestManager_DeadLetterHandlerFallback(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxAttempts = 1
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	manager.SetDeadLetterHandler(func(ctx context.Context, job *dgqueue.Job) error {
+		return errors.New("incident system unavailable")
+	})
+
+	manager.Worker("always-fails", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		return errors.New("boom")
+	})
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(context.Background())
+
+	job, err := manager.Dispatch(context.Background(), "always-fails", "payload")
+	assert.NoError(t, err)
+
+	// Handler failed, so the job falls back to the driver's failed store
+	assert.Eventually(t, func() bool {
+		stored, err := d.Get(context.Background(), job.ID)
+		return err == nil && stored.FailedAt != nil
+	}, 3*time.Second, 50*time.Millisecond)
+}
+
+func TestManager_DeadLettersUnverifiedJobs(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	deadLettered := make(chan *dgqueue.Job, 1)
+	manager.SetDeadLetterHandler(func(ctx context.Context, job *dgqueue.Job) error {
+		deadLettered <- job
+		return nil
+	})
+
+	var ran atomic.Bool
+	manager.Worker("charge-card", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		ran.Store(true)
+		return nil
+	})
+
+	// As a signing driver returns a job whose signature doesn't verify
+	job := manager.NewJob("charge-card", map[string]interface{}{"amount": 900})
+	dgqueue.WithMetadata(job, dgqueue.MetadataDeadLetterReason, dgqueue.ReasonInvalidSignature)
+	assert.NoError(t, manager.Enqueue(context.Background(), job))
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(context.Background())
+
+	select {
+	case rejected := <-deadLettered:
+		assert.Equal(t, job.ID, rejected.ID)
+		assert.Equal(t, dgqueue.ErrBadSignature.Error(), rejected.Error)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected the unverified job to be dead-lettered")
+	}
+	assert.False(t, ran.Load())
+}
+
+func TestManager_Reload(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	d1, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d1)
+
+	var mu sync.Mutex
+	processed := make(map[string]bool)
+	manager.Worker("reload-job", 2, func(ctx context.Context, job *dgqueue.Job) error {
+		mu.Lock()
+		processed[job.ID] = true
+		mu.Unlock()
+		return nil
+	})
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(context.Background())
+
+	ctx := context.Background()
+	var ids []string
+	for i := 0; i < 5; i++ {
+		job, err := manager.Dispatch(ctx, "reload-job", i)
+		assert.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+
+	// Swap drivers mid-operation
+	d2, _ := memory.NewDriver(cfg)
+	assert.NoError(t, manager.Reload(ctx, cfg, d2))
+	assert.Equal(t, d2, manager.Driver())
+
+	for i := 0; i < 5; i++ {
+		job, err := manager.Dispatch(ctx, "reload-job", i)
+		assert.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, id := range ids {
+			if !processed[id] {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 50*time.Millisecond, "Expected all jobs to be processed across the reload")
+}
+
+func TestManager_ReloadMovesPendingJobs(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	d1, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d1)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := manager.Dispatch(ctx, "pending-job", i)
+		assert.NoError(t, err)
+	}
+
+	d2, _ := memory.NewDriver(cfg)
+	assert.NoError(t, manager.Reload(ctx, cfg, d2))
+
+	size, err := d2.Size(ctx, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), size)
+}
+
+func TestManager_ReloadMovesDelayedAndFailedJobs(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	d1, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d1)
+
+	ctx := context.Background()
+	delayed, err := manager.DispatchAfter(ctx, "pending-job", "later", time.Hour)
+	assert.NoError(t, err)
+	failed := manager.NewJob("pending-job", "failed")
+	assert.NoError(t, d1.Failed(ctx, failed))
+
+	d2, _ := memory.NewDriver(cfg)
+	assert.NoError(t, manager.Reload(ctx, cfg, d2))
+
+	moved, err := d2.Get(ctx, delayed.ID)
+	assert.NoError(t, err)
+	assert.False(t, dgqueue.IsAvailable(moved), "Expected the job to stay delayed")
+	_, err = d2.Get(ctx, failed.ID)
+	assert.NoError(t, err)
+
+	_, err = d1.Get(ctx, delayed.ID)
+	assert.ErrorIs(t, err, dgqueue.ErrJobNotFound)
+	_, err = d1.Get(ctx, failed.ID)
+	assert.ErrorIs(t, err, dgqueue.ErrJobNotFound)
+}
+
+// blockingPushDriver is a memory driver whose pushes wait for release, so a
+// dispatch can be caught in flight.
+type blockingPushDriver struct {
+	dgqueue.Driver
+	pushing chan struct{}
+	release chan struct{}
+}
+
+func (d *blockingPushDriver) Push(ctx context.Context, job *dgqueue.Job) error {
+	d.pushing <- struct{}{}
+	<-d.release
+	return d.Driver.Push(ctx, job)
+}
+
+func TestManager_ReloadWaitsForDispatchesInFlight(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	d1, _ := memory.NewDriver(cfg)
+	blocking := &blockingPushDriver{Driver: d1, pushing: make(chan struct{}, 32), release: make(chan struct{})}
+	manager.SetDriver(blocking)
+
+	ctx := context.Background()
+	dispatched := make(chan *dgqueue.Job, 1)
+	go func() {
+		job, err := manager.Dispatch(ctx, "in-flight", "payload")
+		assert.NoError(t, err)
+		dispatched <- job
+	}()
+	<-blocking.pushing
+
+	d2, _ := memory.NewDriver(cfg)
+	reloaded := make(chan error, 1)
+	go func() { reloaded <- manager.Reload(ctx, cfg, d2) }()
+
+	// Dispatches that start during the reload go to either driver
+	var wg sync.WaitGroup
+	ids := make(chan string, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job := manager.NewJob("during-reload", i)
+			assert.NoError(t, manager.Enqueue(ctx, job))
+			ids <- job.ID
+		}()
+	}
+
+	select {
+	case <-reloaded:
+		t.Fatal("Expected Reload to wait for the dispatch in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(blocking.release)
+	assert.NoError(t, <-reloaded)
+	wg.Wait()
+	close(ids)
+
+	job := <-dispatched
+	_, err := d2.Get(ctx, job.ID)
+	assert.NoError(t, err, "Expected the job dispatched in flight to move to the new driver")
+	for id := range ids {
+		_, err := d2.Get(ctx, id)
+		assert.NoError(t, err, "Expected job %s dispatched during the reload on the new driver", id)
+	}
+}
+
+// sameBackendDriver is a view of a memory driver reporting a backend ID, as
+// drivers connected to the same server do.
+type sameBackendDriver struct {
+	dgqueue.Driver
+	pops atomic.Int64
+}
+
+func (d *sameBackendDriver) BackendID() string {
+	return "shared"
+}
+
+func (d *sameBackendDriver) Pop(ctx context.Context, queueName string) (*dgqueue.Job, error) {
+	d.pops.Add(1)
+	return d.Driver.Pop(ctx, queueName)
+}
+
+func (d *sameBackendDriver) Close() error {
+	return nil
+}
+
+func TestManager_ReloadSameBackendLeavesJobs(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	backend, _ := memory.NewDriver(cfg)
+	d1 := &sameBackendDriver{Driver: backend}
+	manager.SetDriver(d1)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := manager.Dispatch(ctx, "pending-job", i)
+		assert.NoError(t, err)
+	}
+
+	d2 := &sameBackendDriver{Driver: backend}
+	assert.NoError(t, manager.Reload(ctx, cfg, d2))
+
+	assert.Zero(t, d1.pops.Load(), "Expected no jobs to be moved")
+	size, err := d2.Size(ctx, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), size)
+}
+
+// cappedDriver is a memory driver that can only delay jobs up to maxDelay.
+type cappedDriver struct {
+	dgqueue.Driver
+	maxDelay time.Duration
+}
+
+func (d *cappedDriver) MaxDelay() time.Duration {
+	return d.maxDelay
+}
+
+func TestManager_DispatchAfterCappedDelay(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	inner, _ := memory.NewDriver(cfg)
+	manager.SetDriver(&cappedDriver{Driver: inner, maxDelay: 15 * time.Minute})
+
+	ctx := context.Background()
+	job, err := manager.DispatchAfter(ctx, "delayed-job", "payload", time.Hour)
+	assert.NoError(t, err)
+
+	// Returned job reports the effective availability, not the requested one
+	assert.Equal(t, 15*time.Minute, job.Delay)
+	assert.Equal(t, job.CreatedAt.Add(15*time.Minute), job.AvailableAt)
+
+	stored, err := inner.Get(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, job.AvailableAt, stored.AvailableAt)
+}
+
+func TestManager_EnqueueCappedDelay(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	inner, _ := memory.NewDriver(cfg)
+	manager.SetDriver(&cappedDriver{Driver: inner, maxDelay: 15 * time.Minute})
+
+	ctx := context.Background()
+	job := manager.NewJob("delayed-job", "payload")
+	job.AvailableAt = job.CreatedAt.Add(time.Hour)
+	assert.NoError(t, manager.Enqueue(ctx, job))
+	assert.Equal(t, job.CreatedAt.Add(15*time.Minute), job.AvailableAt)
+
+	cron, err := manager.DispatchAtNextCron(ctx, "delayed-job", "payload", "0 0 1 1 *")
+	assert.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, cron.Delay)
+}
+
+func TestManager_DispatchAfterUncapped(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	job, err := manager.DispatchAfter(context.Background(), "delayed-job", "payload", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, job.CreatedAt.Add(time.Hour), job.AvailableAt)
+}
+
+func TestManager_GlobalPause(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	d, _ := memory.NewDriver(cfg)
+
+	// Two instances sharing one backend
+	var mu sync.Mutex
+	processed := 0
+	handler := func(ctx context.Context, job *dgqueue.Job) error {
+		mu.Lock()
+		processed++
+		mu.Unlock()
+		return nil
+	}
+
+	m1 := dgqueue.New(cfg)
+	m1.SetDriver(d)
+	m1.Worker("paused-job", 1, handler)
+	m2 := dgqueue.New(cfg)
+	m2.SetDriver(d)
+	m2.Worker("paused-job", 1, handler)
+
+	ctx := context.Background()
+	assert.NoError(t, m1.SetGlobalPause(ctx, true))
+
+	assert.NoError(t, m1.Start())
+	defer m1.Stop(ctx)
+	assert.NoError(t, m2.Start())
+	defer m2.Stop(ctx)
+
+	for i := 0; i < 4; i++ {
+		_, err := m2.Dispatch(ctx, "paused-job", i)
+		assert.NoError(t, err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, 0, processed, "Expected no jobs processed while globally paused")
+	mu.Unlock()
+
+	paused, err := m2.GlobalPaused(ctx)
+	assert.NoError(t, err)
+	assert.True(t, paused)
+
+	assert.NoError(t, m2.SetGlobalPause(ctx, false))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return processed == 4
+	}, 3*time.Second, 50*time.Millisecond)
+}
+
+func TestManager_GlobalPauseNotSupported(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	inner, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(&cappedDriver{Driver: inner})
+
+	err := manager.SetGlobalPause(context.Background(), true)
+	assert.ErrorIs(t, err, dgqueue.ErrNotSupported)
+}
+
+func TestManager_NewJobEnqueue(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.DefaultQueue = "main"
+	cfg.MaxAttempts = 7
+	cfg.Timeout = time.Minute
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	job := manager.NewJob("custom-job", "payload")
+	assert.Equal(t, "main", job.Queue)
+	assert.Equal(t, 7, job.MaxAttempts)
+	assert.Equal(t, time.Minute, job.Timeout)
+
+	dgqueue.WithMetadata(job, "source", "import")
+	ctx := context.Background()
+	assert.NoError(t, manager.Enqueue(ctx, job))
+
+	stored, err := d.Get(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "import", stored.Metadata["source"])
+}
+
+func TestManager_DispatchAtNextCron(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	ctx := context.Background()
+	job, err := manager.DispatchAtNextCron(ctx, "hourly-report", "payload", "0 * * * *")
+	assert.NoError(t, err)
+
+	schedule, _ := cron.ParseStandard("0 * * * *")
+	expected := schedule.Next(job.CreatedAt)
+	assert.True(t, expected.Equal(job.AvailableAt))
+	assert.Equal(t, 0, job.AvailableAt.Minute())
+	assert.True(t, job.AvailableAt.After(job.CreatedAt))
+
+	stored, err := d.Get(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.True(t, expected.Equal(stored.AvailableAt))
+}
+
+func TestManager_DispatchAtNextCronInvalid(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	_, err := manager.DispatchAtNextCron(context.Background(), "hourly-report", "payload", "not a cron")
+	assert.ErrorIs(t, err, dgqueue.ErrInvalidCron)
+}
+
+func TestManager_ServeQueues(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.ServeQueues = []string{"emails"}
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	var mu sync.Mutex
+	processed := make(map[string]bool)
+	manager.Worker("send", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		mu.Lock()
+		processed[job.Queue] = true
+		mu.Unlock()
+		return nil
+	})
+
+	ctx := context.Background()
+	for _, queue := range []string{"emails", "reports", "default"} {
+		job := manager.NewJob("send", queue)
+		dgqueue.WithQueue(job, queue)
+		assert.NoError(t, manager.Enqueue(ctx, job))
+	}
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return processed["emails"]
+	}, 3*time.Second, 50*time.Millisecond)
+
+	// Give the dispatcher a few more polls to prove the others are ignored
+	time.Sleep(300 * time.Millisecond)
+	for _, queue := range []string{"reports", "default"} {
+		size, err := d.Size(ctx, queue)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), size, "Expected %s to be left untouched", queue)
+	}
+}
+
+func TestManager_StartDeadlineExceeded(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	deadLettered := make(chan *dgqueue.Job, 1)
+	manager.SetDeadLetterHandler(func(ctx context.Context, job *dgqueue.Job) error {
+		deadLettered <- job
+		return nil
+	})
+
+	var mu sync.Mutex
+	var ran []string
+	manager.Worker("morning-report", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		mu.Lock()
+		ran = append(ran, job.ID)
+		mu.Unlock()
+		return nil
+	})
+
+	ctx := context.Background()
+	late := manager.NewJob("morning-report", "late")
+	dgqueue.WithStartDeadline(late, time.Now().Add(-time.Minute))
+	assert.NoError(t, manager.Enqueue(ctx, late))
+
+	onTime := manager.NewJob("morning-report", "on-time")
+	dgqueue.WithStartDeadline(onTime, time.Now().Add(time.Hour))
+	assert.NoError(t, manager.Enqueue(ctx, onTime))
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	select {
+	case job := <-deadLettered:
+		assert.Equal(t, late.ID, job.ID)
+		assert.Equal(t, dgqueue.ReasonStartDeadlineExceeded, job.Metadata[dgqueue.MetadataDeadLetterReason])
+		assert.Equal(t, 0, job.Attempts)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected late job to be dead-lettered")
+	}
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(ran) == 1
+	}, 3*time.Second, 50*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []string{onTime.ID}, ran, "Expected only the on-time job to reach the worker")
+	mu.Unlock()
+}
+
+// laggyDriver hides jobs from Get until some time after they were pushed,
+// like an eventually consistent backend.
+type laggyDriver struct {
+	dgqueue.Driver
+	lag time.Duration
+}
+
+func (d *laggyDriver) Get(ctx context.Context, jobID string) (*dgqueue.Job, error) {
+	job, err := d.Driver.Get(ctx, jobID)
+	if err == nil && time.Since(job.CreatedAt) < d.lag {
+		return nil, dgqueue.ErrJobNotFound
+	}
+	return job, err
+}
+
+func TestManager_StatusWithin(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	inner, _ := memory.NewDriver(cfg)
+	manager.SetDriver(&laggyDriver{Driver: inner, lag: 100 * time.Millisecond})
+
+	ctx := context.Background()
+	job, err := manager.Dispatch(ctx, "status-job", nil)
+	assert.NoError(t, err)
+
+	// A plain lookup races the driver
+	_, err = manager.Status(ctx, job.ID)
+	assert.ErrorIs(t, err, dgqueue.ErrJobNotFound)
+
+	status, err := manager.StatusWithin(ctx, job.ID, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, job.ID, status.ID)
+	assert.Equal(t, "pending", status.Status)
+}
+
+func TestManager_StatusWithinGivesUp(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	start := time.Now()
+	_, err := manager.StatusWithin(context.Background(), "missing", 100*time.Millisecond)
+	assert.ErrorIs(t, err, dgqueue.ErrJobNotFound)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = manager.StatusWithin(ctx, "missing", time.Second)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// blindDriver is a memory driver that reports it can't look jobs up by ID.
+type blindDriver struct {
+	dgqueue.Driver
+}
+
+func (d *blindDriver) Capabilities() dgqueue.Capabilities {
+	return dgqueue.Capabilities{Delete: true}
+}
+
+func TestManager_Capabilities(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	manager := dgqueue.New(cfg)
+	inner, _ := memory.NewDriver(cfg)
+
+	// Drivers that don't report capabilities support everything
+	manager.SetDriver(inner)
+	assert.Equal(t, dgqueue.Capabilities{Get: true, Delete: true}, manager.Capabilities())
+
+	manager.SetDriver(&blindDriver{Driver: inner})
+	assert.False(t, manager.Capabilities().Get)
+
+	ctx := context.Background()
+	job, err := manager.Dispatch(ctx, "status-job", nil)
+	assert.NoError(t, err)
+
+	// Lookups fail clearly instead of returning the driver's error or retrying
+	_, err = manager.Status(ctx, job.ID)
+	assert.ErrorIs(t, err, dgqueue.ErrNotSupported)
+	_, err = manager.StatusWithin(ctx, job.ID, time.Second)
+	assert.ErrorIs(t, err, dgqueue.ErrNotSupported)
+	_, err = manager.ExportJob(ctx, job.ID)
+	assert.ErrorIs(t, err, dgqueue.ErrNotSupported)
+}
+
+func TestManager_PriorityOrder(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 10 * time.Millisecond
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	var mu sync.Mutex
+	var order []string
+	manager.Worker("notify", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, job.Payload.(string))
+		return nil
+	})
+
+	// A notification enqueued behind bulk jobs still runs first
+	ctx := context.Background()
+	for _, name := range []string{"bulk-1", "bulk-2", "bulk-3"} {
+		manager.Enqueue(ctx, dgqueue.WithPriority(manager.NewJob("notify", name), dgqueue.PriorityLow))
+	}
+	manager.Enqueue(ctx, dgqueue.WithPriority(manager.NewJob("notify", "urgent"), dgqueue.PriorityHigh))
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(order) == 4
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"urgent", "bulk-1", "bulk-2", "bulk-3"}, order)
+}
+
+// popRecorder records the queue of every job popped from the driver.
+type popRecorder struct {
+	dgqueue.Driver
+	mu     sync.Mutex
+	popped []string
+}
+
+func (d *popRecorder) Pop(ctx context.Context, queue string) (*dgqueue.Job, error) {
+	job, err := d.Driver.Pop(ctx, queue)
+	if err == nil {
+		d.mu.Lock()
+		d.popped = append(d.popped, queue)
+		d.mu.Unlock()
+	}
+	return job, err
+}
+
+func (d *popRecorder) first(n int) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.popped) < n {
+		return nil
+	}
+	return slices.Clone(d.popped[:n])
+}
+
+func TestManager_QueueWeights(t *testing.T) {
+	for _, strategy := range []string{dgqueue.DispatchFIFO, dgqueue.DispatchCapacity} {
+		t.Run(strategy, func(t *testing.T) {
+			cfg := dgqueue.DefaultConfig()
+			cfg.PollInterval = 5 * time.Millisecond
+			cfg.DispatchStrategy = strategy
+			cfg.QueueWeights = map[string]int{"critical": 3, "default": 1}
+
+			manager := dgqueue.New(cfg)
+			inner, _ := memory.NewDriver(cfg)
+			driver := &popRecorder{Driver: inner}
+			manager.SetDriver(driver)
+			manager.Worker("send", 20, func(ctx context.Context, job *dgqueue.Job) error { return nil })
+
+			ctx := context.Background()
+			for i := 0; i < 100; i++ {
+				for _, queue := range []string{"default", "critical"} {
+					assert.NoError(t, manager.Enqueue(ctx, dgqueue.WithQueue(manager.NewJob("send", i), queue)))
+				}
+			}
+
+			assert.NoError(t, manager.Start())
+			defer manager.Stop(ctx)
+
+			if strategy == dgqueue.DispatchFIFO {
+				// Both weighted queues are served, three critical jobs per default one
+				var popped []string
+				assert.Eventually(t, func() bool {
+					popped = driver.first(8)
+					return popped != nil
+				}, time.Second, 5*time.Millisecond)
+				assert.Equal(t, []string{
+					"critical", "critical", "critical", "default",
+					"critical", "critical", "critical", "default",
+				}, popped)
+				return
+			}
+
+			// The first poll splits the pools' 40 free slots by weight
+			var popped []string
+			assert.Eventually(t, func() bool {
+				popped = driver.first(40)
+				return popped != nil
+			}, time.Second, 5*time.Millisecond)
+			counts := make(map[string]int)
+			for _, queue := range popped {
+				counts[queue]++
+			}
+			assert.Equal(t, map[string]int{"critical": 30, "default": 10}, counts)
+		})
+	}
+}
+
+// batchRecorder is a memory driver that counts Pop and PopN calls.
+type batchRecorder struct {
+	*memory.Driver
+	pops, popNs atomic.Int64
+}
+
+func (d *batchRecorder) Pop(ctx context.Context, queue string) (*dgqueue.Job, error) {
+	d.pops.Add(1)
+	return d.Driver.Pop(ctx, queue)
+}
+
+func (d *batchRecorder) PopN(ctx context.Context, queue string, n int) ([]*dgqueue.Job, error) {
+	d.popNs.Add(1)
+	return d.Driver.PopN(ctx, queue, n)
+}
+
+func TestManager_BatchPop(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 20 * time.Millisecond
+	cfg.DispatchStrategy = dgqueue.DispatchCapacity
+
+	manager := dgqueue.New(cfg)
+	inner, _ := memory.NewDriver(cfg)
+	driver := &batchRecorder{Driver: inner.(*memory.Driver)}
+	manager.SetDriver(driver)
+
+	var processed atomic.Int64
+	manager.Worker("bulk", 50, func(ctx context.Context, job *dgqueue.Job) error {
+		processed.Add(1)
+		return nil
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 500; i++ {
+		manager.Dispatch(ctx, "bulk", i)
+	}
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	// 100 free slots per poll drain the queue in a handful of round trips
+	assert.Eventually(t, func() bool {
+		return processed.Load() == 500
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Zero(t, driver.pops.Load())
+	assert.Less(t, driver.popNs.Load(), int64(50))
+}
+
+func TestManager_WorkerOn(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	driver, _ := memory.NewDriver(cfg)
+	manager.SetDriver(driver)
+
+	var mu sync.Mutex
+	handled := make(map[string][]string)
+	handler := func(pool string) dgqueue.WorkerFunc {
+		return func(ctx context.Context, job *dgqueue.Job) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handled[pool] = append(handled[pool], job.Queue)
+			return nil
+		}
+	}
+	assert.NoError(t, manager.Worker("send", 1, handler("any")))
+	assert.NoError(t, manager.WorkerOn("emails", "send", 3, handler("emails")))
+	assert.ErrorIs(t, manager.WorkerOn("", "send", 1, handler("none")), dgqueue.ErrInvalidConfig)
+
+	// Each pool has its own budget
+	stats := manager.PoolStats()
+	assert.Equal(t, 1, stats["send"].Concurrency)
+	assert.Equal(t, 3, stats["emails/send"].Concurrency)
+
+	ctx := context.Background()
+	for _, queue := range []string{"default", "emails", "emails"} {
+		assert.NoError(t, manager.Enqueue(ctx, dgqueue.WithQueue(manager.NewJob("send", nil), queue)))
+	}
+
+	// The emails queue is served without listing it in ServeQueues
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled["any"])+len(handled["emails"]) == 3
+	}, time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"default"}, handled["any"])
+	assert.Equal(t, []string{"emails", "emails"}, handled["emails"])
+}
+
+func TestManager_WorkerMiddleware(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	driver, _ := memory.NewDriver(cfg)
+	manager.SetDriver(driver)
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(call string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}
+	tag := func(name string) dgqueue.Middleware {
+		return func(next dgqueue.WorkerFunc) dgqueue.WorkerFunc {
+			return func(ctx context.Context, job *dgqueue.Job) error {
+				record(name + ":" + job.Name)
+				return next(ctx, job)
+			}
+		}
+	}
+	handler := func(ctx context.Context, job *dgqueue.Job) error {
+		record("handler:" + job.Name)
+		return nil
+	}
+
+	// Global middleware applies to workers registered before and after Use
+	assert.NoError(t, manager.WorkerWith("audit", 1, handler, dgqueue.WithMiddleware(tag("outer"), tag("inner"))))
+	manager.Use(tag("global"))
+	assert.NoError(t, manager.Worker("plain", 1, handler))
+
+	ctx := context.Background()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	_, err := manager.Dispatch(ctx, "audit", nil)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(calls) == 4
+	}, time.Second, 5*time.Millisecond)
+
+	_, err = manager.Dispatch(ctx, "plain", nil)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(calls) == 6
+	}, time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"global:audit", "outer:audit", "inner:audit", "handler:audit",
+		"global:plain", "handler:plain",
+	}, calls)
+}
+
+func TestManager_WorkersAtRuntime(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	driver, _ := memory.NewDriver(cfg)
+	manager.SetDriver(driver)
+
+	handled := make(chan string, 10)
+	handler := func(version string) dgqueue.WorkerFunc {
+		return func(ctx context.Context, job *dgqueue.Job) error {
+			handled <- version
+			return nil
+		}
+	}
+	await := func(want string) {
+		t.Helper()
+		select {
+		case got := <-handled:
+			assert.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the job to be handled by %s", want)
+		}
+	}
+
+	ctx := context.Background()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	// Added after Start, the pool runs right away
+	assert.NoError(t, manager.AddWorker("plugin", 1, handler("v1")))
+	assert.ErrorIs(t, manager.AddWorker("plugin", 1, handler("v1")), dgqueue.ErrWorkerExists)
+	manager.Dispatch(ctx, "plugin", nil)
+	await("v1")
+
+	// Replacing a worker hands new jobs to the new handler
+	assert.NoError(t, manager.Worker("plugin", 2, handler("v2")))
+	manager.Dispatch(ctx, "plugin", nil)
+	await("v2")
+	assert.Equal(t, 2, manager.PoolStats()["plugin"].Concurrency)
+
+	// Removed workers can be added again
+	assert.NoError(t, manager.RemoveWorker(ctx, "plugin"))
+	assert.NotContains(t, manager.PoolStats(), "plugin")
+	assert.NoError(t, manager.AddWorker("plugin", 1, handler("v3")))
+	manager.Dispatch(ctx, "plugin", nil)
+	await("v3")
+}
+
+// notifyingDriver is a memory driver that announces pushes like a Notifier.
+type notifyingDriver struct {
+	dgqueue.Driver
+	pushed chan string
+}
+
+func (d *notifyingDriver) Push(ctx context.Context, job *dgqueue.Job) error {
+	if err := d.Driver.Push(ctx, job); err != nil {
+		return err
+	}
+	d.pushed <- job.Queue
+	return nil
+}
+
+func (d *notifyingDriver) Notify(ctx context.Context) (<-chan string, error) {
+	return d.pushed, nil
+}
+
+func TestManager_PushNotifications(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = time.Hour
+
+	manager := dgqueue.New(cfg)
+	inner, _ := memory.NewDriver(cfg)
+	driver := &notifyingDriver{Driver: inner, pushed: make(chan string, 10)}
+	manager.SetDriver(driver)
+
+	processed := make(chan string, 10)
+	manager.Worker("send", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		processed <- job.Queue
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	// Pushes to other queues don't wake the dispatcher
+	assert.NoError(t, manager.Enqueue(ctx, dgqueue.WithQueue(manager.NewJob("send", nil), "other")))
+	assert.NoError(t, manager.Enqueue(ctx, manager.NewJob("send", nil)))
+
+	// Dispatched long before the next poll
+	select {
+	case queue := <-processed:
+		assert.Equal(t, "default", queue)
+	case <-time.After(time.Second):
+		t.Fatal("Expected the pushed job to be dispatched without polling")
+	}
+
+	// The other queue isn't served
+	size, _ := driver.Size(ctx, "other")
+	assert.Equal(t, int64(1), size)
+}
+
+func TestManager_CapacityDispatchStrategy(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 20 * time.Millisecond
+	cfg.DispatchStrategy = dgqueue.DispatchCapacity
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	var fast, slow atomic.Int64
+	manager.Worker("fast-job", 2, func(ctx context.Context, job *dgqueue.Job) error {
+		fast.Add(1)
+		return nil
+	})
+	manager.Worker("slow-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		time.Sleep(200 * time.Millisecond)
+		slow.Add(1)
+		return nil
+	})
+
+	// Interleave both kinds so the slow pool is saturated with work pending
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		manager.Dispatch(ctx, "slow-job", i)
+		manager.Dispatch(ctx, "fast-job", i)
+	}
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	// One job per poll would need 4s for the fast jobs alone
+	assert.Eventually(t, func() bool {
+		return fast.Load() == 100
+	}, 2*time.Second, 20*time.Millisecond, "Expected fast jobs to bypass the saturated slow pool")
+	assert.Less(t, slow.Load(), int64(15))
+}
+
+func TestManager_MaxConcurrentRetries(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+	cfg.DispatchStrategy = dgqueue.DispatchCapacity
+	cfg.RetryDelay = 10 * time.Millisecond
+	cfg.MaxConcurrentRetries = 2
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	var running, peak, retried atomic.Int64
+	manager.Worker("flaky-job", 20, func(ctx context.Context, job *dgqueue.Job) error {
+		if job.Attempts == 1 {
+			return errors.New("downstream unavailable")
+		}
+
+		current := running.Add(1)
+		for {
+			seen := peak.Load()
+			if current <= seen || peak.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		running.Add(-1)
+		retried.Add(1)
+		return nil
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		manager.Dispatch(ctx, "flaky-job", i)
+	}
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		return retried.Load() == 20
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Greater(t, peak.Load(), int64(0))
+	assert.LessOrEqual(t, peak.Load(), int64(2), "Expected at most 2 retries running at once")
+}
+
+func TestManager_MaxConcurrentRetriesDoesNotStarveFirstAttempts(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+	cfg.RetryDelay = time.Millisecond
+	cfg.MaxConcurrentRetries = 1
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	release := make(chan struct{})
+	fresh := make(chan struct{})
+	manager.Worker("flaky-job", 2, func(ctx context.Context, job *dgqueue.Job) error {
+		if job.Payload == "fresh" {
+			close(fresh)
+			return nil
+		}
+		if job.Attempts == 1 {
+			return errors.New("downstream unavailable")
+		}
+		<-release
+		return nil
+	})
+
+	ctx := context.Background()
+	manager.Dispatch(ctx, "flaky-job", 1)
+	manager.Dispatch(ctx, "flaky-job", 2)
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+	defer close(release)
+
+	// One retry holds the only slot; the other mustn't hold up a worker
+	time.Sleep(50 * time.Millisecond)
+	manager.Dispatch(ctx, "flaky-job", "fresh")
+
+	select {
+	case <-fresh:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the first attempt to run while retries wait for a slot")
+	}
+}
+
+func TestManager_DispatchRaw(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 10 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	received := make(chan []byte, 1)
+	manager.Worker("relay", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		payload, err := dgqueue.PayloadBytes(job)
+		if err != nil {
+			return err
+		}
+		received <- payload
+		return nil
+	})
+
+	ctx := context.Background()
+	raw := []byte(`{"event":"order.created","id":42}`)
+	_, err := manager.DispatchRaw(ctx, "relay", raw)
+	assert.NoError(t, err)
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, raw, payload)
+	case <-time.After(time.Second):
+		t.Fatal("Expected raw payload to be handled")
+	}
+}
@@ -2,5 +2,7 @@ package dgqueue
 
 const (
 	Binding = "queue"
-	Version = "1.0.0"
+	// RegistryBinding is the container key of the named managers' ManagerRegistry
+	RegistryBinding = "queue.registry"
+	Version         = "1.0.0"
 )
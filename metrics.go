@@ -0,0 +1,198 @@
+package dgqueue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// MetricsSink receives queue metrics, for telemetry backends other than
+// OpenTelemetry (StatsD, Prometheus clients, custom collectors).
+// The manager emits through the sink in addition to any OTel instruments
+// registered with RegisterMetrics.
+type MetricsSink interface {
+	// IncCounter adds value to a monotonic counter
+	IncCounter(name string, value int64, labels map[string]string)
+
+	// ObserveHistogram records a value in a distribution
+	ObserveHistogram(name string, value float64, labels map[string]string)
+
+	// SetGauge sets the current value of a gauge
+	SetGauge(name string, value float64, labels map[string]string)
+}
+
+// NoopMetricsSink discards all metrics. It is the manager's default sink.
+type NoopMetricsSink struct{}
+
+func (NoopMetricsSink) IncCounter(name string, value int64, labels map[string]string)         {}
+func (NoopMetricsSink) ObserveHistogram(name string, value float64, labels map[string]string) {}
+func (NoopMetricsSink) SetGauge(name string, value float64, labels map[string]string)         {}
+
+// otelMetricsSink forwards sink calls to OpenTelemetry instruments,
+// creating each instrument on first use.
+type otelMetricsSink struct {
+	meter      metric.Meter
+	mu         sync.Mutex
+	counters   map[string]metric.Int64Counter
+	histograms map[string]metric.Float64Histogram
+	gauges     map[string]metric.Float64Gauge
+}
+
+// NewOTelMetricsSink creates a MetricsSink backed by an OpenTelemetry meter.
+// If meter is nil, the global meter provider is used.
+func NewOTelMetricsSink(meter metric.Meter) MetricsSink {
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter(instrumentationName)
+	}
+	return &otelMetricsSink{
+		meter:      meter,
+		counters:   make(map[string]metric.Int64Counter),
+		histograms: make(map[string]metric.Float64Histogram),
+		gauges:     make(map[string]metric.Float64Gauge),
+	}
+}
+
+// IncCounter adds value to the named Int64Counter.
+func (s *otelMetricsSink) IncCounter(name string, value int64, labels map[string]string) {
+	s.mu.Lock()
+	counter, ok := s.counters[name]
+	if !ok {
+		var err error
+		if counter, err = s.meter.Int64Counter(name); err != nil {
+			s.mu.Unlock()
+			return
+		}
+		s.counters[name] = counter
+	}
+	s.mu.Unlock()
+
+	counter.Add(context.Background(), value, labelAttributes(labels))
+}
+
+// ObserveHistogram records value in the named Float64Histogram.
+func (s *otelMetricsSink) ObserveHistogram(name string, value float64, labels map[string]string) {
+	s.mu.Lock()
+	histogram, ok := s.histograms[name]
+	if !ok {
+		var err error
+		if histogram, err = s.meter.Float64Histogram(name); err != nil {
+			s.mu.Unlock()
+			return
+		}
+		s.histograms[name] = histogram
+	}
+	s.mu.Unlock()
+
+	histogram.Record(context.Background(), value, labelAttributes(labels))
+}
+
+// SetGauge records value on the named Float64Gauge.
+func (s *otelMetricsSink) SetGauge(name string, value float64, labels map[string]string) {
+	s.mu.Lock()
+	gauge, ok := s.gauges[name]
+	if !ok {
+		var err error
+		if gauge, err = s.meter.Float64Gauge(name); err != nil {
+			s.mu.Unlock()
+			return
+		}
+		s.gauges[name] = gauge
+	}
+	s.mu.Unlock()
+
+	gauge.Record(context.Background(), value, labelAttributes(labels))
+}
+
+// labelAttributes converts sink labels to OTel attributes.
+func labelAttributes(labels map[string]string) metric.MeasurementOption {
+	attrs := make([]attribute.KeyValue, 0, len(labels))
+	for k, v := range labels {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	return metric.WithAttributes(attrs...)
+}
+
+// SetMetricsSink sets the sink job metrics are emitted to.
+// Pass nil to restore the no-op sink.
+func (m *Manager) SetMetricsSink(sink MetricsSink) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sink == nil {
+		sink = NoopMetricsSink{}
+	}
+	m.metricsSink = sink
+}
+
+// sink returns the current metrics sink.
+func (m *Manager) sink() MetricsSink {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.metricsSink
+}
+
+// recordJobProcessed records a processed job, successful or not, with the
+// OTel instruments and the metrics sink.
+func (m *Manager) recordJobProcessed(ctx context.Context, pool *workerPool, job *Job, err error, duration time.Duration) {
+	// Any instrument may be missing
+	if m.metricJobProcessed != nil || m.metricJobDuration != nil || m.metricJobWaitTime != nil {
+		status := "success"
+		if err != nil {
+			status = "failed"
+		}
+		attrs := metric.WithAttributes(
+			attribute.String("queue.name", pool.name),
+			attribute.String("job.status", status),
+			attribute.String("job.priority_class", m.priorityClass(job)),
+		)
+		if m.metricJobProcessed != nil {
+			m.metricJobProcessed.Add(ctx, 1, attrs)
+		}
+		if m.metricJobDuration != nil {
+			m.metricJobDuration.Record(ctx, milliseconds(duration), attrs)
+		}
+		if m.metricJobWaitTime != nil {
+			m.metricJobWaitTime.Record(ctx, milliseconds(waitTime(job)), attrs)
+		}
+	}
+	m.emitJobProcessed(pool, job, err, duration)
+}
+
+// emitJobProcessed reports a finished job, whose handler ran for duration,
+// to the metrics sink.
+func (m *Manager) emitJobProcessed(pool *workerPool, job *Job, err error, duration time.Duration) {
+	status := "success"
+	if err != nil {
+		status = "failed"
+	}
+	labels := map[string]string{
+		"queue.name":         pool.name,
+		"job.status":         status,
+		"job.priority_class": m.priorityClass(job),
+	}
+
+	sink := m.sink()
+	sink.IncCounter("queue.job.processed", 1, labels)
+	sink.ObserveHistogram("queue.job.duration", milliseconds(duration), labels)
+	sink.ObserveHistogram("queue.job.wait_time", milliseconds(waitTime(job)), labels)
+	if err == nil {
+		sink.SetGauge("queue.job.throughput", m.completions().rate(m.clock.Now()), nil)
+	}
+}
+
+// waitTime returns how long the job waited in its queue: from when it became
+// available, after any delay or retry backoff, to when its attempt started.
+func waitTime(job *Job) time.Duration {
+	if job.StartedAt == nil || job.StartedAt.Before(job.AvailableAt) {
+		return 0
+	}
+	return job.StartedAt.Sub(job.AvailableAt)
+}
+
+// milliseconds returns d in fractional milliseconds, the unit of duration metrics.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,185 @@
+package dgqueue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// sinkCall is a single call made to recordingSink.
+type sinkCall struct {
+	kind   string
+	name   string
+	value  float64
+	labels map[string]string
+}
+
+// recordingSink is a MetricsSink that records every call.
+type recordingSink struct {
+	mu    sync.Mutex
+	calls []sinkCall
+}
+
+func (s *recordingSink) record(kind, name string, value float64, labels map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, sinkCall{kind: kind, name: name, value: value, labels: labels})
+}
+
+func (s *recordingSink) IncCounter(name string, value int64, labels map[string]string) {
+	s.record("counter", name, float64(value), labels)
+}
+
+func (s *recordingSink) ObserveHistogram(name string, value float64, labels map[string]string) {
+	s.record("histogram", name, value, labels)
+}
+
+func (s *recordingSink) SetGauge(name string, value float64, labels map[string]string) {
+	s.record("gauge", name, value, labels)
+}
+
+// find returns the recorded calls of the given kind and name.
+func (s *recordingSink) find(kind, name string) []sinkCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found []sinkCall
+	for _, c := range s.calls {
+		if c.kind == kind && c.name == name {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+func TestMetricsSink_JobProcessed(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxAttempts = 1
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	sink := &recordingSink{}
+	manager.SetMetricsSink(sink)
+
+	manager.Worker("ok-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		return nil
+	})
+	manager.Worker("bad-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		return errors.New("boom")
+	})
+
+	ctx := context.Background()
+	manager.Dispatch(ctx, "ok-job", nil)
+	manager.Dispatch(ctx, "bad-job", nil)
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		return len(sink.find("counter", "queue.job.processed")) == 2
+	}, 3*time.Second, 50*time.Millisecond)
+
+	statuses := make(map[string]string)
+	for _, c := range sink.find("counter", "queue.job.processed") {
+		assert.Equal(t, 1.0, c.value)
+		assert.Equal(t, "normal", c.labels["job.priority_class"])
+		statuses[c.labels["queue.name"]] = c.labels["job.status"]
+	}
+	assert.Equal(t, map[string]string{"ok-job": "success", "bad-job": "failed"}, statuses)
+
+	assert.Len(t, sink.find("histogram", "queue.job.duration"), 2)
+	assert.Len(t, sink.find("gauge", "queue.job.throughput"), 1)
+}
+
+func TestMetricsSink_TimedOutJob(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxAttempts = 1
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	sink := &recordingSink{}
+	manager.SetMetricsSink(sink)
+
+	manager.Worker("slow-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ctx := context.Background()
+	job := dgqueue.WithTimeout(manager.NewJob("slow-job", nil), 50*time.Millisecond)
+	assert.NoError(t, manager.Enqueue(ctx, job))
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		return len(sink.find("counter", "queue.job.processed")) == 1
+	}, 3*time.Second, 50*time.Millisecond)
+
+	processed := sink.find("counter", "queue.job.processed")[0]
+	assert.Equal(t, "failed", processed.labels["job.status"])
+	assert.Len(t, sink.find("histogram", "queue.job.duration"), 1)
+	assert.Len(t, sink.find("histogram", "queue.job.wait_time"), 1)
+}
+
+func TestMetricsSink_DurationAndWaitTime(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	sink := &recordingSink{}
+	manager.SetMetricsSink(sink)
+
+	manager.Worker("slow-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	// A job that has been waiting in the queue for an hour
+	ctx := context.Background()
+	job := manager.NewJob("slow-job", nil)
+	job.CreatedAt = time.Now().Add(-time.Hour)
+	job.AvailableAt = job.CreatedAt
+	assert.NoError(t, manager.Enqueue(ctx, job))
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		return len(sink.find("histogram", "queue.job.wait_time")) == 1
+	}, 3*time.Second, 50*time.Millisecond)
+
+	durations := sink.find("histogram", "queue.job.duration")
+	if assert.Len(t, durations, 1) {
+		assert.GreaterOrEqual(t, durations[0].value, 50.0)
+		assert.Less(t, durations[0].value, 1000.0, "Expected duration to exclude time spent queued")
+	}
+	wait := sink.find("histogram", "queue.job.wait_time")[0]
+	assert.GreaterOrEqual(t, wait.value, float64(time.Hour/time.Millisecond))
+	assert.Equal(t, "success", wait.labels["job.status"])
+}
+
+func TestMetricsSink_OTelAdapter(t *testing.T) {
+	provider := useRecordingMeterProvider(t)
+
+	sink := dgqueue.NewOTelMetricsSink(nil)
+	sink.IncCounter("custom.events", 2, map[string]string{"source": "import"})
+	sink.IncCounter("custom.events", 3, map[string]string{"source": "import"})
+	sink.ObserveHistogram("custom.latency", 12.5, nil)
+	sink.SetGauge("custom.level", 1, nil)
+
+	counter := provider.counter("custom.events")
+	assert.Equal(t, int64(5), counter.Total())
+	attrs := counter.LastAttrs()
+	source, _ := attrs.Value("source")
+	assert.Equal(t, "import", source.AsString())
+}
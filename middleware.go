@@ -0,0 +1,45 @@
+package dgqueue
+
+import (
+	"context"
+)
+
+// WorkerOption customizes a single worker registration.
+type WorkerOption func(*workerOptions)
+
+type workerOptions struct {
+	middleware []Middleware
+}
+
+// WithMiddleware runs the worker's handler behind middleware, in the order
+// given, inside any middleware added to the manager with Use.
+func WithMiddleware(middleware ...Middleware) WorkerOption {
+	return func(o *workerOptions) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// chain wraps handler in middleware, the first outermost.
+func chain(handler WorkerFunc, middleware []Middleware) WorkerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// wrapHandler puts the pool's handler behind the manager's middleware, which
+// sees jobs already migrated to their current payload version.
+// Callers must hold m.mu.
+func (m *Manager) wrapHandler(pool *workerPool) {
+	wrapped := m.migrating(chain(pool.handler, m.middleware))
+	pool.wrapped.Store(&wrapped)
+}
+
+// run calls the pool's handler, behind the manager's middleware once the
+// pool is installed.
+func (pool *workerPool) run(ctx context.Context, job *Job) error {
+	if wrapped := pool.wrapped.Load(); wrapped != nil {
+		return (*wrapped)(ctx, job)
+	}
+	return pool.handler(ctx, job)
+}
@@ -0,0 +1,109 @@
+package dgqueue
+
+import (
+	"context"
+	"fmt"
+)
+
+// MetadataPayloadVersion is the job metadata key holding the version of the
+// job's payload shape.
+const MetadataPayloadVersion = "payload_version"
+
+// PayloadMigration upgrades a payload by one version.
+type PayloadMigration func(payload interface{}) (interface{}, error)
+
+// MigrateFunc adapts a function from one payload type to the next into a
+// PayloadMigration. The payload is decoded into a From first, so it works
+// the same whether the driver kept the payload's type or not.
+func MigrateFunc[From, To any](migrate func(From) (To, error)) PayloadMigration {
+	return func(payload interface{}) (interface{}, error) {
+		var from From
+		if err := decodePayload(payload, &from); err != nil {
+			return nil, err
+		}
+		return migrate(from)
+	}
+}
+
+// WithPayloadVersion sets the version of the job's payload shape.
+func WithPayloadVersion(j *Job, version int) *Job {
+	return WithMetadata(j, MetadataPayloadVersion, version)
+}
+
+// GetPayloadVersion returns the version of the job's payload shape (1 if unset).
+func GetPayloadVersion(j *Job) int {
+	if version, ok := metadataInt(j, MetadataPayloadVersion); ok {
+		return version
+	}
+	return 1
+}
+
+// RegisterMigration registers the migration upgrading payloads of jobs named
+// name from version from to from+1. The highest version migrated to is the
+// name's current version: jobs dispatched by the manager are stamped with it,
+// and older jobs are migrated up to it before their worker's middleware and
+// handler see them, so handlers only deal with the current payload shape.
+//
+// Register migrations on dispatching managers as well as workers. Jobs newer
+// than the current version, dispatched by a newer deployment, fail with
+// ErrPayloadVersion and are retried, so they wait for a worker that knows them.
+func (m *Manager) RegisterMigration(name string, from int, migration PayloadMigration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.migrations[name] == nil {
+		m.migrations[name] = make(map[int]PayloadMigration)
+	}
+	m.migrations[name][from] = migration
+}
+
+// payloadVersion returns the current payload version of jobs named name.
+func (m *Manager) payloadVersion(name string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	version := 1
+	for from := range m.migrations[name] {
+		if from+1 > version {
+			version = from + 1
+		}
+	}
+	return version
+}
+
+// migrate upgrades the job's payload to the current version of its name.
+func (m *Manager) migrate(job *Job) error {
+	version, current := GetPayloadVersion(job), m.payloadVersion(job.Name)
+	if version > current {
+		return fmt.Errorf("%w: job %s has payload version %d, newest known is %d",
+			ErrPayloadVersion, job.Name, version, current)
+	}
+
+	for ; version < current; version++ {
+		m.mu.RLock()
+		migration := m.migrations[job.Name][version]
+		m.mu.RUnlock()
+
+		if migration == nil {
+			return fmt.Errorf("%w: job %s has no migration from payload version %d",
+				ErrPayloadVersion, job.Name, version)
+		}
+		payload, err := migration(job.Payload)
+		if err != nil {
+			return fmt.Errorf("migrate job %s from payload version %d: %w", job.Name, version, err)
+		}
+		job.Payload = payload
+		WithPayloadVersion(job, version+1)
+	}
+	return nil
+}
+
+// migrating runs handler on jobs migrated to their current payload version.
+func (m *Manager) migrating(handler WorkerFunc) WorkerFunc {
+	return func(ctx context.Context, job *Job) error {
+		if err := m.migrate(job); err != nil {
+			return err
+		}
+		return handler(ctx, job)
+	}
+}
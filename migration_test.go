@@ -0,0 +1,94 @@
+package dgqueue_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+type invoiceV1 struct {
+	Customer string `json:"customer"`
+	Amount   int    `json:"amount"`
+}
+
+type invoiceV2 struct {
+	Customer string `json:"customer"`
+	Cents    int    `json:"cents"`
+}
+
+type invoiceV3 struct {
+	Customer string `json:"customer"`
+	Cents    int    `json:"cents"`
+	Currency string `json:"currency"`
+}
+
+func TestManager_MigratesOldPayloads(t *testing.T) {
+	ctx := context.Background()
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxAttempts = 1
+	cfg.PollInterval = 10 * time.Millisecond
+	d, _ := memory.NewDriver(cfg)
+
+	// A deployment from before the payload changed
+	old := dgqueue.New(cfg)
+	old.SetDriver(d)
+
+	manager := dgqueue.New(cfg)
+	manager.SetDriver(d)
+	manager.RegisterMigration("invoice", 1, dgqueue.MigrateFunc(func(v1 invoiceV1) (invoiceV2, error) {
+		return invoiceV2{Customer: v1.Customer, Cents: v1.Amount * 100}, nil
+	}))
+	manager.RegisterMigration("invoice", 2, dgqueue.MigrateFunc(func(v2 invoiceV2) (invoiceV3, error) {
+		return invoiceV3{Customer: v2.Customer, Cents: v2.Cents, Currency: "USD"}, nil
+	}))
+
+	handled := make(chan invoiceV3, 2)
+	err := dgqueue.RegisterWorker(manager, "invoice", 1, func(ctx context.Context, invoice invoiceV3) error {
+		handled <- invoice
+		return nil
+	})
+	assert.NoError(t, err)
+
+	exhausted := make(chan *dgqueue.Job, 1)
+	manager.OnRetryExhausted(func(job *dgqueue.Job) { exhausted <- job })
+
+	job, err := old.Dispatch(ctx, "invoice", invoiceV1{Customer: "acme", Amount: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dgqueue.GetPayloadVersion(job))
+
+	job, err = manager.Dispatch(ctx, "invoice", invoiceV3{Customer: "globex", Cents: 250, Currency: "EUR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, dgqueue.GetPayloadVersion(job), "Expected jobs to be stamped with the current version")
+
+	// A job from a newer deployment
+	future := dgqueue.WithPayloadVersion(manager.NewJob("invoice", map[string]interface{}{}), 4)
+	assert.NoError(t, manager.Enqueue(ctx, future))
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	for _, want := range []invoiceV3{
+		{Customer: "acme", Cents: 500, Currency: "USD"},
+		{Customer: "globex", Cents: 250, Currency: "EUR"},
+	} {
+		select {
+		case invoice := <-handled:
+			assert.Equal(t, want, invoice)
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected job to be handled")
+		}
+	}
+
+	select {
+	case job := <-exhausted:
+		assert.Equal(t, future.ID, job.ID)
+		assert.True(t, strings.Contains(job.Error, dgqueue.ErrPayloadVersion.Error()), "got error %q", job.Error)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the newer job to fail")
+	}
+}
@@ -2,6 +2,8 @@ package dgqueue
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -14,10 +16,19 @@ const (
 
 // RegisterMetrics registers queue metrics with OpenTelemetry.
 // This initializes instruments and registers callbacks for observable metrics.
-func (m *Manager) RegisterMetrics() error {
+// If any instrument fails, none are kept, so the manager runs without metrics.
+func (m *Manager) RegisterMetrics() (err error) {
 	meter := otel.GetMeterProvider().Meter(instrumentationName)
 
-	var err error
+	var registration metric.Registration
+	defer func() {
+		if err != nil {
+			if registration != nil {
+				registration.Unregister()
+			}
+			m.resetMetrics()
+		}
+	}()
 
 	// 1. Observable Gauges (State)
 	// Queue Depth
@@ -40,8 +51,42 @@ func (m *Manager) RegisterMetrics() error {
 		return err
 	}
 
+	// Busy Workers (executing a handler)
+	m.metricBusyWorkers, err = meter.Int64ObservableGauge(
+		"queue.workers.busy",
+		metric.WithDescription("Number of workers currently executing a handler"),
+		metric.WithUnit("{worker}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Completed-job Throughput
+	m.metricThroughput, err = meter.Float64ObservableGauge(
+		"queue.job.throughput",
+		metric.WithDescription("Jobs completed per second over the throughput window"),
+		metric.WithUnit("{job}/s"),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Oldest Job Age (lag)
+	m.metricOldestAge, err = meter.Float64ObservableGauge(
+		"queue.oldest_age_seconds",
+		metric.WithDescription("Time the oldest ready job in the queue has been waiting"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return err
+	}
+
 	// Register Callback for Gauges
-	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+	registration, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveFloat64(m.metricThroughput, m.Throughput())
+		m.observeOldestAge(ctx, o)
+		m.observeQueueDepth(ctx, o)
+
 		m.mu.RLock()
 		defer m.mu.RUnlock()
 
@@ -50,15 +95,14 @@ func (m *Manager) RegisterMetrics() error {
 				attribute.String("queue.name", name),
 			)
 
-			// Approximate depth: length of the channel
-			o.ObserveInt64(m.metricQueueDepth, int64(len(pool.jobs)), attrs)
-
-			// Active workers: concurrency (static for now, unless we track busy workers separately)
-			// For better accuracy we might want to track 'busy' workers, but static concurrency is a good start
+			// Active workers: concurrency, which autoscaled pools adjust over time
 			o.ObserveInt64(m.metricActiveWorkers, int64(pool.concurrency), attrs)
+
+			// Busy workers: the saturated share of that concurrency
+			o.ObserveInt64(m.metricBusyWorkers, pool.busy.Load(), attrs)
 		}
 		return nil
-	}, m.metricQueueDepth, m.metricActiveWorkers)
+	}, m.metricQueueDepth, m.metricActiveWorkers, m.metricBusyWorkers, m.metricThroughput, m.metricOldestAge)
 	if err != nil {
 		return err
 	}
@@ -74,15 +118,142 @@ func (m *Manager) RegisterMetrics() error {
 		return err
 	}
 
-	// Job Duration Histogram
+	// Job Duration Histogram (handler execution time)
 	m.metricJobDuration, err = meter.Float64Histogram(
 		"queue.job.duration",
-		metric.WithDescription("Duration of job processing"),
+		metric.WithDescription("Time spent running the job's handler"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Job Wait Time Histogram (time ready in the queue before starting)
+	m.metricJobWaitTime, err = meter.Float64Histogram(
+		"queue.job.wait_time",
+		metric.WithDescription("Time jobs waited in the queue between becoming available and starting"),
 		metric.WithUnit("ms"),
 	)
 	if err != nil {
 		return err
 	}
 
+	// Job Deferred Counter (pool full, job pushed back)
+	m.metricJobDeferred, err = meter.Int64Counter(
+		"queue.job.deferred",
+		metric.WithDescription("Total number of jobs pushed back to the queue without running (pool full or deferred by middleware)"),
+		metric.WithUnit("{job}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Job Exhausted Counter (failed on its last attempt)
+	m.metricJobExhausted, err = meter.Int64Counter(
+		"queue.job.exhausted",
+		metric.WithDescription("Total number of jobs that failed after exhausting all retries"),
+		metric.WithUnit("{job}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Orphaned Queue Counter (jobs waiting with no consumer)
+	m.metricQueueOrphaned, err = meter.Int64Counter(
+		"queue.orphaned",
+		metric.WithDescription("Number of checks that found a queue holding jobs no running instance serves"),
+		metric.WithUnit("{check}"),
+	)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
+
+// observeQueueDepth reports the number of pending jobs in each served queue.
+func (m *Manager) observeQueueDepth(ctx context.Context, o metric.Observer) {
+	for queue, size := range m.QueueDepths(ctx) {
+		o.ObserveInt64(m.metricQueueDepth, size, metric.WithAttributes(
+			attribute.String("queue.name", queue),
+		))
+	}
+}
+
+// depthCacheTTL is how long queue sizes read for queue.depth are reused, so
+// frequent or concurrent metric collections don't each query the driver.
+const depthCacheTTL = 5 * time.Second
+
+// depthCache holds the queue sizes last read from the driver.
+type depthCache struct {
+	mu      sync.Mutex
+	sizes   map[string]int64
+	fetched time.Time
+}
+
+// QueueDepths returns the number of pending jobs in each queue the manager
+// serves, as reported by the queue.depth gauge. Sizes are read from the driver
+// at most once every few seconds; queues whose size can't be read are left
+// out. The returned map must not be modified.
+func (m *Manager) QueueDepths(ctx context.Context) map[string]int64 {
+	m.mu.RLock()
+	driver := m.driver
+	queues := m.servedQueues()
+	m.mu.RUnlock()
+	if driver == nil {
+		return nil
+	}
+
+	m.depth.mu.Lock()
+	defer m.depth.mu.Unlock()
+
+	now := m.clock.Now()
+	if m.depth.sizes != nil && now.Sub(m.depth.fetched) < depthCacheTTL {
+		return m.depth.sizes
+	}
+
+	sizes := make(map[string]int64, len(queues))
+	for _, queue := range queues {
+		if size, err := driver.Size(ctx, queue); err == nil {
+			sizes[queue] = size
+		}
+	}
+	m.depth.sizes, m.depth.fetched = sizes, now
+	return sizes
+}
+
+// observeOldestAge reports the oldest job age of each served queue, if the driver supports it.
+func (m *Manager) observeOldestAge(ctx context.Context, o metric.Observer) {
+	m.mu.RLock()
+	ager, ok := m.driver.(OldestJobAger)
+	queues := m.servedQueues()
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	for _, queue := range queues {
+		age, err := ager.OldestJobAge(ctx, queue)
+		if err != nil {
+			continue
+		}
+		o.ObserveFloat64(m.metricOldestAge, age.Seconds(), metric.WithAttributes(
+			attribute.String("queue.name", queue),
+		))
+	}
+}
+
+// resetMetrics clears every instrument, disabling OTel metric recording.
+func (m *Manager) resetMetrics() {
+	m.metricQueueDepth = nil
+	m.metricActiveWorkers = nil
+	m.metricBusyWorkers = nil
+	m.metricThroughput = nil
+	m.metricOldestAge = nil
+	m.metricQueueOrphaned = nil
+	m.metricJobProcessed = nil
+	m.metricJobDuration = nil
+	m.metricJobWaitTime = nil
+	m.metricJobDeferred = nil
+	m.metricJobExhausted = nil
+}
@@ -0,0 +1,433 @@
+package dgqueue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// recordingMeterProvider is a MeterProvider that records counter additions.
+type recordingMeterProvider struct {
+	noop.MeterProvider
+	meter *recordingMeter
+}
+
+func newRecordingMeterProvider() *recordingMeterProvider {
+	return &recordingMeterProvider{
+		meter: &recordingMeter{counters: make(map[string]*recordingCounter)},
+	}
+}
+
+func (p *recordingMeterProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return p.meter
+}
+
+// counter returns the recorded counter with the given name (nil if never created).
+func (p *recordingMeterProvider) counter(name string) *recordingCounter {
+	p.meter.mu.Lock()
+	defer p.meter.mu.Unlock()
+	return p.meter.counters[name]
+}
+
+type recordingMeter struct {
+	noop.Meter
+	mu        sync.Mutex
+	counters  map[string]*recordingCounter
+	callbacks []metric.Callback
+
+	// failHistograms makes histogram creation fail
+	failHistograms bool
+}
+
+func (m *recordingMeter) Float64Histogram(name string, opts ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	if m.failHistograms {
+		return nil, errors.New("histogram unavailable")
+	}
+	return m.Meter.Float64Histogram(name, opts...)
+}
+
+func (m *recordingMeter) Int64Counter(name string, opts ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := &recordingCounter{}
+	m.counters[name] = c
+	return c, nil
+}
+
+func (m *recordingMeter) Float64ObservableGauge(name string, opts ...metric.Float64ObservableGaugeOption) (metric.Float64ObservableGauge, error) {
+	return &recordingGauge{name: name}, nil
+}
+
+func (m *recordingMeter) Int64ObservableGauge(name string, opts ...metric.Int64ObservableGaugeOption) (metric.Int64ObservableGauge, error) {
+	return &recordingInt64Gauge{name: name}, nil
+}
+
+func (m *recordingMeter) RegisterCallback(f metric.Callback, instruments ...metric.Observable) (metric.Registration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.callbacks = append(m.callbacks, f)
+	return m.Meter.RegisterCallback(f, instruments...)
+}
+
+// recordingGauge is a float gauge identified by name in recordingObserver.
+type recordingGauge struct {
+	noop.Float64ObservableGauge
+	name string
+}
+
+// recordingInt64Gauge is an int gauge identified by name in recordingObserver.
+type recordingInt64Gauge struct {
+	noop.Int64ObservableGauge
+	name string
+}
+
+// recordingObserver records observations keyed by gauge name and queue.name.
+type recordingObserver struct {
+	noop.Observer
+	values map[string]float64
+}
+
+func (o *recordingObserver) ObserveInt64(obs metric.Int64Observable, value int64, opts ...metric.ObserveOption) {
+	gauge, ok := obs.(*recordingInt64Gauge)
+	if !ok {
+		return
+	}
+	attrs := metric.NewObserveConfig(opts).Attributes()
+	queue, _ := attrs.Value("queue.name")
+	o.values[gauge.name+"/"+queue.AsString()] = float64(value)
+}
+
+func (o *recordingObserver) ObserveFloat64(obs metric.Float64Observable, value float64, opts ...metric.ObserveOption) {
+	gauge, ok := obs.(*recordingGauge)
+	if !ok {
+		return
+	}
+	attrs := metric.NewObserveConfig(opts).Attributes()
+	queue, _ := attrs.Value("queue.name")
+	o.values[gauge.name+"/"+queue.AsString()] = value
+}
+
+// collect runs the registered callbacks, returning the gauge values.
+func (p *recordingMeterProvider) collect(t *testing.T) map[string]float64 {
+	p.meter.mu.Lock()
+	callbacks := append([]metric.Callback(nil), p.meter.callbacks...)
+	p.meter.mu.Unlock()
+
+	observer := &recordingObserver{values: make(map[string]float64)}
+	for _, callback := range callbacks {
+		assert.NoError(t, callback(context.Background(), observer))
+	}
+	return observer.values
+}
+
+type recordingCounter struct {
+	noop.Int64Counter
+	mu    sync.Mutex
+	total int64
+	attrs []attribute.Set
+}
+
+func (c *recordingCounter) Add(ctx context.Context, incr int64, opts ...metric.AddOption) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.total += incr
+	c.attrs = append(c.attrs, metric.NewAddConfig(opts).Attributes())
+}
+
+func (c *recordingCounter) Total() int64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+func (c *recordingCounter) AllAttrs() []attribute.Set {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]attribute.Set(nil), c.attrs...)
+}
+
+func (c *recordingCounter) LastAttrs() attribute.Set {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.attrs) == 0 {
+		return attribute.Set{}
+	}
+	return c.attrs[len(c.attrs)-1]
+}
+
+// useRecordingMeterProvider installs a recording provider for the test duration.
+func useRecordingMeterProvider(t *testing.T) *recordingMeterProvider {
+	previous := otel.GetMeterProvider()
+	provider := newRecordingMeterProvider()
+	otel.SetMeterProvider(provider)
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+	return provider
+}
+
+func TestMetrics_JobDeferredWhenPoolFull(t *testing.T) {
+	provider := useRecordingMeterProvider(t)
+
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	assert.NoError(t, manager.RegisterMetrics())
+
+	// Block the single worker so its buffer (capacity 2) fills up
+	release := make(chan struct{})
+	manager.Worker("slow-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		<-release
+		return nil
+	})
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(context.Background())
+	defer close(release)
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		_, err := manager.Dispatch(ctx, "slow-job", i)
+		assert.NoError(t, err)
+	}
+
+	assert.Eventually(t, func() bool {
+		return provider.counter("queue.job.deferred").Total() > 0
+	}, 3*time.Second, 50*time.Millisecond)
+
+	attrs := provider.counter("queue.job.deferred").LastAttrs()
+	name, _ := attrs.Value("job.name")
+	queueName, _ := attrs.Value("queue.name")
+	assert.Equal(t, "slow-job", name.AsString())
+	assert.Equal(t, "default", queueName.AsString())
+}
+
+func TestMetrics_PriorityClassAttribute(t *testing.T) {
+	provider := useRecordingMeterProvider(t)
+
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	assert.NoError(t, manager.RegisterMetrics())
+
+	manager.Worker("prioritized", 3, func(ctx context.Context, job *dgqueue.Job) error {
+		return nil
+	})
+
+	ctx := context.Background()
+	for _, priority := range []int{dgqueue.PriorityHigh, dgqueue.PriorityNormal, dgqueue.PriorityLow} {
+		job := dgqueue.NewJob("prioritized", priority)
+		dgqueue.WithMetadata(job, dgqueue.MetadataPriority, priority)
+		assert.NoError(t, d.Push(ctx, job))
+	}
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		return provider.counter("queue.job.processed").Total() == 3
+	}, 3*time.Second, 50*time.Millisecond)
+
+	classes := make(map[string]bool)
+	for _, attrs := range provider.counter("queue.job.processed").AllAttrs() {
+		class, ok := attrs.Value("job.priority_class")
+		assert.True(t, ok)
+		classes[class.AsString()] = true
+	}
+	assert.Equal(t, map[string]bool{"high": true, "normal": true, "low": true}, classes)
+}
+
+func TestMetrics_RetryExhausted(t *testing.T) {
+	provider := useRecordingMeterProvider(t)
+
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxAttempts = 2
+	cfg.RetryDelay = 10 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+	assert.NoError(t, manager.RegisterMetrics())
+
+	var mu sync.Mutex
+	var exhausted []*dgqueue.Job
+	manager.OnRetryExhausted(func(job *dgqueue.Job) {
+		mu.Lock()
+		exhausted = append(exhausted, job)
+		mu.Unlock()
+	})
+
+	attempts := 0
+	manager.Worker("always-fails", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		return errors.New("boom")
+	})
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(context.Background())
+
+	job, err := manager.Dispatch(context.Background(), "always-fails", nil)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return provider.counter("queue.job.exhausted").Total() > 0
+	}, 3*time.Second, 50*time.Millisecond)
+
+	// Wait past another retry interval to make sure nothing fires twice
+	time.Sleep(200 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, attempts)
+	assert.Len(t, exhausted, 1)
+	assert.Equal(t, job.ID, exhausted[0].ID)
+	assert.Equal(t, int64(1), provider.counter("queue.job.exhausted").Total())
+	assert.Equal(t, int64(2), provider.counter("queue.job.processed").Total())
+}
+
+func TestMetrics_PartialRegistrationFailure(t *testing.T) {
+	provider := useRecordingMeterProvider(t)
+	provider.meter.failHistograms = true
+
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	assert.Error(t, manager.RegisterMetrics())
+
+	done := make(chan struct{}, 2)
+	manager.Worker("unmetered", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		done <- struct{}{}
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	for i := 0; i < 2; i++ {
+		manager.Dispatch(ctx, "unmetered", i)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(3 * time.Second):
+			t.Fatal("Expected jobs to be processed without metrics")
+		}
+	}
+
+	// Instruments created before the failure were rolled back
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int64(0), provider.counter("queue.job.processed").Total())
+}
+
+func TestMetrics_OldestJobAge(t *testing.T) {
+	provider := useRecordingMeterProvider(t)
+
+	cfg := dgqueue.DefaultConfig()
+	cfg.ServeQueues = []string{"default", "reports"}
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+	assert.NoError(t, manager.RegisterMetrics())
+
+	// The head job has waited an hour; the newer one a minute
+	ctx := context.Background()
+	now := time.Now()
+	for _, age := range []time.Duration{time.Hour, time.Minute} {
+		job := manager.NewJob("report", nil)
+		job.Queue = "reports"
+		job.AvailableAt = now.Add(-age)
+		assert.NoError(t, manager.Enqueue(ctx, job))
+	}
+
+	age, err := manager.OldestJobAge(ctx, "reports")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Hour.Seconds(), age.Seconds(), 1)
+
+	values := provider.collect(t)
+	assert.InDelta(t, time.Hour.Seconds(), values["queue.oldest_age_seconds/reports"], 1)
+	assert.Equal(t, 0.0, values["queue.oldest_age_seconds/default"])
+}
+
+func TestMetrics_QueueDepthFromDriver(t *testing.T) {
+	provider := useRecordingMeterProvider(t)
+
+	cfg := dgqueue.DefaultConfig()
+	cfg.QueueWeights = map[string]int{"critical": 2, "default": 1}
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+	assert.NoError(t, manager.RegisterMetrics())
+
+	// Far more jobs than any worker buffer holds
+	ctx := context.Background()
+	for i := 0; i < 25; i++ {
+		_, err := manager.Dispatch(ctx, "report", i)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, manager.Enqueue(ctx, dgqueue.WithQueue(manager.NewJob("alert", nil), "critical")))
+
+	values := provider.collect(t)
+	assert.Equal(t, 25.0, values["queue.depth/default"])
+	assert.Equal(t, 1.0, values["queue.depth/critical"])
+
+	// Sizes are cached between close collections
+	_, err := manager.Dispatch(ctx, "report", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 25.0, provider.collect(t)["queue.depth/default"])
+}
+
+func TestMetrics_BusyWorkers(t *testing.T) {
+	provider := useRecordingMeterProvider(t)
+
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	assert.NoError(t, manager.RegisterMetrics())
+
+	started := make(chan struct{}, 4)
+	release := make(chan struct{})
+	manager.Worker("slow-job", 4, func(ctx context.Context, job *dgqueue.Job) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	for i := 0; i < 3; i++ {
+		_, err := manager.Dispatch(ctx, "slow-job", i)
+		assert.NoError(t, err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-started:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected job to start")
+		}
+	}
+
+	values := provider.collect(t)
+	assert.Equal(t, 4.0, values["queue.workers/slow-job"])
+	assert.Equal(t, 3.0, values["queue.workers.busy/slow-job"])
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return provider.collect(t)["queue.workers.busy/slow-job"] == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
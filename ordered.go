@@ -0,0 +1,58 @@
+package dgqueue
+
+import "hash/fnv"
+
+// MetadataPartitionKey is the job metadata key used to order jobs in ordered workers.
+const MetadataPartitionKey = "partition_key"
+
+// WithPartitionKey sets the key ordered workers use to serialize related jobs.
+func WithPartitionKey(j *Job, key string) *Job {
+	j.Metadata[MetadataPartitionKey] = key
+	return j
+}
+
+// GetPartitionKey returns the job's partition key (empty if unset).
+func GetPartitionKey(j *Job) string {
+	if j.Metadata == nil {
+		return ""
+	}
+	key, _ := j.Metadata[MetadataPartitionKey].(string)
+	return key
+}
+
+// OrderedWorker registers a worker that processes jobs with the same partition
+// key strictly in dispatch order, while jobs for different keys run in parallel
+// across the given number of lanes.
+//
+// Jobs without a partition key share a single lane, so with lanes=1 every job
+// runs sequentially. Ordering covers first attempts; a retried job re-enters
+// the queue behind jobs dispatched after it.
+func (m *Manager) OrderedWorker(name string, lanes int, handler WorkerFunc, opts ...WorkerOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pool := m.newWorkerPool("", name, lanes, handler, opts)
+	pool.jobs = nil
+	pool.lanes = make([]chan *Job, pool.concurrency)
+	for i := range pool.lanes {
+		pool.lanes[i] = make(chan *Job, 2)
+	}
+	m.installPool(pool)
+
+	return nil
+}
+
+// lane returns the channel a job is routed to in an ordered pool.
+func (pool *workerPool) lane(job *Job) chan *Job {
+	h := fnv.New32a()
+	h.Write([]byte(GetPartitionKey(job)))
+	return pool.lanes[h.Sum32()%uint32(len(pool.lanes))]
+}
+
+// channels returns every channel buffering jobs for the pool.
+func (pool *workerPool) channels() []chan *Job {
+	if pool.lanes != nil {
+		return pool.lanes
+	}
+	return []chan *Job{pool.jobs}
+}
@@ -0,0 +1,86 @@
+package dgqueue_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionKey(t *testing.T) {
+	job := dgqueue.NewJob("test", "payload")
+	assert.Equal(t, "", dgqueue.GetPartitionKey(job))
+
+	dgqueue.WithPartitionKey(job, "order-1")
+	assert.Equal(t, "order-1", dgqueue.GetPartitionKey(job))
+}
+
+func TestManager_OrderedWorker(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+	running := make(map[string]int)
+	active, maxActive := 0, 0
+	overlap := false
+
+	err := manager.OrderedWorker("ordered-job", 2, func(ctx context.Context, job *dgqueue.Job) error {
+		key := dgqueue.GetPartitionKey(job)
+
+		mu.Lock()
+		running[key]++
+		if running[key] > 1 {
+			overlap = true
+		}
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+
+		time.Sleep(30 * time.Millisecond)
+
+		mu.Lock()
+		seen[key] = append(seen[key], job.Payload.(int))
+		running[key]--
+		active--
+		mu.Unlock()
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// Interleave two keys that land on different lanes
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		for _, key := range []string{"tenant-a", "tenant-b"} {
+			job := manager.NewJob("ordered-job", i)
+			dgqueue.WithPartitionKey(job, key)
+			assert.NoError(t, manager.Enqueue(ctx, job))
+		}
+	}
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen["tenant-a"]) == 5 && len(seen["tenant-b"]) == 5
+	}, 5*time.Second, 20*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.False(t, overlap, "Expected jobs for the same key never to run concurrently")
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, seen["tenant-a"])
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, seen["tenant-b"])
+	assert.Equal(t, 2, maxActive, "Expected different keys to run in parallel")
+}
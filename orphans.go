@@ -0,0 +1,85 @@
+package dgqueue
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// OrphanQueues returns the queues holding jobs that no running instance serves.
+// A queue counts as served if this manager is running and serves it, or, when
+// the driver is a ConsumerTracker, if any other instance has marked it served.
+// It returns ErrNotSupported if the driver can't list its queues.
+func (m *Manager) OrphanQueues(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	driver := m.driver
+	running := m.running
+	served := m.servedQueues()
+	m.mu.RUnlock()
+
+	lister, ok := driver.(QueueLister)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	queues, err := lister.Queues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	local := make(map[string]bool, len(served))
+	if running {
+		for _, queue := range served {
+			local[queue] = true
+		}
+	}
+	tracker, tracked := driver.(ConsumerTracker)
+
+	var orphans []string
+	for _, queue := range queues {
+		if local[queue] {
+			continue
+		}
+		if tracked {
+			isServed, err := tracker.IsServed(ctx, queue)
+			if err != nil {
+				return nil, err
+			}
+			if isServed {
+				continue
+			}
+		}
+		orphans = append(orphans, queue)
+	}
+	return orphans, nil
+}
+
+// checkOrphanQueues records this instance's served queues with the driver and
+// warns about every queue left without a consumer.
+func (m *Manager) checkOrphanQueues(ctx context.Context) {
+	if tracker, ok := m.driver.(ConsumerTracker); ok {
+		// Stay marked across a missed check or two
+		ttl := 3 * m.config.OrphanCheckInterval
+		if err := tracker.MarkServed(ctx, m.servedQueues(), ttl); err != nil {
+			m.logError("Failed to record served queues", err)
+		}
+	}
+
+	orphans, err := m.OrphanQueues(ctx)
+	if err != nil {
+		m.logError("Failed to check for orphaned queues", err)
+		return
+	}
+
+	for _, queue := range orphans {
+		size, _ := m.driver.Size(ctx, queue)
+		m.logWarn("Queue has jobs but no instance serves it; add it to serve_queues", "queue", queue, "size", size)
+
+		if m.metricQueueOrphaned != nil {
+			m.metricQueueOrphaned.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("queue.name", queue),
+			))
+		}
+		m.sink().IncCounter("queue.orphaned", 1, map[string]string{"queue.name": queue})
+	}
+}
@@ -0,0 +1,101 @@
+package dgqueue_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// warnLogger records Warn messages and their arguments.
+type warnLogger struct {
+	mu    sync.Mutex
+	warns [][]interface{}
+}
+
+func (l *warnLogger) Debug(msg string, args ...interface{}) {}
+func (l *warnLogger) Info(msg string, args ...interface{})  {}
+func (l *warnLogger) Error(msg string, args ...interface{}) {}
+func (l *warnLogger) Warn(msg string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warns = append(l.warns, append([]interface{}{msg}, args...))
+}
+func (l *warnLogger) With(args ...interface{}) dgqueue.Logger { return l }
+
+func (l *warnLogger) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.warns)
+}
+
+func TestManager_OrphanQueueWarning(t *testing.T) {
+	logger := &warnLogger{}
+	cfg := dgqueue.DefaultConfig()
+	cfg.Logger = logger
+	cfg.OrphanCheckInterval = time.Hour
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+	sink := &recordingSink{}
+	manager.SetMetricsSink(sink)
+
+	// Nothing serves "reports"; "default" is served by this manager
+	ctx := context.Background()
+	job := manager.NewJob("report", nil)
+	job.Queue = "reports"
+	assert.NoError(t, manager.Enqueue(ctx, job))
+	manager.Dispatch(ctx, "send-email", nil)
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	// The check runs at start without waiting for the interval
+	assert.Eventually(t, func() bool {
+		return logger.count() == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Contains(t, logger.warns[0], "reports")
+
+	calls := sink.find("counter", "queue.orphaned")
+	assert.Len(t, calls, 1)
+	assert.Equal(t, "reports", calls[0].labels["queue.name"])
+
+	orphans, err := manager.OrphanQueues(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"reports"}, orphans)
+}
+
+func TestManager_OrphanQueuesServedElsewhere(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	d, _ := memory.NewDriver(cfg)
+
+	// A web instance dispatches but doesn't run workers
+	web := dgqueue.New(cfg)
+	web.SetDriver(d)
+	ctx := context.Background()
+	job := web.NewJob("report", nil)
+	job.Queue = "reports"
+	assert.NoError(t, web.Enqueue(ctx, job))
+
+	orphans, err := web.OrphanQueues(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"reports"}, orphans)
+
+	// A worker instance sharing the driver starts serving the queue
+	workerCfg := cfg
+	workerCfg.ServeQueues = []string{"reports"}
+	worker := dgqueue.New(workerCfg)
+	worker.SetDriver(d)
+	assert.NoError(t, worker.Start())
+	defer worker.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		orphans, err := web.OrphanQueues(ctx)
+		return err == nil && len(orphans) == 0
+	}, time.Second, 5*time.Millisecond)
+}
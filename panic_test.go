@@ -0,0 +1,59 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_RecoversHandlerPanic(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	for _, execution := range []string{HandlerGoroutine, HandlerInline} {
+		t.Run(execution, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.HandlerExecution = execution
+			driver := &retryingDriver{}
+			m := New(cfg)
+			m.SetDriver(driver)
+
+			pool := &workerPool{
+				name:        "import",
+				concurrency: 1,
+				handler: func(ctx context.Context, job *Job) error {
+					panic(errBoom)
+				},
+			}
+			job := m.NewJob("import", nil)
+
+			m.processJob(pool, job)
+
+			// The panic fails the attempt like a returned error
+			assert.Len(t, driver.retried, 1)
+			assert.Equal(t, "job panicked: boom", job.Error)
+			stack, _ := job.Metadata[MetadataPanicStack].(string)
+			assert.Contains(t, stack, "panic_test.go")
+
+			// A later failure without a panic drops the stale stack
+			pool.handler = func(ctx context.Context, job *Job) error {
+				return errors.New("timeout calling API")
+			}
+			m.processJob(pool, job)
+			assert.NotContains(t, job.Metadata, MetadataPanicStack)
+		})
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	err := error(&PanicError{Value: errBoom})
+	assert.ErrorIs(t, err, ErrJobPanicked)
+	assert.ErrorIs(t, err, errBoom)
+
+	err = &PanicError{Value: "index out of range"}
+	assert.ErrorIs(t, err, ErrJobPanicked)
+	assert.Equal(t, "job panicked: index out of range", err.Error())
+}
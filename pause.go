@@ -0,0 +1,63 @@
+package dgqueue
+
+import "sort"
+
+// Pause stops this manager popping jobs from the queue until Resume is called.
+// Jobs can still be dispatched to the queue, and jobs already handed to
+// workers run to completion. Unlike SetGlobalPause, it only affects this
+// manager and is not stored in the driver.
+func (m *Manager) Pause(queue string) {
+	m.mu.Lock()
+	m.paused[queue] = struct{}{}
+	m.mu.Unlock()
+
+	m.logInfo("Queue paused", "queue", queue)
+}
+
+// Resume lets this manager pop jobs from a queue paused with Pause again.
+func (m *Manager) Resume(queue string) {
+	m.mu.Lock()
+	delete(m.paused, queue)
+	m.mu.Unlock()
+
+	m.logInfo("Queue resumed", "queue", queue)
+}
+
+// Paused reports whether the queue was paused with Pause.
+func (m *Manager) Paused(queue string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, paused := m.paused[queue]
+	return paused
+}
+
+// PausedQueues returns the queues paused with Pause, sorted by name.
+func (m *Manager) PausedQueues() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	queues := make([]string, 0, len(m.paused))
+	for queue := range m.paused {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+	return queues
+}
+
+// unpaused returns the queues that aren't paused, keeping their order.
+func (m *Manager) unpaused(queues []string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.paused) == 0 {
+		return queues
+	}
+	active := make([]string, 0, len(queues))
+	for _, queue := range queues {
+		if _, paused := m.paused[queue]; !paused {
+			active = append(active, queue)
+		}
+	}
+	return active
+}
@@ -0,0 +1,76 @@
+package dgqueue_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_PauseQueue(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+	cfg.ServeQueues = []string{"default", "reports"}
+
+	manager := dgqueue.New(cfg)
+	driver, _ := memory.NewDriver(cfg)
+	manager.SetDriver(driver)
+
+	var processed atomic.Int64
+	manager.Worker("work", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		processed.Add(1)
+		return nil
+	})
+
+	manager.Pause("default")
+	assert.True(t, manager.Paused("default"))
+	assert.False(t, manager.Paused("reports"))
+	assert.Equal(t, []string{"default"}, manager.MetricsSnapshot().PausedQueues)
+
+	// Dispatching to a paused queue still works
+	ctx := context.Background()
+	manager.Dispatch(ctx, "work", 1)
+	manager.Dispatch(ctx, "work", 2)
+	assert.NoError(t, manager.Enqueue(ctx, dgqueue.WithQueue(manager.NewJob("work", 3), "reports")))
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	// Other queues keep being served
+	assert.Eventually(t, func() bool {
+		return processed.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	size, _ := driver.Size(ctx, "default")
+	assert.Equal(t, int64(2), size)
+
+	manager.Resume("default")
+	assert.Empty(t, manager.PausedQueues())
+	assert.Eventually(t, func() bool {
+		return processed.Load() == 3
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestManager_RunUntilEmptySkipsPausedQueues(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 5 * time.Millisecond
+	cfg.DrainIdle = 20 * time.Millisecond
+	cfg.DrainWaitDelayed = true
+
+	manager := dgqueue.New(cfg)
+	driver, _ := memory.NewDriver(cfg)
+	manager.SetDriver(driver)
+	manager.Worker("work", 1, func(ctx context.Context, job *dgqueue.Job) error { return nil })
+
+	ctx := context.Background()
+	manager.Dispatch(ctx, "work", nil)
+	manager.Pause("default")
+
+	assert.NoError(t, manager.RunUntilEmpty(ctx, nil))
+	size, _ := driver.Size(ctx, "default")
+	assert.Equal(t, int64(1), size)
+}
@@ -0,0 +1,52 @@
+package dgqueue
+
+import "fmt"
+
+// PayloadMap returns the job payload as a map.
+// Unlike a type assertion it never panics: payloads that don't describe an
+// object (strings, numbers, nil, ...) return an error wrapping ErrInvalidPayload.
+func PayloadMap(j *Job) (map[string]interface{}, error) {
+	switch payload := j.Payload.(type) {
+	case map[string]interface{}:
+		return payload, nil
+	case map[string]string:
+		result := make(map[string]interface{}, len(payload))
+		for k, v := range payload {
+			result[k] = v
+		}
+		return result, nil
+	case nil:
+		return nil, fmt.Errorf("%w: expected map payload, got nil", ErrInvalidPayload)
+	}
+
+	// Structs and other maps are normalized the way serializing drivers would
+	var result map[string]interface{}
+	if err := decodePayload(j.Payload, &result); err != nil {
+		return nil, fmt.Errorf("%w: expected map payload, got %T", ErrInvalidPayload, j.Payload)
+	}
+	return result, nil
+}
+
+// PayloadString returns the job payload as a string.
+// Byte slices are converted; any other type returns an error wrapping ErrInvalidPayload.
+func PayloadString(j *Job) (string, error) {
+	switch payload := j.Payload.(type) {
+	case string:
+		return payload, nil
+	case []byte:
+		return string(payload), nil
+	}
+	return "", fmt.Errorf("%w: expected string payload, got %T", ErrInvalidPayload, j.Payload)
+}
+
+// PayloadBytes returns the job payload as bytes, such as one dispatched with DispatchRaw.
+// Strings are converted; any other type returns an error wrapping ErrInvalidPayload.
+func PayloadBytes(j *Job) ([]byte, error) {
+	switch payload := j.Payload.(type) {
+	case []byte:
+		return payload, nil
+	case string:
+		return []byte(payload), nil
+	}
+	return nil, fmt.Errorf("%w: expected bytes payload, got %T", ErrInvalidPayload, j.Payload)
+}
@@ -0,0 +1,87 @@
+package dgqueue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadMap(t *testing.T) {
+	payload, err := PayloadMap(NewJob("test", map[string]interface{}{"to": "a@example.com"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "a@example.com", payload["to"])
+
+	payload, err = PayloadMap(NewJob("test", map[string]string{"to": "b@example.com"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "b@example.com", payload["to"])
+
+	type email struct {
+		To string `json:"to"`
+	}
+	payload, err = PayloadMap(NewJob("test", email{To: "c@example.com"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "c@example.com", payload["to"])
+}
+
+func TestPayloadMap_Mismatch(t *testing.T) {
+	for _, value := range []interface{}{"a@example.com", 42, []string{"a"}, nil} {
+		assert.NotPanics(t, func() {
+			payload, err := PayloadMap(NewJob("test", value))
+			assert.ErrorIs(t, err, ErrInvalidPayload)
+			assert.Nil(t, payload)
+		})
+	}
+}
+
+func TestPayloadString(t *testing.T) {
+	payload, err := PayloadString(NewJob("test", "hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", payload)
+
+	payload, err = PayloadString(NewJob("test", []byte("bytes")))
+	assert.NoError(t, err)
+	assert.Equal(t, "bytes", payload)
+}
+
+func TestPayloadString_Mismatch(t *testing.T) {
+	for _, value := range []interface{}{map[string]interface{}{"to": "a"}, 42, nil} {
+		assert.NotPanics(t, func() {
+			payload, err := PayloadString(NewJob("test", value))
+			assert.ErrorIs(t, err, ErrInvalidPayload)
+			assert.Empty(t, payload)
+		})
+	}
+}
+
+func TestPayloadBytes(t *testing.T) {
+	payload, err := PayloadBytes(NewJob("test", []byte{0x00, 0xff}))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0xff}, payload)
+
+	_, err = PayloadBytes(NewJob("test", 42))
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+}
+
+func TestRawPayload_RoundTrip(t *testing.T) {
+	raw := []byte("{\"forwarded\":true}\x00\xff")
+
+	for _, format := range []JobFormat{FormatJSON, FormatGob, FormatMsgpack} {
+		job := WithMetadata(NewJob("relay", raw), MetadataRawPayload, true)
+
+		data, err := MarshalJobAs(job, format)
+		assert.NoError(t, err)
+
+		decoded, err := UnmarshalJob(data)
+		assert.NoError(t, err)
+		assert.Equal(t, raw, decoded.Payload, "format %d", format)
+	}
+}
+
+func TestRawPayload_StringPayloadUnchanged(t *testing.T) {
+	data, err := MarshalJob(NewJob("plain", "aGVsbG8="))
+	assert.NoError(t, err)
+
+	decoded, err := UnmarshalJob(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "aGVsbG8=", decoded.Payload)
+}
@@ -0,0 +1,69 @@
+package dgqueue
+
+// MetadataPriority is the job metadata key holding the job priority.
+const MetadataPriority = "priority"
+
+// Priority levels. Higher values are more urgent; any int is valid.
+const (
+	PriorityLow    = -10
+	PriorityNormal = 0
+	PriorityHigh   = 10
+)
+
+// WithPriority sets the job priority. Drivers that order by priority (memory,
+// Redis) pop higher-priority jobs first within a queue, FIFO among equals.
+func WithPriority(j *Job, priority int) *Job {
+	return WithMetadata(j, MetadataPriority, priority)
+}
+
+// GetPriority returns the job priority (PriorityNormal if unset).
+func GetPriority(j *Job) int {
+	if priority, ok := metadataInt(j, MetadataPriority); ok {
+		return priority
+	}
+	return PriorityNormal
+}
+
+// metadataInt returns the integer job metadata value for key.
+func metadataInt(j *Job, key string) (int, bool) {
+	if j.Metadata == nil {
+		return 0, false
+	}
+
+	// Metadata that went through JSON holds numbers as float64, and through
+	// msgpack as the smallest integer type that fits
+	switch v := j.Metadata[key].(type) {
+	case int:
+		return v, true
+	case int8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case uint8:
+		return int(v), true
+	case uint16:
+		return int(v), true
+	case uint32:
+		return int(v), true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
+
+// DefaultPriorityClass maps a priority value to a coarse class (high, normal, low).
+func DefaultPriorityClass(priority int) string {
+	switch {
+	case priority >= PriorityHigh:
+		return "high"
+	case priority <= PriorityLow:
+		return "low"
+	default:
+		return "normal"
+	}
+}
@@ -0,0 +1,42 @@
+package dgqueue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriority_GetPriority(t *testing.T) {
+	job := NewJob("test", "payload")
+	assert.Equal(t, PriorityNormal, GetPriority(job))
+
+	WithMetadata(job, MetadataPriority, PriorityHigh)
+	assert.Equal(t, PriorityHigh, GetPriority(job))
+
+	// Survives a JSON round trip
+	data, _ := MarshalJob(job)
+	decoded, _ := UnmarshalJob(data)
+	assert.Equal(t, PriorityHigh, GetPriority(decoded))
+}
+
+func TestPriority_WithPriority(t *testing.T) {
+	job := WithPriority(NewJob("test", "payload"), -300)
+	assert.Equal(t, -300, GetPriority(job))
+
+	// Survives every format's round trip
+	for _, format := range []JobFormat{FormatJSON, FormatGob, FormatMsgpack} {
+		data, err := MarshalJobAs(job, format)
+		assert.NoError(t, err)
+		decoded, err := UnmarshalJob(data)
+		assert.NoError(t, err)
+		assert.Equal(t, -300, GetPriority(decoded), "format %d", format)
+	}
+}
+
+func TestPriority_DefaultPriorityClass(t *testing.T) {
+	assert.Equal(t, "high", DefaultPriorityClass(PriorityHigh))
+	assert.Equal(t, "high", DefaultPriorityClass(50))
+	assert.Equal(t, "normal", DefaultPriorityClass(PriorityNormal))
+	assert.Equal(t, "normal", DefaultPriorityClass(5))
+	assert.Equal(t, "low", DefaultPriorityClass(PriorityLow))
+}
@@ -0,0 +1,250 @@
+// Package prometheus exposes queue metrics to Prometheus, for deployments
+// without an OpenTelemetry collector.
+//
+// A Collector receives the manager's job counters and histograms as its
+// metrics sink, and reads queue depth, worker and throughput gauges from the
+// manager when scraped:
+//
+//	collector := prometheus.NewCollector(q)
+//	http.Handle("/metrics", collector.Handler())
+//
+// Metric names are the OpenTelemetry ones with dots replaced by underscores,
+// and counters suffixed with _total (queue.job.processed becomes
+// queue_job_processed_total).
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// scrapeTimeout bounds the driver queries made for a scrape.
+const scrapeTimeout = 5 * time.Second
+
+// millisecondBuckets suit the millisecond durations the manager reports,
+// from 1ms to about 2.5 minutes.
+var millisecondBuckets = prom.ExponentialBuckets(1, 2.5, 14)
+
+// metricSpec describes how a metric emitted by the manager is exposed.
+type metricSpec struct {
+	name    string
+	help    string
+	buckets []float64
+}
+
+// specs covers the metrics the manager emits through its sink. Others, such
+// as ones emitted by custom middleware, get a name derived from theirs.
+var specs = map[string]metricSpec{
+	"queue.job.processed": {name: "queue_job_processed_total", help: "Total number of jobs processed"},
+	"queue.job.deferred":  {name: "queue_job_deferred_total", help: "Total number of jobs pushed back to the queue without running"},
+	"queue.job.exhausted": {name: "queue_job_exhausted_total", help: "Total number of jobs that failed after exhausting all retries"},
+	"queue.orphaned":      {name: "queue_orphaned_total", help: "Number of checks that found a queue holding jobs no running instance serves"},
+	"queue.job.duration": {
+		name:    "queue_job_duration_milliseconds",
+		help:    "Time spent running the job's handler",
+		buckets: millisecondBuckets,
+	},
+	"queue.job.wait_time": {
+		name:    "queue_job_wait_time_milliseconds",
+		help:    "Time jobs waited in the queue between becoming available and starting",
+		buckets: millisecondBuckets,
+	},
+}
+
+// Gauges read from the manager when scraped.
+var (
+	depthDesc = prom.NewDesc("queue_depth",
+		"Current number of pending jobs in the queue", []string{"queue_name"}, nil)
+	workersDesc = prom.NewDesc("queue_workers",
+		"Number of workers in the pool", []string{"queue_name"}, nil)
+	busyWorkersDesc = prom.NewDesc("queue_workers_busy",
+		"Number of workers currently executing a handler", []string{"queue_name"}, nil)
+	oldestAgeDesc = prom.NewDesc("queue_oldest_age_seconds",
+		"Time the oldest ready job in the queue has been waiting", []string{"queue_name"}, nil)
+	throughputDesc = prom.NewDesc("queue_job_throughput",
+		"Jobs completed per second over the throughput window", nil, nil)
+)
+
+// scraped are the sink gauges the collector reads from the manager instead.
+var scraped = map[string]bool{
+	"queue.job.throughput": true,
+}
+
+// Collector is a prometheus.Collector for a queue manager's metrics, and the
+// manager's dgqueue.MetricsSink.
+//
+// Job metrics are created as the manager first emits them, so the collector
+// is unchecked: its Describe reports no descriptors.
+type Collector struct {
+	manager *dgqueue.Manager
+
+	mu         sync.Mutex
+	counters   map[string]*prom.CounterVec
+	histograms map[string]*prom.HistogramVec
+	gauges     map[string]*prom.GaugeVec
+}
+
+// NewCollector creates a collector for the manager's metrics and installs it
+// as the manager's metrics sink, replacing any sink set before.
+func NewCollector(manager *dgqueue.Manager) *Collector {
+	c := &Collector{
+		manager:    manager,
+		counters:   make(map[string]*prom.CounterVec),
+		histograms: make(map[string]*prom.HistogramVec),
+		gauges:     make(map[string]*prom.GaugeVec),
+	}
+	manager.SetMetricsSink(c)
+	return c
+}
+
+// Handler returns an HTTP handler serving the collector's metrics in the
+// Prometheus exposition format, from a registry of its own. To serve them
+// alongside other metrics, register the collector with that registry instead.
+func (c *Collector) Handler() http.Handler {
+	registry := prom.NewRegistry()
+	registry.MustRegister(c)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// IncCounter adds value to the named counter.
+func (c *Collector) IncCounter(name string, value int64, labels map[string]string) {
+	c.mu.Lock()
+	counter, ok := c.counters[name]
+	if !ok {
+		spec := specFor(name, "_total")
+		counter = prom.NewCounterVec(prom.CounterOpts{Name: spec.name, Help: spec.help}, labelNames(labels))
+		c.counters[name] = counter
+	}
+	c.mu.Unlock()
+
+	// Calls with other labels than the first for the name are dropped
+	if m, err := counter.GetMetricWith(labelValues(labels)); err == nil {
+		m.Add(float64(value))
+	}
+}
+
+// ObserveHistogram records value in the named histogram.
+func (c *Collector) ObserveHistogram(name string, value float64, labels map[string]string) {
+	c.mu.Lock()
+	histogram, ok := c.histograms[name]
+	if !ok {
+		spec := specFor(name, "")
+		histogram = prom.NewHistogramVec(prom.HistogramOpts{
+			Name:    spec.name,
+			Help:    spec.help,
+			Buckets: spec.buckets,
+		}, labelNames(labels))
+		c.histograms[name] = histogram
+	}
+	c.mu.Unlock()
+
+	if m, err := histogram.GetMetricWith(labelValues(labels)); err == nil {
+		m.Observe(value)
+	}
+}
+
+// SetGauge sets the named gauge, unless the collector reads it from the
+// manager when scraped.
+func (c *Collector) SetGauge(name string, value float64, labels map[string]string) {
+	if scraped[name] {
+		return
+	}
+
+	c.mu.Lock()
+	gauge, ok := c.gauges[name]
+	if !ok {
+		spec := specFor(name, "")
+		gauge = prom.NewGaugeVec(prom.GaugeOpts{Name: spec.name, Help: spec.help}, labelNames(labels))
+		c.gauges[name] = gauge
+	}
+	c.mu.Unlock()
+
+	if m, err := gauge.GetMetricWith(labelValues(labels)); err == nil {
+		m.Set(value)
+	}
+}
+
+// Describe reports no descriptors, making the collector unchecked.
+func (c *Collector) Describe(ch chan<- *prom.Desc) {}
+
+// Collect sends the job metrics emitted so far, and the manager's gauges.
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	c.mu.Lock()
+	for _, counter := range c.counters {
+		counter.Collect(ch)
+	}
+	for _, histogram := range c.histograms {
+		histogram.Collect(ch)
+	}
+	for _, gauge := range c.gauges {
+		gauge.Collect(ch)
+	}
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	for queue, size := range c.manager.QueueDepths(ctx) {
+		ch <- prom.MustNewConstMetric(depthDesc, prom.GaugeValue, float64(size), queue)
+
+		// Drivers that can't report job age are left out
+		if age, err := c.manager.OldestJobAge(ctx, queue); err == nil {
+			ch <- prom.MustNewConstMetric(oldestAgeDesc, prom.GaugeValue, age.Seconds(), queue)
+		}
+	}
+
+	for name, stat := range c.manager.PoolStats() {
+		ch <- prom.MustNewConstMetric(workersDesc, prom.GaugeValue, float64(stat.Concurrency), name)
+		ch <- prom.MustNewConstMetric(busyWorkersDesc, prom.GaugeValue, float64(stat.Busy), name)
+	}
+
+	ch <- prom.MustNewConstMetric(throughputDesc, prom.GaugeValue, c.manager.Throughput())
+}
+
+// specFor returns the spec of the named metric, deriving one for metrics the
+// manager doesn't emit itself.
+func specFor(name, suffix string) metricSpec {
+	if spec, ok := specs[name]; ok {
+		return spec
+	}
+	return metricSpec{name: sanitize(name) + suffix, help: name}
+}
+
+// labelNames returns the sorted Prometheus names of the labels.
+func labelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, sanitize(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// labelValues returns the labels keyed by their Prometheus names.
+func labelValues(labels map[string]string) prom.Labels {
+	values := make(prom.Labels, len(labels))
+	for name, value := range labels {
+		values[sanitize(name)] = value
+	}
+	return values
+}
+
+// sanitize turns a dotted OpenTelemetry name into a valid Prometheus one.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,99 @@
+package prometheus_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/donnigundala/dg-queue/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+// scrape returns the metrics served by the collector's handler.
+func scrape(t *testing.T, collector *prometheus.Collector) string {
+	t.Helper()
+	server := httptest.NewServer(collector.Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+func TestCollector(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxAttempts = 1
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	collector := prometheus.NewCollector(manager)
+
+	manager.Worker("ok-job", 2, func(ctx context.Context, job *dgqueue.Job) error {
+		return nil
+	})
+	manager.Worker("bad-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		return errors.New("boom")
+	})
+
+	ctx := context.Background()
+	manager.Dispatch(ctx, "ok-job", nil)
+	manager.Dispatch(ctx, "bad-job", nil)
+
+	assert.NoError(t, manager.Start())
+	assert.Eventually(t, func() bool {
+		return manager.MetricsSnapshot().Processed == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.NoError(t, manager.Stop(ctx))
+
+	// Jobs left queued after the workers stopped
+	manager.Dispatch(ctx, "ok-job", nil)
+	manager.Dispatch(ctx, "ok-job", nil)
+
+	metrics := scrape(t, collector)
+	for _, want := range []string{
+		`queue_job_processed_total{job_priority_class="normal",job_status="success",queue_name="ok-job"} 1`,
+		`queue_job_processed_total{job_priority_class="normal",job_status="failed",queue_name="bad-job"} 1`,
+		`queue_job_exhausted_total{job_name="bad-job",job_priority_class="normal",queue_name="default"} 1`,
+		`queue_job_duration_milliseconds_count{job_priority_class="normal",job_status="success",queue_name="ok-job"} 1`,
+		`queue_job_wait_time_milliseconds_bucket{job_priority_class="normal",job_status="failed",queue_name="bad-job",le="+Inf"} 1`,
+		`queue_depth{queue_name="default"} 2`,
+		`queue_workers{queue_name="ok-job"} 2`,
+		`queue_workers_busy{queue_name="ok-job"} 0`,
+		`queue_oldest_age_seconds{queue_name="default"}`,
+		`# TYPE queue_job_throughput gauge`,
+	} {
+		assert.True(t, strings.Contains(metrics, want), "Expected metrics to contain %s, got:\n%s", want, metrics)
+	}
+}
+
+func TestCollector_CustomMetrics(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+	collector := prometheus.NewCollector(manager)
+
+	collector.IncCounter("billing.charges", 2, map[string]string{"payment.provider": "stripe"})
+	collector.IncCounter("billing.charges", 1, map[string]string{"payment.provider": "stripe"})
+	collector.SetGauge("billing.balance", 42, nil)
+
+	// Labels differing from the metric's first are dropped rather than panicking
+	collector.IncCounter("billing.charges", 1, map[string]string{"region": "eu"})
+
+	metrics := scrape(t, collector)
+	assert.Contains(t, metrics, `billing_charges_total{payment_provider="stripe"} 3`)
+	assert.Contains(t, metrics, `billing_balance 42`)
+}
@@ -0,0 +1,257 @@
+package dgqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Field numbers of the protobuf job encoding:
+//
+//	message Job {
+//	  string id = 1;
+//	  string name = 2;
+//	  string queue = 3;
+//	  oneof payload {
+//	    google.protobuf.Any message = 4; // payloads that are proto messages
+//	    bytes json = 5;                  // any other payload, as JSON
+//	  }
+//	  int64 attempts = 6;
+//	  int64 max_attempts = 7;
+//	  int64 timeout = 8; // nanoseconds
+//	  int64 delay = 9;   // nanoseconds
+//	  google.protobuf.Timestamp created_at = 10;
+//	  google.protobuf.Timestamp updated_at = 11;
+//	  google.protobuf.Timestamp available_at = 12;
+//	  google.protobuf.Timestamp started_at = 13;
+//	  google.protobuf.Timestamp completed_at = 14;
+//	  google.protobuf.Timestamp failed_at = 15;
+//	  string error = 16;
+//	  bytes metadata = 17; // JSON
+//	}
+const (
+	pbID protowire.Number = iota + 1
+	pbName
+	pbQueue
+	pbPayloadMessage
+	pbPayloadJSON
+	pbAttempts
+	pbMaxAttempts
+	pbTimeout
+	pbDelay
+	pbCreatedAt
+	pbUpdatedAt
+	pbAvailableAt
+	pbStartedAt
+	pbCompletedAt
+	pbFailedAt
+	pbError
+	pbMetadata
+)
+
+// protobufCodec is Protocol Buffers. Payloads that are proto messages are
+// stored as google.protobuf.Any and decoded back into their own type, so
+// their fields keep exact types; the message type must be linked into the
+// reading binary. Other payloads and metadata are stored as JSON.
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string      { return "protobuf" }
+func (protobufCodec) Format() JobFormat { return FormatProtobuf }
+
+func (protobufCodec) Marshal(j *Job) ([]byte, error) {
+	var b []byte
+	b = appendString(b, pbID, j.ID)
+	b = appendString(b, pbName, j.Name)
+	b = appendString(b, pbQueue, j.Queue)
+
+	switch payload := j.Payload.(type) {
+	case nil:
+	case proto.Message:
+		message, err := anypb.New(payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		if b, err = appendMessage(b, pbPayloadMessage, message); err != nil {
+			return nil, err
+		}
+	default:
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		b = appendBytes(b, pbPayloadJSON, data)
+	}
+
+	b = appendVarint(b, pbAttempts, int64(j.Attempts))
+	b = appendVarint(b, pbMaxAttempts, int64(j.MaxAttempts))
+	b = appendVarint(b, pbTimeout, int64(j.Timeout))
+	b = appendVarint(b, pbDelay, int64(j.Delay))
+
+	times := []struct {
+		num protowire.Number
+		t   *time.Time
+	}{
+		{pbCreatedAt, &j.CreatedAt},
+		{pbUpdatedAt, &j.UpdatedAt},
+		{pbAvailableAt, &j.AvailableAt},
+		{pbStartedAt, j.StartedAt},
+		{pbCompletedAt, j.CompletedAt},
+		{pbFailedAt, j.FailedAt},
+	}
+	for _, field := range times {
+		if field.t == nil || field.t.IsZero() {
+			continue
+		}
+		var err error
+		if b, err = appendMessage(b, field.num, timestamppb.New(*field.t)); err != nil {
+			return nil, err
+		}
+	}
+
+	b = appendString(b, pbError, j.Error)
+	if len(j.Metadata) > 0 {
+		data, err := json.Marshal(j.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		b = appendBytes(b, pbMetadata, data)
+	}
+	return b, nil
+}
+
+func (protobufCodec) Unmarshal(data []byte, j *Job) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch typ {
+		case protowire.BytesType:
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			if err := unmarshalBytesField(j, num, value); err != nil {
+				return err
+			}
+		case protowire.VarintType:
+			value, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			unmarshalVarintField(j, num, int64(value))
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
+
+// unmarshalBytesField sets the job field num, encoded as bytes, to value.
+func unmarshalBytesField(j *Job, num protowire.Number, value []byte) error {
+	switch num {
+	case pbID:
+		j.ID = string(value)
+	case pbName:
+		j.Name = string(value)
+	case pbQueue:
+		j.Queue = string(value)
+	case pbError:
+		j.Error = string(value)
+	case pbPayloadMessage:
+		var message anypb.Any
+		if err := proto.Unmarshal(value, &message); err != nil {
+			return err
+		}
+		payload, err := message.UnmarshalNew()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		j.Payload = payload
+	case pbPayloadJSON:
+		return json.Unmarshal(value, &j.Payload)
+	case pbMetadata:
+		return json.Unmarshal(value, &j.Metadata)
+	case pbCreatedAt, pbUpdatedAt, pbAvailableAt, pbStartedAt, pbCompletedAt, pbFailedAt:
+		var ts timestamppb.Timestamp
+		if err := proto.Unmarshal(value, &ts); err != nil {
+			return err
+		}
+		t := ts.AsTime().Local()
+		switch num {
+		case pbCreatedAt:
+			j.CreatedAt = t
+		case pbUpdatedAt:
+			j.UpdatedAt = t
+		case pbAvailableAt:
+			j.AvailableAt = t
+		case pbStartedAt:
+			j.StartedAt = &t
+		case pbCompletedAt:
+			j.CompletedAt = &t
+		case pbFailedAt:
+			j.FailedAt = &t
+		}
+	}
+	return nil
+}
+
+// unmarshalVarintField sets the job field num, encoded as a varint, to value.
+func unmarshalVarintField(j *Job, num protowire.Number, value int64) {
+	switch num {
+	case pbAttempts:
+		j.Attempts = int(value)
+	case pbMaxAttempts:
+		j.MaxAttempts = int(value)
+	case pbTimeout:
+		j.Timeout = time.Duration(value)
+	case pbDelay:
+		j.Delay = time.Duration(value)
+	}
+}
+
+// appendString appends a string field, omitting it when empty.
+func appendString(b []byte, num protowire.Number, value string) []byte {
+	if value == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+// appendBytes appends a bytes field.
+func appendBytes(b []byte, num protowire.Number, value []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, value)
+}
+
+// appendVarint appends an int64 field, omitting it when zero.
+func appendVarint(b []byte, num protowire.Number, value int64) []byte {
+	if value == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(value))
+}
+
+// appendMessage appends an embedded message field.
+func appendMessage(b []byte, num protowire.Number, message proto.Message) ([]byte, error) {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	return appendBytes(b, num, data), nil
+}
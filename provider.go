@@ -25,6 +25,10 @@ type QueueServiceProvider struct {
 	// DriverFactory is an optional function to create the driver
 	// If nil, the driver must be set manually after registration
 	DriverFactory func(Config) (Driver, error)
+
+	// Managers configures additional managers by name, each with its own driver.
+	// They are bound as a ManagerRegistry and resolved with ResolveNamed.
+	Managers map[string]Config
 }
 
 // NewQueueServiceProvider creates a new queue service provider.
@@ -57,58 +61,79 @@ func (p *QueueServiceProvider) Register(app foundation.Application) error {
 		if cfg.Driver == "" {
 			cfg = DefaultConfig()
 		}
+		return p.newManager(app, cfg)
+	})
 
-		// Try to resolve logger (optional)
-		if cfg.Logger == nil {
-			if loggerInstance, err := app.Make("logger"); err == nil {
-				// Adapt dg-core logger to queue.Logger interface
-				if adapted, ok := loggerInstance.(interface {
-					Debug(msg string, args ...interface{})
-					Info(msg string, args ...interface{})
-					Warn(msg string, args ...interface{})
-					Error(msg string, args ...interface{})
-				}); ok {
-					cfg.Logger = &loggerAdapter{logger: adapted}
+	if len(p.Managers) > 0 {
+		app.Singleton(RegistryBinding, func() (interface{}, error) {
+			registry := NewManagerRegistry()
+			for name, cfg := range p.Managers {
+				manager, err := p.newManager(app, cfg)
+				if err != nil {
+					registry.closeDrivers()
+					return nil, fmt.Errorf("queue manager %s: %w", name, err)
 				}
+				registry.Register(name, manager)
+			}
+			return registry, nil
+		})
+	}
+
+	return nil
+}
+
+// newManager creates a manager for cfg with the application's logger and its driver attached.
+func (p *QueueServiceProvider) newManager(app foundation.Application, cfg Config) (*Manager, error) {
+	// Try to resolve logger (optional)
+	if cfg.Logger == nil {
+		if loggerInstance, err := app.Make("logger"); err == nil {
+			// Adapt dg-core logger to queue.Logger interface
+			if adapted, ok := loggerInstance.(interface {
+				Debug(msg string, args ...interface{})
+				Info(msg string, args ...interface{})
+				Warn(msg string, args ...interface{})
+				Error(msg string, args ...interface{})
+			}); ok {
+				cfg.Logger = &loggerAdapter{logger: adapted}
+			} else {
+				fmt.Printf("[Queue] WARN: logger adaptation failed: %T does not implement Debug/Info/Warn/Error, using fallback logging\n", loggerInstance)
 			}
 		}
+	}
 
-		// Create the manager
-		manager := New(cfg)
+	// Create the manager
+	manager := New(cfg)
 
-		// Resolve driver
-		var driver Driver
-		if p.DriverFactory != nil {
+	// Resolve driver
+	var driver Driver
+	if p.DriverFactory != nil {
+		var err error
+		driver, err = p.DriverFactory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create queue driver from factory: %w", err)
+		}
+	} else {
+		// Use global registry
+		globalDriversMu.RLock()
+		factory, ok := globalDrivers[cfg.Driver]
+		globalDriversMu.RUnlock()
+
+		if ok {
 			var err error
-			driver, err = p.DriverFactory(cfg)
+			driver, err = factory(cfg)
 			if err != nil {
-				return nil, fmt.Errorf("failed to create queue driver from factory: %w", err)
-			}
-		} else {
-			// Use global registry
-			globalDriversMu.RLock()
-			factory, ok := globalDrivers[cfg.Driver]
-			globalDriversMu.RUnlock()
-
-			if ok {
-				var err error
-				driver, err = factory(cfg)
-				if err != nil {
-					return nil, fmt.Errorf("failed to create queue driver %s: %w", cfg.Driver, err)
-				}
+				return nil, fmt.Errorf("failed to create queue driver %s: %w", cfg.Driver, err)
 			}
 		}
+	}
 
-		if driver != nil {
-			manager.SetDriver(driver)
-		} else if cfg.Driver != "" {
-			return nil, fmt.Errorf("queue driver %s not found and no factory provided", cfg.Driver)
-		}
-
-		return manager, nil
-	})
+	if driver != nil {
+		manager.SetDriver(driver)
+	} else if cfg.Driver != "" {
+		return nil, fmt.Errorf("queue driver %s not found and no factory provided", cfg.Driver)
+	}
 
-	return nil
+	return manager, nil
 }
 
 // Boot boots the queue service provider.
@@ -118,16 +143,23 @@ func (p *QueueServiceProvider) Boot(app foundation.Application) error {
 
 // Shutdown gracefully stops the queue manager.
 func (p *QueueServiceProvider) Shutdown(app foundation.Application) error {
-	queueInstance, err := app.Make("queue")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if len(p.Managers) > 0 {
+		if registry, err := ResolveRegistry(app); err == nil {
+			if err := registry.Stop(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	lifecycle, err := ResolveLifecycle(app)
 	if err != nil {
 		return nil // Queue not initialized
 	}
 
-	manager := queueInstance.(*Manager)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	return manager.Stop(ctx)
+	return lifecycle.Stop(ctx)
 }
 
 // loggerAdapter adapts a generic logger to queue.Logger interface.
@@ -156,7 +188,15 @@ func (l *loggerAdapter) Error(msg string, args ...interface{}) {
 	l.logger.Error(msg, args...)
 }
 
-func (l *loggerAdapter) With(args ...interface{}) Logger {
+func (l *loggerAdapter) With(args ...interface{}) (result Logger) {
+	// Reflection panics on mismatched signatures; keep the current logger instead
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("[Queue] WARN: logger adaptation failed: With panicked: %v\n", r)
+			result = l
+		}
+	}()
+
 	// Try to call With(args...) via reflection to support different return types
 	v := reflect.ValueOf(l.logger)
 	m := v.MethodByName("With")
@@ -176,6 +216,7 @@ func (l *loggerAdapter) With(args ...interface{}) Logger {
 				return &loggerAdapter{logger: nextLogger}
 			}
 		}
+		fmt.Printf("[Queue] WARN: logger adaptation failed: With returned an unsupported type\n")
 	}
 	return l
 }
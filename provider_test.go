@@ -43,3 +43,39 @@ func TestQueueServiceProvider_CustomConfig(t *testing.T) {
 	assert.Equal(t, "memory", provider.Config.Driver)
 	assert.Equal(t, 10, provider.Config.Workers)
 }
+
+// strictLogger implements the logging methods but its With has a fixed signature
+// and returns a type the adapter cannot use.
+type strictLogger struct {
+	messages []string
+}
+
+func (l *strictLogger) Debug(msg string, args ...interface{}) { l.messages = append(l.messages, msg) }
+func (l *strictLogger) Info(msg string, args ...interface{})  { l.messages = append(l.messages, msg) }
+func (l *strictLogger) Warn(msg string, args ...interface{})  { l.messages = append(l.messages, msg) }
+func (l *strictLogger) Error(msg string, args ...interface{}) { l.messages = append(l.messages, msg) }
+func (l *strictLogger) With(key string, value int) string     { return key }
+
+func TestLoggerAdapter_WithUnexpectedReturnType(t *testing.T) {
+	base := &strictLogger{}
+	adapter := &loggerAdapter{logger: base}
+
+	var next Logger
+	assert.NotPanics(t, func() {
+		next = adapter.With("attempt", 1)
+	})
+	assert.Same(t, adapter, next)
+
+	next.Info("still usable")
+	assert.Equal(t, []string{"still usable"}, base.messages)
+}
+
+func TestLoggerAdapter_WithMismatchedArgs(t *testing.T) {
+	adapter := &loggerAdapter{logger: &strictLogger{}}
+
+	assert.NotPanics(t, func() {
+		assert.Same(t, adapter, adapter.With("attempt", "one"))
+		assert.Same(t, adapter, adapter.With("attempt"))
+		assert.Same(t, adapter, adapter.With(nil, nil))
+	})
+}
@@ -1,6 +1,7 @@
 package dgqueue
 
 import (
+	"context"
 	"time"
 
 	"github.com/donnigundala/dg-core/contracts/queue"
@@ -14,6 +15,15 @@ type JobStatus = queue.JobStatus
 type WorkerFunc = queue.WorkerFunc
 type Middleware = queue.Middleware
 
+// Lifecycle is implemented by queues that can be started and gracefully stopped.
+// Resolve it with ResolveLifecycle to shut a queue down without depending on *Manager.
+type Lifecycle interface {
+	Start() error
+	Stop(ctx context.Context) error
+}
+
+var _ Lifecycle = (*Manager)(nil)
+
 // BatchMapper is the function signature for batch item mapping.
 type BatchMapper func(item interface{}) (interface{}, error)
 
@@ -24,4 +34,10 @@ type BatchConfig struct {
 	OnError         func(item interface{}, err error)
 	ContinueOnError bool
 	RateLimit       time.Duration
+
+	// BatchID makes the batch idempotent: re-running a batch with the same ID
+	// skips items (identified by position) that were already dispatched. It is
+	// also the ID the batch's status is saved under; a random one is used if
+	// empty
+	BatchID string
 }
@@ -0,0 +1,87 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit returns middleware that runs at most n jobs named name per
+// interval, such as jobs calling a rate-limited API. Jobs over the limit
+// return ErrJobDeferred and go back to the queue without using an attempt,
+// delayed until the next token is due. Jobs with other names are not limited.
+//
+// Drivers implementing RateLimiter share the limit across every instance
+// using the backend; otherwise each manager enforces it on its own.
+func (m *Manager) RateLimit(name string, n int, per time.Duration) Middleware {
+	local := &tokenBucket{}
+
+	return func(next WorkerFunc) WorkerFunc {
+		return func(ctx context.Context, job *Job) error {
+			if job.Name != name || n <= 0 || per <= 0 {
+				return next(ctx, job)
+			}
+
+			wait, err := m.takeToken(ctx, local, name, n, per)
+			if err != nil {
+				// Without the shared bucket the limit can't be checked; try again later
+				m.logError("Failed to check rate limit", err, "job_id", job.ID, "job_name", job.Name)
+				wait = per / time.Duration(n)
+			}
+			if wait > 0 {
+				job.AvailableAt = m.clock.Now().Add(wait)
+				return fmt.Errorf("%w: %s rate limited for %s", ErrJobDeferred, name, wait)
+			}
+			return next(ctx, job)
+		}
+	}
+}
+
+// takeToken takes a token from the driver's bucket named name, or from local
+// if the driver doesn't keep buckets.
+func (m *Manager) takeToken(ctx context.Context, local *tokenBucket, name string, n int, per time.Duration) (time.Duration, error) {
+	m.mu.RLock()
+	limiter, ok := m.driver.(RateLimiter)
+	m.mu.RUnlock()
+	if ok {
+		wait, err := limiter.TakeToken(ctx, name, n, per)
+		if !errors.Is(err, ErrNotSupported) {
+			return wait, err
+		}
+	}
+	return local.take(m.clock.Now(), n, per), nil
+}
+
+// tokenBucket is a process-local token bucket.
+type tokenBucket struct {
+	mu      sync.Mutex
+	tokens  float64
+	updated time.Time
+}
+
+// take takes a token, returning 0 if one was available or how long until one
+// will be.
+func (b *tokenBucket) take(now time.Time, n int, per time.Duration) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A new bucket starts full
+	rate := float64(n) / float64(per)
+	if b.updated.IsZero() {
+		b.tokens = float64(n)
+	} else if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = min(float64(n), b.tokens+float64(elapsed)*rate)
+	}
+	if now.After(b.updated) {
+		b.updated = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / rate))
+}
@@ -0,0 +1,100 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := &tokenBucket{}
+	now := time.Now()
+
+	// Starts full
+	for i := 0; i < 3; i++ {
+		assert.Zero(t, bucket.take(now, 3, time.Second))
+	}
+	wait := bucket.take(now, 3, time.Second)
+	assert.Equal(t, 333*time.Millisecond, wait.Round(time.Millisecond))
+
+	// Refills over time, up to n
+	assert.Zero(t, bucket.take(now.Add(wait), 3, time.Second))
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.Zero(t, bucket.take(now, 3, time.Second))
+	}
+	assert.NotZero(t, bucket.take(now, 3, time.Second))
+}
+
+func TestManager_RateLimit(t *testing.T) {
+	clk := newFakeClock()
+	m := New(DefaultConfig())
+	m.SetDriver(emptyDriver{})
+	m.clock = clk
+
+	runs := 0
+	handler := m.RateLimit("charge", 2, time.Minute)(func(ctx context.Context, job *Job) error {
+		runs++
+		return nil
+	})
+	ctx := context.Background()
+
+	assert.NoError(t, handler(ctx, NewJob("charge", nil)))
+	assert.NoError(t, handler(ctx, NewJob("charge", nil)))
+
+	job := NewJob("charge", nil)
+	err := handler(ctx, job)
+	assert.True(t, errors.Is(err, ErrJobDeferred))
+	assert.Equal(t, clk.Now().Add(30*time.Second), job.AvailableAt)
+	assert.Equal(t, 2, runs)
+
+	// Other jobs aren't limited
+	assert.NoError(t, handler(ctx, NewJob("send-email", nil)))
+	assert.Equal(t, 3, runs)
+
+	<-clk.After(30 * time.Second)
+	assert.NoError(t, handler(ctx, NewJob("charge", nil)))
+	assert.Equal(t, 4, runs)
+}
+
+// limitingDriver keeps a shared bucket that is always empty.
+type limitingDriver struct {
+	emptyDriver
+	err error
+}
+
+func (d limitingDriver) TakeToken(ctx context.Context, key string, n int, per time.Duration) (time.Duration, error) {
+	return time.Second, d.err
+}
+
+func TestManager_RateLimitSharedBucket(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		allowed bool
+	}{
+		{name: "driver bucket", err: nil},
+		{name: "driver error", err: errors.New("connection refused")},
+		{name: "not supported falls back to local", err: ErrNotSupported, allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(DefaultConfig())
+			m.SetDriver(limitingDriver{err: tt.err})
+
+			handler := m.RateLimit("charge", 10, time.Second)(func(ctx context.Context, job *Job) error {
+				return nil
+			})
+			err := handler(context.Background(), NewJob("charge", nil))
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrJobDeferred)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/donnigundala/dg-core/contracts/foundation"
+)
+
+// ManagerRegistry holds named queue managers, so one process can run queues on
+// different drivers or configs side by side (e.g. a fast Redis queue and a durable one).
+type ManagerRegistry struct {
+	mu       sync.RWMutex
+	managers map[string]*Manager
+}
+
+// NewManagerRegistry creates an empty registry.
+func NewManagerRegistry() *ManagerRegistry {
+	return &ManagerRegistry{managers: make(map[string]*Manager)}
+}
+
+// Register adds a manager under name, replacing any manager already registered with it.
+func (r *ManagerRegistry) Register(name string, manager *Manager) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.managers[name] = manager
+}
+
+// Manager returns the manager registered under name.
+func (r *ManagerRegistry) Manager(name string) (*Manager, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	manager, ok := r.managers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrManagerNotFound, name)
+	}
+	return manager, nil
+}
+
+// Names returns the registered manager names in sorted order.
+func (r *ManagerRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.managers))
+	for name := range r.managers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start starts every registered manager.
+func (r *ManagerRegistry) Start() error {
+	for _, name := range r.Names() {
+		manager, err := r.Manager(name)
+		if err != nil {
+			continue
+		}
+		if err := manager.Start(); err != nil {
+			return fmt.Errorf("queue manager %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Stop stops every registered manager, returning the errors of those that failed.
+func (r *ManagerRegistry) Stop(ctx context.Context) error {
+	var errs []error
+	for _, name := range r.Names() {
+		manager, err := r.Manager(name)
+		if err != nil {
+			continue
+		}
+		if err := manager.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("queue manager %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeDrivers closes the drivers of managers that were never started.
+func (r *ManagerRegistry) closeDrivers() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, manager := range r.managers {
+		if manager.driver != nil {
+			manager.driver.Close()
+		}
+	}
+}
+
+// ResolveRegistry resolves the named managers' registry from the application container.
+func ResolveRegistry(app foundation.Application) (*ManagerRegistry, error) {
+	instance, err := app.Make(RegistryBinding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve queue registry: %w", err)
+	}
+
+	registry, ok := instance.(*ManagerRegistry)
+	if !ok {
+		return nil, fmt.Errorf("resolved instance is not a ManagerRegistry")
+	}
+
+	return registry, nil
+}
+
+// ResolveNamed resolves a named queue manager from the application container.
+func ResolveNamed(app foundation.Application, name string) (Queue, error) {
+	registry, err := ResolveRegistry(app)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := registry.Manager(name)
+	if err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
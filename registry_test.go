@@ -0,0 +1,91 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/donnigundala/dg-core/foundation"
+	"github.com/stretchr/testify/assert"
+)
+
+// namedDriver is an emptyDriver that remembers which config created it.
+type namedDriver struct {
+	emptyDriver
+	name string
+}
+
+func TestQueueServiceProvider_NamedManagers(t *testing.T) {
+	RegisterDriver("registry-fast", func(cfg Config) (Driver, error) {
+		return &namedDriver{name: "fast"}, nil
+	})
+	RegisterDriver("registry-durable", func(cfg Config) (Driver, error) {
+		return &namedDriver{name: "durable"}, nil
+	})
+
+	fastConfig := DefaultConfig()
+	fastConfig.Driver = "registry-fast"
+	durableConfig := DefaultConfig()
+	durableConfig.Driver = "registry-durable"
+	durableConfig.MaxAttempts = 10
+
+	app := foundation.New(".")
+	provider := &QueueServiceProvider{
+		Managers: map[string]Config{
+			"fast":    fastConfig,
+			"durable": durableConfig,
+		},
+	}
+	assert.NoError(t, provider.Register(app))
+
+	fast, err := ResolveNamed(app, "fast")
+	assert.NoError(t, err)
+	durable, err := ResolveNamed(app, "durable")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "fast", fast.(*Manager).driver.(*namedDriver).name)
+	assert.Equal(t, "durable", durable.(*Manager).driver.(*namedDriver).name)
+	assert.Equal(t, 10, durable.(*Manager).config.MaxAttempts)
+
+	// Repeated resolution returns the same manager
+	again, err := ResolveNamed(app, "fast")
+	assert.NoError(t, err)
+	assert.Same(t, fast, again)
+
+	// The default binding is unaffected
+	q, err := Resolve(app)
+	assert.NoError(t, err)
+	assert.NotSame(t, fast, q)
+
+	_, err = ResolveNamed(app, "missing")
+	assert.True(t, errors.Is(err, ErrManagerNotFound))
+}
+
+func TestResolveNamed_WithoutRegistry(t *testing.T) {
+	app := foundation.New(".")
+
+	_, err := ResolveNamed(app, "fast")
+	assert.Error(t, err)
+}
+
+func TestManagerRegistry_StartStop(t *testing.T) {
+	registry := NewManagerRegistry()
+	for _, name := range []string{"b", "a"} {
+		manager := New(DefaultConfig())
+		manager.SetDriver(emptyDriver{})
+		registry.Register(name, manager)
+	}
+	assert.Equal(t, []string{"a", "b"}, registry.Names())
+
+	assert.NoError(t, registry.Start())
+	for _, name := range registry.Names() {
+		manager, _ := registry.Manager(name)
+		assert.True(t, manager.running)
+	}
+
+	assert.NoError(t, registry.Stop(context.Background()))
+	for _, name := range registry.Names() {
+		manager, _ := registry.Manager(name)
+		assert.False(t, manager.running)
+	}
+}
@@ -0,0 +1,124 @@
+package dgqueue
+
+import (
+	"context"
+	"fmt"
+)
+
+// Policies for Config.RemovedWorkerPolicy.
+const (
+	// RemovedWorkerDeadLetter dead-letters jobs of removed workers with
+	// MetadataDeadLetterReason set to ReasonHandlerRemoved
+	RemovedWorkerDeadLetter = "dead_letter"
+	// RemovedWorkerRequeue pushes jobs of removed workers back, delayed by
+	// Config.RemovedWorkerGrace, in case the worker is registered again
+	RemovedWorkerRequeue = "requeue"
+	// RemovedWorkerMigrate hands jobs of removed workers to the worker
+	// registered as Config.RemovedWorkerFallback
+	RemovedWorkerMigrate = "migrate"
+)
+
+// ReasonHandlerRemoved marks jobs dead-lettered because their worker was removed.
+const ReasonHandlerRemoved = "handler_removed"
+
+// RemoveWorker unregisters the workers for a job name, including those
+// registered on a queue with WorkerOn. If the manager is running, their pools
+// are stopped after in-flight jobs finish.
+// Jobs for the name that are still buffered or queued are then handled
+// according to Config.RemovedWorkerPolicy, until a worker is registered again.
+func (m *Manager) RemoveWorker(ctx context.Context, name string) error {
+	return m.removeWorkers(ctx, name, func(pool *workerPool) bool {
+		return pool.name == name
+	})
+}
+
+// RemoveWorkerOn unregisters the worker registered for a job name on a queue
+// with WorkerOn, like RemoveWorker. Jobs on the queue go to the worker
+// registered for the name with Worker, if there is one.
+func (m *Manager) RemoveWorkerOn(ctx context.Context, queue, name string) error {
+	key := poolKey(queue, name)
+	return m.removeWorkers(ctx, name, func(pool *workerPool) bool {
+		return poolKey(pool.queue, pool.name) == key
+	})
+}
+
+// removeWorkers unregisters the job name's pools that match, stops them and
+// routes their buffered jobs.
+func (m *Manager) removeWorkers(ctx context.Context, name string, match func(*workerPool) bool) error {
+	m.mu.Lock()
+	var pools []*workerPool
+	for key, pool := range m.workers {
+		if match(pool) {
+			delete(m.workers, key)
+			pools = append(pools, pool)
+		}
+	}
+	if len(pools) == 0 {
+		m.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrWorkerNotFound, name)
+	}
+	m.removed[name] = struct{}{}
+	m.updateWorkerQueues()
+	running := m.running
+	m.mu.Unlock()
+
+	for _, pool := range pools {
+		if running {
+			close(pool.stopChan)
+			pool.wg.Wait()
+		}
+
+		// Buffered jobs were already popped; send any that need a worker back
+		// through the dispatcher
+		for _, jobs := range pool.channels() {
+			for len(jobs) > 0 {
+				job := <-jobs
+				if _, exists := m.poolFor(job); exists || m.routeUnhandled(ctx, job) != nil {
+					if err := m.driver.Push(ctx, job); err != nil {
+						m.logError("Failed to requeue job of removed worker", err, "job_id", job.ID, "job_name", job.Name)
+					}
+				}
+			}
+		}
+	}
+
+	m.logInfo("Worker removed", "job_name", name, "pools", len(pools), "policy", m.config.RemovedWorkerPolicy)
+	return nil
+}
+
+// routeUnhandled deals with a job that has no registered worker.
+// Jobs of removed workers follow Config.RemovedWorkerPolicy; any other job is
+// dead-lettered. It returns the pool that should run the job, or nil if the
+// job has been dealt with.
+func (m *Manager) routeUnhandled(ctx context.Context, job *Job) *workerPool {
+	m.mu.RLock()
+	_, removed := m.removed[job.Name]
+	fallback, _ := m.poolForName(job.Queue, m.config.RemovedWorkerFallback)
+	m.mu.RUnlock()
+
+	if !removed {
+		// No worker registered for this job type -> dead letter queue
+		m.moveToDeadLetter(ctx, job)
+		return nil
+	}
+
+	switch m.config.RemovedWorkerPolicy {
+	case RemovedWorkerRequeue:
+		job.Delay = m.config.RemovedWorkerGrace
+		job.AvailableAt = m.clock.Now().Add(m.config.RemovedWorkerGrace)
+		if err := m.driver.Push(ctx, job); err != nil {
+			m.logError("Failed to requeue job of removed worker", err, "job_id", job.ID, "job_name", job.Name)
+		}
+		return nil
+	case RemovedWorkerMigrate:
+		if fallback != nil {
+			return fallback
+		}
+		m.logError("Fallback worker not registered, dead-lettering", ErrWorkerNotFound, "job_id", job.ID, "job_name", job.Name, "fallback", m.config.RemovedWorkerFallback)
+	}
+
+	MarkFailed(job, ErrWorkerNotFound)
+	WithMetadata(job, MetadataDeadLetterReason, ReasonHandlerRemoved)
+	m.moveToDeadLetter(ctx, job)
+	return nil
+}
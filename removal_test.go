@@ -0,0 +1,216 @@
+package dgqueue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRemovalManager registers and removes the "retired-job" worker, leaving
+// two of its jobs pending.
+func newRemovalManager(t *testing.T, cfg dgqueue.Config) (*dgqueue.Manager, dgqueue.Driver) {
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	manager.Worker("retired-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		t.Error("Removed worker should not run")
+		return nil
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := manager.Dispatch(ctx, "retired-job", i)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, manager.RemoveWorker(ctx, "retired-job"))
+	return manager, d
+}
+
+func TestManager_RemoveWorkerDeadLetters(t *testing.T) {
+	manager, _ := newRemovalManager(t, dgqueue.DefaultConfig())
+
+	deadLettered := make(chan *dgqueue.Job, 2)
+	manager.SetDeadLetterHandler(func(ctx context.Context, job *dgqueue.Job) error {
+		deadLettered <- job
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case job := <-deadLettered:
+			assert.Equal(t, dgqueue.ReasonHandlerRemoved, job.Metadata[dgqueue.MetadataDeadLetterReason])
+		case <-time.After(3 * time.Second):
+			t.Fatal("Expected jobs of the removed worker to be dead-lettered")
+		}
+	}
+}
+
+func TestManager_RemoveWorkerRequeues(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.RemovedWorkerPolicy = dgqueue.RemovedWorkerRequeue
+	cfg.RemovedWorkerGrace = time.Hour
+	manager, d := newRemovalManager(t, cfg)
+
+	manager.SetDeadLetterHandler(func(ctx context.Context, job *dgqueue.Job) error {
+		t.Error("Requeued jobs should not be dead-lettered")
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+	time.Sleep(300 * time.Millisecond)
+
+	// Both jobs are back in the queue, waiting out the grace period
+	size, err := d.Size(ctx, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), size)
+	_, err = d.Pop(ctx, "default")
+	assert.ErrorIs(t, err, dgqueue.ErrQueueEmpty)
+}
+
+func TestManager_RemoveWorkerMigrates(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.RemovedWorkerPolicy = dgqueue.RemovedWorkerMigrate
+	cfg.RemovedWorkerFallback = "legacy"
+	manager, _ := newRemovalManager(t, cfg)
+
+	migrated := make(chan string, 2)
+	manager.Worker("legacy", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		migrated <- job.Name
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case name := <-migrated:
+			assert.Equal(t, "retired-job", name)
+		case <-time.After(3 * time.Second):
+			t.Fatal("Expected jobs of the removed worker to run on the fallback worker")
+		}
+	}
+}
+
+func TestManager_RemoveWorkerWhileRunning(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.RemovedWorkerPolicy = dgqueue.RemovedWorkerMigrate
+	cfg.RemovedWorkerFallback = "legacy"
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	release := make(chan struct{})
+	handled := make(chan string, 5)
+	manager.Worker("retired-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		<-release
+		handled <- job.ID
+		return nil
+	})
+	manager.Worker("legacy", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		handled <- job.ID
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	// One job in flight, the rest buffered in the pool
+	for i := 0; i < 3; i++ {
+		manager.Dispatch(ctx, "retired-job", i)
+	}
+	assert.Eventually(t, func() bool {
+		return manager.PoolStats()["retired-job"].Buffered == 2
+	}, 3*time.Second, 20*time.Millisecond)
+
+	close(release)
+	assert.NoError(t, manager.RemoveWorker(ctx, "retired-job"))
+	assert.NotContains(t, manager.PoolStats(), "retired-job")
+
+	// Jobs the stopping pool didn't get to are migrated, so none are lost
+	seen := make(map[string]bool)
+	for len(seen) < 3 {
+		select {
+		case id := <-handled:
+			seen[id] = true
+		case <-time.After(3 * time.Second):
+			t.Fatalf("Expected every job to be handled, got %d", len(seen))
+		}
+	}
+}
+
+func TestManager_RemoveWorkerUnknown(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	err := manager.RemoveWorker(context.Background(), "missing")
+	assert.ErrorIs(t, err, dgqueue.ErrWorkerNotFound)
+}
+
+func TestManager_RemoveWorkerOn(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	handler := func(ctx context.Context, job *dgqueue.Job) error { return nil }
+	assert.NoError(t, manager.Worker("retired-job", 1, handler))
+	assert.NoError(t, manager.WorkerOn("emails", "retired-job", 1, handler))
+	assert.NoError(t, manager.WorkerOn("reports", "retired-job", 1, handler))
+
+	ctx := context.Background()
+	assert.NoError(t, manager.RemoveWorkerOn(ctx, "emails", "retired-job"))
+	stats := manager.PoolStats()
+	assert.NotContains(t, stats, "emails/retired-job")
+	assert.Contains(t, stats, "reports/retired-job")
+	assert.Contains(t, stats, "retired-job")
+
+	// RemoveWorker removes the name's pools on every queue
+	assert.NoError(t, manager.RemoveWorker(ctx, "retired-job"))
+	assert.Empty(t, manager.PoolStats())
+	assert.ErrorIs(t, manager.RemoveWorkerOn(ctx, "reports", "retired-job"), dgqueue.ErrWorkerNotFound)
+}
+
+func TestManager_RemoveWorkerOnDeadLetters(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 10 * time.Millisecond
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	handler := func(ctx context.Context, job *dgqueue.Job) error { return nil }
+	assert.NoError(t, manager.WorkerOn("emails", "retired-job", 1, handler))
+	// Keep the emails queue served once its worker is gone
+	assert.NoError(t, manager.WorkerOn("emails", "other-job", 1, handler))
+
+	deadLettered := make(chan *dgqueue.Job, 1)
+	manager.SetDeadLetterHandler(func(ctx context.Context, job *dgqueue.Job) error {
+		deadLettered <- job
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, manager.RemoveWorkerOn(ctx, "emails", "retired-job"))
+	assert.NoError(t, manager.Enqueue(ctx, dgqueue.WithQueue(manager.NewJob("retired-job", nil), "emails")))
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	select {
+	case job := <-deadLettered:
+		assert.Equal(t, dgqueue.ReasonHandlerRemoved, job.Metadata[dgqueue.MetadataDeadLetterReason])
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected the job of the removed worker to be dead-lettered")
+	}
+}
@@ -0,0 +1,41 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ExportJob serializes a stored or failed job as JSON, for reproducing it
+// elsewhere with ReplayJob. It returns ErrNotSupported if the driver can't
+// look jobs up by ID.
+func (m *Manager) ExportJob(ctx context.Context, jobID string) ([]byte, error) {
+	job, err := m.getJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalJob(job)
+}
+
+// ReplayJob pushes a job exported with ExportJob onto this manager's driver so
+// its handler runs against the exact failing input. The ID, payload, metadata
+// and attempts are kept; the job is made available immediately and its
+// start/finish times and error are cleared.
+func (m *Manager) ReplayJob(ctx context.Context, data []byte) error {
+	job, err := UnmarshalJob(data)
+	if errors.Is(err, ErrInvalidPayload) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	job.Delay = 0
+	job.AvailableAt = m.clock.Now()
+	job.StartedAt = nil
+	job.CompletedAt = nil
+	job.FailedAt = nil
+	job.Error = ""
+
+	return m.Enqueue(ctx, job)
+}
@@ -0,0 +1,96 @@
+package dgqueue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_ExportAndReplayFailedJob(t *testing.T) {
+	ctx := context.Background()
+
+	// Production: the job fails permanently and lands in the failed store
+	prodCfg := dgqueue.DefaultConfig()
+	prodCfg.MaxAttempts = 1
+	prodCfg.PollInterval = 10 * time.Millisecond
+	prod := dgqueue.New(prodCfg)
+	prodDriver, _ := memory.NewDriver(prodCfg)
+	prod.SetDriver(prodDriver)
+
+	prod.Worker("charge-card", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		return errors.New("card declined")
+	})
+
+	job := prod.NewJob("charge-card", map[string]interface{}{"order_id": "A-42", "amount": 1999.0})
+	dgqueue.WithMetadata(job, "tenant", "acme")
+	assert.NoError(t, prod.Enqueue(ctx, job))
+
+	exhausted := make(chan struct{})
+	prod.OnRetryExhausted(func(*dgqueue.Job) { close(exhausted) })
+
+	assert.NoError(t, prod.Start())
+	select {
+	case <-exhausted:
+	case <-time.After(time.Second):
+		t.Fatal("Expected job to fail permanently")
+	}
+
+	var data []byte
+	assert.Eventually(t, func() bool {
+		var err error
+		data, err = prod.ExportJob(ctx, job.ID)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	assert.NoError(t, prod.Stop(ctx))
+
+	// Local: replay into a fresh manager and inspect what the handler gets
+	local := dgqueue.New(dgqueue.DefaultConfig())
+	localDriver, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	local.SetDriver(localDriver)
+
+	received := make(chan *dgqueue.Job, 1)
+	local.Worker("charge-card", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		received <- job
+		return nil
+	})
+
+	assert.NoError(t, local.ReplayJob(ctx, data))
+	assert.NoError(t, local.Start())
+	defer local.Stop(ctx)
+
+	select {
+	case replayed := <-received:
+		assert.Equal(t, job.ID, replayed.ID)
+		assert.Equal(t, 2, replayed.Attempts, "Expected the exported attempt count to carry over")
+
+		payload, err := dgqueue.PayloadMap(replayed)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"order_id": "A-42", "amount": 1999.0}, payload)
+		assert.Equal(t, "acme", replayed.Metadata["tenant"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected replayed job to be handled")
+	}
+}
+
+func TestManager_ReplayJobInvalid(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	assert.ErrorIs(t, manager.ReplayJob(context.Background(), []byte("not a job")), dgqueue.ErrInvalidPayload)
+	assert.ErrorIs(t, manager.ReplayJob(context.Background(), nil), dgqueue.ErrInvalidPayload)
+}
+
+func TestManager_ExportJobNotFound(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	_, err := manager.ExportJob(context.Background(), "missing")
+	assert.ErrorIs(t, err, dgqueue.ErrJobNotFound)
+}
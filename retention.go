@@ -0,0 +1,48 @@
+package dgqueue
+
+import (
+	"context"
+	"time"
+)
+
+// FailedArchiver receives failed jobs before they are pruned from the
+// driver's failed store, e.g. to copy them to cold storage. Returning an
+// error keeps the job and stops pruning until the next run.
+type FailedArchiver func(ctx context.Context, job *Job) error
+
+// SetFailedArchiver sets the hook failed jobs are passed to before pruning.
+func (m *Manager) SetFailedArchiver(archiver FailedArchiver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.archiver = archiver
+}
+
+// PruneFailed removes failed jobs older than Config.FailedMaxAge or beyond
+// the newest Config.FailedMaxCount from the driver's failed store, archiving
+// them first if a FailedArchiver is set. It returns how many jobs were
+// removed, or ErrNotSupported if the driver can't prune its failed store.
+// Running managers prune every Config.FailedPruneInterval.
+func (m *Manager) PruneFailed(ctx context.Context) (int64, error) {
+	m.mu.RLock()
+	pruner, ok := m.driver.(FailedPruner)
+	archiver := m.archiver
+	m.mu.RUnlock()
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	if m.config.FailedMaxAge <= 0 && m.config.FailedMaxCount <= 0 {
+		return 0, nil
+	}
+
+	var cutoff time.Time
+	if m.config.FailedMaxAge > 0 {
+		cutoff = m.clock.Now().Add(-m.config.FailedMaxAge)
+	}
+	var archive func(*Job) error
+	if archiver != nil {
+		archive = func(job *Job) error {
+			return archiver(ctx, job)
+		}
+	}
+	return pruner.PruneFailed(ctx, cutoff, m.config.FailedMaxCount, archive)
+}
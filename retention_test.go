@@ -0,0 +1,96 @@
+package dgqueue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// failJobs stores jobs in the driver's failed store, one per age given.
+func failJobs(t *testing.T, d dgqueue.Driver, ages ...time.Duration) []*dgqueue.Job {
+	var jobs []*dgqueue.Job
+	for _, age := range ages {
+		job := dgqueue.NewJob("import", nil)
+		failedAt := time.Now().Add(-age)
+		job.FailedAt = &failedAt
+		assert.NoError(t, d.Failed(context.Background(), job))
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func TestManager_PruneFailed(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.FailedMaxAge = 24 * time.Hour
+	cfg.FailedMaxCount = 3
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	jobs := failJobs(t, d, 48*time.Hour, 5*time.Hour, 4*time.Hour, 3*time.Hour, 2*time.Hour, time.Hour)
+
+	var archived []string
+	manager.SetFailedArchiver(func(ctx context.Context, job *dgqueue.Job) error {
+		archived = append(archived, job.ID)
+		return nil
+	})
+
+	ctx := context.Background()
+	pruned, err := manager.PruneFailed(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), pruned)
+
+	// The expired job and the oldest beyond the newest three, archived first
+	assert.Equal(t, []string{jobs[0].ID, jobs[1].ID, jobs[2].ID}, archived)
+	for i, job := range jobs {
+		_, err := d.Get(ctx, job.ID)
+		if i < 3 {
+			assert.ErrorIs(t, err, dgqueue.ErrJobNotFound)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestManager_PruneFailedArchiveError(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.FailedMaxCount = 1
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+	jobs := failJobs(t, d, 3*time.Hour, 2*time.Hour, time.Hour)
+
+	errArchive := errors.New("bucket unavailable")
+	manager.SetFailedArchiver(func(ctx context.Context, job *dgqueue.Job) error {
+		if job.ID == jobs[1].ID {
+			return errArchive
+		}
+		return nil
+	})
+
+	// Jobs the archiver rejects are kept
+	pruned, err := manager.PruneFailed(context.Background())
+	assert.ErrorIs(t, err, errArchive)
+	assert.Equal(t, int64(1), pruned)
+	_, err = d.Get(context.Background(), jobs[1].ID)
+	assert.NoError(t, err)
+}
+
+func TestManager_PruneFailedNotSupported(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.FailedMaxCount = 10
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(struct{ dgqueue.Driver }{d})
+
+	_, err := manager.PruneFailed(context.Background())
+	assert.ErrorIs(t, err, dgqueue.ErrNotSupported)
+}
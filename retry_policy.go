@@ -0,0 +1,58 @@
+package dgqueue
+
+import "time"
+
+// RetryPolicy overrides the global retry settings for one job name. Zero
+// fields keep the global value.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts
+	MaxAttempts int `mapstructure:"max_attempts"`
+
+	// Timeout is how long each attempt may run
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// RetryDelay is the base delay between retries, multiplied by the
+	// attempts made as with Config.RetryDelay
+	RetryDelay time.Duration `mapstructure:"retry_delay"`
+
+	// Backoff returns the delay of the next attempt given the attempts made
+	// so far, in place of RetryDelay times the attempts
+	Backoff func(attempts int) time.Duration `mapstructure:"-"`
+}
+
+// SetRetryPolicy sets the retry policy of jobs named name, taking precedence
+// over Config.RetryPolicies. MaxAttempts and Timeout are stamped on jobs as
+// NewJob creates them, so set the policy on managers that dispatch the job;
+// the backoff applies on managers that run it.
+func (m *Manager) SetRetryPolicy(name string, policy RetryPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policies[name] = policy
+}
+
+// RetryPolicy returns the retry policy of jobs named name, if one is set.
+func (m *Manager) RetryPolicy(name string) (RetryPolicy, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if policy, ok := m.policies[name]; ok {
+		return policy, true
+	}
+	policy, ok := m.config.RetryPolicies[name]
+	return policy, ok
+}
+
+// retryBackoff returns the backoff shouldRetry schedules a failed job's next
+// attempt with.
+func (m *Manager) retryBackoff(job *Job) time.Duration {
+	delay := m.config.RetryDelay
+	if policy, ok := m.RetryPolicy(job.Name); ok {
+		if policy.Backoff != nil {
+			return policy.Backoff(job.Attempts)
+		}
+		if policy.RetryDelay > 0 {
+			delay = policy.RetryDelay
+		}
+	}
+	return delay * time.Duration(job.Attempts)
+}
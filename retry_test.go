@@ -0,0 +1,277 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// retryingDriver is an empty driver recording retried and failed jobs.
+type retryingDriver struct {
+	emptyDriver
+	mu      sync.Mutex
+	retried []*Job
+	failed  []*Job
+}
+
+func (d *retryingDriver) Retry(ctx context.Context, job *Job) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.retried = append(d.retried, job)
+	return nil
+}
+
+func (d *retryingDriver) Failed(ctx context.Context, job *Job) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.failed = append(d.failed, job)
+	return nil
+}
+
+var (
+	errNotFound    = errors.New("404 not found")
+	errUnavailable = errors.New("503 service unavailable")
+)
+
+func TestManager_RetryDecider(t *testing.T) {
+	clk := newFakeClock()
+	cfg := DefaultConfig()
+	cfg.MaxAttempts = 10
+	cfg.RetryDecider = func(job *Job, err error) (bool, time.Duration) {
+		switch {
+		case errors.Is(err, errNotFound):
+			return false, 0
+		case errors.Is(err, errUnavailable):
+			return job.Attempts < 5, 30 * time.Second
+		}
+		return CanRetry(job), 0
+	}
+
+	tests := []struct {
+		name          string
+		err           error
+		attempts      int
+		wantRetry     bool
+		wantAvailable time.Time
+	}{
+		{name: "denied", err: errNotFound, attempts: 0, wantRetry: false},
+		{name: "allowed with delay", err: errUnavailable, attempts: 0, wantRetry: true, wantAvailable: clk.Now().Add(30 * time.Second)},
+		{name: "capped below MaxAttempts", err: errUnavailable, attempts: 4, wantRetry: false},
+		{name: "default schedule", err: errors.New("boom"), attempts: 0, wantRetry: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := &retryingDriver{}
+			m := New(cfg)
+			m.SetDriver(driver)
+			m.clock = clk
+
+			pool := &workerPool{
+				name:        "call-api",
+				concurrency: 1,
+				handler:     func(ctx context.Context, job *Job) error { return tt.err },
+			}
+			job := NewJob("call-api", nil)
+			job.MaxAttempts = cfg.MaxAttempts
+			job.Attempts = tt.attempts
+			availableAt := job.AvailableAt
+
+			m.processJob(pool, job)
+
+			if !tt.wantRetry {
+				assert.Empty(t, driver.retried)
+				assert.Len(t, driver.failed, 1)
+				return
+			}
+			assert.Len(t, driver.retried, 1)
+			assert.Empty(t, driver.failed)
+			if tt.wantAvailable.IsZero() {
+				assert.Equal(t, availableAt, job.AvailableAt, "Expected the schedule to be unchanged")
+			} else {
+				assert.True(t, tt.wantAvailable.Equal(job.AvailableAt), "Expected retry at %v, got %v", tt.wantAvailable, job.AvailableAt)
+			}
+		})
+	}
+}
+
+func TestManager_RetryDeciderOnTimeout(t *testing.T) {
+	var decided error
+	cfg := DefaultConfig()
+	cfg.RetryDecider = func(job *Job, err error) (bool, time.Duration) {
+		decided = err
+		return false, 0
+	}
+
+	driver := &retryingDriver{}
+	m := New(cfg)
+	m.SetDriver(driver)
+	pool := &workerPool{
+		name:        "slow-job",
+		concurrency: 1,
+		handler: func(ctx context.Context, job *Job) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	job := NewJob("slow-job", nil)
+	job.Timeout = 10 * time.Millisecond
+	m.processJob(pool, job)
+
+	assert.ErrorIs(t, decided, ErrJobTimeout)
+	assert.Empty(t, driver.retried)
+	assert.Len(t, driver.failed, 1)
+}
+
+func TestManager_TimeoutRetriedWithBackoff(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxAttempts = 3
+	cfg.RetryDelay = time.Minute
+
+	driver := &retryingDriver{}
+	m := New(cfg)
+	m.SetDriver(driver)
+	pool := &workerPool{
+		name:        "slow-job",
+		concurrency: 1,
+		handler: func(ctx context.Context, job *Job) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	job := m.NewJob("slow-job", nil)
+	job.Timeout = 10 * time.Millisecond
+	m.processJob(pool, job)
+
+	assert.Len(t, driver.retried, 1)
+	assert.GreaterOrEqual(t, job.AvailableAt.Sub(job.CreatedAt), time.Minute, "Expected the retry to back off")
+}
+
+func TestManager_RetryPolicy(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RetryPolicies = map[string]RetryPolicy{
+		"generate-report": {MaxAttempts: 1, Timeout: 4 * time.Hour},
+		"send-email":      {MaxAttempts: 2, RetryDelay: time.Hour},
+	}
+	m := New(cfg)
+	m.SetRetryPolicy("send-email", RetryPolicy{
+		MaxAttempts: 8,
+		Backoff: func(attempts int) time.Duration {
+			return time.Duration(attempts) * time.Minute
+		},
+	})
+
+	// Zero fields keep the global settings
+	report := m.NewJob("generate-report", nil)
+	assert.Equal(t, 1, report.MaxAttempts)
+	assert.Equal(t, 4*time.Hour, report.Timeout)
+
+	// SetRetryPolicy replaces the configured policy
+	email := m.NewJob("send-email", nil)
+	assert.Equal(t, 8, email.MaxAttempts)
+	assert.Equal(t, cfg.Timeout, email.Timeout)
+
+	other := m.NewJob("resize-image", nil)
+	assert.Equal(t, cfg.MaxAttempts, other.MaxAttempts)
+	assert.Equal(t, cfg.Timeout, other.Timeout)
+}
+
+func TestManager_RetryPolicyBackoff(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RetryPolicies = map[string]RetryPolicy{
+		"send-email":  {RetryDelay: time.Hour},
+		"call-api":    {Backoff: func(attempts int) time.Duration { return time.Duration(attempts*attempts) * time.Minute }},
+		"resize-jpeg": {MaxAttempts: 5},
+	}
+
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{name: "send-email", attempts: 1, want: 2 * time.Hour},
+		{name: "call-api", attempts: 2, want: 9 * time.Minute},
+		{name: "resize-jpeg", attempts: 0, want: cfg.RetryDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := &retryingDriver{}
+			clk := newFakeClock()
+			m := New(cfg)
+			m.SetDriver(driver)
+			m.clock = clk
+
+			pool := &workerPool{
+				name:        tt.name,
+				concurrency: 1,
+				handler:     func(ctx context.Context, job *Job) error { return errUnavailable },
+			}
+			job := m.NewJob(tt.name, nil)
+			job.MaxAttempts = 10
+			job.Attempts = tt.attempts
+
+			m.processJob(pool, job)
+
+			assert.Len(t, driver.retried, 1)
+			assert.Equal(t, clk.Now().Add(tt.want), job.AvailableAt)
+		})
+	}
+}
+
+func TestUnretryable(t *testing.T) {
+	errInvalid := errors.New("invalid email address")
+	err := Unretryable(errInvalid)
+
+	assert.ErrorIs(t, err, errInvalid)
+	assert.ErrorIs(t, err, ErrNoRetry)
+	assert.Equal(t, "invalid email address", err.Error())
+	assert.NoError(t, Unretryable(nil))
+}
+
+func TestManager_UnretryableError(t *testing.T) {
+	errInvalid := errors.New("invalid email address")
+
+	tests := []struct {
+		name    string
+		decider func(job *Job, err error) (bool, time.Duration)
+	}{
+		{name: "default retries"},
+		{name: "overrides RetryDecider", decider: func(job *Job, err error) (bool, time.Duration) { return true, 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.MaxAttempts = 5
+			cfg.RetryDecider = tt.decider
+
+			driver := &retryingDriver{}
+			m := New(cfg)
+			m.SetDriver(driver)
+
+			pool := &workerPool{
+				name:        "send-email",
+				concurrency: 1,
+				handler: func(ctx context.Context, job *Job) error {
+					return fmt.Errorf("validate: %w", Unretryable(errInvalid))
+				},
+			}
+			job := m.NewJob("send-email", nil)
+
+			m.processJob(pool, job)
+
+			assert.Empty(t, driver.retried)
+			assert.Len(t, driver.failed, 1)
+			assert.Equal(t, 1, job.Attempts)
+			assert.Equal(t, "validate: invalid email address", job.Error)
+		})
+	}
+}
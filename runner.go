@@ -0,0 +1,41 @@
+package dgqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// runNow runs a job on the caller's goroutine through the same pipeline as a
+// worker: idempotency check, span, timeout, middleware, handler and metrics.
+// It backs drivers that execute jobs on Push, so failures are returned to the
+// caller instead of being retried. A deferred job runs again once it is
+// available, unless ctx is done first.
+func (m *Manager) runNow(ctx context.Context, job *Job) error {
+	pool, ok := m.poolFor(job)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrWorkerNotFound, job.Name)
+	}
+
+	for {
+		_, err := m.attemptJob(ctx, pool, job)
+		if !errors.Is(err, ErrJobDeferred) {
+			return err
+		}
+
+		// Deferrals don't use up an attempt, as with a worker
+		job.Attempts--
+		job.StartedAt = nil
+		m.recordDeferred(ctx, job)
+
+		wait := job.AvailableAt.Sub(m.clock.Now())
+		if wait <= 0 {
+			wait = m.pollInterval()
+		}
+		select {
+		case <-m.clock.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
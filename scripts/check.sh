@@ -0,0 +1,49 @@
+#!/usr/bin/env bash
+# Runs gofmt, build, vet and test for the module.
+#
+# github.com/donnigundala/dg-core v1.0.0 can't be downloaded from the module
+# proxy, so the module is built against a local checkout of dg-core instead:
+#
+#	DG_CORE_DIR=../dg-core scripts/check.sh
+#
+# The replace lives in a temporary go.work outside the tree, so the committed
+# go.mod keeps requiring the published version. Extra arguments are passed to
+# go test, e.g. scripts/check.sh -race -count=1.
+set -euo pipefail
+
+if [ -z "${DG_CORE_DIR:-}" ]; then
+	echo "DG_CORE_DIR must point at a checkout of github.com/donnigundala/dg-core" >&2
+	exit 2
+fi
+if [ ! -f "$DG_CORE_DIR/go.mod" ]; then
+	echo "$DG_CORE_DIR has no go.mod" >&2
+	exit 2
+fi
+
+root=$(cd "$(dirname "$0")/.." && pwd)
+core=$(cd "$DG_CORE_DIR" && pwd)
+
+work=$(mktemp -d)
+trap 'rm -rf "$work"' EXIT
+cat >"$work/go.work" <<WORK
+go 1.25.0
+
+use $root
+
+replace github.com/donnigundala/dg-core => $core
+WORK
+export GOWORK="$work/go.work"
+# Workspaces only allow -mod=readonly
+export GOFLAGS="${GOFLAGS//-mod=mod/}"
+
+cd "$root"
+unformatted=$(gofmt -l .)
+if [ -n "$unformatted" ]; then
+	echo "gofmt needed:" >&2
+	echo "$unformatted" >&2
+	exit 1
+fi
+
+go build ./...
+go vet ./...
+go test "$@" ./...
@@ -0,0 +1,98 @@
+package dgqueue
+
+import "sync/atomic"
+
+// PoolStat is a point-in-time snapshot of a worker pool.
+type PoolStat struct {
+	// Buffered is the number of jobs waiting in the pool's channel
+	Buffered int
+
+	// Capacity is the size of the pool's channel buffer
+	Capacity int
+
+	// Concurrency is the number of workers in the pool
+	Concurrency int
+
+	// Busy is the number of workers currently executing a handler
+	Busy int
+}
+
+// PoolStats returns a snapshot of every worker pool, keyed by job name.
+func (m *Manager) PoolStats() map[string]PoolStat {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[string]PoolStat, len(m.workers))
+	for name, pool := range m.workers {
+		stat := PoolStat{
+			Concurrency: pool.concurrency,
+			Busy:        int(pool.busy.Load()),
+		}
+		for _, jobs := range pool.channels() {
+			stat.Buffered += len(jobs)
+			stat.Capacity += cap(jobs)
+		}
+		stats[name] = stat
+	}
+	return stats
+}
+
+// MetricsSnapshot is a point-in-time copy of the manager's in-process counters.
+// Counters accumulate from New and are unaffected by RegisterMetrics.
+type MetricsSnapshot struct {
+	// Processed is the number of handler runs that finished (succeeded or failed)
+	Processed int64
+
+	// Succeeded is the number of handler runs that returned nil
+	Succeeded int64
+
+	// Failed is the number of handler runs that returned an error or timed out
+	Failed int64
+
+	// Retried is the number of failed jobs scheduled for another attempt
+	Retried int64
+
+	// DeadLettered is the number of jobs moved to the dead letter handler or store
+	DeadLettered int64
+
+	// Duplicates is the number of jobs completed without running their handler
+	// because their idempotency key was already processed
+	Duplicates int64
+
+	// Depth is the number of jobs buffered in worker pools
+	Depth int
+
+	// BusyWorkers is the number of workers currently executing a handler
+	BusyWorkers int
+
+	// PausedQueues are the queues paused with Manager.Pause
+	PausedQueues []string
+}
+
+// jobCounters holds the counters behind MetricsSnapshot.
+type jobCounters struct {
+	processed    atomic.Int64
+	succeeded    atomic.Int64
+	failed       atomic.Int64
+	retried      atomic.Int64
+	deadLettered atomic.Int64
+	duplicates   atomic.Int64
+}
+
+// MetricsSnapshot returns the current job counters and pool gauges.
+func (m *Manager) MetricsSnapshot() MetricsSnapshot {
+	snapshot := MetricsSnapshot{
+		Processed:    m.stats.processed.Load(),
+		Succeeded:    m.stats.succeeded.Load(),
+		Failed:       m.stats.failed.Load(),
+		Retried:      m.stats.retried.Load(),
+		DeadLettered: m.stats.deadLettered.Load(),
+		Duplicates:   m.stats.duplicates.Load(),
+		PausedQueues: m.PausedQueues(),
+	}
+	for _, stat := range m.PoolStats() {
+		snapshot.Depth += stat.Buffered
+		snapshot.BusyWorkers += stat.Busy
+	}
+	return snapshot
+}
@@ -0,0 +1,132 @@
+package dgqueue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_PoolStats(t *testing.T) {
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	release := make(chan struct{})
+	manager.Worker("stuck-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		<-release
+		return nil
+	})
+
+	stats := manager.PoolStats()
+	assert.Equal(t, dgqueue.PoolStat{Capacity: 2, Concurrency: 1}, stats["stuck-job"])
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(context.Background())
+	defer close(release)
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		manager.Dispatch(ctx, "stuck-job", i)
+	}
+
+	// One job in the handler, the buffer filled to capacity
+	assert.Eventually(t, func() bool {
+		stat := manager.PoolStats()["stuck-job"]
+		return stat.Busy == 1 && stat.Buffered == 2
+	}, 3*time.Second, 50*time.Millisecond)
+
+	stat := manager.PoolStats()["stuck-job"]
+	assert.Equal(t, 2, stat.Capacity)
+	assert.Equal(t, 1, stat.Concurrency)
+}
+
+func TestManager_InFlight(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.PollInterval = 10 * time.Millisecond
+	cfg.ServeQueues = []string{"default", "reports"}
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	release := make(chan struct{})
+	handler := func(ctx context.Context, job *dgqueue.Job) error {
+		<-release
+		return nil
+	}
+	manager.Worker("stuck-job", 1, handler)
+	manager.WorkerOn("reports", "stuck-job", 1, handler)
+	assert.Empty(t, manager.InFlight())
+
+	ctx := context.Background()
+	first, _ := manager.Dispatch(ctx, "stuck-job", 1)
+	second := manager.NewJob("stuck-job", 2)
+	assert.NoError(t, manager.Enqueue(ctx, dgqueue.WithQueue(second, "reports")))
+
+	start := time.Now()
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		return len(manager.InFlight()) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	pools := map[string]string{}
+	for _, job := range manager.InFlight() {
+		pools[job.ID] = job.Pool
+		assert.Equal(t, "stuck-job", job.Name)
+		assert.Equal(t, 0, job.Worker)
+		assert.False(t, job.StartedAt.Before(start))
+		assert.True(t, job.HeartbeatAt.IsZero(), "memory driver doesn't heartbeat")
+	}
+	assert.Equal(t, map[string]string{first.ID: "stuck-job", second.ID: "reports/stuck-job"}, pools)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return len(manager.InFlight()) == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestManager_MetricsSnapshot(t *testing.T) {
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxAttempts = 2
+	cfg.RetryDelay = 10 * time.Millisecond
+
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	manager.Worker("ok-job", 2, func(ctx context.Context, job *dgqueue.Job) error {
+		return nil
+	})
+	manager.Worker("bad-job", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		return errors.New("boom")
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		manager.Dispatch(ctx, "ok-job", i)
+	}
+	manager.Dispatch(ctx, "bad-job", nil)
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(ctx)
+
+	assert.Eventually(t, func() bool {
+		return manager.MetricsSnapshot().DeadLettered == 1
+	}, 3*time.Second, 20*time.Millisecond)
+
+	// 3 successes, plus the failing job's two attempts
+	snapshot := manager.MetricsSnapshot()
+	assert.Equal(t, int64(5), snapshot.Processed)
+	assert.Equal(t, int64(3), snapshot.Succeeded)
+	assert.Equal(t, int64(2), snapshot.Failed)
+	assert.Equal(t, int64(1), snapshot.Retried)
+	assert.Equal(t, 0, snapshot.Depth)
+	assert.Equal(t, 0, snapshot.BusyWorkers)
+}
@@ -0,0 +1,73 @@
+package dgqueue
+
+import (
+	"sync"
+	"time"
+)
+
+// throughputWindow counts completed jobs in one-second buckets over a sliding window.
+type throughputWindow struct {
+	mu      sync.Mutex
+	counts  []int64
+	seconds []int64 // unix second each bucket currently holds
+}
+
+func newThroughputWindow(window time.Duration) *throughputWindow {
+	size := int(window / time.Second)
+	if size < 1 {
+		size = 1
+	}
+	return &throughputWindow{
+		counts:  make([]int64, size),
+		seconds: make([]int64, size),
+	}
+}
+
+// window returns the duration covered by the window.
+func (w *throughputWindow) window() time.Duration {
+	return time.Duration(len(w.counts)) * time.Second
+}
+
+// add records one completion at now.
+func (w *throughputWindow) add(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sec := now.Unix()
+	i := int(sec % int64(len(w.counts)))
+	if w.seconds[i] != sec {
+		w.seconds[i] = sec
+		w.counts[i] = 0
+	}
+	w.counts[i]++
+}
+
+// rate returns the average completions per second over the window ending at now.
+func (w *throughputWindow) rate(now time.Time) float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sec := now.Unix()
+	size := int64(len(w.counts))
+	var total int64
+	for i, s := range w.seconds {
+		if age := sec - s; age >= 0 && age < size {
+			total += w.counts[i]
+		}
+	}
+	return float64(total) / float64(size)
+}
+
+// Throughput returns the number of jobs completed per second,
+// averaged over Config.ThroughputWindow.
+func (m *Manager) Throughput() float64 {
+	return m.completions().rate(m.clock.Now())
+}
+
+// completions returns the window counting completed jobs, which Reload
+// replaces when Config.ThroughputWindow changes.
+func (m *Manager) completions() *throughputWindow {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.throughput
+}
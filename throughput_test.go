@@ -0,0 +1,60 @@
+package dgqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_ThroughputBurst(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ThroughputWindow = 10 * time.Second
+
+	m := New(cfg)
+	m.SetDriver(emptyDriver{})
+	pool := &workerPool{
+		name:        "burst-job",
+		concurrency: 1,
+		handler:     func(ctx context.Context, job *Job) error { return nil },
+	}
+
+	for i := 0; i < 50; i++ {
+		m.processJob(pool, NewJob("burst-job", i))
+	}
+
+	// 50 completions averaged over a 10 second window
+	assert.InDelta(t, 5.0, m.Throughput(), 0.5)
+}
+
+func TestManager_ThroughputIgnoresFailures(t *testing.T) {
+	m := New(DefaultConfig())
+	m.SetDriver(emptyDriver{})
+	pool := &workerPool{
+		name:        "failing-job",
+		concurrency: 1,
+		handler:     func(ctx context.Context, job *Job) error { return ErrInvalidPayload },
+	}
+
+	m.processJob(pool, NewJob("failing-job", nil))
+	assert.Equal(t, 0.0, m.Throughput())
+}
+
+func TestThroughputWindow_Slides(t *testing.T) {
+	w := newThroughputWindow(5 * time.Second)
+	start := time.Unix(1000, 0)
+
+	for i := 0; i < 10; i++ {
+		w.add(start)
+	}
+	assert.Equal(t, 2.0, w.rate(start))
+	assert.Equal(t, 2.0, w.rate(start.Add(4*time.Second)))
+
+	// Once the window has moved past the burst, nothing is counted
+	assert.Equal(t, 0.0, w.rate(start.Add(5*time.Second)))
+
+	// Buckets are reused for later seconds
+	w.add(start.Add(5 * time.Second))
+	assert.Equal(t, 0.2, w.rate(start.Add(5*time.Second)))
+}
@@ -0,0 +1,135 @@
+package dgqueue
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.38.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MetadataTraceContext is the job metadata key holding the trace context of
+// the span that dispatched the job, as encoded by the global propagator.
+const MetadataTraceContext = "trace_context"
+
+// Span names, and the messaging.system attribute identifying the queue.
+const (
+	spanDispatch    = "queue.dispatch"
+	spanProcess     = "queue.process"
+	messagingSystem = "dg-queue"
+)
+
+// Outcomes recorded in the job.status attribute of processing spans.
+const (
+	spanStatusSuccess  = "success"
+	spanStatusFailed   = "failed"
+	spanStatusTimeout  = "timeout"
+	spanStatusDeferred = "deferred"
+)
+
+// tracer returns the tracer of the global provider, which may be set after
+// the manager is created.
+func tracer() trace.Tracer {
+	return otel.GetTracerProvider().Tracer(instrumentationName)
+}
+
+// jobSpanAttributes returns the attributes describing the job on its spans.
+func jobSpanAttributes(job *Job) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		semconv.MessagingSystemKey.String(messagingSystem),
+		semconv.MessagingDestinationName(job.Queue),
+		semconv.MessagingMessageID(job.ID),
+		attribute.String("job.name", job.Name),
+		attribute.Int("job.attempt", job.Attempts),
+	}
+}
+
+// startDispatchSpan starts the producer span for pushing the job, and stores
+// its trace context in the job so processing joins the trace.
+func startDispatchSpan(ctx context.Context, job *Job) (context.Context, trace.Span) {
+	ctx, span := tracer().Start(ctx, spanDispatch,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(jobSpanAttributes(job)...),
+		trace.WithAttributes(
+			semconv.MessagingOperationName("dispatch"),
+			semconv.MessagingOperationTypeSend,
+		),
+	)
+	injectTrace(ctx, job)
+	return ctx, span
+}
+
+// startProcessSpan starts the consumer span for an attempt of the job. It
+// continues the trace of the span that dispatched the job, and links to that
+// span as messaging conventions recommend, so the attempt shows up both in the
+// producer's trace and as its own operation.
+func startProcessSpan(ctx context.Context, job *Job) (context.Context, trace.Span) {
+	ctx = extractTrace(ctx, job)
+
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(jobSpanAttributes(job)...),
+		trace.WithAttributes(
+			semconv.MessagingOperationName("process"),
+			semconv.MessagingOperationTypeProcess,
+		),
+	}
+	if producer := trace.SpanContextFromContext(ctx); producer.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: producer}))
+	}
+	return tracer().Start(ctx, spanProcess, opts...)
+}
+
+// endProcessSpan records the outcome of the attempt and ends its span.
+func endProcessSpan(span trace.Span, status string, err error) {
+	span.SetAttributes(attribute.String("job.status", status))
+	if err != nil {
+		failSpan(span, err)
+	}
+	span.End()
+}
+
+// failSpan records err as the reason the span's operation failed.
+func failSpan(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// injectTrace stores the trace context of ctx's span in the job, if it has one.
+func injectTrace(ctx context.Context, job *Job) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return
+	}
+
+	// Stored as generic values, which every serializer round-trips
+	fields := make(map[string]interface{}, len(carrier))
+	for key, value := range carrier {
+		fields[key] = value
+	}
+	WithMetadata(job, MetadataTraceContext, fields)
+}
+
+// extractTrace returns ctx carrying the trace context stored in the job.
+func extractTrace(ctx context.Context, job *Job) context.Context {
+	fields, _ := job.Metadata[MetadataTraceContext].(map[string]interface{})
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	carrier := make(propagation.MapCarrier, len(fields))
+	for key, value := range fields {
+		if s, ok := value.(string); ok {
+			carrier[key] = s
+		}
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
@@ -0,0 +1,200 @@
+package dgqueue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// spanRecorder is a tracer provider keeping the spans that ended.
+type spanRecorder struct {
+	noop.TracerProvider
+	mu     sync.Mutex
+	nextID byte
+	ended  []*recordedSpan
+}
+
+func (r *spanRecorder) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{recorder: r}
+}
+
+func (r *spanRecorder) spans() []*recordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*recordedSpan(nil), r.ended...)
+}
+
+type recordingTracer struct {
+	noop.Tracer
+	recorder *spanRecorder
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	t.recorder.mu.Lock()
+	t.recorder.nextID++
+	spanID := trace.SpanID{0xaa, t.recorder.nextID}
+	t.recorder.mu.Unlock()
+
+	parent := trace.SpanContextFromContext(ctx)
+	span := &recordedSpan{
+		recorder: t.recorder,
+		name:     name,
+		parent:   parent,
+		config:   trace.NewSpanStartConfig(opts...),
+		spanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    parent.TraceID(),
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		}),
+	}
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordedSpan struct {
+	noop.Span
+	recorder    *spanRecorder
+	name        string
+	parent      trace.SpanContext
+	spanContext trace.SpanContext
+	config      trace.SpanConfig
+	attributes  map[attribute.Key]attribute.Value
+	status      codes.Code
+}
+
+// attribute returns the value of the attribute set on the span with key.
+func (s *recordedSpan) attribute(key attribute.Key) attribute.Value {
+	if value, ok := s.attributes[key]; ok {
+		return value
+	}
+	for _, kv := range s.config.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func (s *recordedSpan) SpanContext() trace.SpanContext { return s.spanContext }
+
+func (s *recordedSpan) IsRecording() bool { return true }
+
+func (s *recordedSpan) SetStatus(code codes.Code, _ string) { s.status = code }
+
+func (s *recordedSpan) SetAttributes(kvs ...attribute.KeyValue) {
+	if s.attributes == nil {
+		s.attributes = make(map[attribute.Key]attribute.Value)
+	}
+	for _, kv := range kvs {
+		s.attributes[kv.Key] = kv.Value
+	}
+}
+
+func (s *recordedSpan) End(...trace.SpanEndOption) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.recorder.ended = append(s.recorder.ended, s)
+}
+
+func TestManager_PropagatesTraceContext(t *testing.T) {
+	recorder := &spanRecorder{}
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(recorder)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	}()
+
+	cfg := dgqueue.DefaultConfig()
+	cfg.MaxAttempts = 1
+	cfg.PollInterval = 10 * time.Millisecond
+	manager := dgqueue.New(cfg)
+	d, _ := memory.NewDriver(cfg)
+	manager.SetDriver(d)
+
+	handled := make(chan trace.SpanContext, 1)
+	manager.Worker("charge", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		handled <- trace.SpanContextFromContext(ctx)
+		return nil
+	})
+	manager.Worker("refund", 1, func(ctx context.Context, job *dgqueue.Job) error {
+		return errors.New("card declined")
+	})
+
+	// The span of the request dispatching the jobs
+	producer := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), producer)
+
+	job, err := manager.Dispatch(ctx, "charge", map[string]interface{}{"amount": 100})
+	assert.NoError(t, err)
+	assert.Contains(t, job.Metadata, dgqueue.MetadataTraceContext)
+	_, err = manager.Dispatch(ctx, "refund", map[string]interface{}{"amount": 100})
+	assert.NoError(t, err)
+
+	// Jobs dispatched outside a trace carry none
+	untraced, err := manager.Dispatch(context.Background(), "other", nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, untraced.Metadata, dgqueue.MetadataTraceContext)
+
+	assert.NoError(t, manager.Start())
+	defer manager.Stop(context.Background())
+
+	select {
+	case handlerSpan := <-handled:
+		assert.Equal(t, producer.TraceID(), handlerSpan.TraceID(), "Expected the handler to run in the producer's trace")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected job to be handled")
+	}
+
+	// Dispatching the traced jobs, the untraced one and processing the traced ones
+	assert.Eventually(t, func() bool { return len(recorder.spans()) == 5 }, 2*time.Second, 10*time.Millisecond)
+	spans := make(map[string]*recordedSpan)
+	for _, span := range recorder.spans() {
+		spans[span.name+" "+span.attribute("job.name").AsString()] = span
+	}
+
+	dispatch := spans["queue.dispatch charge"]
+	if assert.NotNil(t, dispatch) {
+		assert.Equal(t, producer.SpanID(), dispatch.parent.SpanID())
+		assert.Equal(t, trace.SpanKindProducer, dispatch.config.SpanKind())
+		assert.Equal(t, "dg-queue", dispatch.attribute("messaging.system").AsString())
+		assert.Equal(t, "send", dispatch.attribute("messaging.operation.type").AsString())
+		assert.Equal(t, job.ID, dispatch.attribute("messaging.message.id").AsString())
+	}
+
+	charge := spans["queue.process charge"]
+	if assert.NotNil(t, charge) && assert.NotNil(t, dispatch) {
+		assert.Equal(t, dispatch.spanContext.SpanID(), charge.parent.SpanID())
+		assert.Equal(t, trace.SpanKindConsumer, charge.config.SpanKind())
+		if assert.Len(t, charge.config.Links(), 1) {
+			assert.Equal(t, dispatch.spanContext.SpanID(), charge.config.Links()[0].SpanContext.SpanID())
+		}
+		assert.Equal(t, "default", charge.attribute("messaging.destination.name").AsString())
+		assert.Equal(t, "process", charge.attribute("messaging.operation.type").AsString())
+		assert.Equal(t, int64(1), charge.attribute("job.attempt").AsInt64())
+		assert.Equal(t, "success", charge.attribute("job.status").AsString())
+		assert.Equal(t, codes.Unset, charge.status)
+	}
+
+	refund := spans["queue.process refund"]
+	if assert.NotNil(t, refund) {
+		assert.Equal(t, "failed", refund.attribute("job.status").AsString())
+		assert.Equal(t, codes.Error, refund.status)
+	}
+}
@@ -0,0 +1,345 @@
+package dgqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PayloadValidator checks the payload of a job being dispatched, returning
+// an error describing what is wrong with it.
+type PayloadValidator func(payload interface{}) error
+
+// SetValidator sets the validator payloads of jobs named name are checked
+// with when they are enqueued. Jobs it rejects are not enqueued: the
+// dispatch fails with ErrInvalidPayload, wrapping the validator's error.
+// A nil validator removes it.
+func (m *Manager) SetValidator(name string, validator PayloadValidator) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if validator == nil {
+		delete(m.validators, name)
+		return
+	}
+	m.validators[name] = validator
+}
+
+// validate checks the job's payload with the validator set for its name.
+func (m *Manager) validate(job *Job) error {
+	m.mu.RLock()
+	validator := m.validators[job.Name]
+	m.mu.RUnlock()
+
+	if validator == nil {
+		return nil
+	}
+	if err := validator(job.Payload); err != nil {
+		return fmt.Errorf("%w: job %s: %w", ErrInvalidPayload, job.Name, err)
+	}
+	return nil
+}
+
+// jsonSchema is the subset of JSON Schema JSONSchema supports.
+type jsonSchema struct {
+	Type                 []string
+	Enum                 []interface{}
+	Properties           map[string]*jsonSchema
+	Required             []string
+	AdditionalProperties *jsonSchema // nil allows any; closed rejects all
+	Items                *jsonSchema
+	Minimum, Maximum     *float64
+	MinLength, MaxLength *int
+	MinItems, MaxItems   *int
+	Pattern              *regexp.Regexp
+
+	closed bool // additionalProperties: false
+}
+
+// schemaKeywords are the keywords JSONSchema accepts. Annotations are
+// ignored; any other keyword is rejected rather than silently not enforced.
+var schemaKeywords = map[string]bool{
+	"type": true, "enum": true, "properties": true, "required": true,
+	"additionalProperties": true, "items": true, "minimum": true, "maximum": true,
+	"minLength": true, "maxLength": true, "minItems": true, "maxItems": true, "pattern": true,
+	"$schema": true, "$id": true, "title": true, "description": true, "default": true, "examples": true,
+}
+
+// JSONSchema returns a validator checking payloads against a JSON Schema.
+// Payloads are checked as they will be serialized: structs by their JSON
+// encoding, and raw payloads as the JSON they hold.
+//
+// The keywords supported are type, enum, properties, required,
+// additionalProperties, items, minimum, maximum, minLength, maxLength,
+// minItems, maxItems and pattern, plus annotations such as title and
+// description. A schema using any other keyword is rejected with
+// ErrInvalidConfig.
+func JSONSchema(schema []byte) (PayloadValidator, error) {
+	var raw interface{}
+	if err := json.Unmarshal(schema, &raw); err != nil {
+		return nil, fmt.Errorf("%w: json schema: %v", ErrInvalidConfig, err)
+	}
+	parsed, err := parseSchema(raw, "schema")
+	if err != nil {
+		return nil, fmt.Errorf("%w: json schema: %v", ErrInvalidConfig, err)
+	}
+
+	return func(payload interface{}) error {
+		value, err := jsonValue(payload)
+		if err != nil {
+			return err
+		}
+		var violations []string
+		parsed.check(value, "payload", &violations)
+		if len(violations) > 0 {
+			return errors.New(strings.Join(violations, "; "))
+		}
+		return nil
+	}, nil
+}
+
+// parseSchema parses the schema at path.
+func parseSchema(raw interface{}, path string) (*jsonSchema, error) {
+	fields, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: must be an object", path)
+	}
+
+	s := &jsonSchema{}
+	for keyword, value := range fields {
+		if !schemaKeywords[keyword] {
+			return nil, fmt.Errorf("%s: unsupported keyword %q", path, keyword)
+		}
+
+		var err error
+		switch keyword {
+		case "type":
+			s.Type, err = parseStrings(value)
+		case "enum":
+			enum, ok := value.([]interface{})
+			if !ok {
+				err = errors.New("must be an array")
+			}
+			s.Enum = enum
+		case "properties":
+			props, ok := value.(map[string]interface{})
+			if !ok {
+				err = errors.New("must be an object")
+				break
+			}
+			s.Properties = make(map[string]*jsonSchema, len(props))
+			for name, prop := range props {
+				if s.Properties[name], err = parseSchema(prop, path+".properties."+name); err != nil {
+					return nil, err
+				}
+			}
+		case "required":
+			s.Required, err = parseStrings(value)
+		case "additionalProperties":
+			if allowed, ok := value.(bool); ok {
+				s.closed = !allowed
+				break
+			}
+			s.AdditionalProperties, err = parseSchema(value, path+".additionalProperties")
+			if err != nil {
+				return nil, err
+			}
+		case "items":
+			if s.Items, err = parseSchema(value, path+".items"); err != nil {
+				return nil, err
+			}
+		case "minimum":
+			s.Minimum, err = parseNumber(value)
+		case "maximum":
+			s.Maximum, err = parseNumber(value)
+		case "minLength":
+			s.MinLength, err = parseCount(value)
+		case "maxLength":
+			s.MaxLength, err = parseCount(value)
+		case "minItems":
+			s.MinItems, err = parseCount(value)
+		case "maxItems":
+			s.MaxItems, err = parseCount(value)
+		case "pattern":
+			pattern, ok := value.(string)
+			if !ok {
+				err = errors.New("must be a string")
+				break
+			}
+			s.Pattern, err = regexp.Compile(pattern)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", path, keyword, err)
+		}
+	}
+	return s, nil
+}
+
+func parseStrings(value interface{}) ([]string, error) {
+	if s, ok := value.(string); ok {
+		return []string{s}, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("must be a string or an array of strings")
+	}
+	strs := make([]string, len(list))
+	for i, item := range list {
+		if strs[i], ok = item.(string); !ok {
+			return nil, errors.New("must be a string or an array of strings")
+		}
+	}
+	return strs, nil
+}
+
+func parseNumber(value interface{}) (*float64, error) {
+	n, ok := value.(float64)
+	if !ok {
+		return nil, errors.New("must be a number")
+	}
+	return &n, nil
+}
+
+func parseCount(value interface{}) (*int, error) {
+	n, ok := value.(float64)
+	if !ok || n < 0 || n != math.Trunc(n) {
+		return nil, errors.New("must be a non-negative integer")
+	}
+	count := int(n)
+	return &count, nil
+}
+
+// check appends the ways value at path violates the schema to violations.
+func (s *jsonSchema) check(value interface{}, path string, violations *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(s.Type) > 0 && !matchesType(value, s.Type) {
+		fail("expected %s, got %s", strings.Join(s.Type, " or "), jsonType(value))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		fail("must be one of %s", mustJSON(s.Enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.check(v[name], path+"."+name, violations)
+			} else if s.closed {
+				fail("unexpected property %q", name)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.check(v[name], path+"."+name, violations)
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.check(item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.Pattern != nil && !s.Pattern.MatchString(v) {
+			fail("must match %q", s.Pattern.String())
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	}
+}
+
+// jsonValue returns the payload as generic JSON values.
+func jsonValue(payload interface{}) (interface{}, error) {
+	data, ok := payload.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// jsonType returns the JSON Schema type of a generic JSON value.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func matchesType(value interface{}, types []string) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	encoded := mustJSON(value)
+	for _, allowed := range enum {
+		if mustJSON(allowed) == encoded {
+			return true
+		}
+	}
+	return false
+}
+
+// mustJSON encodes a generic JSON value, which can't fail.
+func mustJSON(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,102 @@
+package dgqueue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dgqueue "github.com/donnigundala/dg-queue"
+	"github.com/donnigundala/dg-queue/drivers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+type signupPayload struct {
+	Email string   `json:"email"`
+	Age   int      `json:"age"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+const signupSchema = `{
+	"type": "object",
+	"required": ["email", "age"],
+	"properties": {
+		"email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+		"age": {"type": "integer", "minimum": 13},
+		"tags": {"type": "array", "maxItems": 2, "items": {"enum": ["beta", "staff"]}}
+	},
+	"additionalProperties": false
+}`
+
+func TestManager_ValidatesPayloadsOnDispatch(t *testing.T) {
+	ctx := context.Background()
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	validator, err := dgqueue.JSONSchema([]byte(signupSchema))
+	assert.NoError(t, err)
+	manager.SetValidator("signup", validator)
+
+	_, err = manager.Dispatch(ctx, "signup", signupPayload{Email: "a@example.com", Age: 30, Tags: []string{"beta"}})
+	assert.NoError(t, err)
+
+	_, err = manager.Dispatch(ctx, "signup", signupPayload{Email: "nope", Age: 9, Tags: []string{"admin"}})
+	assert.ErrorIs(t, err, dgqueue.ErrInvalidPayload)
+	assert.ErrorContains(t, err, "job signup")
+	assert.ErrorContains(t, err, `payload.age: must be at least 13`)
+	assert.ErrorContains(t, err, `payload.email: must match`)
+	assert.ErrorContains(t, err, `payload.tags[0]: must be one of ["beta","staff"]`)
+
+	_, err = manager.DispatchRaw(ctx, "signup", []byte(`{"email":"a@example.com","age":30,"admin":true}`))
+	assert.ErrorContains(t, err, `unexpected property "admin"`)
+
+	_, err = manager.Dispatch(ctx, "signup", map[string]interface{}{"age": "30"})
+	assert.ErrorContains(t, err, `missing required property "email"`)
+	assert.ErrorContains(t, err, "payload.age: expected integer, got string")
+
+	size, _ := d.Size(ctx, "default")
+	assert.Equal(t, int64(1), size, "Expected only the valid job to be enqueued")
+
+	// Other job names aren't validated
+	_, err = manager.Dispatch(ctx, "other", "anything")
+	assert.NoError(t, err)
+}
+
+func TestManager_SetValidatorFunc(t *testing.T) {
+	ctx := context.Background()
+	manager := dgqueue.New(dgqueue.DefaultConfig())
+	d, _ := memory.NewDriver(dgqueue.DefaultConfig())
+	manager.SetDriver(d)
+
+	errNoEmail := errors.New("email is required")
+	manager.SetValidator("signup", func(payload interface{}) error {
+		if p, ok := payload.(signupPayload); !ok || p.Email == "" {
+			return errNoEmail
+		}
+		return nil
+	})
+
+	_, err := manager.Dispatch(ctx, "signup", signupPayload{Age: 30})
+	assert.ErrorIs(t, err, dgqueue.ErrInvalidPayload)
+	assert.ErrorIs(t, err, errNoEmail)
+
+	manager.SetValidator("signup", nil)
+	_, err = manager.Dispatch(ctx, "signup", signupPayload{Age: 30})
+	assert.NoError(t, err, "Expected removed validator to allow any payload")
+}
+
+func TestJSONSchema_RejectsUnsupportedSchemas(t *testing.T) {
+	for _, schema := range []string{
+		`not json`,
+		`{"type": "object", "oneOf": [{"type": "string"}]}`,
+		`{"properties": {"id": {"format": "uuid"}}}`,
+		`{"pattern": "("}`,
+		`{"minLength": -1}`,
+	} {
+		_, err := dgqueue.JSONSchema([]byte(schema))
+		assert.ErrorIs(t, err, dgqueue.ErrInvalidConfig, "schema %s", schema)
+	}
+
+	_, err := dgqueue.JSONSchema([]byte(`{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "Signup", "type": ["string", "null"]}`))
+	assert.NoError(t, err, "Expected annotations to be allowed")
+}